@@ -0,0 +1,221 @@
+package metacoin
+
+import (
+	"encoding/json"
+
+	"github.com/hyperledger/fabric-chaincode-go/pkg/cid"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+
+	"inblock/metacoin/mcerr"
+)
+
+// adminKey : state key of the admin list
+const adminKey = "ADMIN_LIST"
+
+// Admin certificate attribute.
+// Admin entry with the "*" subject accepts the client of the MSP with this
+// attribute. It is opt-in per MSP, the CA of the MSP decides who has the attribute.
+const (
+	AdminAttribute      = "metacoin.role"
+	AdminAttributeValue = "admin"
+	AdminAnySubject     = "*"
+)
+
+// TAdmin : admin identity
+// Subject is the certificate subject (ex: "CN=admin,OU=client,O=Org1"),
+// "*" is any client of the MSP with the metacoin.role=admin attribute.
+type TAdmin struct {
+	MSPID   string `json:"mspid"`
+	Subject string `json:"subject"`
+	RegDate int64  `json:"regdate"`
+	AddedBy string `json:"addedby"` // mspid/subject of the admin who added
+}
+
+// TAdminList : admin list, saved to the ADMIN_LIST key
+type TAdminList struct {
+	Admins  []TAdmin `json:"admins"`
+	JobType string   `json:"job_type"`
+	JobArgs string   `json:"job_args"`
+	JobDate int64    `json:"jobdate"`
+}
+
+// TIdentity : transaction submitter
+type TIdentity struct {
+	MSPID   string `json:"mspid"`
+	Subject string `json:"subject"`
+	IsAdmin bool   `json:"-"` // has metacoin.role=admin attribute
+}
+
+// String - mspid/subject
+func (id TIdentity) String() string {
+	return id.MSPID + "/" + id.Subject
+}
+
+// match - admin entry is the identity
+func (a TAdmin) match(id TIdentity) bool {
+	if a.MSPID != id.MSPID {
+		return false
+	}
+	if a.Subject == AdminAnySubject {
+		return id.IsAdmin
+	}
+	return a.Subject != "" && a.Subject == id.Subject
+}
+
+// GetIdentity - MSP ID and certificate subject of the transaction submitter
+func GetIdentity(stub shim.ChaincodeStubInterface) (TIdentity, error) {
+	var id TIdentity
+
+	client, err := cid.New(stub)
+	if err != nil {
+		return id, mcerr.Wrap(mcerr.NoPermission, "Client identity error", err)
+	}
+	if id.MSPID, err = client.GetMSPID(); err != nil {
+		return id, mcerr.Wrap(mcerr.NoPermission, "Client identity error", err)
+	}
+	cert, err := client.GetX509Certificate()
+	if err != nil {
+		return id, mcerr.Wrap(mcerr.NoPermission, "Client identity error", err)
+	}
+	if cert != nil {
+		id.Subject = cert.Subject.String()
+	}
+	if value, found, err := client.GetAttributeValue(AdminAttribute); err == nil && found {
+		id.IsAdmin = value == AdminAttributeValue
+	}
+	return id, nil
+}
+
+// GetAdminList - admin list, empty list if no admin
+func GetAdminList(stub shim.ChaincodeStubInterface) (TAdminList, error) {
+	var list TAdminList
+
+	value, err := stub.GetState(adminKey)
+	if err != nil {
+		return list, mcerr.Wrap(mcerr.LedgerRead, "Hyperledger internal error", err)
+	}
+	if value == nil {
+		list.Admins = make([]TAdmin, 0)
+		return list, nil
+	}
+	if err = json.Unmarshal(value, &list); err != nil {
+		return list, mcerr.Wrap(mcerr.InvalidData, "Admin list data is broken", err)
+	}
+	return list, nil
+}
+
+// setAdminList - save admin list
+func setAdminList(stub shim.ChaincodeStubInterface, list TAdminList, JobType string, args []string) error {
+	var dat []byte
+	var err error
+
+	list.JobType = JobType
+	list.JobDate = GetTxTime(stub)
+	list.JobArgs = ""
+	if len(args) > 0 {
+		if dat, err = json.Marshal(args); err == nil {
+			list.JobArgs = string(dat)
+		}
+	}
+	if dat, err = json.Marshal(list); err != nil {
+		return mcerr.Wrap(mcerr.InvalidData, "Invalid Data format", err)
+	}
+	if err = stub.PutState(adminKey, dat); err != nil {
+		return mcerr.Wrap(mcerr.LedgerWrite, "Hyperledger internal error", err)
+	}
+	return nil
+}
+
+// CheckAdmin - the transaction submitter is the admin
+//
+// All privileged functions are checked here. CallFunction checks the
+// function registered with Admin: true before the handler.
+func CheckAdmin(stub shim.ChaincodeStubInterface) (TIdentity, error) {
+	id, err := GetIdentity(stub)
+	if err != nil {
+		return id, err
+	}
+	list, err := GetAdminList(stub)
+	if err != nil {
+		return id, err
+	}
+	for _, admin := range list.Admins {
+		if admin.match(id) {
+			return id, nil
+		}
+	}
+	return id, mcerr.New(mcerr.NoPermission, "You do not have admin permission").WithDetails(id)
+}
+
+// AdminAdd - add admin, the caller is the admin (checked by CheckAdmin)
+// subject is the certificate subject, "*" adds any client of the MSP with the admin attribute.
+func AdminAdd(stub shim.ChaincodeStubInterface, mspid, subject string, args []string) error {
+	id, err := CheckAdmin(stub)
+	if err != nil {
+		return err
+	}
+	list, err := GetAdminList(stub)
+	if err != nil {
+		return err
+	}
+	if list, err = addAdmin(stub, list, TAdmin{MSPID: mspid, Subject: subject}, id.String()); err != nil {
+		return err
+	}
+	if err = setAdminList(stub, list, "adminAdd", args); err != nil {
+		return err
+	}
+	AddEvent(stub, TEvent{Type: "admin_add", Key: mspid, Args: []string{mspid, subject, id.String()}})
+	return nil
+}
+
+// AdminRemove - remove admin, the last admin can not be removed
+func AdminRemove(stub shim.ChaincodeStubInterface, mspid, subject string, args []string) error {
+	id, err := CheckAdmin(stub)
+	if err != nil {
+		return err
+	}
+	list, err := GetAdminList(stub)
+	if err != nil {
+		return err
+	}
+
+	found := -1
+	for index, admin := range list.Admins {
+		if admin.MSPID == mspid && admin.Subject == subject {
+			found = index
+			break
+		}
+	}
+	if found == -1 {
+		return mcerr.New(mcerr.NotFound, "Admin not found").WithField("mspid")
+	}
+	if len(list.Admins) == 1 {
+		return mcerr.New(mcerr.InvalidValue, "The last admin can not be removed").WithField("mspid")
+	}
+	list.Admins = append(list.Admins[:found], list.Admins[found+1:]...)
+
+	if err = setAdminList(stub, list, "adminRemove", args); err != nil {
+		return err
+	}
+	AddEvent(stub, TEvent{Type: "admin_remove", Key: mspid, Args: []string{mspid, subject, id.String()}})
+	return nil
+}
+
+// addAdmin - add admin to the list
+func addAdmin(stub shim.ChaincodeStubInterface, list TAdminList, admin TAdmin, addedBy string) (TAdminList, error) {
+	if admin.MSPID == "" {
+		return list, mcerr.New(mcerr.InvalidArguments, "MSP ID is empty").WithField("mspid")
+	}
+	if admin.Subject == "" {
+		return list, mcerr.New(mcerr.InvalidArguments, "Subject is empty, \""+AdminAnySubject+"\" is any client of the MSP with the admin attribute").WithField("subject")
+	}
+	for _, a := range list.Admins {
+		if a.MSPID == admin.MSPID && a.Subject == admin.Subject {
+			return list, mcerr.New(mcerr.AlreadyExists, "Admin already exists").WithField("mspid")
+		}
+	}
+	admin.RegDate = GetTxTime(stub)
+	admin.AddedBy = addedBy
+	list.Admins = append(list.Admins, admin)
+	return list, nil
+}
@@ -0,0 +1,64 @@
+package metacoin
+
+import (
+	"testing"
+)
+
+func TestAdmin(t *testing.T) {
+	s := newTestStub()
+	newGenesisWallet(t, s, "0")
+	admin := s.Creator
+
+	// the admin list of the genesis data
+	list, err := GetAdminList(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Admins) != 1 || list.Admins[0].MSPID != "Org1MSP" || list.Admins[0].Subject != "CN=admin,O=Org1MSP" {
+		t.Fatalf("admin list after the genesis : %+v", list.Admins)
+	}
+
+	// not admin, the admin attribute alone is not the admin
+	s.Creator = testCreator("Org2MSP", "user", nil)
+	assertError(t, s.tx(func() error { return AdminAdd(s, "Org2MSP", AdminAnySubject, nil) }), "6030")
+	s.Creator = testCreator("Org1MSP", "operator", map[string]string{AdminAttribute: AdminAttributeValue})
+	assertError(t, s.tx(func() error { return AdminAdd(s, "Org2MSP", AdminAnySubject, nil) }), "6030")
+
+	// Org2MSP client with the admin attribute, opt-in by the "*" subject
+	s.Creator = admin
+	assertError(t, s.tx(func() error { return AdminAdd(s, "Org2MSP", "", nil) }), "1000")
+	s.mustTx(t, func() error { return AdminAdd(s, "Org2MSP", AdminAnySubject, nil) })
+	assertError(t, s.tx(func() error { return AdminAdd(s, "Org2MSP", AdminAnySubject, nil) }), "6100")
+
+	s.Creator = testCreator("Org2MSP", "user", nil)
+	if _, err := CheckAdmin(s); err == nil {
+		t.Error("Org2MSP client without the attribute is the admin")
+	}
+	s.Creator = testCreator("Org2MSP", "operator", map[string]string{AdminAttribute: AdminAttributeValue})
+	if _, err := CheckAdmin(s); err != nil {
+		t.Errorf("Org2MSP client with the attribute : %v", err)
+	}
+
+	// Org2MSP removes Org1MSP admin, the last admin stays
+	s.mustTx(t, func() error { return AdminRemove(s, "Org1MSP", "CN=admin,O=Org1MSP", nil) })
+	assertError(t, s.tx(func() error { return AdminRemove(s, "Org2MSP", AdminAnySubject, nil) }), "1003")
+	s.Creator = admin
+	assertError(t, s.tx(func() error { return AdminRemove(s, "Org2MSP", AdminAnySubject, nil) }), "6030")
+	if ev := s.lastEvent(t, "admin_remove"); ev.Key != "Org1MSP" {
+		t.Errorf("admin_remove event key : %s", ev.Key)
+	}
+}
+
+func TestAdminFunction(t *testing.T) {
+	s := newTestStub()
+	newGenesisWallet(t, s, "0")
+
+	// the registered admin function is checked before the handler
+	s.Creator = testCreator("Org2MSP", "user", nil)
+	assertError(t, s.tx(func() error { _, err := CallFunction(s, "adminAdd", []string{"Org3MSP", AdminAnySubject}); return err }), "6030")
+	s.Creator = testCreator("Org1MSP", "admin", nil)
+	s.mustTx(t, func() error { _, err := CallFunction(s, "adminAdd", []string{"Org3MSP", AdminAnySubject}); return err })
+	if ev := s.lastEvent(t, "admin_add"); ev.Key != "Org3MSP" {
+		t.Errorf("admin_add event key : %s", ev.Key)
+	}
+}
@@ -0,0 +1,216 @@
+package metacoin
+
+import (
+	"encoding/json"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/shopspring/decimal"
+
+	"inblock/metacoin/mcerr"
+	"inblock/metacoin/mtc"
+	"inblock/metacoin/util"
+)
+
+// MRC010 allowance
+//
+// The owner approves the spender to move the token up to the amount, and the
+// spender moves it with transferFrom signed by the spender. The allowance is
+// saved to the composite key (owner, spender, token), not to the wallet, so
+// approve and transferFrom do not conflict with the other token of the wallet.
+const ObjectAllowance = "allowance"
+
+// TAllowance : allowance of the spender
+type TAllowance struct {
+	Owner   string `json:"owner"`
+	Spender string `json:"spender"`
+	Token   string `json:"token"`
+	Amount  string `json:"amount"`
+	JobType string `json:"job_type"`
+	JobDate int64  `json:"jobdate"`
+}
+
+// allowanceKey - state key of the allowance
+func allowanceKey(stub shim.ChaincodeStubInterface, owner, spender, token string) (string, error) {
+	key, err := stub.CreateCompositeKey(ObjectAllowance, []string{owner, spender, token})
+	if err != nil {
+		return "", mcerr.Wrap(mcerr.InvalidData, "Allowance key error", err)
+	}
+	return key, nil
+}
+
+// getAllowance - allowance of the spender, the amount is "0" when it is not approved
+func getAllowance(stub shim.ChaincodeStubInterface, owner, spender, token string) (TAllowance, error) {
+	var a = TAllowance{Owner: owner, Spender: spender, Token: token, Amount: "0"}
+
+	key, err := allowanceKey(stub, owner, spender, token)
+	if err != nil {
+		return a, err
+	}
+	value, err := stub.GetState(key)
+	if err != nil {
+		return a, mcerr.Wrap(mcerr.LedgerRead, "Hyperledger internal error", err)
+	}
+	if value == nil {
+		return a, nil
+	}
+	if err = json.Unmarshal(value, &a); err != nil {
+		return a, mcerr.Wrap(mcerr.InvalidData, "Allowance ["+key+"] is in the wrong data", err)
+	}
+	return a, nil
+}
+
+// setAllowance - save the allowance, the zero amount deletes it
+func setAllowance(stub shim.ChaincodeStubInterface, a TAllowance, JobType string) error {
+	key, err := allowanceKey(stub, a.Owner, a.Spender, a.Token)
+	if err != nil {
+		return err
+	}
+	if decimalOf(a.Amount).IsZero() {
+		if err = stub.DelState(key); err != nil {
+			return mcerr.Wrap(mcerr.LedgerWrite, "Hyperledger internal error", err)
+		}
+		return nil
+	}
+
+	a.JobType = JobType
+	a.JobDate = GetTxTime(stub)
+	value, err := json.Marshal(a)
+	if err != nil {
+		return mcerr.Wrap(mcerr.InvalidData, "Invalid Data format", err)
+	}
+	if err = stub.PutState(key, value); err != nil {
+		return mcerr.Wrap(mcerr.LedgerWrite, "Hyperledger internal error", err)
+	}
+	return nil
+}
+
+// Approve - set the allowance of the spender, "0" removes it
+// The owner signs "owner|spender|token|amount|nonce".
+func Approve(stub shim.ChaincodeStubInterface, owner, spender, token, amount, signature, tkey string, args []string) error {
+	var value decimal.Decimal
+	var err error
+
+	if !util.IsAddress(owner) {
+		return mcerr.New(mcerr.InvalidFrom, "Invalid owner address").WithField("owner")
+	}
+	if !util.IsAddress(spender) {
+		return mcerr.New(mcerr.InvalidTo, "Invalid spender address").WithField("spender")
+	}
+	if owner == spender {
+		return mcerr.New(mcerr.SameAddress, "Owner and spender must be different values")
+	}
+	if value, err = util.ParseNotNegative(amount); err != nil {
+		return mcerr.New(mcerr.NotInteger, "Amount must be an integer string").WithField("amount")
+	}
+	if _, _, err = GetMRC010(stub, token); err != nil {
+		return err
+	}
+	if _, err = GetAddressInfo(stub, spender); err != nil {
+		return err
+	}
+	ownerData, err := GetAddressInfo(stub, owner)
+	if err != nil {
+		return err
+	}
+	if err = NonceCheck(stub, &ownerData, tkey, "approve",
+		[]string{owner, spender, token, amount, tkey},
+		signature); err != nil {
+		return err
+	}
+
+	a := TAllowance{Owner: owner, Spender: spender, Token: token, Amount: value.String()}
+	if err = setAllowance(stub, a, "approve"); err != nil {
+		return err
+	}
+	if err = SetAddressInfo(stub, ownerData, "approve", args); err != nil {
+		return err
+	}
+	AddEvent(stub, TEvent{Type: "approve", Key: owner, Args: []string{owner, spender, token, a.Amount}})
+	return nil
+}
+
+// TransferFrom - the spender sends the token of the owner within the allowance
+// The spender signs "spender|from|to|token|amount|nonce".
+func TransferFrom(stub shim.ChaincodeStubInterface, spender, fromAddr, toAddr, token, amount, signature, tkey string, args []string) error {
+	var value decimal.Decimal
+	var err error
+	var fromData, toData, spenderData mtc.TWallet
+
+	if !util.IsAddress(fromAddr) {
+		return mcerr.New(mcerr.InvalidFrom, "Invalid from address").WithField("from")
+	}
+	if !util.IsAddress(toAddr) {
+		return mcerr.New(mcerr.InvalidTo, "Invalid to address").WithField("to")
+	}
+	if fromAddr == toAddr {
+		return mcerr.New(mcerr.SameAddress, "From address and to address must be different values")
+	}
+	if value, err = util.ParsePositive(amount); err != nil {
+		return mcerr.New(mcerr.NotInteger, "Amount must be a positive integer").WithField("amount")
+	}
+	if err = mrc010MoveCheckByID(stub, token, spender); err != nil {
+		return err
+	}
+
+	a, err := getAllowance(stub, fromAddr, spender, token)
+	if err != nil {
+		return err
+	}
+	if value.GreaterThan(decimalOf(a.Amount)) {
+		return mcerr.New(mcerr.NotEnoughAllow, "Not enough allowance").
+			WithDetails(map[string]interface{}{"allowance": a.Amount})
+	}
+
+	if spenderData, err = GetAddressInfo(stub, spender); err != nil {
+		return err
+	}
+	if err = NonceCheck(stub, &spenderData, tkey, "transferFrom",
+		[]string{spender, fromAddr, toAddr, token, amount, tkey},
+		signature); err != nil {
+		return err
+	}
+	if fromData, err = GetAddressInfo(stub, fromAddr); err != nil {
+		return err
+	}
+
+	// the spender can pull the token to the spender wallet
+	var to = &toData
+	if toAddr == spender {
+		to = &spenderData
+	} else if toData, err = GetAddressInfo(stub, toAddr); err != nil {
+		return err
+	}
+	if err = MoveToken(stub, &fromData, to, token, amount, 0); err != nil {
+		if mcerr.Is(err, mcerr.NotEnoughBalance) {
+			return mcerr.New(mcerr.FromBalance, "The balance of fromuser is insufficient")
+		}
+		return err
+	}
+
+	a.Amount = decimalOf(a.Amount).Sub(value).String()
+	if err = setAllowance(stub, a, "transferFrom"); err != nil {
+		return err
+	}
+	if err = SetAddressInfo(stub, fromData, "transferFrom", args); err != nil {
+		return err
+	}
+	if toAddr != spender {
+		if err = SetAddressInfo(stub, toData, "receive", args); err != nil {
+			return err
+		}
+	}
+	if err = SetAddressInfo(stub, spenderData, "transferFromSpender", args); err != nil {
+		return err
+	}
+	AddEvent(stub, TEvent{Type: "transfer_from", Key: fromAddr, Args: args,
+		Payment: []mtc.TDexPaymentInfo{{FromAddr: fromAddr, ToAddr: toAddr, Amount: amount, TokenID: token, PayType: "transfer_from"}}})
+	return nil
+}
+
+// Allowance - allowance of the spender, "0" is not approved
+func Allowance(stub shim.ChaincodeStubInterface, owner, spender, token string) (TAllowance, error) {
+	if !util.IsAddress(owner) || !util.IsAddress(spender) {
+		return TAllowance{}, mcerr.New(mcerr.InvalidAddress, "Invalid address")
+	}
+	return getAllowance(stub, owner, spender, token)
+}
@@ -0,0 +1,70 @@
+package metacoin
+
+import (
+	"testing"
+)
+
+func TestAllowance(t *testing.T) {
+	s := newTestStub()
+	alice := newGenesisWallet(t, s, "1000")
+	bob := newTestWallet(t, s)
+	carol := newTestWallet(t, s)
+
+	approve := func(signer *testWallet, amount string) error {
+		nonce := alice.nonce(t, s)
+		sig := signer.sign(t, s, "approve", alice.Address, bob.Address, "0", amount, nonce)
+		return s.tx(func() error { return Approve(s, alice.Address, bob.Address, "0", amount, sig, nonce, nil) })
+	}
+	transferFrom := func(to *testWallet, amount string) error {
+		nonce := bob.nonce(t, s)
+		sig := bob.sign(t, s, "transferFrom", bob.Address, alice.Address, to.Address, "0", amount, nonce)
+		return s.tx(func() error {
+			return TransferFrom(s, bob.Address, alice.Address, to.Address, "0", amount, sig, nonce, nil)
+		})
+	}
+	allowance := func() string {
+		a, err := Allowance(s, alice.Address, bob.Address, "0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return a.Amount
+	}
+
+	assertError(t, approve(bob, "100"), "2010")
+	if err := approve(alice, "100"); err != nil {
+		t.Fatal(err)
+	}
+	if v := allowance(); v != "100" {
+		t.Errorf("allowance : %s", v)
+	}
+
+	// the spender sends to the other address and to the spender wallet
+	if err := transferFrom(carol, "60"); err != nil {
+		t.Fatal(err)
+	}
+	if err := transferFrom(bob, "30"); err != nil {
+		t.Fatal(err)
+	}
+	assertError(t, transferFrom(carol, "11"), "5010")
+	if v := allowance(); v != "10" {
+		t.Errorf("allowance : %s", v)
+	}
+	assertBalance(t, s, alice, "0", "910")
+	assertBalance(t, s, bob, "0", "30")
+	assertBalance(t, s, carol, "0", "60")
+
+	// the allowance is not the balance
+	if err := approve(alice, "5000"); err != nil {
+		t.Fatal(err)
+	}
+	assertError(t, transferFrom(carol, "1000"), "5001")
+
+	// "0" removes the allowance
+	if err := approve(alice, "0"); err != nil {
+		t.Fatal(err)
+	}
+	if v := allowance(); v != "0" {
+		t.Errorf("allowance : %s", v)
+	}
+	assertError(t, transferFrom(carol, "1"), "5010")
+}
@@ -0,0 +1,179 @@
+package metacoin
+
+import (
+	"sort"
+
+	"github.com/shopspring/decimal"
+
+	"inblock/metacoin/mcerr"
+	"inblock/metacoin/mtc"
+)
+
+// BalanceOf output format
+const (
+	BalanceFormatLegacy = ""   // []TMRC010Balance, locked amount is the item with the unlockdate
+	BalanceFormatV2     = "v2" // map[token]TMRC010BalanceV2
+)
+
+// decimalOf - "" or the invalid value is zero
+func decimalOf(value string) decimal.Decimal {
+	d, err := decimal.NewFromString(value)
+	if err != nil {
+		return decimal.Zero
+	}
+	return d
+}
+
+// newBalanceV2 - empty balance
+func newBalanceV2() mtc.TMRC010BalanceV2 {
+	return mtc.TMRC010BalanceV2{Balance: "0", SaleAmount: "0", AuctionAmount: "0"}
+}
+
+// migrateBalance - move the legacy Balance list to the MRC010 map
+//
+// The locked item of the list goes to the LockedList by the unlock date,
+// the item with the past unlock date goes to the balance.
+func migrateBalance(wallet *mtc.TWallet, now int64) {
+	if wallet.MRC010 == nil {
+		wallet.MRC010 = make(map[int]mtc.TMRC010BalanceV2)
+	}
+	if len(wallet.Balance) == 0 {
+		wallet.Balance = nil
+		return
+	}
+
+	for _, element := range wallet.Balance {
+		b, exists := wallet.MRC010[element.Token]
+		if !exists {
+			b = newBalanceV2()
+		}
+		if element.UnlockDate > now {
+			if b.LockedList == nil {
+				b.LockedList = make(map[int]string)
+			}
+			b.LockedList[int(element.UnlockDate)] = decimalOf(b.LockedList[int(element.UnlockDate)]).
+				Add(decimalOf(element.Balance)).String()
+		} else {
+			b.Balance = decimalOf(b.Balance).Add(decimalOf(element.Balance)).String()
+		}
+		b.SaleAmount = decimalOf(b.SaleAmount).Add(decimalOf(element.SaleAmount)).String()
+		b.AuctionAmount = decimalOf(b.AuctionAmount).Add(decimalOf(element.AuctionAmount)).String()
+		wallet.MRC010[element.Token] = b
+	}
+	wallet.Balance = nil
+}
+
+// releaseLocked - move the locked amount of the past unlock date to the balance
+func releaseLocked(wallet *mtc.TWallet, now int64) {
+	for token, b := range wallet.MRC010 {
+		if len(b.LockedList) == 0 {
+			continue
+		}
+		for unlockDate, amount := range b.LockedList {
+			if int64(unlockDate) > now {
+				continue
+			}
+			b.Balance = decimalOf(b.Balance).Add(decimalOf(amount)).String()
+			delete(b.LockedList, unlockDate)
+		}
+		if len(b.LockedList) == 0 {
+			b.LockedList = nil
+		}
+		wallet.MRC010[token] = b
+	}
+}
+
+// normalizeBalance - legacy migration and the lock release, called on read
+func normalizeBalance(wallet *mtc.TWallet, now int64) {
+	migrateBalance(wallet, now)
+	releaseLocked(wallet, now)
+}
+
+// compactBalance - remove the empty token balance, token 0 is always kept
+func compactBalance(wallet *mtc.TWallet, token int) {
+	b, exists := wallet.MRC010[token]
+	if !exists || token == 0 {
+		return
+	}
+	if decimalOf(b.Balance).IsZero() && decimalOf(b.SaleAmount).IsZero() &&
+		decimalOf(b.AuctionAmount).IsZero() && len(b.LockedList) == 0 {
+		delete(wallet.MRC010, token)
+	}
+}
+
+// mrc010AddBalance - add the amount, locked until unlockDate if it is after now
+// The amount received by the sharded wallet is saved to the shard key.
+func mrc010AddBalance(wallet *mtc.TWallet, token int, amount decimal.Decimal, unlockDate, now int64) {
+	normalizeBalance(wallet, now)
+
+	b, exists := wallet.MRC010[token]
+	if !exists {
+		b = newBalanceV2()
+	}
+	if wallet.Shards > 0 {
+		state := walletState(wallet)
+		credit, exists := state.Credit[token]
+		if !exists {
+			credit = newBalanceV2()
+		}
+		state.Credit[token] = addBalance(credit, amount, unlockDate, now)
+	} else {
+		b = addBalance(b, amount, unlockDate, now)
+	}
+	wallet.MRC010[token] = b
+}
+
+// mrc010SubtractBalance - subtract the amount from the unlocked balance
+// The amount is added to the spent amount of the wallet state. (see delegateSpend)
+func mrc010SubtractBalance(wallet *mtc.TWallet, token int, amount decimal.Decimal, now int64) error {
+	normalizeBalance(wallet, now)
+
+	b, exists := wallet.MRC010[token]
+	if !exists {
+		return mcerr.New(mcerr.NotEnoughBalance, "Not enough balance")
+	}
+	remain := decimalOf(b.Balance).Sub(amount)
+	if remain.IsNegative() {
+		return mcerr.New(mcerr.NotEnoughBalance, "Not enough balance")
+	}
+	b.Balance = remain.String()
+	wallet.MRC010[token] = b
+
+	state := walletState(wallet)
+	if state.Spent == nil {
+		state.Spent = make(map[int]string)
+	}
+	state.Spent[token] = decimalOf(state.Spent[token]).Add(amount).String()
+	return nil
+}
+
+// legacyBalance - MRC010 map to the legacy Balance list
+//
+// The unlocked balance of the token is first, and the locked amount by the
+// unlock date follows. Token 0 is the first item.
+func legacyBalance(wallet mtc.TWallet) []mtc.TMRC010Balance {
+	var tokens []int
+	var list = make([]mtc.TMRC010Balance, 0, len(wallet.MRC010))
+
+	for token := range wallet.MRC010 {
+		tokens = append(tokens, token)
+	}
+	sort.Ints(tokens)
+
+	for _, token := range tokens {
+		b := wallet.MRC010[token]
+		list = append(list, mtc.TMRC010Balance{Balance: b.Balance, Token: token,
+			SaleAmount: b.SaleAmount, AuctionAmount: b.AuctionAmount})
+
+		var dates []int
+		for unlockDate := range b.LockedList {
+			dates = append(dates, unlockDate)
+		}
+		sort.Ints(dates)
+		for _, unlockDate := range dates {
+			list = append(list, mtc.TMRC010Balance{Balance: b.LockedList[unlockDate], Token: token,
+				UnlockDate: int64(unlockDate), SaleAmount: "0", AuctionAmount: "0"})
+		}
+	}
+	return list
+}
@@ -0,0 +1,107 @@
+package metacoin
+
+import (
+	"encoding/json"
+	"strconv"
+	"testing"
+
+	"inblock/metacoin/mtc"
+)
+
+func TestBalanceMigration(t *testing.T) {
+	s := newTestStub()
+	newGenesisWallet(t, s, "0")
+	bob := newTestWallet(t, s)
+	future, past := s.txTime+3600, s.txTime-60
+
+	// wallet saved by the old chaincode
+	wallet := bob.wallet(t, s)
+	wallet.MRC010, wallet.Assets = nil, nil
+	wallet.Balance = []mtc.TMRC010Balance{
+		{Balance: "100", Token: 0},
+		{Balance: "50", Token: 0, UnlockDate: future},
+		{Balance: "30", Token: 0, UnlockDate: past},
+		{Balance: "20", Token: 1, SaleAmount: "5", AuctionAmount: "0"},
+	}
+	s.state[bob.Address], _ = json.Marshal(wallet)
+
+	wallet = bob.wallet(t, s)
+	if wallet.Balance != nil {
+		t.Errorf("legacy balance after read : %+v", wallet.Balance)
+	}
+	b := wallet.MRC010[0]
+	if b.Balance != "130" || len(b.LockedList) != 1 || b.LockedList[int(future)] != "50" {
+		t.Errorf("token 0 : %+v", b)
+	}
+	if b = wallet.MRC010[1]; b.Balance != "20" || b.SaleAmount != "5" {
+		t.Errorf("token 1 : %+v", b)
+	}
+
+	// compatibility output
+	value, err := BalanceOf(s, bob.Address, BalanceFormatLegacy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var list []mtc.TMRC010Balance
+	if err = json.Unmarshal([]byte(value), &list); err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 3 || list[0].Balance != "130" || list[1].UnlockDate != future || list[2].Token != 1 {
+		t.Errorf("legacy format : %s", value)
+	}
+	if value, err = BalanceOf(s, bob.Address, BalanceFormatV2); err != nil {
+		t.Fatal(err)
+	}
+	var v2 map[int]mtc.TMRC010BalanceV2
+	if err = json.Unmarshal([]byte(value), &v2); err != nil || v2[0].LockedList[int(future)] != "50" {
+		t.Errorf("v2 format : %s", value)
+	}
+	if _, err = BalanceOf(s, bob.Address, "v3"); err == nil {
+		t.Error("unknown format without error")
+	}
+
+	// the lock is released after the unlock date
+	s.advance(3600)
+	if b = bob.wallet(t, s).MRC010[0]; b.Balance != "180" || b.LockedList != nil {
+		t.Errorf("token 0 after the unlock date : %+v", b)
+	}
+}
+
+func TestBalanceLocked(t *testing.T) {
+	s := newTestStub()
+	alice := newGenesisWallet(t, s, "1000")
+	bob := newTestWallet(t, s)
+	carol := newTestWallet(t, s)
+	item := registerToken(t, s, alice, "ITEM", "100")
+
+	// 100 locked for an hour
+	unlockDate := strconv.FormatInt(s.txTime+3600, 10)
+	nonce := alice.nonce(t, s)
+	sig := alice.sign(t, s, "transfer", alice.Address, bob.Address, "0", "100", nonce)
+	s.mustTx(t, func() error {
+		return Transfer(s, alice.Address, bob.Address, "100", "0", unlockDate, sig, nonce, nil)
+	})
+	assertBalance(t, s, bob, "0", "0")
+	assertError(t, transfer(t, s, bob, carol, "0", "10"), "5001")
+
+	s.advance(3600)
+	if err := transfer(t, s, bob, carol, "0", "10"); err != nil {
+		t.Fatal(err)
+	}
+	assertBalance(t, s, bob, "0", "90")
+
+	// empty token balance is removed, token 0 is kept
+	if err := transfer(t, s, alice, bob, item, "5"); err != nil {
+		t.Fatal(err)
+	}
+	if err := transfer(t, s, bob, carol, item, "5"); err != nil {
+		t.Fatal(err)
+	}
+	if err := transfer(t, s, bob, carol, "0", "90"); err != nil {
+		t.Fatal(err)
+	}
+	tokens := bob.wallet(t, s).MRC010
+	if _, exists := tokens[1]; exists || len(tokens) != 1 {
+		t.Errorf("bob balance after sending all : %+v", tokens)
+	}
+}
@@ -1,134 +1,145 @@
 package metacoin
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
-	"hash/crc32"
 	"regexp"
 	"strconv"
-	"strings"
-	"time"
 
 	"encoding/json"
-	"encoding/pem"
-
-	"crypto/ecdsa"
-	"crypto/x509"
 
 	"github.com/hyperledger/fabric-chaincode-go/shim"
 	"github.com/shopspring/decimal"
 
+	"inblock/metacoin/mcerr"
 	"inblock/metacoin/mtc"
 	"inblock/metacoin/util"
 )
 
 // NewWallet Create new wallet and address
-func NewWallet(stub shim.ChaincodeStubInterface, publicKey string, addinfo string) (string, error) {
-	var pub interface{}
-	var pubkey *ecdsa.PublicKey
-	var ok bool
-	var err error
-	var block *pem.Block
+// The key type is detected from the public key, hash is the signature hash
+// ("" is the default hash of the key type, the trial hash for ECDSA, see parseWalletKey).
+// payer is the wallet which pays the protocol fee of newwallet, "" is no payer.
+// The payer signs "payer|publicKey|addinfo|hash|nonce".
+func NewWallet(stub shim.ChaincodeStubInterface, publicKey, addinfo, hash, payer, signature, tkey string) (string, error) {
+	mcData, err := newWalletData(stub, publicKey, addinfo, hash)
+	if err != nil {
+		return "", err
+	}
+	if payer != "" {
+		payerData, err := GetAddressInfo(stub, payer)
+		if err != nil {
+			return "", err
+		}
+		if err = NonceCheck(stub, &payerData, tkey, "newwallet",
+			[]string{payer, publicKey, addinfo, hash, tkey},
+			signature); err != nil {
+			return "", err
+		}
+		if err = SetAddressInfo(stub, payerData, "newwalletPayer", []string{mcData.Id, publicKey}); err != nil {
+			return "", err
+		}
+	}
+	if err := SetAddressInfo(stub, mcData, "NewWallet", []string{mcData.Id, publicKey, addinfo, mcData.Hash}); err != nil {
+		return "", err
+	}
+	return mcData.Id, nil
+}
+
+// newWalletData - check the public key and make the new wallet data (not saved)
+func newWalletData(stub shim.ChaincodeStubInterface, publicKey, addinfo, hash string) (mtc.TWallet, error) {
 	var address string
 
+	pub, hash, err := parseWalletKey(publicKey, hash)
+	if err != nil {
+		return mtc.TWallet{}, err
+	}
+
+	// address = hash of public key + tx id. (deterministic for all endorsers)
 	var isSuccess = false
-	for i := 1; i <= 10; i++ {
-		w := util.MakeRandomString(30)
-		address = fmt.Sprintf("MT%30s%08x", w, crc32.Checksum([]byte(w), crc32.MakeTable(crc32.IEEE)))
+	for i := 0; i < 10; i++ {
+		address = util.MakeAddress(pub.Bytes, stub.GetTxID(), i)
 
-		_, err := stub.GetState(address)
+		data, err := stub.GetState(address)
 		if err != nil {
-			continue
-		} else {
+			return mtc.TWallet{}, mcerr.Wrap(mcerr.LedgerRead, "Hyperledger internal error", err)
+		}
+		if data == nil {
 			isSuccess = true
 			break
 		}
 	}
 
-	mcData := mtc.TWallet{Regdate: time.Now().Unix(),
-		Id:       address,
-		Addinfo:  addinfo,
-		Password: publicKey,
-		JobDate:  time.Now().Unix(),
-		JobType:  "NewWallet",
-		Nonce:    util.MakeRandomString(40),
-		Balance:  []mtc.TMRC010Balance{{Balance: "0", Token: 0, UnlockDate: 0}}}
-
 	if !isSuccess {
-		return "", errors.New("3005,Address generate error")
-	}
+		return mtc.TWallet{}, mcerr.New(mcerr.InvalidData, "Address generate error").WithDetails(map[string]interface{}{"tries": 10})
+	}
+
+	mcData := mtc.TWallet{Regdate: GetTxTime(stub),
+		Id:         address,
+		Addinfo:    addinfo,
+		Password:   publicKey,
+		KeyType:    pub.KeyType,
+		Hash:       hash,
+		SignFormat: SignFormatV1,
+		JobDate:    GetTxTime(stub),
+		JobType:    "NewWallet",
+		Nonce:      util.GetMD5(address + "|" + stub.GetTxID()),
+		MRC010:     map[int]mtc.TMRC010BalanceV2{0: newBalanceV2()}}
+	return mcData, nil
+}
 
+// parseWalletKey - parse the public key of the wallet and check the hash
+//
+// "" hash is the default hash of the key type, the ECDSA key keeps "" (HashLegacy)
+// so the SHA-384, SHA-512, SHA-256 trial of the legacy wallet applies. The hash
+// is pinned only when the client sets it.
+func parseWalletKey(publicKey, hash string) (util.TPublicKey, string, error) {
 	if len(publicKey) < 40 {
-		return "", errors.New("3103,Invalid Public key")
+		return util.TPublicKey{}, "", mcerr.New(mcerr.InvalidPublicKey, "Invalid Public key").WithField("publicKey")
 	}
-
-	block, _ = pem.Decode([]byte(mcData.Password))
-	if block == nil {
-		if !strings.Contains(publicKey, "\n") {
-			var dt = len(publicKey) - 24
-			if dt < 26 {
-				return "", errors.New("3103,Public key decode error " + publicKey)
-			}
-			var buf = make([]string, 3)
-			buf[0] = publicKey[0:26]
-			buf[1] = publicKey[26:dt]
-			buf[2] = publicKey[dt:]
-			publicKey = strings.Join(buf, "\n")
-		}
-		block, _ = pem.Decode([]byte(publicKey))
-		if block == nil {
-			return "", errors.New("3103,Public key decode error")
-		}
-	}
-
-	pub, err = x509.ParsePKIXPublicKey(block.Bytes)
+	pub, err := util.ParsePublicKey(publicKey)
 	if err != nil {
-		return "", errors.New("3105,Public key parsing error")
+		return util.TPublicKey{}, "", err
 	}
-
-	switch pub.(type) {
-	case *ecdsa.PublicKey:
-		break
-	default:
-		return "", errors.New("3106,Public key type error")
+	if hash == util.HashLegacy && pub.KeyType == util.KeyTypeECDSA {
+		return pub, util.HashLegacy, nil
 	}
-
-	pubkey, ok = pub.(*ecdsa.PublicKey)
-	if !ok {
-		return "", errors.New("3104,Public key format error")
-	}
-
-	switch pubkey.Curve.Params().BitSize {
-	case 256:
-		break
-	case 384:
-		break
-	case 521:
-		break
-	default:
-		return "", errors.New("3102,Public key curve size must be 384 or 521")
+	if hash, err = util.CheckHash(pub, hash); err != nil {
+		return util.TPublicKey{}, "", err
 	}
-
-	if err := SetAddressInfo(stub, mcData, "NewWallet", []string{address, publicKey, addinfo}); err != nil {
-		return "", err
-	}
-	return address, nil
+	return pub, hash, nil
 }
 
 // BalanceOf - get balance of address.
-func BalanceOf(stub shim.ChaincodeStubInterface, address string) (string, error) {
+// format is BalanceFormatLegacy ("", the list of the old wallet) or BalanceFormatV2 ("v2").
+func BalanceOf(stub shim.ChaincodeStubInterface, address, format string) (string, error) {
 	var err error
 	var dat mtc.TWallet
 	var value []byte
 	if dat, err = GetAddressInfo(stub, address); err != nil {
 		return "[]", err
 	}
-
-	if value, err = json.Marshal(dat.Balance); err != nil {
+	if err = loadAssets(stub, &dat); err != nil {
 		return "[]", err
 	}
-	if value == nil {
-		return "[]", nil
+	for token := range dat.MRC010 {
+		if err = mrc010ShardSum(stub, &dat, token, false); err != nil {
+			return "[]", err
+		}
+	}
+
+	switch format {
+	case BalanceFormatLegacy:
+		value, err = json.Marshal(legacyBalance(dat))
+	case BalanceFormatV2:
+		value, err = json.Marshal(dat.MRC010)
+	default:
+		return "[]", mcerr.New(mcerr.InvalidValue, "Unknown balance format ["+format+"]").WithField("format")
+	}
+	if err != nil {
+		return "[]", err
 	}
 	return string(value), nil
 }
@@ -162,150 +173,69 @@ func DexFeeCalc(
 // MRC010Add 잔액 추가
 func MRC010Add(stub shim.ChaincodeStubInterface, wallet *mtc.TWallet, TokenSN string, amount string, iUnlockDate int64) error {
 	var err error
-	var toCoin, addAmount decimal.Decimal
-	var toIDX, iTokenSN int
-
-	nowTime := time.Now().Unix()
-	if iUnlockDate < nowTime {
-		iUnlockDate = 0
-	}
+	var tk mtc.TMRC010
+	var addAmount decimal.Decimal
+	var iTokenSN int
 
 	if addAmount, err = util.ParsePositive(amount); err != nil {
-		return errors.New("1101," + amount + " is not positive integer")
+		return mcerr.New(mcerr.NotInteger, amount+" is not positive integer")
 	}
 
-	if _, iTokenSN, err = GetMRC010(stub, TokenSN); err != nil {
+	if tk, iTokenSN, err = GetMRC010(stub, TokenSN); err != nil {
 		return err
 	}
-
-	toIDX = -1
-	toCoin = decimal.Zero
-	for index, element := range wallet.Balance {
-		if element.Token != iTokenSN {
-			continue
-		}
-		if element.UnlockDate != iUnlockDate {
-			continue
-		}
-		toCoin, _ = decimal.NewFromString(element.Balance)
-		toIDX = index
-		break
+	if err = mrc010MoveCheck(tk, wallet.Id); err != nil {
+		return err
 	}
-
-	toCoin = toCoin.Add(addAmount).Truncate(0)
-	if toIDX == -1 {
-		if iUnlockDate > 0 {
-			wallet.Balance = append(wallet.Balance, mtc.TMRC010Balance{Balance: toCoin.String(), Token: iTokenSN, UnlockDate: iUnlockDate})
-		} else {
-			wallet.Balance = append(wallet.Balance, mtc.TMRC010Balance{Balance: toCoin.String(), Token: iTokenSN, UnlockDate: 0})
-		}
-	} else {
-		wallet.Balance[toIDX].Balance = toCoin.String()
-		if iUnlockDate > 0 {
-			wallet.Balance[toIDX].UnlockDate = iUnlockDate
-		}
+	if err = loadMRC010(stub, wallet, iTokenSN); err != nil {
+		return err
 	}
+
+	mrc010AddBalance(wallet, iTokenSN, addAmount.Truncate(0), iUnlockDate, GetTxTime(stub))
 	return nil
 }
 
 // MRC010Subtract 잔액 감소
+// The sale or auction amount is added to SaleAmount, AuctionAmount. (see mrc010SubtractSubBalance)
 func MRC010Subtract(stub shim.ChaincodeStubInterface, wallet *mtc.TWallet,
 	TokenSN string, amount string, SubtractType MRC010ModifyType) error {
 	var err error
-	var subtractAmount, remainAmount, fromCoin decimal.Decimal
-	var balanceTemp []mtc.TMRC010Balance
-	var iTokenSN, findIndex int
-
-	nowTime := time.Now().Unix()
+	var tk mtc.TMRC010
+	var subtractAmount decimal.Decimal
+	var iTokenSN int
 
 	// value check
 	if subtractAmount, err = util.ParsePositive(amount); err != nil {
-		return errors.New("1101,Amount must be an integer string")
+		return mcerr.New(mcerr.NotInteger, "Amount must be an integer string").WithField("amount")
 	}
-	remainAmount = subtractAmount
 
 	// mrc010 check
-	if _, iTokenSN, err = GetMRC010(stub, TokenSN); err != nil {
-		return errors.New("8100,Hyperledger internal error - " + err.Error())
+	if tk, iTokenSN, err = GetMRC010(stub, TokenSN); err != nil {
+		return mcerr.Wrap(mcerr.LedgerRead, "Hyperledger internal error", err)
 	}
-
-	for index, element := range wallet.Balance {
-		if element.Token != iTokenSN {
-			continue
-		}
-
-		if nowTime < element.UnlockDate {
-			continue
-		}
-
-		if fromCoin, err = decimal.NewFromString(element.Balance); err != nil {
-			continue
-		}
-
-		if fromCoin.Cmp(remainAmount) < 0 {
-			remainAmount = remainAmount.Sub(fromCoin)
-			wallet.Balance[index].Balance = "0"
-			continue
-		} else {
-			wallet.Balance[index].Balance = fromCoin.Sub(remainAmount).String()
-			remainAmount = remainAmount.Sub(remainAmount)
-			break
-		}
+	if err = mrc010MoveCheck(tk, wallet.Id); err != nil {
+		return err
 	}
-
-	// tradeType
-	if remainAmount.IsPositive() {
-		return errors.New("5000,Not enough balance")
+	if err = loadMRC010(stub, wallet, iTokenSN); err != nil {
+		return err
 	}
-
-	// write sale or auction price.
-	findIndex = -1
-
-	for index, element := range wallet.Balance {
-		// is empty elements ?
-		if element.Token != iTokenSN {
-			continue
-		}
-		if element.UnlockDate != 0 {
-
-			continue
-		}
-		findIndex = index
-		break
+	if err = mrc010ShardSum(stub, wallet, iTokenSN, true); err != nil {
+		return err
 	}
-	if findIndex == -1 {
-		wallet.Balance = append(wallet.Balance, mtc.TMRC010Balance{Balance: "0",
-			Token:         iTokenSN,
-			UnlockDate:    0,
-			AuctionAmount: "0",
-			SaleAmount:    "0"})
-		findIndex = len(wallet.Balance) - 1
+
+	if err = mrc010SubtractBalance(wallet, iTokenSN, subtractAmount, GetTxTime(stub)); err != nil {
+		return err
 	}
 
+	// write sale or auction price.
+	b := wallet.MRC010[iTokenSN]
 	if SubtractType == MRC010MT_Sell {
-		sa, _ := decimal.NewFromString(wallet.Balance[findIndex].SaleAmount)
-		wallet.Balance[findIndex].SaleAmount = sa.Add(subtractAmount).String()
-
+		b.SaleAmount = decimalOf(b.SaleAmount).Add(subtractAmount).String()
 	} else if SubtractType == MRC010MT_Auction {
-		sa, _ := decimal.NewFromString(wallet.Balance[findIndex].AuctionAmount)
-		wallet.Balance[findIndex].AuctionAmount = sa.Add(subtractAmount).String()
+		b.AuctionAmount = decimalOf(b.AuctionAmount).Add(subtractAmount).String()
 	}
-
-	// empty balance remove
-	for _, element := range wallet.Balance {
-		// is empty elements ?
-		if element.Token == 0 {
-			balanceTemp = append(balanceTemp, element)
-			continue
-		}
-
-		if (element.Balance != "0" && element.Balance != "") ||
-			(element.AuctionAmount != "0" && element.AuctionAmount != "") ||
-			(element.SaleAmount != "0" && element.SaleAmount != "") {
-			balanceTemp = append(balanceTemp, element)
-		}
-	}
-	wallet.Balance = balanceTemp
+	wallet.MRC010[iTokenSN] = b
+	compactBalance(wallet, iTokenSN)
 	return nil
 }
 
@@ -329,20 +259,20 @@ func Transfer(stub shim.ChaincodeStubInterface, fromAddr, toAddr, transferAmount
 	var iUnlockDate int64
 
 	if !util.IsAddress(fromAddr) {
-		return errors.New("3001,Invalid from address")
+		return mcerr.New(mcerr.InvalidFrom, "Invalid from address").WithField("from")
 	}
 	if !util.IsAddress(toAddr) {
-		return errors.New("3002,Invalid to address")
+		return mcerr.New(mcerr.InvalidTo, "Invalid to address").WithField("to")
 	}
 	if fromAddr == toAddr {
-		return errors.New("3201,From address and to address must be different values")
+		return mcerr.New(mcerr.SameAddress, "From address and to address must be different values").WithField("to")
 	}
 
 	if _, _, err = GetMRC010(stub, token); err != nil {
 		return err
 	}
 	if iUnlockDate, err = util.Strtoint64(unlockdate); err != nil {
-		return errors.New("1102,Invalid unlock date")
+		return mcerr.New(mcerr.NonceError, "Invalid unlock date").WithField("unlockdate")
 	}
 	if fromData, err = GetAddressInfo(stub, fromAddr); err != nil {
 		return err
@@ -351,15 +281,15 @@ func Transfer(stub shim.ChaincodeStubInterface, fromAddr, toAddr, transferAmount
 		return err
 	}
 
-	if err = NonceCheck(&fromData, tkey,
-		strings.Join([]string{fromAddr, toAddr, token, transferAmount, tkey}, "|"),
+	if err = NonceCheck(stub, &fromData, tkey, "transfer",
+		[]string{fromAddr, toAddr, token, transferAmount, tkey},
 		signature); err != nil {
 		return err
 	}
 
 	if err = MoveToken(stub, &fromData, &toData, token, transferAmount, iUnlockDate); err != nil {
-		if strings.Index(err.Error(), "5000,") == 0 {
-			return errors.New("5001,The balance of fromuser is insufficient")
+		if errors.Is(err, mcerr.NotEnoughBalance) {
+			return mcerr.New(mcerr.FromBalance, "The balance of fromuser is insufficient")
 		}
 		return err
 	}
@@ -370,6 +300,8 @@ func Transfer(stub shim.ChaincodeStubInterface, fromAddr, toAddr, transferAmount
 	if err = SetAddressInfo(stub, toData, "receive", args); err != nil {
 		return err
 	}
+	AddEvent(stub, TEvent{Type: "transfer", Key: fromAddr, Args: args,
+		Payment: []mtc.TDexPaymentInfo{{FromAddr: fromAddr, ToAddr: toAddr, Amount: transferAmount, TokenID: token, PayType: "transfer"}}})
 	fmt.Printf("Transfer [%s] => [%s]  / Amount : [%s] TokenID : [%s] UnlockDate : [%s]\n", fromAddr, toAddr, transferAmount, token, unlockdate)
 	return nil
 }
@@ -381,49 +313,50 @@ func MultiTransfer(stub shim.ChaincodeStubInterface, fromAddr, transferlist, tok
 	var iUnlockDate int64
 	var target []mtc.TMRC010TransferList
 	var toList map[string]int
+	var payment []mtc.TDexPaymentInfo
 
 	if !util.IsAddress(fromAddr) {
-		return errors.New("3001,Invalid from address")
+		return mcerr.New(mcerr.InvalidFrom, "Invalid from address").WithField("from")
 	}
 	if fromData, err = GetAddressInfo(stub, fromAddr); err != nil {
 		return err
 	}
-	if err = NonceCheck(&fromData, tkey,
-		strings.Join([]string{fromAddr, transferlist, token, tkey}, "|"),
+	if err = NonceCheck(stub, &fromData, tkey, "multitransfer",
+		[]string{fromAddr, transferlist, token, tkey},
 		signature); err != nil {
 		return err
 	}
 
 	if err = json.Unmarshal([]byte(transferlist), &target); err != nil {
-		return errors.New("3290,Transfer list is in the wrong data - " + err.Error())
+		return mcerr.Wrap(mcerr.WalletData, "Transfer list is in the wrong data", err).WithField("transferlist")
 	}
 
 	if _, _, err = GetMRC010(stub, token); err != nil {
 		return err
 	}
 	if len(target) < 1 {
-		return errors.New("3002, There are no multiple transmission recipients")
+		return mcerr.New(mcerr.InvalidTo, "There are no multiple transmission recipients").WithField("transferlist")
 	}
 
 	if len(target) > 100 {
-		return errors.New("3002,There must be 100 or fewer recipients of multitransfer")
+		return mcerr.New(mcerr.InvalidTo, "There must be 100 or fewer recipients of multitransfer").WithField("transferlist")
 	}
 
 	toList = make(map[string]int)
 	for _, ele := range target {
 		if !util.IsAddress(ele.Address) {
-			return errors.New("3002,Invalid to address")
+			return mcerr.New(mcerr.InvalidTo, "Invalid to address").WithField("transferlist").WithDetails(ele.Address)
 		}
 		if _, exists := toList[ele.Address]; exists {
-			return errors.New("6100, [" + ele.Address + "] already exists on the transfer list.")
+			return mcerr.New(mcerr.AlreadyExists, "["+ele.Address+"] already exists on the transfer list.").WithField("transferlist")
 		}
 		toList[ele.Address] = 1
 		if fromAddr == ele.Address {
-			return errors.New("3201,From address and to address must be different values")
+			return mcerr.New(mcerr.SameAddress, "From address and to address must be different values").WithField("transferlist")
 		}
 
 		if iUnlockDate, err = util.Strtoint64(ele.UnlockDate); err != nil {
-			return errors.New("1102,Invalid unlock date")
+			return mcerr.New(mcerr.NonceError, "Invalid unlock date").WithField("transferlist").WithDetails(ele.Address)
 		}
 
 		if toData, err = GetAddressInfo(stub, ele.Address); err != nil {
@@ -431,8 +364,8 @@ func MultiTransfer(stub shim.ChaincodeStubInterface, fromAddr, transferlist, tok
 		}
 
 		if err = MoveToken(stub, &fromData, &toData, token, ele.Amount, iUnlockDate); err != nil {
-			if strings.Index(err.Error(), "5000,") == 0 {
-				return errors.New("5001,The balance of fromuser is insufficient")
+			if errors.Is(err, mcerr.NotEnoughBalance) {
+				return mcerr.New(mcerr.FromBalance, "The balance of fromuser is insufficient")
 			}
 			return err
 		}
@@ -447,11 +380,12 @@ func MultiTransfer(stub shim.ChaincodeStubInterface, fromAddr, transferlist, tok
 			return err
 		}
 		fmt.Printf("Transfer [%s] => [%s]  / Amount : [%s] TokenID : [%s] UnlockDate : [%s]\n", fromAddr, ele.Address, ele.Amount, token, ele.UnlockDate)
-
+		payment = append(payment, mtc.TDexPaymentInfo{FromAddr: fromAddr, ToAddr: ele.Address, Amount: ele.Amount, TokenID: token, PayType: "multi_transfer"})
 	}
 	if err = SetAddressInfo(stub, fromData, "multi_transfer", args); err != nil {
 		return err
 	}
+	AddEvent(stub, TEvent{Type: "multi_transfer", Key: fromAddr, Args: args, Payment: payment})
 	return nil
 }
 
@@ -470,45 +404,62 @@ func GetNonce(stub shim.ChaincodeStubInterface, address string) (string, error)
 }
 
 // NonceCheck - nonce check & sign check & generate new nonce
-func NonceCheck(walletData *mtc.TWallet, nonce, Data, signature string) error {
-	if walletData.Nonce != "" {
-		if nonce != walletData.Nonce {
-			return errors.New("1102,nonce error")
-		}
-	} else {
-		// Compatibility code for old wallet users who do not use nonce values
-		if nonce != strconv.FormatInt(walletData.JobDate, 10) {
-			return errors.New("1102,nonce error")
-		}
+// fields are the signed fields of the function, see SignPayload.
+func NonceCheck(stub shim.ChaincodeStubInterface, walletData *mtc.TWallet, nonce, function string, fields []string, signature string) error {
+	if err := nonceMatch(*walletData, nonce); err != nil {
+		return err
 	}
+	data, err := payloadSignVerify(stub, walletData, function, fields, signature)
+	if err != nil {
+		return err
+	}
+	setTxSigner(stub, walletData.Id)
+	walletData.Nonce = nextNonce(walletData.Nonce, data, signature)
+	return nil
+}
 
-	if err := util.EcdsaSignVerify(walletData.Password,
-		Data,
-		signature); err != nil {
+// NonceCheckOnly - nonce check & sign check, the nonce is not changed
+func NonceCheckOnly(stub shim.ChaincodeStubInterface, walletData *mtc.TWallet, nonce, function string, fields []string, signature string) error {
+	if err := nonceMatch(*walletData, nonce); err != nil {
 		return err
 	}
-	walletData.Nonce = util.MakeRandomString(40)
+	if _, err := payloadSignVerify(stub, walletData, function, fields, signature); err != nil {
+		return err
+	}
+	setTxSigner(stub, walletData.Id)
 	return nil
 }
 
-func NonceCheckOnly(walletData *mtc.TWallet, nonce, Data, signature string) error {
+// nonceMatch - nonce is the current nonce of the wallet
+func nonceMatch(walletData mtc.TWallet, nonce string) error {
 	if walletData.Nonce != "" {
 		if nonce != walletData.Nonce {
-			return errors.New("1102,nonce error")
+			return mcerr.New(mcerr.NonceError, "nonce error")
 		}
 	} else {
 		// Compatibility code for old wallet users who do not use nonce values
 		if nonce != strconv.FormatInt(walletData.JobDate, 10) {
-			return errors.New("1102,nonce error")
+			return mcerr.New(mcerr.NonceError, "nonce error")
 		}
 	}
+	return nil
+}
 
-	if err := util.EcdsaSignVerify(walletData.Password,
-		Data,
-		signature); err != nil {
-		return err
+// nextNonce - derive the next wallet nonce from the signed request
+// Every endorser derives the same nonce, a random nonce breaks the endorsement.
+func nextNonce(prevNonce, data, signature string) string {
+	h := sha256.Sum256([]byte(prevNonce + "|" + data + "|" + signature))
+	return hex.EncodeToString(h[:])[:40]
+}
+
+// WalletSignVerify - verify the signature with the key type and the hash of the wallet
+// The legacy wallet (no key type) tries SHA-384, SHA-512 and SHA-256,
+// the multisig wallet takes the JSON array of the signatures.
+func WalletSignVerify(wallet mtc.TWallet, data, signature string) error {
+	if wallet.KeyType == KeyTypeMultisig {
+		return multisigVerify(wallet, data, signature)
 	}
-	return nil
+	return util.SignVerify(wallet.Password, wallet.KeyType, wallet.Hash, data, signature)
 }
 
 // GetAddressInfo address info.
@@ -516,32 +467,37 @@ func GetAddressInfo(stub shim.ChaincodeStubInterface, key string) (mtc.TWallet,
 	var mcData mtc.TWallet
 
 	if !util.IsAddress(key) {
-		return mcData, errors.New("3190,[" + key + "] is not Metacoin address")
+		return mcData, mcerr.New(mcerr.InvalidAddress, "["+key+"] is not Metacoin address")
 	}
 	value, err := stub.GetState(key)
 	if err != nil {
-		return mcData, errors.New("8100,Hyperledger internal error - " + err.Error())
+		return mcData, mcerr.Wrap(mcerr.LedgerRead, "Hyperledger internal error", err)
 	}
 	if value == nil {
-		return mcData, errors.New("3090,Can not find the address [" + key + "]")
+		return mcData, mcerr.New(mcerr.AddressNotFound, "Can not find the address ["+key+"]")
 	}
 	if err = json.Unmarshal(value, &mcData); err != nil {
-		return mcData, errors.New("3290,Address [" + key + "] is in the wrong data - " + err.Error())
+		return mcData, mcerr.Wrap(mcerr.WalletData, "Address ["+key+"] is in the wrong data", err)
 	}
 	if mcData.Id == "" {
 		mcData.Id = key
 	}
+	loadBalance(&mcData)
+	normalizeBalance(&mcData, GetTxTime(stub))
 	return mcData, nil
 }
 
 // SetAddressInfo address info
+// The changed balance is saved to the balance key, the wallet document is
+// written when the other data is changed. (see walletstate.go)
 func SetAddressInfo(stub shim.ChaincodeStubInterface, mcData mtc.TWallet, JobType string, args []string) error {
 	var dat []byte
 	var argdat []byte
 	var err error
 
+	state := walletState(&mcData)
 	mcData.JobType = JobType
-	mcData.JobDate = time.Now().Unix()
+	mcData.JobDate = GetTxTime(stub)
 	if len(args) > 0 {
 		if argdat, err = json.Marshal(args); err == nil {
 			mcData.JobArgs = string(argdat)
@@ -550,12 +506,25 @@ func SetAddressInfo(stub shim.ChaincodeStubInterface, mcData mtc.TWallet, JobTyp
 		mcData.JobArgs = ""
 	}
 
+	if err = delegateSpend(stub, &mcData); err != nil {
+		return err
+	}
+	if mcData.Assets, err = saveBalance(stub, mcData); err != nil {
+		return err
+	}
+	doc := docValue(mcData)
+	if doc == state.Doc {
+		return nil
+	}
+
+	mcData.MRC010, mcData.MRC402, mcData.MRC800, mcData.Balance = nil, nil, nil, nil
 	if dat, err = json.Marshal(mcData); err != nil {
-		return errors.New("3209,Invalid address data format")
+		return mcerr.New(mcerr.InvalidItemData, "Invalid address data format")
 	}
 	if err := stub.PutState(mcData.Id, dat); err != nil {
-		return errors.New("8600,Hyperledger internal error - " + err.Error() + " - " + mcData.Id)
+		return mcerr.New(mcerr.LedgerWrite, "Hyperledger internal error - "+err.Error()+" - "+mcData.Id)
 	}
+	state.Doc = doc
 	return nil
 }
 
@@ -567,17 +536,15 @@ func SignCheck(stub shim.ChaincodeStubInterface, Address, Data, Sign string) err
 		return err
 	}
 	if len(Data) == 0 || len(Data) > 20 {
-		return errors.New("9001, SignCheck data is too long or empty")
+		return mcerr.New(mcerr.SignCheckLength, "SignCheck data is too long or empty")
 	}
 
 	r, _ := regexp.Compile("^[a-zA-Z0-9]{1,20}$")
 	if !r.MatchString(Data) {
-		return errors.New("9002,SignCheck data only accepts a-z, A-Z, 0-9")
+		return mcerr.New(mcerr.SignCheckChars, "SignCheck data only accepts a-z, A-Z, 0-9")
 	}
 
-	if err = util.EcdsaSignVerify(walletData.Password,
-		Data,
-		Sign); err != nil {
+	if err = WalletSignVerify(walletData, Data, Sign); err != nil {
 		return err
 	}
 	return nil
@@ -0,0 +1,176 @@
+package metacoin
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha512"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"testing"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	secp256k1ecdsa "github.com/decred/dcrd/dcrec/secp256k1/v4/ecdsa"
+	"golang.org/x/crypto/sha3"
+
+	"inblock/metacoin/mcerr"
+	"inblock/metacoin/util"
+)
+
+func TestNewWallet(t *testing.T) {
+	s := newTestStub()
+	alice := newTestWallet(t, s)
+	bob := newTestWallet(t, s)
+
+	if alice.Address == bob.Address {
+		t.Fatal("same address for the different key")
+	}
+	wallet := alice.wallet(t, s)
+	if wallet.Regdate != s.txTime {
+		t.Errorf("regdate : got %d, expect %d", wallet.Regdate, s.txTime)
+	}
+	if wallet.Nonce == "" {
+		t.Error("nonce is empty")
+	}
+}
+
+func TestTransfer(t *testing.T) {
+	s := newTestStub()
+	alice := newGenesisWallet(t, s, "1000000")
+	bob := newTestWallet(t, s)
+
+	assertBalance(t, s, alice, "0", "1000000")
+
+	if err := transfer(t, s, alice, bob, "0", "300"); err != nil {
+		t.Fatal(err)
+	}
+	assertBalance(t, s, alice, "0", "999700")
+	assertBalance(t, s, bob, "0", "300")
+
+	ev := s.lastEvent(t, "transfer")
+	if len(ev.Payment) != 1 || ev.Payment[0].ToAddr != bob.Address || ev.Payment[0].Amount != "300" {
+		t.Errorf("transfer event payment : %+v", ev.Payment)
+	}
+
+	// not enough balance, nothing is written
+	assertError(t, transfer(t, s, bob, alice, "0", "301"), "5001")
+	assertBalance(t, s, alice, "0", "999700")
+	assertBalance(t, s, bob, "0", "300")
+
+	// the argument error has the field
+	err := Transfer(s, alice.Address, alice.Address, "1", "0", "0", "", "", nil)
+	if e := mcerr.Parse(err); e.Code != mcerr.SameAddress || e.Field != "to" {
+		t.Errorf("same address error : %+v", e)
+	}
+	err = Transfer(s, alice.Address, bob.Address, "1", "0", "soon", "", "", nil)
+	if e := mcerr.Parse(err); e.Code != mcerr.NonceError || e.Field != "unlockdate" {
+		t.Errorf("unlock date error : %+v", e)
+	}
+}
+
+func TestTransferNonce(t *testing.T) {
+	s := newTestStub()
+	alice := newGenesisWallet(t, s, "1000")
+	bob := newTestWallet(t, s)
+
+	nonce := alice.nonce(t, s)
+	sig := alice.sign(t, s, "transfer", alice.Address, bob.Address, "0", "10", nonce)
+	send := func() error {
+		return s.tx(func() error {
+			return Transfer(s, alice.Address, bob.Address, "10", "0", "0", sig, nonce, nil)
+		})
+	}
+	if err := send(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the next nonce is derived from the signed request, the same on every endorser
+	data, _ := SignPayload(s, "transfer", alice.Address, bob.Address, "0", "10", nonce)
+	if next := alice.nonce(t, s); next != nextNonce(nonce, data, sig) {
+		t.Errorf("next nonce : %s", next)
+	}
+
+	// replay
+	assertError(t, send(), "1102")
+
+	// signed by the other key
+	nonce = alice.nonce(t, s)
+	sig = bob.sign(t, s, "transfer", alice.Address, bob.Address, "0", "10", nonce)
+	assertError(t, send(), "2010")
+	assertBalance(t, s, bob, "0", "10")
+}
+
+func TestNewWalletKeyType(t *testing.T) {
+	s := newTestStub()
+	alice := newGenesisWallet(t, s, "1000")
+
+	// Ed25519 signs the data
+	edPub, edKey, _ := ed25519.GenerateKey(rand.Reader)
+	der, _ := x509.MarshalPKIXPublicKey(edPub)
+	ed := &testWallet{PublicKey: string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})),
+		signer: func(data []byte) []byte { return ed25519.Sign(edKey, data) }}
+
+	// secp256k1 hex key, Keccak-256
+	k1Key, _ := secp256k1.GeneratePrivateKey()
+	k1 := &testWallet{PublicKey: hex.EncodeToString(k1Key.PubKey().SerializeCompressed()),
+		signer: func(data []byte) []byte {
+			hasher := sha3.NewLegacyKeccak256()
+			hasher.Write(data)
+			return secp256k1ecdsa.Sign(k1Key, hasher.Sum(nil)).Serialize()
+		}}
+
+	for _, c := range []struct {
+		w       *testWallet
+		hash    string
+		keyType string
+	}{
+		{ed, "", util.KeyTypeEd25519},
+		{k1, util.HashKeccak256, util.KeyTypeSecp256k1},
+	} {
+		assertError(t, s.tx(func() (err error) {
+			_, err = NewWallet(s, c.w.PublicKey, "", util.HashSHA384, "", "", "")
+			return err
+		}), "3107")
+		s.mustTx(t, func() (err error) {
+			c.w.Address, err = NewWallet(s, c.w.PublicKey, "", c.hash, "", "", "")
+			return err
+		})
+		wallet := c.w.wallet(t, s)
+		if wallet.KeyType != c.keyType || wallet.Hash == "" {
+			t.Errorf("key type of %s : %s, %s", c.keyType, wallet.KeyType, wallet.Hash)
+		}
+
+		if err := transfer(t, s, alice, c.w, "0", "10"); err != nil {
+			t.Fatal(err)
+		}
+		if err := transfer(t, s, c.w, alice, "0", "4"); err != nil {
+			t.Fatalf("transfer of %s : %v", c.keyType, err)
+		}
+		assertBalance(t, s, c.w, "0", "6")
+	}
+
+	// the ECDSA wallet without the hash tries the hashes as the legacy wallet,
+	// the hash set by the client is pinned
+	bob := newTestWallet(t, s)
+	if wallet := bob.wallet(t, s); wallet.KeyType != util.KeyTypeECDSA || wallet.Hash != util.HashLegacy {
+		t.Errorf("P-256 wallet : %s, %s", wallet.KeyType, wallet.Hash)
+	}
+	h := sha512.Sum384([]byte("data"))
+	sig, _ := ecdsa.SignASN1(rand.Reader, bob.key, h[:])
+	if err := WalletSignVerify(bob.wallet(t, s), "data", base64.StdEncoding.EncodeToString(sig)); err != nil {
+		t.Errorf("ECDSA wallet without the hash, SHA-384 : %v", err)
+	}
+
+	carol := newTestKey(t)
+	s.mustTx(t, func() (err error) {
+		carol.Address, err = NewWallet(s, carol.PublicKey, "", util.HashSHA256, "", "", "")
+		return err
+	})
+	if wallet := carol.wallet(t, s); wallet.Hash != util.HashSHA256 {
+		t.Errorf("P-256 wallet with the hash : %s", wallet.Hash)
+	}
+	sig, _ = ecdsa.SignASN1(rand.Reader, carol.key, h[:])
+	assertError(t, WalletSignVerify(carol.wallet(t, s), "data", base64.StdEncoding.EncodeToString(sig)), "2010")
+}
@@ -0,0 +1,102 @@
+package metacoin
+
+import (
+	"encoding/json"
+	"strconv"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+
+	"inblock/metacoin/mcerr"
+	"inblock/metacoin/util"
+)
+
+// BatchMax : max operations in one batch
+const BatchMax = 100
+
+// TBatchOperation : one operation of the batch
+type TBatchOperation struct {
+	Function string   `json:"function"`
+	Args     []string `json:"args"`
+}
+
+// batchStub : stub for the batch operations
+//
+// Fabric GetState returns the committed value only, so the writes of the
+// previous operations are kept here and returned by GetState.
+// Range, composite key and history queries do not see these writes.
+type batchStub struct {
+	shim.ChaincodeStubInterface
+	writes map[string][]byte // nil value = deleted
+}
+
+// GetState - pending write or committed value
+func (b *batchStub) GetState(key string) ([]byte, error) {
+	if value, exists := b.writes[key]; exists {
+		return value, nil
+	}
+	return b.ChaincodeStubInterface.GetState(key)
+}
+
+// PutState - write and keep the value
+func (b *batchStub) PutState(key string, value []byte) error {
+	if err := b.ChaincodeStubInterface.PutState(key, value); err != nil {
+		return err
+	}
+	b.writes[key] = value
+	return nil
+}
+
+// DelState - delete and keep the delete
+func (b *batchStub) DelState(key string) error {
+	if err := b.ChaincodeStubInterface.DelState(key); err != nil {
+		return err
+	}
+	b.writes[key] = nil
+	return nil
+}
+
+// Batch - run the operations in order in one transaction
+//
+// data is JSON array of {function, args}. If an operation fails, the whole
+// transaction fails. Each operation is checked (signature, nonce) the same as
+// a single call, later operations see the writes of the earlier ones.
+// result is JSON array of the operation results.
+//
+// Example
+//
+//	[{"function":"transfer","args":["MT..", "MT..", "100", "0", "sign", "0", "", "", "nonce"]},
+//	 {"function":"mrc010sell","args":[...]}]
+func Batch(stub shim.ChaincodeStubInterface, data string) ([]byte, error) {
+	var operations []TBatchOperation
+	var results []string
+
+	if err := json.Unmarshal([]byte(data), &operations); err != nil {
+		return nil, mcerr.Wrap(mcerr.InvalidData, "Batch data is in the wrong data", err).WithField("operations")
+	}
+	if len(operations) < 1 {
+		return nil, mcerr.New(mcerr.InvalidData, "There is no operation").WithField("operations")
+	}
+	if len(operations) > BatchMax {
+		return nil, mcerr.New(mcerr.InvalidData, "There must be "+strconv.Itoa(BatchMax)+" or fewer operation").WithField("operations")
+	}
+
+	bs := &batchStub{ChaincodeStubInterface: stub, writes: make(map[string][]byte)}
+	results = make([]string, 0, len(operations))
+	for index, op := range operations {
+		if op.Function == "batch" {
+			return nil, mcerr.New(mcerr.InvalidArguments, "batch cannot be nested").WithField("operations")
+		}
+		if op.Args == nil {
+			op.Args = []string{}
+		}
+		value, err := CallFunction(bs, op.Function, op.Args)
+		if err != nil {
+			e := mcerr.Parse(err)
+			return nil, mcerr.New(e.Code, util.GetOrdNumber(index+1)+" operation "+op.Function+" error : "+e.Message).
+				WithField(e.Field).
+				WithDetails(map[string]interface{}{"index": index, "function": op.Function, "details": e.Details})
+		}
+		results = append(results, string(value))
+	}
+	return json.Marshal(results)
+}
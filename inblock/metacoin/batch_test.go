@@ -0,0 +1,58 @@
+package metacoin
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"inblock/metacoin/util"
+)
+
+// batchTransfer - transfer operation and the next nonce of the sender
+func batchTransfer(t *testing.T, s *testStub, from, to *testWallet, amount, nonce string) (TBatchOperation, string) {
+	t.Helper()
+	data, _ := SignPayload(s, "transfer", from.Address, to.Address, "0", amount, nonce)
+	sig := from.sign(t, s, "transfer", from.Address, to.Address, "0", amount, nonce)
+	return TBatchOperation{Function: "transfer",
+		Args: []string{from.Address, to.Address, amount, "0", sig, "0", "", "", nonce}}, nextNonce(nonce, data, sig)
+}
+
+func TestBatch(t *testing.T) {
+	s := newTestStub()
+	alice := newGenesisWallet(t, s, "1000")
+	bob := newTestWallet(t, s)
+	carol := newTestWallet(t, s)
+
+	// alice => bob => carol, and alice => carol with the chained nonce
+	op1, aliceNonce := batchTransfer(t, s, alice, bob, "100", alice.nonce(t, s))
+	op2, _ := batchTransfer(t, s, bob, carol, "60", bob.nonce(t, s))
+	op3, _ := batchTransfer(t, s, alice, carol, "10", aliceNonce)
+	s.mustTx(t, func() error {
+		_, err := Batch(s, util.JSONEncode([]TBatchOperation{op1, op2, op3}))
+		return err
+	})
+	assertBalance(t, s, alice, "0", "890")
+	assertBalance(t, s, bob, "0", "40")
+	assertBalance(t, s, carol, "0", "70")
+	if n := len(s.events[len(s.events)-1].Events); n != 3 {
+		t.Errorf("batch events : got %d, expect 3", n)
+	}
+
+	// 2nd operation fails, nothing is written
+	op1, _ = batchTransfer(t, s, alice, bob, "100", alice.nonce(t, s))
+	op2, _ = batchTransfer(t, s, bob, carol, "141", bob.nonce(t, s))
+	err := s.tx(func() error {
+		_, err := Batch(s, util.JSONEncode([]TBatchOperation{op1, op2}))
+		return err
+	})
+	assertError(t, err, "5001")
+	if !strings.Contains(err.Error(), "2nd operation transfer") {
+		t.Errorf("error message : %s", err.Error())
+	}
+	assertBalance(t, s, alice, "0", "890")
+	assertBalance(t, s, bob, "0", "40")
+
+	// nested batch
+	data, _ := json.Marshal([]TBatchOperation{{Function: "batch", Args: []string{"[]"}}})
+	assertError(t, s.tx(func() error { _, err := Batch(s, string(data)); return err }), "1000")
+}
@@ -0,0 +1,34 @@
+package metacoin
+
+import (
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+
+	"inblock/metacoin/mcerr"
+)
+
+// TxClock returns the unix time (seconds) of the current transaction.
+//
+// All endorsing peers must see the same time, so it is taken from the
+// transaction timestamp, never from the local clock. Tests set the time
+// through the timestamp of the stub.
+func TxClock(stub shim.ChaincodeStubInterface) (int64, error) {
+	ts, err := stub.GetTxTimestamp()
+	if err != nil {
+		return 0, mcerr.Wrap(mcerr.LedgerRead, "Transaction timestamp read error", err)
+	}
+	if ts == nil {
+		return 0, mcerr.New(mcerr.LedgerRead, "Transaction timestamp is missing")
+	}
+	return ts.GetSeconds(), nil
+}
+
+// GetTxTime returns the current transaction time.
+// The proposal always has the timestamp, a missing one panics rather than
+// writing the 1970 date to the expire, the unlock date or the job date.
+func GetTxTime(stub shim.ChaincodeStubInterface) int64 {
+	now, err := TxClock(stub)
+	if err != nil {
+		panic(err)
+	}
+	return now
+}
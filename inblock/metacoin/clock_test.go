@@ -0,0 +1,28 @@
+package metacoin
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestTxClock(t *testing.T) {
+	s := newTestStub()
+	if now := GetTxTime(s); now != s.txTime {
+		t.Errorf("tx time : %d", now)
+	}
+	s.advance(10)
+	if now, err := TxClock(s); err != nil || now != s.txTime {
+		t.Errorf("tx time after advance : %d, %v", now, err)
+	}
+
+	// the missing timestamp is not the 1970 date
+	s.tsErr = errors.New("no timestamp")
+	_, err := TxClock(s)
+	assertError(t, err, "8100")
+	defer func() {
+		if r := recover(); r == nil {
+			t.Error("GetTxTime without the timestamp does not panic")
+		}
+	}()
+	GetTxTime(s)
+}
@@ -0,0 +1,244 @@
+package metacoin
+
+import (
+	"encoding/json"
+	"strconv"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+
+	"inblock/metacoin/mcerr"
+	"inblock/metacoin/mtc"
+	"inblock/metacoin/util"
+)
+
+// Delegate key (session key)
+//
+// The wallet key registers the delegate key with the scope, the function list,
+// the MRC010 spend cap of each token and the expire time. NonceCheck accepts
+// the signature of the delegate for the function in the scope, the delegate
+// signs the canonical payload only (see SignPayload).
+//
+// The MRC010 unlocked balance spent by the request of the delegate (transfer,
+// payment, the amount moved to the sale or the auction, the fee ...) is added
+// to the spent amount of the delegate, and the request over the cap fails. The
+// token without the cap can not be spent by the delegate.
+//
+// The delegate can not sign the functions which change the keys of the wallet,
+// and all delegates are removed when the key or the signers of the wallet change.
+// The delegate can not sign approve either, the allowance is spent by the
+// spender and would not be counted in the cap of the delegate.
+const (
+	DelegateMax       = 16
+	DelegateExpireMax = 365 * 86400
+)
+
+var delegateDenied = map[string]bool{
+	"rotateKey":      true,
+	"confirmKey":     true,
+	"setSigners":     true,
+	"setSignFormat":  true,
+	"setKeyPolicy":   true,
+	"addDelegate":    true,
+	"revokeDelegate": true,
+	"approve":        true,
+}
+
+// setTxDelegate - the delegate signed the request of the address in the transaction
+// "" is the wallet key, the next request of the batch is not counted to the delegate.
+// The wallet can be loaded again in the transaction, so the signer is kept in
+// the transaction context, not in the wallet.
+func setTxDelegate(stub shim.ChaincodeStubInterface, address, publicKey string) {
+	ctx := getTxContext(stub)
+	if publicKey == "" {
+		delete(ctx.delegates, address)
+		return
+	}
+	if ctx.delegates == nil {
+		ctx.delegates = make(map[string]string)
+	}
+	ctx.delegates[address] = publicKey
+}
+
+// txDelegate - delegate key which signed the request of the address, "" is the wallet key
+func txDelegate(stub shim.ChaincodeStubInterface, address string) string {
+	return getTxContext(stub).delegates[address]
+}
+
+// TDelegateRequest : delegate of addDelegate
+// hash "" is the default hash of the key type (see parseWalletKey), caps is token id : spend cap.
+type TDelegateRequest struct {
+	PublicKey string            `json:"publickey"`
+	Hash      string            `json:"hash"`
+	Functions []string          `json:"functions"`
+	Caps      map[string]string `json:"caps"`
+	Expire    int64             `json:"expire"`
+}
+
+// parseDelegate - check the delegate request
+func parseDelegate(stub shim.ChaincodeStubInterface, delegate string) (mtc.TWalletDelegate, error) {
+	var req TDelegateRequest
+	var d mtc.TWalletDelegate
+	now := GetTxTime(stub)
+
+	if err := json.Unmarshal([]byte(delegate), &req); err != nil {
+		return d, mcerr.Wrap(mcerr.InvalidData, "Delegate is in the wrong data", err).WithField("delegate")
+	}
+	pub, hash, err := parseWalletKey(req.PublicKey, req.Hash)
+	if err != nil {
+		return d, err
+	}
+	if len(req.Functions) == 0 {
+		return d, mcerr.New(mcerr.InvalidValue, "Delegate must have the function").WithField("functions")
+	}
+	for _, function := range req.Functions {
+		if function == "" || delegateDenied[function] {
+			return d, mcerr.New(mcerr.InvalidValue, "Function ["+function+"] can not be delegated").WithField("functions")
+		}
+	}
+	if req.Expire <= now || req.Expire > now+DelegateExpireMax {
+		return d, mcerr.New(mcerr.InvalidValue, "Expire must be within "+strconv.Itoa(DelegateExpireMax/86400)+" days").WithField("expire")
+	}
+
+	d = mtc.TWalletDelegate{PublicKey: req.PublicKey, KeyType: pub.KeyType, Hash: hash,
+		Functions: req.Functions, Expire: req.Expire, Regdate: now}
+	for token, amount := range req.Caps {
+		tokenID, err := strconv.Atoi(token)
+		if err != nil || tokenID < 0 {
+			return d, mcerr.New(mcerr.InvalidValue, "Invalid token ["+token+"]").WithField("caps")
+		}
+		limit, err := util.ParsePositive(amount)
+		if err != nil {
+			return d, mcerr.New(mcerr.InvalidValue, "Cap of the token ["+token+"] must be a positive integer").WithField("caps")
+		}
+		if d.Caps == nil {
+			d.Caps = make(map[int]string)
+		}
+		d.Caps[tokenID] = limit.String()
+	}
+	return d, nil
+}
+
+// findDelegate - index of the delegate key, -1 is not found
+func findDelegate(wallet mtc.TWallet, publicKey string) int {
+	for i, d := range wallet.Delegates {
+		if d.PublicKey == publicKey {
+			return i
+		}
+	}
+	return -1
+}
+
+// scopeOf - the function is in the scope of the delegate
+func scopeOf(d mtc.TWalletDelegate, function string) bool {
+	for _, f := range d.Functions {
+		if f == function {
+			return true
+		}
+	}
+	return false
+}
+
+// delegateVerify - public key of the delegate which signs the data for the function, "" is not signed
+func delegateVerify(stub shim.ChaincodeStubInterface, wallet mtc.TWallet, function, data, signature string) string {
+	now := GetTxTime(stub)
+	for _, d := range wallet.Delegates {
+		if d.Expire < now || !scopeOf(d, function) {
+			continue
+		}
+		if util.SignVerify(d.PublicKey, d.KeyType, d.Hash, data, signature) == nil {
+			return d.PublicKey
+		}
+	}
+	return ""
+}
+
+// delegateSpend - add the spent amount of the wallet to the delegate which signed the request
+func delegateSpend(stub shim.ChaincodeStubInterface, wallet *mtc.TWallet) error {
+	state := walletState(wallet)
+	spent := state.Spent
+	state.Spent = nil
+	delegate := txDelegate(stub, wallet.Id)
+	if delegate == "" || len(spent) == 0 {
+		return nil
+	}
+
+	i := findDelegate(*wallet, delegate)
+	if i < 0 {
+		return mcerr.New(mcerr.NoPermission, "Delegate is revoked")
+	}
+	d := &wallet.Delegates[i]
+	for token, amount := range spent {
+		limit, exists := d.Caps[token]
+		total := decimalOf(d.Spent[token]).Add(decimalOf(amount))
+		if !exists || total.GreaterThan(decimalOf(limit)) {
+			return mcerr.New(mcerr.NoPermission, "Delegate spend cap is exceeded").
+				WithDetails(map[string]interface{}{"token": token, "cap": limit, "spent": total.String()})
+		}
+		if d.Spent == nil {
+			d.Spent = make(map[int]string)
+		}
+		d.Spent[token] = total.String()
+	}
+	return nil
+}
+
+// AddDelegate - add or replace the delegate key of the wallet
+//
+// delegate is the TDelegateRequest JSON, the spent amount of the replaced
+// delegate is reset. The expired delegates are removed.
+// The wallet key signs "address|delegate|nonce".
+func AddDelegate(stub shim.ChaincodeStubInterface, address, delegate, signature, tkey string, args []string) error {
+	d, err := parseDelegate(stub, delegate)
+	if err != nil {
+		return err
+	}
+	wallet, err := GetAddressInfo(stub, address)
+	if err != nil {
+		return err
+	}
+	if err = NonceCheck(stub, &wallet, tkey, "addDelegate",
+		[]string{address, delegate, tkey},
+		signature); err != nil {
+		return err
+	}
+
+	var list []mtc.TWalletDelegate
+	for _, v := range wallet.Delegates {
+		if v.Expire >= d.Regdate && v.PublicKey != d.PublicKey {
+			list = append(list, v)
+		}
+	}
+	if len(list) >= DelegateMax {
+		return mcerr.New(mcerr.InvalidValue, "There must be "+strconv.Itoa(DelegateMax)+" or fewer delegate").WithField("delegate")
+	}
+	wallet.Delegates = append(list, d)
+	if err = SetAddressInfo(stub, wallet, "addDelegate", args); err != nil {
+		return err
+	}
+	AddEvent(stub, TEvent{Type: "add_delegate", Key: address, Args: []string{address, d.PublicKey, strconv.FormatInt(d.Expire, 10)}})
+	return nil
+}
+
+// RevokeDelegate - remove the delegate key of the wallet
+// The wallet key signs "address|publicKey|nonce".
+func RevokeDelegate(stub shim.ChaincodeStubInterface, address, publicKey, signature, tkey string, args []string) error {
+	wallet, err := GetAddressInfo(stub, address)
+	if err != nil {
+		return err
+	}
+	i := findDelegate(wallet, publicKey)
+	if i < 0 {
+		return mcerr.New(mcerr.NotFound, "Delegate is not found").WithField("publicKey")
+	}
+	if err = NonceCheck(stub, &wallet, tkey, "revokeDelegate",
+		[]string{address, publicKey, tkey},
+		signature); err != nil {
+		return err
+	}
+	wallet.Delegates = append(wallet.Delegates[:i:i], wallet.Delegates[i+1:]...)
+	if err = SetAddressInfo(stub, wallet, "revokeDelegate", args); err != nil {
+		return err
+	}
+	AddEvent(stub, TEvent{Type: "revoke_delegate", Key: address, Args: []string{address, publicKey}})
+	return nil
+}
@@ -0,0 +1,86 @@
+package metacoin
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestDelegate(t *testing.T) {
+	s := newTestStub()
+	alice := newGenesisWallet(t, s, "1000")
+	bob := newTestWallet(t, s)
+	item := registerToken(t, s, alice, "ITEM", "100")
+	session := newTestKey(t)
+	session.Address = alice.Address
+
+	addDelegate := func(signer *testWallet, functions []string, caps map[string]string, expire int64) error {
+		value, _ := json.Marshal(TDelegateRequest{PublicKey: session.PublicKey, Functions: functions, Caps: caps, Expire: expire})
+		nonce := alice.nonce(t, s)
+		sig := signer.sign(t, s, "addDelegate", alice.Address, string(value), nonce)
+		return s.tx(func() error { return AddDelegate(s, alice.Address, string(value), sig, nonce, nil) })
+	}
+	caps := map[string]string{"0": "50"}
+
+	assertError(t, addDelegate(alice, []string{"transfer", "rotateKey"}, caps, s.txTime+3600), "1003")
+	assertError(t, addDelegate(alice, []string{"transfer", "approve"}, caps, s.txTime+3600), "1003")
+	assertError(t, addDelegate(alice, []string{"transfer"}, caps, s.txTime), "1003")
+	assertError(t, addDelegate(session, []string{"transfer"}, caps, s.txTime+3600), "2010")
+	if err := addDelegate(alice, []string{"transfer"}, caps, s.txTime+3600); err != nil {
+		t.Fatal(err)
+	}
+
+	// the delegate spends within the cap of the token
+	if err := transfer(t, s, session, bob, "0", "30"); err != nil {
+		t.Fatal(err)
+	}
+	assertError(t, transfer(t, s, session, bob, "0", "21"), "6030")
+	assertError(t, transfer(t, s, session, bob, item, "1"), "6030")
+	if err := transfer(t, s, session, bob, "0", "20"); err != nil {
+		t.Fatal(err)
+	}
+	if d := alice.wallet(t, s).Delegates[0]; d.Spent[0] != "50" {
+		t.Errorf("spent of the delegate : %+v", d.Spent)
+	}
+	assertBalance(t, s, bob, "0", "50")
+
+	// the delegate can not sign out of the scope, the wallet key is not limited
+	nonce := alice.nonce(t, s)
+	sig := session.sign(t, s, "revokeDelegate", alice.Address, session.PublicKey, nonce)
+	assertError(t, s.tx(func() error { return RevokeDelegate(s, alice.Address, session.PublicKey, sig, nonce, nil) }), "2010")
+	if err := transfer(t, s, alice, bob, "0", "100"); err != nil {
+		t.Fatal(err)
+	}
+
+	// revoke
+	nonce = alice.nonce(t, s)
+	sig = alice.sign(t, s, "revokeDelegate", alice.Address, session.PublicKey, nonce)
+	s.mustTx(t, func() error { return RevokeDelegate(s, alice.Address, session.PublicKey, sig, nonce, nil) })
+	assertError(t, transfer(t, s, session, bob, "0", "1"), "2010")
+
+	// expire
+	if err := addDelegate(alice, []string{"transfer"}, map[string]string{item: "10"}, s.txTime+10); err != nil {
+		t.Fatal(err)
+	}
+	if err := transfer(t, s, session, bob, item, "10"); err != nil {
+		t.Fatal(err)
+	}
+	s.advance(11)
+	assertError(t, transfer(t, s, session, bob, item, "1"), "2010")
+	if n := len(alice.wallet(t, s).Delegates); n != 1 {
+		t.Errorf("delegate count : %d", n)
+	}
+	assertBalance(t, s, bob, item, "10")
+
+	// the delegates of the replaced key are removed
+	if err := addDelegate(alice, []string{"transfer"}, caps, s.txTime+3600); err != nil {
+		t.Fatal(err)
+	}
+	key2 := newTestKey(t)
+	nonce = alice.nonce(t, s)
+	sig = alice.sign(t, s, "rotateKey", alice.Address, key2.PublicKey, "", "", nonce)
+	s.mustTx(t, func() error { return RotateKey(s, alice.Address, key2.PublicKey, "", "", sig, nonce, nil) })
+	if n := len(alice.wallet(t, s).Delegates); n != 0 {
+		t.Errorf("delegate count after rotateKey : %d", n)
+	}
+	assertError(t, transfer(t, s, session, bob, "0", "1"), "2010")
+}
@@ -0,0 +1,78 @@
+package metacoin
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+
+	"inblock/metacoin/mcerr"
+	"inblock/metacoin/mtc"
+)
+
+// EventName : chaincode event name
+// Fabric keeps only one event per transaction, so every state change of the
+// transaction is collected in one TEventEnvelope and sent at the end. The
+// envelope is kept in the transaction context (see txContext), the events of
+// the failed transaction are dropped with it.
+const EventName = "metacoin"
+
+// TEvent : one state change
+type TEvent struct {
+	Type    string                `json:"type"`              // snake case job type, transfer, token_pause, mrc010_buy ...
+	Key     string                `json:"key,omitempty"`     // changed state key (token id, DEX id, MRC401 id ...)
+	Args    []string              `json:"args,omitempty"`    // job arguments
+	Payment []mtc.TDexPaymentInfo `json:"payment,omitempty"` // balance movement
+}
+
+// TEventEnvelope : all events of one transaction
+type TEventEnvelope struct {
+	TxID      string   `json:"txid"`
+	Timestamp int64    `json:"timestamp"`
+	Events    []TEvent `json:"events"`
+}
+
+// AddEvent - add event to the transaction event envelope
+func AddEvent(stub shim.ChaincodeStubInterface, event TEvent) {
+	ctx := getTxContext(stub)
+	if ctx.events == nil {
+		ctx.events = &TEventEnvelope{TxID: stub.GetTxID(), Timestamp: GetTxTime(stub)}
+	}
+	event.Type = eventType(event.Type)
+	ctx.events.Events = append(ctx.events.Events, event)
+}
+
+// eventType - snake case of the job type, tokenPause is token_pause
+func eventType(jobType string) string {
+	var sb strings.Builder
+
+	for i, c := range jobType {
+		if c >= 'A' && c <= 'Z' {
+			if i > 0 && jobType[i-1] != '_' {
+				sb.WriteByte('_')
+			}
+			c += 'a' - 'A'
+		}
+		sb.WriteRune(c)
+	}
+	return sb.String()
+}
+
+// FlushEvents - send the event envelope, call once at the end of the transaction
+func FlushEvents(stub shim.ChaincodeStubInterface) error {
+	ctx := getTxContext(stub)
+	env := ctx.events
+	ctx.events = nil
+
+	if env == nil || len(env.Events) == 0 {
+		return nil
+	}
+	data, err := json.Marshal(env)
+	if err != nil {
+		return mcerr.Wrap(mcerr.InvalidData, "Event data format error", err)
+	}
+	if err = stub.SetEvent(EventName, data); err != nil {
+		return mcerr.Wrap(mcerr.LedgerWrite, "Hyperledger internal error", err)
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package metacoin
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestEvents(t *testing.T) {
+	s := newTestStub()
+	alice := newGenesisWallet(t, s, "1000")
+	bob := newTestWallet(t, s)
+	item := registerToken(t, s, alice, "ITEM", "100")
+
+	// one envelope per transaction with the tx id and the tx time
+	if err := transfer(t, s, alice, bob, "0", "10"); err != nil {
+		t.Fatal(err)
+	}
+	env := s.events[len(s.events)-1]
+	if env.TxID != fmt.Sprintf("%064x", s.txNo) || env.Timestamp != s.txTime || len(env.Events) != 1 {
+		t.Fatalf("envelope : %+v", env)
+	}
+	if p := env.Events[0].Payment; len(p) != 1 || p[0].FromAddr != alice.Address || p[0].ToAddr != bob.Address || p[0].Amount != "10" {
+		t.Errorf("transfer payment : %+v", p)
+	}
+
+	// the failed transaction sends no event
+	count := len(s.events)
+	if err := transfer(t, s, bob, alice, "0", "11"); err == nil {
+		t.Fatal("transfer over the balance")
+	}
+	if len(s.events) != count {
+		t.Errorf("event of the failed transaction : %+v", s.events[len(s.events)-1])
+	}
+
+	// token increase and burning carry the supply movement
+	tokenJob := func(function, amount string) error {
+		nonce := alice.nonce(t, s)
+		sig := alice.sign(t, s, function, item, amount, nonce)
+		args := []string{item, amount, "memo", sig, nonce}
+		return s.tx(func() error {
+			if function == "tokenIncrease" {
+				return TokenIncrease(s, args)
+			}
+			return TokenBurning(s, args)
+		})
+	}
+	if err := tokenJob("tokenIncrease", "50"); err != nil {
+		t.Fatal(err)
+	}
+	p := s.lastEvent(t, "token_increase").Payment
+	if len(p) != 1 || p[0].FromAddr != "TOKEN_DATA_"+item || p[0].ToAddr != alice.Address || p[0].Amount != "50" || p[0].PayType != "token_increase" {
+		t.Errorf("tokenIncrease payment : %+v", p)
+	}
+	if err := tokenJob("tokenBurning", "30"); err != nil {
+		t.Fatal(err)
+	}
+	p = s.lastEvent(t, "token_burning").Payment
+	if len(p) != 1 || p[0].FromAddr != alice.Address || p[0].ToAddr != "TOKEN_DATA_"+item || p[0].Amount != "30" || p[0].PayType != "token_burn" {
+		t.Errorf("tokenBurning payment : %+v", p)
+	}
+	assertBalance(t, s, alice, item, "120")
+}
+
+func TestEventType(t *testing.T) {
+	for jobType, expect := range map[string]string{
+		"transfer":              "transfer",
+		"tokenPause":            "token_pause",
+		"setKeyPolicy":          "set_key_policy",
+		"mrc010_auctionwinning": "mrc010_auctionwinning",
+		"NewWallet":             "new_wallet",
+	} {
+		if got := eventType(jobType); got != expect {
+			t.Errorf("eventType(%s) : %s, expect %s", jobType, got, expect)
+		}
+	}
+}
+
+func TestTxContext(t *testing.T) {
+	s := newTestStub()
+	alice := newGenesisWallet(t, s, "1000")
+	bob := newTestWallet(t, s)
+
+	// the panic of the transaction leaves nothing for the next one
+	func() {
+		defer func() { recover() }()
+		s.tx(func() error {
+			AddEvent(s, TEvent{Type: "transfer", Key: "aborted"})
+			setTxSigner(s, bob.Address)
+			panic("abort")
+		})
+	}()
+	if err := transfer(t, s, alice, bob, "0", "10"); err != nil {
+		t.Fatal(err)
+	}
+	if env := s.events[len(s.events)-1]; len(env.Events) != 1 || env.Events[0].Key != alice.Address {
+		t.Errorf("envelope : %+v", env)
+	}
+
+	// outside the transaction there is no context
+	defer func() {
+		if recover() == nil {
+			t.Error("event outside the transaction")
+		}
+	}()
+	AddEvent(s, TEvent{Type: "transfer"})
+}
@@ -0,0 +1,227 @@
+package metacoin
+
+import (
+	"encoding/json"
+	"strconv"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+
+	"inblock/metacoin/mcerr"
+	"inblock/metacoin/mtc"
+	"inblock/metacoin/util"
+)
+
+// Protocol fee
+//
+// The admin sets the fee of the write function. After the function succeeds,
+// CallFunction moves the fee from the wallet which signed the request (the
+// first NonceCheck of the function) to the treasury address in the same
+// transaction. The fee of the function is the list of the token and the
+// amount, the first one the wallet can pay is charged.
+//
+// The exempt address does not pay. The exempt MSP is checked against the
+// client which submits the transaction, not the signing wallet: every request
+// submitted by the client of the exempt MSP is free, whichever wallet signed
+// it, so only the MSP of the trusted operator should be listed. The function
+// without the signing wallet can not be charged, newwallet takes the payer
+// wallet for the fee and relayedTransfer charges the relayer.
+const (
+	feeScheduleKey = "FEE_SCHEDULE"
+	FeeChoiceMax   = 8
+)
+
+// TFeeAmount : fee of the function in the token
+type TFeeAmount struct {
+	Token  string `json:"token"`
+	Amount string `json:"amount"`
+}
+
+// TFeeSchedule : protocol fee, saved to the FEE_SCHEDULE key
+type TFeeSchedule struct {
+	Treasury  string                  `json:"treasury"`
+	Fees      map[string][]TFeeAmount `json:"fees"`      // function : fee list
+	Exempt    []string                `json:"exempt"`    // fee exempt address
+	ExemptMSP []string                `json:"exemptmsp"` // fee exempt MSP of the submitting client, any signer
+	JobType   string                  `json:"job_type"`
+	JobArgs   string                  `json:"job_args"`
+	JobDate   int64                   `json:"jobdate"`
+}
+
+// setTxSigner - keep the first wallet which signed the function
+func setTxSigner(stub shim.ChaincodeStubInterface, address string) {
+	if ctx := getTxContext(stub); ctx.signer == "" {
+		ctx.signer = address
+	}
+}
+
+// takeTxSigner - wallet which signed the function, "" is not signed
+func takeTxSigner(stub shim.ChaincodeStubInterface) string {
+	ctx := getTxContext(stub)
+	address := ctx.signer
+	ctx.signer = ""
+	return address
+}
+
+// GetFeeSchedule - protocol fee, no fee if not set
+func GetFeeSchedule(stub shim.ChaincodeStubInterface) (TFeeSchedule, error) {
+	var fs TFeeSchedule
+
+	value, err := stub.GetState(feeScheduleKey)
+	if err != nil {
+		return fs, mcerr.Wrap(mcerr.LedgerRead, "Hyperledger internal error", err)
+	}
+	if value == nil {
+		fs.Fees = make(map[string][]TFeeAmount)
+		return fs, nil
+	}
+	if err = json.Unmarshal(value, &fs); err != nil {
+		return fs, mcerr.Wrap(mcerr.InvalidData, "Fee schedule data is broken", err)
+	}
+	return fs, nil
+}
+
+// checkFeeSchedule - check the functions, the tokens and the addresses of the fee schedule
+func checkFeeSchedule(stub shim.ChaincodeStubInterface, fs *TFeeSchedule) error {
+	if _, err := GetAddressInfo(stub, fs.Treasury); err != nil {
+		return mcerr.Wrap(mcerr.InvalidAddress, "Invalid treasury address", err).WithField("treasury")
+	}
+	for function, fees := range fs.Fees {
+		f, exists := GetFunction(function)
+		if !exists || f.Type != FuncWrite || f.Admin || function == "batch" {
+			return mcerr.New(mcerr.InvalidValue, "Function ["+function+"] can not have the fee").WithField("fees")
+		}
+		if len(fees) == 0 || len(fees) > FeeChoiceMax {
+			return mcerr.New(mcerr.InvalidValue, "Fee of ["+function+"] must be 1 ~ "+strconv.Itoa(FeeChoiceMax)+" tokens").WithField("fees")
+		}
+		for i, fee := range fees {
+			if _, _, err := GetMRC010(stub, fee.Token); err != nil {
+				return err
+			}
+			amount, err := util.ParsePositive(fee.Amount)
+			if err != nil {
+				return mcerr.New(mcerr.InvalidValue, "Fee of ["+function+"] must be a positive integer").WithField("fees")
+			}
+			fees[i].Amount = amount.String()
+		}
+	}
+	for _, address := range fs.Exempt {
+		if !util.IsAddress(address) {
+			return mcerr.New(mcerr.InvalidAddress, "["+address+"] is not Metacoin address").WithField("exempt")
+		}
+	}
+	for _, mspid := range fs.ExemptMSP {
+		if mspid == "" {
+			return mcerr.New(mcerr.InvalidValue, "MSP ID is empty").WithField("exemptmsp")
+		}
+	}
+	return nil
+}
+
+// SetFeeSchedule - replace the protocol fee (admin)
+//
+// schedule is the TFeeSchedule JSON, ex)
+//
+//	{"treasury":"MT...","fees":{"newwallet":[{"token":"0","amount":"10"}],
+//	 "mrc100Log":[{"token":"0","amount":"1"},{"token":"5","amount":"3"}]},
+//	 "exempt":["MT..."],"exemptmsp":["Org1MSP"]}
+func SetFeeSchedule(stub shim.ChaincodeStubInterface, schedule string, args []string) error {
+	var fs TFeeSchedule
+	var dat []byte
+
+	id, err := CheckAdmin(stub)
+	if err != nil {
+		return err
+	}
+	if err = json.Unmarshal([]byte(schedule), &fs); err != nil {
+		return mcerr.Wrap(mcerr.InvalidData, "Fee schedule is in the wrong data", err).WithField("schedule")
+	}
+	if fs.Fees == nil {
+		fs.Fees = make(map[string][]TFeeAmount)
+	}
+	if err = checkFeeSchedule(stub, &fs); err != nil {
+		return err
+	}
+
+	fs.JobType = "setFeeSchedule"
+	fs.JobDate = GetTxTime(stub)
+	if len(args) > 0 {
+		if dat, err = json.Marshal(args); err == nil {
+			fs.JobArgs = string(dat)
+		}
+	}
+	if dat, err = json.Marshal(fs); err != nil {
+		return mcerr.Wrap(mcerr.InvalidData, "Invalid Data format", err)
+	}
+	if err = stub.PutState(feeScheduleKey, dat); err != nil {
+		return mcerr.Wrap(mcerr.LedgerWrite, "Hyperledger internal error", err)
+	}
+	AddEvent(stub, TEvent{Type: "set_fee_schedule", Key: fs.Treasury, Args: []string{schedule, id.String()}})
+	return nil
+}
+
+// feeExempt - the signer or the submitter does not pay the fee
+// The exempt MSP exempts by the submitting client, whichever wallet signed.
+func feeExempt(stub shim.ChaincodeStubInterface, fs TFeeSchedule, signer string) bool {
+	if signer == fs.Treasury {
+		return true
+	}
+	for _, address := range fs.Exempt {
+		if address == signer {
+			return true
+		}
+	}
+	if len(fs.ExemptMSP) == 0 {
+		return false
+	}
+	id, err := GetIdentity(stub)
+	if err != nil {
+		return false
+	}
+	for _, mspid := range fs.ExemptMSP {
+		if mspid == id.MSPID {
+			return true
+		}
+	}
+	return false
+}
+
+// chargeFee - move the fee of the function from the signer to the treasury
+func chargeFee(stub shim.ChaincodeStubInterface, fs TFeeSchedule, function, signer string) error {
+	fees := fs.Fees[function]
+	if len(fees) == 0 {
+		return nil
+	}
+	if signer == "" {
+		return mcerr.New(mcerr.NoPermission, "Function ["+function+"] has the fee, the paying wallet must sign")
+	}
+	if feeExempt(stub, fs, signer) {
+		return nil
+	}
+
+	payer, err := GetAddressInfo(stub, signer)
+	if err != nil {
+		return err
+	}
+	treasury, err := GetAddressInfo(stub, fs.Treasury)
+	if err != nil {
+		return err
+	}
+	for _, fee := range fees {
+		if err = MoveToken(stub, &payer, &treasury, fee.Token, fee.Amount, 0); err != nil {
+			if mcerr.Is(err, mcerr.NotEnoughBalance) {
+				continue
+			}
+			return err
+		}
+		if err = SetAddressInfo(stub, payer, "fee", []string{function, fee.Token, fee.Amount}); err != nil {
+			return err
+		}
+		if err = SetAddressInfo(stub, treasury, "receive_fee", []string{function, fee.Token, fee.Amount}); err != nil {
+			return err
+		}
+		AddEvent(stub, TEvent{Type: "fee", Key: signer, Args: []string{function},
+			Payment: []mtc.TDexPaymentInfo{{FromAddr: signer, ToAddr: fs.Treasury, Amount: fee.Amount, TokenID: fee.Token, PayType: "protocol_fee"}}})
+		return nil
+	}
+	return mcerr.New(mcerr.NotEnoughBalance, "Not enough balance for the fee of ["+function+"]").WithDetails(fees)
+}
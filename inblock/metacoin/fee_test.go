@@ -0,0 +1,105 @@
+package metacoin
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestFee(t *testing.T) {
+	s := newTestStub()
+	alice := newGenesisWallet(t, s, "1000")
+	bob := newTestWallet(t, s)
+	treasury := newTestWallet(t, s)
+	item := registerToken(t, s, alice, "ITEM", "100")
+	if err := transfer(t, s, alice, bob, item, "50"); err != nil {
+		t.Fatal(err)
+	}
+	if err := transfer(t, s, alice, bob, "0", "10"); err != nil {
+		t.Fatal(err)
+	}
+
+	setFee := func(fs TFeeSchedule) error {
+		value, _ := json.Marshal(fs)
+		return s.tx(func() error { return SetFeeSchedule(s, string(value), nil) })
+	}
+	send := func(from, to *testWallet, token, amount string) error {
+		nonce := from.nonce(t, s)
+		sig := from.sign(t, s, "transfer", from.Address, to.Address, token, amount, nonce)
+		return s.tx(func() error {
+			_, err := CallFunction(s, "transfer", []string{from.Address, to.Address, amount, token, sig, "0", "", "", nonce})
+			return err
+		})
+	}
+	fees := []TFeeAmount{{Token: "0", Amount: "4"}, {Token: item, Amount: "2"}}
+
+	assertError(t, setFee(TFeeSchedule{Treasury: treasury.Address, Fees: map[string][]TFeeAmount{"unknown": fees}}), "1003")
+	assertError(t, setFee(TFeeSchedule{Treasury: treasury.Address, Fees: map[string][]TFeeAmount{"setFeeSchedule": fees}}), "1003")
+	assertError(t, setFee(TFeeSchedule{Treasury: treasury.Address, Fees: map[string][]TFeeAmount{"transfer": {{Token: "0", Amount: "-1"}}}}), "1003")
+	s.Creator = testCreator("Org2MSP", "user", nil)
+	assertError(t, setFee(TFeeSchedule{Treasury: treasury.Address, Fees: map[string][]TFeeAmount{"transfer": fees}}), "6030")
+	s.Creator = testCreator("Org1MSP", "admin", nil)
+	if err := setFee(TFeeSchedule{Treasury: treasury.Address, Fees: map[string][]TFeeAmount{"transfer": fees}}); err != nil {
+		t.Fatal(err)
+	}
+
+	// the signer pays the first fee token it can pay
+	if err := send(bob, alice, "0", "1"); err != nil {
+		t.Fatal(err)
+	}
+	assertBalance(t, s, bob, "0", "5")
+	assertBalance(t, s, treasury, "0", "4")
+	if err := send(bob, alice, "0", "3"); err != nil {
+		t.Fatal(err)
+	}
+	assertBalance(t, s, bob, "0", "2")
+	assertBalance(t, s, bob, item, "48")
+	assertBalance(t, s, treasury, item, "2")
+	if ev := s.lastEvent(t, "fee"); len(ev.Payment) != 1 || ev.Payment[0].TokenID != item {
+		t.Errorf("fee event : %+v", ev)
+	}
+
+	// the function and the fee fail together
+	assertError(t, send(bob, alice, item, "47"), "5000")
+	assertBalance(t, s, bob, item, "48")
+
+	// exempt address and MSP
+	if err := setFee(TFeeSchedule{Treasury: treasury.Address, Fees: map[string][]TFeeAmount{"transfer": fees},
+		Exempt: []string{alice.Address}, ExemptMSP: []string{"Org2MSP"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := send(alice, bob, "0", "10"); err != nil {
+		t.Fatal(err)
+	}
+	assertBalance(t, s, alice, "0", "984")
+	s.Creator = testCreator("Org2MSP", "user", nil)
+	if err := send(bob, alice, item, "48"); err != nil {
+		t.Fatal(err)
+	}
+	assertBalance(t, s, bob, item, "0")
+	assertBalance(t, s, treasury, "0", "4")
+
+	// newwallet is paid by the payer wallet
+	s.Creator = testCreator("Org1MSP", "admin", nil)
+	if err := setFee(TFeeSchedule{Treasury: treasury.Address, Fees: map[string][]TFeeAmount{"newwallet": fees}}); err != nil {
+		t.Fatal(err)
+	}
+	publicKey := strings.TrimSpace(newTestKey(t).PublicKey)
+	assertError(t, s.tx(func() error { _, err := CallFunction(s, "newwallet", []string{publicKey, ""}); return err }), "6030")
+	nonce := alice.nonce(t, s)
+	sig := alice.sign(t, s, "newwallet", alice.Address, publicKey, "", "", nonce)
+	s.mustTx(t, func() error {
+		_, err := CallFunction(s, "newwallet", []string{publicKey, "", "", alice.Address, sig, nonce})
+		return err
+	})
+	assertBalance(t, s, alice, "0", "980")
+	assertBalance(t, s, treasury, "0", "8")
+
+	fs, err := GetFeeSchedule(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fs.Treasury != treasury.Address || len(fs.Fees["newwallet"]) != 2 || fs.JobType != "setFeeSchedule" {
+		t.Errorf("fee schedule : %+v", fs)
+	}
+}
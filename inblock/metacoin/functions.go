@@ -0,0 +1,647 @@
+package metacoin
+
+import (
+	"encoding/json"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+
+	"inblock/metacoin/mcerr"
+	"inblock/metacoin/util"
+)
+
+// =========================================
+// functions.go
+// chaincode function catalog
+// =========================================
+
+// argsHandler - handler for functions that take the whole argument list and return only error
+func argsHandler(fn func(stub shim.ChaincodeStubInterface, args []string) error) FuncHandler {
+	return func(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+		return nil, fn(stub, args)
+	}
+}
+
+// stringResult - convert (string, error) result to handler result
+func stringResult(value string, err error) ([]byte, error) {
+	if err != nil {
+		return nil, err
+	}
+	return []byte(value), nil
+}
+
+// jsonResult - convert (value, error) result to JSON handler result
+func jsonResult(value interface{}, err error) ([]byte, error) {
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(value)
+}
+
+// getState - raw state value
+func getState(stub shim.ChaincodeStubInterface, key string) ([]byte, error) {
+	value, err := stub.GetState(key)
+	if err != nil {
+		return nil, err
+	}
+	if value == nil {
+		return nil, mcerr.New(mcerr.InvalidArguments, "Key not exist")
+	}
+	return value, nil
+}
+
+func init() {
+	// Function to quickly fill blocks to avoid collisions
+	RegisterFunction(TFunc{Name: "dummy", Type: FuncWrite,
+		Params: Params("index"),
+		Handler: func(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+			if args[0] < "0" || args[0] > "9" {
+				return nil, mcerr.New(mcerr.InvalidIndex, "index is must 0 to 9")
+			}
+			return nil, stub.PutState("DUMMY_IDX_"+args[0], []byte(args[0]))
+		}})
+
+	// function catalog
+	RegisterFunction(TFunc{Name: "functions", Type: FuncRead,
+		Params: Params(),
+		Handler: func(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+			return json.Marshal(FunctionList())
+		}})
+
+	// Simple GET funhction
+	RegisterFunction(TFunc{Name: "get", Type: FuncRead,
+		Params: Params("key"),
+		Handler: func(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+			return getState(stub, args[0])
+		}})
+
+	// genesis.go - genesis record (admin, allocations, params)
+	RegisterFunction(TFunc{Name: "genesis", Type: FuncRead,
+		Params: Params(),
+		Handler: func(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+			return jsonResult(GetGenesis(stub))
+		}})
+
+	// admin.go - admin list, the caller of adminAdd, adminRemove must be the admin
+	RegisterFunction(TFunc{Name: "adminList", Type: FuncRead,
+		Params: Params(),
+		Handler: func(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+			return jsonResult(GetAdminList(stub))
+		}})
+
+	RegisterFunction(TFunc{Name: "adminAdd", Type: FuncWrite, Admin: true,
+		Params: Params("mspid", "subject"),
+		Handler: func(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+			return nil, AdminAdd(stub, args[0], args[1], args)
+		}})
+
+	RegisterFunction(TFunc{Name: "adminRemove", Type: FuncWrite, Admin: true,
+		Params: Params("mspid", "subject"),
+		Handler: func(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+			return nil, AdminRemove(stub, args[0], args[1], args)
+		}})
+
+	// walletstate.go - MRC010 receive shards of the hot address
+	RegisterFunction(TFunc{Name: "addressShards", Type: FuncWrite, Admin: true,
+		Params: Params("address", "shards"),
+		Handler: func(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+			return nil, AddressShards(stub, args[0], args[1], args)
+		}})
+
+	// policy.go - key-level endorsement policy of the wallet and the token
+	RegisterFunction(TFunc{Name: "setKeyPolicy", Type: FuncWrite,
+		Params: Params("target", "orgs?", "signature", "tkey"),
+		Handler: func(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+			return nil, SetKeyPolicy(stub, args[0], args[1], args[2], args[3], args)
+		}})
+
+	RegisterFunction(TFunc{Name: "keyPolicy", Type: FuncRead,
+		Params: Params("key"),
+		Handler: func(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+			return jsonResult(KeyPolicy(stub, args[0]))
+		}})
+
+	RegisterFunction(TFunc{Name: "setPolicyOrgs", Type: FuncWrite, Admin: true,
+		Params: Params("orgs"),
+		Handler: func(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+			return nil, SetPolicyOrgs(stub, args[0], args)
+		}})
+
+	RegisterFunction(TFunc{Name: "policyOrgs", Type: FuncRead,
+		Params: Params(),
+		Handler: func(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+			return jsonResult(GetPolicyOrgs(stub))
+		}})
+
+	// sign.go - signing payload format of the wallet
+	RegisterFunction(TFunc{Name: "setSignFormat", Type: FuncWrite,
+		Params: Params("address", "format?", "signature", "tkey"),
+		Handler: func(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+			return nil, SetSignFormat(stub, args[0], args[1], args[2], args[3], args)
+		}})
+
+	// walletkey.go - public key rotation of the wallet
+	RegisterFunction(TFunc{Name: "rotateKey", Type: FuncWrite,
+		Params: Params("address", "publicKey", "hash?", "window?", "signature", "tkey"),
+		Handler: func(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+			return nil, RotateKey(stub, args[0], args[1], args[2], args[3], args[4], args[5], args)
+		}})
+
+	RegisterFunction(TFunc{Name: "confirmKey", Type: FuncWrite,
+		Params: Params("address", "signature", "tkey"),
+		Handler: func(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+			return nil, ConfirmKey(stub, args[0], args[1], args[2], args)
+		}})
+
+	RegisterFunction(TFunc{Name: "keyHistory", Type: FuncRead,
+		Params: Params("address"),
+		Handler: func(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+			return jsonResult(KeyHistory(stub, args[0]))
+		}})
+
+	// multisig.go - signers and the threshold of the multisig wallet
+	RegisterFunction(TFunc{Name: "setSigners", Type: FuncWrite,
+		Params: Params("address", "signers", "threshold", "signature", "tkey"),
+		Handler: func(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+			return nil, SetSigners(stub, args[0], args[1], args[2], args[3], args[4], args)
+		}})
+
+	// delegate.go - delegate keys (session keys) of the wallet
+	RegisterFunction(TFunc{Name: "addDelegate", Type: FuncWrite,
+		Params: Params("address", "delegate", "signature", "tkey"),
+		Handler: func(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+			return nil, AddDelegate(stub, args[0], args[1], args[2], args[3], args)
+		}})
+
+	RegisterFunction(TFunc{Name: "revokeDelegate", Type: FuncWrite,
+		Params: Params("address", "publicKey", "signature", "tkey"),
+		Handler: func(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+			return nil, RevokeDelegate(stub, args[0], args[1], args[2], args[3], args)
+		}})
+
+	// relay.go - transfer submitted by the relayer for the fee
+	RegisterFunction(TFunc{Name: "relayedTransfer", Type: FuncWrite,
+		Params: Params("from", "to", "token", "amount", "feeToken", "maxFee", "relayer", "fee", "signature", "relayerSignature", "tkey"),
+		Handler: func(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+			return nil, RelayedTransfer(stub, args[0], args[1], args[2], args[3], args[4], args[5], args[6], args[7], args[8], args[9], args[10], args)
+		}})
+
+	// fee.go - protocol fee of the write functions
+	RegisterFunction(TFunc{Name: "setFeeSchedule", Type: FuncWrite, Admin: true,
+		Params: Params("schedule"),
+		Handler: func(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+			return nil, SetFeeSchedule(stub, args[0], args)
+		}})
+
+	RegisterFunction(TFunc{Name: "feeSchedule", Type: FuncRead,
+		Params: Params(),
+		Handler: func(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+			return jsonResult(GetFeeSchedule(stub))
+		}})
+
+	// batch.go - run several functions in one transaction
+	RegisterFunction(TFunc{Name: "batch", Type: FuncWrite,
+		Params: Params("operations"),
+		Handler: func(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+			return Batch(stub, args[0])
+		}})
+
+	// history.go - versions of the address, DEX010, DEX402, MRC401
+	RegisterFunction(TFunc{Name: "history", Type: FuncRead,
+		Params: Params("key", "pagesize?", "bookmark?"),
+		Handler: func(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+			return jsonResult(History(stub, args[0], args[1], args[2]))
+		}})
+
+	// index.go - list query, {records, count, bookmark}
+	RegisterFunction(TFunc{Name: "tokenList", Type: FuncRead,
+		Params: Params("pagesize?", "bookmark?"),
+		Handler: func(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+			return jsonResult(TokenList(stub, args[0], args[1]))
+		}})
+
+	for name, index := range map[string]struct {
+		objectType string
+		key        string
+	}{
+		"dex010ByToken":   {IndexDEX010Token, "mrc010id"},
+		"dex010BySeller":  {IndexDEX010Seller, "seller"},
+		"dex402ByToken":   {IndexDEX402Token, "mrc402id"},
+		"dex402BySeller":  {IndexDEX402Seller, "seller"},
+		"mrc401ByProject": {IndexMRC401Project, "mrc400id"},
+		"mrc401ByOwner":   {IndexMRC401Owner, "owner"},
+	} {
+		objectType := index.objectType
+		RegisterFunction(TFunc{Name: name, Type: FuncRead,
+			Params: Params(index.key, "pagesize?", "bookmark?"),
+			Handler: func(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+				return jsonResult(QueryIndex(stub, objectType, args[0], args[1], args[2]))
+			}})
+	}
+
+	RegisterFunction(TFunc{Name: "reindex", Type: FuncWrite, Admin: true,
+		Params: Params("target", "start?", "count?"),
+		Handler: func(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+			return jsonResult(Reindex(stub, args[0], args[1], args[2], args))
+		}})
+
+	// base.go
+	RegisterFunction(TFunc{Name: "newwallet", Type: FuncWrite,
+		Params: Params("publicKey", "addinfo", "hash?", "payer?", "signature?", "tkey?"),
+		Handler: func(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+			return stringResult(NewWallet(stub, args[0], args[1], args[2], args[3], args[4], args[5]))
+		}})
+
+	RegisterFunction(TFunc{Name: "getNonce", Type: FuncRead,
+		Params: Params("address"),
+		Handler: func(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+			return stringResult(GetNonce(stub, args[0]))
+		}})
+
+	RegisterFunction(TFunc{Name: "balanceOf", Type: FuncRead,
+		Params: Params("address", "format?"),
+		Handler: func(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+			if !util.IsAddress(args[0]) {
+				return nil, mcerr.New(mcerr.InvalidAddress, "Invalid address format").WithField("address")
+			}
+			return stringResult(BalanceOf(stub, args[0], args[1]))
+		}})
+
+	RegisterFunction(TFunc{Name: "transfer", Type: FuncWrite,
+		Params: Params("fromAddr", "toAddr", "amount", "tokenID", "signature", "unlockdate", "tag", "memo", "tkey"),
+		Handler: func(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+			return nil, Transfer(stub, args[0], args[1], args[2], args[3], args[5], args[4], args[8], args)
+		}})
+
+	RegisterFunction(TFunc{Name: "signcheck", Type: FuncRead,
+		Params: Params("address", "data", "signature"),
+		Handler: func(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+			return nil, SignCheck(stub, args[0], args[1], args[2])
+		}})
+
+	RegisterFunction(TFunc{Name: "multitransfer", Type: FuncWrite,
+		Params: Params("fromAddr", "transferlist", "tokenID", "signature", "tkey"),
+		Handler: func(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+			return nil, MultiTransfer(stub, args[0], args[1], args[2], args[3], args[4], args)
+		}})
+
+	// allowance.go - MRC010 allowance of the spender
+	RegisterFunction(TFunc{Name: "approve", Type: FuncWrite,
+		Params: Params("owner", "spender", "token", "amount", "signature", "tkey"),
+		Handler: func(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+			return nil, Approve(stub, args[0], args[1], args[2], args[3], args[4], args[5], args)
+		}})
+
+	RegisterFunction(TFunc{Name: "transferFrom", Type: FuncWrite,
+		Params: Params("spender", "from", "to", "token", "amount", "signature", "tkey"),
+		Handler: func(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+			return nil, TransferFrom(stub, args[0], args[1], args[2], args[3], args[4], args[5], args[6], args)
+		}})
+
+	RegisterFunction(TFunc{Name: "allowance", Type: FuncRead,
+		Params: Params("owner", "spender", "token"),
+		Handler: func(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+			return jsonResult(Allowance(stub, args[0], args[1], args[2]))
+		}})
+
+	// token.go
+	RegisterFunction(TFunc{Name: "tokenRegister", Type: FuncWrite,
+		Params: Params("tokeninfo", "signature", "tkey"),
+		Handler: func(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+			return stringResult(TokenRegister(stub, args[0], args[1], args[2]))
+		}})
+
+	RegisterFunction(TFunc{Name: "tokenUpdate", Type: FuncWrite,
+		Params: Params("TokenID", "url", "info", "image", "signature", "tkey"),
+		Handler: func(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+			return nil, TokenUpdate(stub, args[0], args[1], args[2], args[3], args[4], args[5], args)
+		}})
+
+	RegisterFunction(TFunc{Name: "tokenPause", Type: FuncWrite,
+		Params: Params("TokenID", "signature", "tkey"),
+		Handler: func(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+			return nil, TokenPause(stub, args[0], args[1], args[2], args)
+		}})
+
+	RegisterFunction(TFunc{Name: "tokenUnpause", Type: FuncWrite,
+		Params: Params("TokenID", "signature", "tkey"),
+		Handler: func(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+			return nil, TokenUnpause(stub, args[0], args[1], args[2], args)
+		}})
+
+	RegisterFunction(TFunc{Name: "tokenFreezeAddress", Type: FuncWrite,
+		Params: Params("TokenID", "address", "signature", "tkey"),
+		Handler: func(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+			return nil, TokenFreezeAddress(stub, args[0], args[1], args[2], args[3], args)
+		}})
+
+	RegisterFunction(TFunc{Name: "tokenUnfreezeAddress", Type: FuncWrite,
+		Params: Params("TokenID", "address", "signature", "tkey"),
+		Handler: func(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+			return nil, TokenUnfreezeAddress(stub, args[0], args[1], args[2], args[3], args)
+		}})
+
+	RegisterFunction(TFunc{Name: "tokenIncrease", Type: FuncWrite,
+		Params:  Params("TokenID", "amount", "memo", "signature", "tkey"),
+		Handler: argsHandler(TokenIncrease)})
+
+	RegisterFunction(TFunc{Name: "tokenBurning", Type: FuncWrite,
+		Params:  Params("TokenID", "amount", "memo", "signature", "tkey"),
+		Handler: argsHandler(TokenBurning)})
+
+	RegisterFunction(TFunc{Name: "mrc010sell", Type: FuncWrite,
+		Params: Params("seller", "amount", "mrc010id", "sellPrice", "selltoken",
+			"platformName", "platformURL", "platformAddress", "platformCommission", "mintradeunit",
+			"signature", "nonce"),
+		Handler: argsHandler(Mrc010Sell)})
+
+	RegisterFunction(TFunc{Name: "mrc010unsell", Type: FuncWrite,
+		Params:  Params("dexid", "signature", "nonce"),
+		Handler: argsHandler(Mrc010UnSell)})
+
+	RegisterFunction(TFunc{Name: "mrc010buy", Type: FuncWrite,
+		Params:  Params("dexid", "buyer", "amount", "signature", "nonce"),
+		Handler: argsHandler(Mrc010Buy)})
+
+	RegisterFunction(TFunc{Name: "mrc010reqsell", Type: FuncWrite,
+		Params: Params("seller", "amount", "mrc010id", "buyPrice", "buytoken",
+			"platformName", "platformURL", "platformAddress", "platformCommission", "mintradeunit",
+			"signature", "nonce"),
+		Handler: argsHandler(Mrc010ReqSell)})
+
+	RegisterFunction(TFunc{Name: "mrc010unreqsell", Type: FuncWrite,
+		Params:  Params("dexid", "signature", "nonce"),
+		Handler: argsHandler(Mrc010UnReqSell)})
+
+	RegisterFunction(TFunc{Name: "mrc010acceptreqsell", Type: FuncWrite,
+		Params:  Params("dexid", "seller", "amount", "signature", "nonce"),
+		Handler: argsHandler(Mrc010AcceptReqSell)})
+
+	RegisterFunction(TFunc{Name: "mrc010auction", Type: FuncWrite,
+		Params: Params("address", "amount", "mrc010id", "auction_start_price", "selltoken",
+			"auction_bidding_unit", "auction_buynow_price", "auction_start_date", "auction_end_date", "platformName",
+			"platformURL", "platformAddress", "platformCommission", "signature", "nonce"),
+		Handler: argsHandler(Mrc010Auction)})
+
+	RegisterFunction(TFunc{Name: "mrc010unauction", Type: FuncWrite,
+		Params:  Params("mrc010dexid", "signature", "nonce"),
+		Handler: argsHandler(Mrc010UnAuction)})
+
+	RegisterFunction(TFunc{Name: "mrc010bid", Type: FuncWrite,
+		Params:  Params("mrc010dexid", "address", "amount", "signature", "nonce"),
+		Handler: argsHandler(Mrc010AuctionBid)})
+
+	RegisterFunction(TFunc{Name: "mrc010auctionfinish", Type: FuncWrite,
+		Params:  Params("mrc010dexid"),
+		Handler: argsHandler(Mrc010AuctionFinish)})
+
+	// mrc020.go
+	RegisterFunction(TFunc{Name: "mrc020", Type: FuncWrite,
+		Params: Params("owner", "algorithm", "data", "publickey", "opendate", "referencekey", "signature", "tkey"),
+		Handler: func(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+			return stringResult(Mrc020set(stub, args[0], args[1], args[2], args[3], args[4], args[5], args[6], args[7]))
+		}})
+
+	RegisterFunction(TFunc{Name: "mrc020get", Type: FuncRead,
+		Params: Params("mrc020Key"),
+		Handler: func(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+			return stringResult(Mrc020get(stub, args[0]))
+		}})
+
+	// mrc030.go
+	RegisterFunction(TFunc{Name: "mrc030create", Type: FuncWrite,
+		Params: Params("Creator", "mrc030id", "Title", "Description", "StartDate",
+			"EndDate", "Reward", "RewardToken", "MaxRewardRecipient", "RewardType",
+			"URL", "Question", "SignNeed", "signature", "tkey"),
+		Handler: func(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+			return nil, MRC030Create(stub, args[1], args[0], args[2], args[3], args[4], args[5], args[6], args[7],
+				args[8], args[9], args[10], args[11], args[12], args[13], args[14], args)
+		}})
+
+	RegisterFunction(TFunc{Name: "mrc030join", Type: FuncWrite,
+		Params: Params("mrc030id", "Voter", "Answer", "voteCreatorSign", "signature"),
+		Handler: func(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+			return nil, MRC030Join(stub, args[0], args[1], args[2], args[3], args[4], args)
+		}})
+
+	RegisterFunction(TFunc{Name: "mrc030get", Type: FuncRead,
+		Params: Params("mrc030id"),
+		Handler: func(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+			if _, err := Mrc030get(stub, args[0]); err != nil {
+				return nil, err
+			}
+			return getState(stub, args[0])
+		}})
+
+	RegisterFunction(TFunc{Name: "mrc030finish", Type: FuncWrite,
+		Params: Params("mrc030id"),
+		Handler: func(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+			return nil, MRC030Finish(stub, args[0], args)
+		}})
+
+	RegisterFunction(TFunc{Name: "mrc030replay", Type: FuncRead,
+		Params: Params("mrc030id"),
+		Handler: func(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+			return jsonResult(MRC030DrawReplay(stub, args[0]))
+		}})
+
+	RegisterFunction(TFunc{Name: "mrc031get", Type: FuncRead,
+		Params: Params("mrc031id"),
+		Handler: func(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+			if _, err := Mrc031get(stub, args[0]); err != nil {
+				return nil, err
+			}
+			return getState(stub, args[0])
+		}})
+
+	// mrc100.go
+	RegisterFunction(TFunc{Name: "mrc100Payment", Type: FuncWrite,
+		Params: Params("to", "TokenID", "tag", "userlist", "gameid", "gamememo"),
+		Handler: func(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+			return nil, Mrc100Payment(stub, args[0], args[1], args[2], args[3], args[4], args[5], args)
+		}})
+
+	RegisterFunction(TFunc{Name: "mrc100Reward", Type: FuncWrite,
+		Params: Params("from", "TokenID", "userlist", "gameid", "gamememo", "signature", "tkey"),
+		Handler: func(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+			return nil, Mrc100Reward(stub, args[0], args[1], args[2], args[3], args[4], args[5], args[6], args)
+		}})
+
+	RegisterFunction(TFunc{Name: "mrc100Log", Type: FuncWrite,
+		Params: Params("key", "TokenID", "logger", "log", "signature", "tkey"),
+		Handler: func(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+			return stringResult(Mrc100Log(stub, args[0], args[1], args[2], args[3], args[4], args[5], args))
+		}})
+
+	RegisterFunction(TFunc{Name: "mrc100get", Type: FuncRead,
+		Params: Params("mrc100Key"),
+		Handler: func(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+			return stringResult(Mrc100get(stub, args[0]))
+		}})
+
+	// mrc400.go
+	RegisterFunction(TFunc{Name: "mrc400get", Type: FuncRead,
+		Params: Params("mrc400id"),
+		Handler: func(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+			_, value, err := GetMRC400(stub, args[0])
+			return value, err
+		}})
+
+	RegisterFunction(TFunc{Name: "mrc400create", Type: FuncWrite,
+		Params: Params("owner", "name", "url", "imageurl", "allowtoken",
+			"category", "description", "itemurl", "itemimageurl", "data",
+			"signature", "tkey"),
+		Handler: func(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+			return nil, Mrc400Create(stub, args[0], args[1], args[2], args[3], args[4], args[5],
+				args[6], args[7], args[8], args[9], args[10], args[11], args)
+		}})
+
+	RegisterFunction(TFunc{Name: "mrc400update", Type: FuncWrite,
+		Params: Params("mrc400id", "name", "url", "imageurl", "allowtoken",
+			"category", "description", "itemurl", "itemimageurl", "data",
+			"signature", "tkey"),
+		Handler: func(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+			return nil, Mrc400Update(stub, args[0], args[1], args[2], args[3], args[4], args[5],
+				args[6], args[7], args[8], args[9], args[10], args[11], args)
+		}})
+
+	RegisterFunction(TFunc{Name: "mrc401get", Type: FuncRead,
+		Params: Params("mrc401id"),
+		Handler: func(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+			_, value, err := GetMRC401(stub, args[0])
+			return value, err
+		}})
+
+	RegisterFunction(TFunc{Name: "mrc401create", Type: FuncWrite,
+		Params: Params("mrc400id", "itemData", "signature", "tkey"),
+		Handler: func(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+			return nil, Mrc401Create(stub, args[0], args[1], args[2], args[3], args)
+		}})
+
+	RegisterFunction(TFunc{Name: "mrc401update", Type: FuncWrite,
+		Params: Params("mrc400id", "itemData", "signature", "tkey"),
+		Handler: func(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+			return nil, Mrc401Update(stub, args[0], args[1], args[2], args[3], args)
+		}})
+
+	RegisterFunction(TFunc{Name: "mrc401transfer", Type: FuncWrite,
+		Params: Params("mrc401id", "fromAddr", "toAddr", "signature", "tkey"),
+		Handler: func(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+			return nil, Mrc401Transfer(stub, args[0], args[1], args[2], args[3], args[4], args)
+		}})
+
+	RegisterFunction(TFunc{Name: "mrc401sell", Type: FuncWrite,
+		Params: Params("seller", "mrc400id", "itemData", "signature", "tkey"),
+		Handler: func(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+			return nil, Mrc401Sell(stub, args[0], args[1], args[2], args[3], args[4], args)
+		}})
+
+	RegisterFunction(TFunc{Name: "mrc401unsell", Type: FuncWrite,
+		Params: Params("seller", "mrc400id", "itemData", "signature", "tkey"),
+		Handler: func(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+			return nil, Mrc401UnSell(stub, args[0], args[1], args[2], args[3], args[4], args)
+		}})
+
+	RegisterFunction(TFunc{Name: "mrc401buy", Type: FuncWrite,
+		Params: Params("buyer", "mrc401id", "signature", "tkey"),
+		Handler: func(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+			return nil, Mrc401Buy(stub, args[0], args[1], args[2], args[3], args)
+		}})
+
+	RegisterFunction(TFunc{Name: "mrc401melt", Type: FuncWrite,
+		Params: Params("mrc401id", "signature", "tkey"),
+		Handler: func(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+			return nil, Mrc401Melt(stub, args[0], args[1], args[2], args)
+		}})
+
+	RegisterFunction(TFunc{Name: "mrc401auction", Type: FuncWrite,
+		Params: Params("seller", "mrc400id", "itemData", "signature", "tkey"),
+		Handler: func(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+			return nil, Mrc401Auction(stub, args[0], args[1], args[2], args[3], args[4], args)
+		}})
+
+	RegisterFunction(TFunc{Name: "mrc401unauction", Type: FuncWrite,
+		Params: Params("seller", "mrc400id", "itemData", "signature", "tkey"),
+		Handler: func(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+			return nil, Mrc401UnAuction(stub, args[0], args[1], args[2], args[3], args[4], args)
+		}})
+
+	RegisterFunction(TFunc{Name: "mrc401bid", Type: FuncWrite,
+		Params: Params("buyer", "mrc401id", "amount", "token", "signature", "tkey"),
+		Handler: func(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+			return nil, Mrc401AuctionBid(stub, args[0], args[1], args[2], args[3], args[4], args[5], args)
+		}})
+
+	RegisterFunction(TFunc{Name: "mrc401auctionfinish", Type: FuncWrite,
+		Params: Params("mrc401id"),
+		Handler: func(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+			return nil, Mrc401AuctionFinish(stub, args[0])
+		}})
+
+	// mrc402.go
+	RegisterFunction(TFunc{Name: "mrc402create", Type: FuncWrite,
+		Params: Params("creator", "name", "creatorcommission", "totalsupply", "decimal",
+			"url", "imageurl", "shareholder", "initialreserve", "expiredate",
+			"data", "information", "socialmedia", "copyright_registration_country", "copyright_registrar",
+			"copyright_registration_number", "signature", "nonce"),
+		Handler: argsHandler(Mrc402Create)})
+
+	RegisterFunction(TFunc{Name: "mrc402get", Type: FuncRead,
+		Params: Params("MRC402ID"),
+		Handler: func(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+			_, value, err := GetMRC402(stub, args[0])
+			return value, err
+		}})
+
+	RegisterFunction(TFunc{Name: "mrc402update", Type: FuncWrite,
+		Params: Params("MRC402ID", "url", "data", "info", "socialmedia",
+			"copyright_registration_country", "copyright_registrar", "copyright_registration_number", "signature", "nonce"),
+		Handler: argsHandler(Mrc402Update)})
+
+	RegisterFunction(TFunc{Name: "mrc402transfer", Type: FuncWrite,
+		Params:  Params("fromAddr", "toAddr", "amount", "MRC402ID", "tag", "memo", "signature", "nonce"),
+		Handler: argsHandler(Mrc402Transfer)})
+
+	RegisterFunction(TFunc{Name: "mrc402mint", Type: FuncWrite,
+		Params:  Params("MRC402ID", "amount", "memo", "signature", "nonce"),
+		Handler: argsHandler(Mrc402Mint)})
+
+	RegisterFunction(TFunc{Name: "mrc402burn", Type: FuncWrite,
+		Params:  Params("MRC402ID", "amount", "memo", "signature", "nonce"),
+		Handler: argsHandler(Mrc402Burn)})
+
+	RegisterFunction(TFunc{Name: "mrc402melt", Type: FuncWrite,
+		Params:  Params("mrc402id", "address", "amount", "signature", "nonce"),
+		Handler: argsHandler(Mrc402Melt)})
+
+	RegisterFunction(TFunc{Name: "mrc402sell", Type: FuncWrite,
+		Params: Params("seller", "amount", "mrc402id", "sellPrice", "selltoken",
+			"platformName", "platformURL", "platformAddress", "platformCommission",
+			"signature", "nonce"),
+		Handler: argsHandler(Mrc402Sell)})
+
+	RegisterFunction(TFunc{Name: "mrc402unsell", Type: FuncWrite,
+		Params:  Params("dexid", "signature", "nonce"),
+		Handler: argsHandler(Mrc402UnSell)})
+
+	RegisterFunction(TFunc{Name: "mrc402buy", Type: FuncWrite,
+		Params:  Params("dexid", "buyer", "amount", "signature", "nonce"),
+		Handler: argsHandler(Mrc402Buy)})
+
+	RegisterFunction(TFunc{Name: "mrc402auction", Type: FuncWrite,
+		Params: Params("address", "amount", "mrc402id", "auction_start_price", "selltoken",
+			"auction_bidding_unit", "auction_buynow_price", "auction_start_date", "auction_end_date", "platformName",
+			"platformURL", "platformAddress", "platformCommission", "signature", "nonce"),
+		Handler: argsHandler(Mrc402Auction)})
+
+	RegisterFunction(TFunc{Name: "mrc402unauction", Type: FuncWrite,
+		Params:  Params("mrc402dexid", "signature", "nonce"),
+		Handler: argsHandler(Mrc402UnAuction)})
+
+	RegisterFunction(TFunc{Name: "mrc402bid", Type: FuncWrite,
+		Params:  Params("mrc402dexid", "address", "amount", "signature", "nonce"),
+		Handler: argsHandler(Mrc402AuctionBid)})
+
+	RegisterFunction(TFunc{Name: "mrc402auctionfinish", Type: FuncWrite,
+		Params:  Params("mrc402dexid"),
+		Handler: argsHandler(Mrc402AuctionFinish)})
+}
@@ -0,0 +1,60 @@
+package metacoin
+
+import (
+	"encoding/json"
+	"sort"
+	"testing"
+)
+
+func TestFunctions(t *testing.T) {
+	s := newTestStub()
+	alice := newGenesisWallet(t, s, "1000")
+
+	// catalog query
+	value, err := CallFunction(s, "functions", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var list []TFunc
+	if err = json.Unmarshal(value, &list); err != nil {
+		t.Fatal(err)
+	}
+	names := make([]string, len(list))
+	for i, f := range list {
+		names[i] = f.Name
+	}
+	if !sort.StringsAreSorted(names) {
+		t.Errorf("catalog is not sorted : %v", names)
+	}
+	for _, name := range []string{"transfer", "balanceOf", "batch", "adminAdd", "setFeeSchedule", "mrc402auctionfinish"} {
+		if _, exists := GetFunction(name); !exists {
+			t.Errorf("function %s is not registered", name)
+		}
+	}
+	if f, _ := GetFunction("adminAdd"); !f.Admin {
+		t.Error("adminAdd is not the admin function")
+	}
+
+	// every handler reads its arguments in the declared parameter range
+	for _, f := range list {
+		args := make([]string, f.RequiredCount())
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					t.Errorf("%s with the empty arguments : %v", f.Name, r)
+				}
+			}()
+			s.tx(func() error { _, err := CallFunction(s, f.Name, args); return err })
+		}()
+	}
+
+	// argument mapping
+	assertError(t, s.tx(func() error { _, err := CallFunction(s, "transfer", []string{alice.Address}); return err }), "1000")
+	value, err = CallFunction(s, "balanceOf", []string{alice.Address})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(value) == 0 {
+		t.Error("balanceOf result is empty")
+	}
+}
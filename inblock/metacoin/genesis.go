@@ -0,0 +1,306 @@
+package metacoin
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/shopspring/decimal"
+
+	"inblock/metacoin/mcerr"
+	"inblock/metacoin/mtc"
+	"inblock/metacoin/util"
+)
+
+// genesisKey : state key of the genesis record
+const genesisKey = "GENESIS"
+
+// TGenesisAccount : wallet created by the genesis
+// The address is made from the public key and the tx id, so the wallet can not
+// exist before the genesis. amount is the base coin allocation (minimum unit).
+type TGenesisAccount struct {
+	PublicKey  string `json:"publickey"`
+	Hash       string `json:"hash"` // signature hash, "" is the default of the key type (see parseWalletKey)
+	Addinfo    string `json:"addinfo"`
+	Amount     string `json:"amount"`
+	UnlockDate int64  `json:"unlockdate"`
+}
+
+// TGenesisData : Init argument
+// Admins is the first admin list and is required, the Init submitter is not the admin
+// by itself. The upgrade Init of the ledger without the admin list takes Admins only.
+type TGenesisData struct {
+	Admin       TGenesisAccount   `json:"admin"`
+	Allocations []TGenesisAccount `json:"allocations"`
+	Admins      []TAdmin          `json:"admins"`
+	Params      map[string]string `json:"params"`
+}
+
+// TGenesis : genesis record, saved to the GENESIS key
+type TGenesis struct {
+	TxID        string            `json:"txid"`
+	Admin       string            `json:"admin"`
+	Allocations []string          `json:"allocations"`
+	Params      map[string]string `json:"params"`
+	CreateDate  int64             `json:"createdate"`
+}
+
+// Init - chaincode Init, the last argument is the genesis data
+//
+// Init on the existing ledger is the upgrade of the chaincode which requires
+// the Init (--init-required), it does nothing without the argument. The
+// argument {"admins":[...]} sets the admin list of the ledger created before
+// the admin list. (see upgradeAdmin)
+func Init(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	stub = newTxStub(stub)
+	data := genesisArg(args)
+	done, err := genesisDone(stub)
+	if err != nil {
+		return nil, err
+	}
+	if done {
+		if data == "" {
+			return nil, nil
+		}
+		if err = upgradeAdmin(stub, data); err != nil {
+			return nil, err
+		}
+		return nil, FlushEvents(stub)
+	}
+	if data == "" {
+		return nil, mcerr.New(mcerr.InvalidArguments, "Genesis data is required")
+	}
+	genesis, err := Genesis(stub, data)
+	if err != nil {
+		return nil, err
+	}
+	if err = FlushEvents(stub); err != nil {
+		return nil, err
+	}
+	return json.Marshal(genesis)
+}
+
+// genesisArg - genesis data of the Init arguments, "" is none
+// The function name ("init") or the empty argument is not the genesis data.
+func genesisArg(args []string) string {
+	if len(args) == 0 {
+		return ""
+	}
+	data := strings.TrimSpace(args[len(args)-1])
+	if !strings.HasPrefix(data, "{") {
+		return ""
+	}
+	return data
+}
+
+// genesisDone - the genesis record or TOKEN_MAX_NO exists
+// The ledger before the genesis (token 0 registered by tokenRegister) has TOKEN_MAX_NO only.
+func genesisDone(stub shim.ChaincodeStubInterface) (bool, error) {
+	for _, key := range []string{genesisKey, "TOKEN_MAX_NO"} {
+		value, err := stub.GetState(key)
+		if err != nil {
+			return false, mcerr.Wrap(mcerr.LedgerRead, "Hyperledger internal error", err)
+		}
+		if value != nil {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Genesis - create the admin and allocation wallets, register the base coin (token 0)
+// and save the admin list.
+//
+// Runs once per channel, fails when the genesis record or TOKEN_MAX_NO exists.
+//
+// Example
+//
+//	{"admin":{"publickey":"-----BEGIN PUBLIC KEY-----...", "amount":"0"},
+//	 "allocations":[{"publickey":"...", "amount":"1000000000000000000", "unlockdate":0}],
+//	 "admins":[{"mspid":"Org1MSP", "subject":"CN=admin,OU=admin,O=org1.example.com"}],
+//	 "params":{"network":"mainnet"}}
+func Genesis(stub shim.ChaincodeStubInterface, data string) (TGenesis, error) {
+	var genesisData TGenesisData
+	var genesis TGenesis
+	var value []byte
+	var err error
+
+	done, err := genesisDone(stub)
+	if err != nil {
+		return genesis, err
+	}
+	if done {
+		return genesis, mcerr.New(mcerr.AlreadyExists, "Genesis is already done")
+	}
+
+	if err = json.Unmarshal([]byte(data), &genesisData); err != nil {
+		return genesis, mcerr.Wrap(mcerr.InvalidData, "Genesis data is in the wrong data", err).WithField("genesis")
+	}
+
+	totalSupply := decimal.New(mtc.InitSupply, mtc.CoinDecimals)
+	reserved := decimal.Zero
+	now := GetTxTime(stub)
+
+	genesis = TGenesis{TxID: stub.GetTxID(), Params: genesisData.Params, CreateDate: now, Allocations: make([]string, 0)}
+	if genesis.Params == nil {
+		genesis.Params = make(map[string]string)
+	}
+
+	tk := mtc.TMRC010{
+		Id:         "0",
+		Token:      0,
+		Symbol:     mtc.CoinSymbol,
+		Name:       mtc.CoinName,
+		Decimal:    mtc.CoinDecimals,
+		Type:       "010",
+		CreateDate: now,
+		JobDate:    now,
+		JobType:    "genesis",
+		JobArgs:    data,
+	}
+	var payment []mtc.TDexPaymentInfo
+	created := make(map[string]bool)
+
+	accounts := append([]TGenesisAccount{genesisData.Admin}, genesisData.Allocations...)
+	for index, account := range accounts {
+		var amount = decimal.Zero
+		var field = "allocations"
+		if index == 0 {
+			field = "admin"
+		}
+
+		wallet, err := newWalletData(stub, account.PublicKey, account.Addinfo, account.Hash)
+		if err != nil {
+			return genesis, mcerr.Parse(err).WithField(field)
+		}
+		if created[wallet.Id] {
+			return genesis, mcerr.New(mcerr.AlreadyExists, "Public key is duplicated").WithField(field)
+		}
+		created[wallet.Id] = true
+
+		if account.Amount != "" && account.Amount != "0" {
+			if amount, err = util.ParsePositive(account.Amount); err != nil {
+				return genesis, mcerr.New(mcerr.NotInteger, account.Amount+" is not positive integer").WithField(field)
+			}
+			if account.UnlockDate <= now {
+				account.UnlockDate = 0
+			}
+			mrc010AddBalance(&wallet, 0, amount, account.UnlockDate, now)
+			reserved = reserved.Add(amount)
+			tk.Reserve = append(tk.Reserve, mtc.TMRC010Reserve{Address: wallet.Id, Value: amount.String(), UnlockDate: account.UnlockDate})
+			payment = append(payment, mtc.TDexPaymentInfo{FromAddr: "TOKEN_DATA_0", ToAddr: wallet.Id,
+				Amount: amount.String(), TokenID: "0", PayType: "token_reserve"})
+		}
+
+		if err = SetAddressInfo(stub, wallet, "NewWallet", []string{wallet.Id, account.PublicKey, account.Addinfo, wallet.Hash}); err != nil {
+			return genesis, err
+		}
+		if index == 0 {
+			genesis.Admin = wallet.Id
+		} else {
+			genesis.Allocations = append(genesis.Allocations, wallet.Id)
+		}
+	}
+
+	if err = genesisAdmin(stub, genesisData.Admins, "genesis"); err != nil {
+		return genesis, err
+	}
+
+	if reserved.GreaterThan(totalSupply) {
+		return genesis, mcerr.New(mcerr.InvalidValue, "The allocation amount is greater than totalsupply").WithField("allocations")
+	}
+
+	tk.Owner = genesis.Admin
+	tk.TotalSupply = totalSupply.String()
+	tk.ReservedAmount = reserved.String()
+	tk.RemainAmount = totalSupply.Sub(reserved).String()
+
+	if value, err = json.Marshal(tk); err != nil {
+		return genesis, mcerr.Wrap(mcerr.InvalidData, "Invalid Data format", err)
+	}
+	if err = stub.PutState("TOKEN_DATA_0", value); err != nil {
+		return genesis, mcerr.Wrap(mcerr.LedgerWrite, "Hyperledger internal error", err)
+	}
+	if err = stub.PutState("TOKEN_MAX_NO", []byte("0")); err != nil {
+		return genesis, mcerr.Wrap(mcerr.LedgerWrite, "Hyperledger internal error", err)
+	}
+
+	if value, err = json.Marshal(genesis); err != nil {
+		return genesis, mcerr.Wrap(mcerr.InvalidData, "Invalid Data format", err)
+	}
+	if err = stub.PutState(genesisKey, value); err != nil {
+		return genesis, mcerr.Wrap(mcerr.LedgerWrite, "Hyperledger internal error", err)
+	}
+
+	AddEvent(stub, TEvent{Type: "token_register", Key: "0",
+		Args: []string{tk.Owner, tk.Name, tk.Symbol, tk.TotalSupply}, Payment: payment})
+	AddEvent(stub, TEvent{Type: "genesis", Key: genesis.Admin,
+		Args: []string{genesis.Admin, strconv.Itoa(len(genesis.Allocations))}})
+	return genesis, nil
+}
+
+// genesisAdmin - save the first admin list
+func genesisAdmin(stub shim.ChaincodeStubInterface, admins []TAdmin, jobType string) error {
+	var list = TAdminList{Admins: make([]TAdmin, 0)}
+	var err error
+
+	if len(admins) == 0 {
+		return mcerr.New(mcerr.InvalidArguments, "Admin list is required").WithField("admins")
+	}
+	for _, admin := range admins {
+		if list, err = addAdmin(stub, list, admin, jobType); err != nil {
+			return mcerr.Parse(err).WithField("admins").WithDetails(admin)
+		}
+	}
+	return setAdminList(stub, list, jobType, nil)
+}
+
+// upgradeAdmin - first admin list of the ledger created before the admin list
+//
+// The list is taken from the Init argument {"admins":[...]}, never from the
+// submitter. The Init is endorsed by the endorsement policy of the chaincode,
+// so the orgs agree on the list by endorsing the same argument.
+// It runs once, the ledger with the admin list or the argument with the
+// genesis accounts fails.
+func upgradeAdmin(stub shim.ChaincodeStubInterface, data string) error {
+	var genesisData TGenesisData
+
+	if err := json.Unmarshal([]byte(data), &genesisData); err != nil {
+		return mcerr.Wrap(mcerr.InvalidData, "Init data is in the wrong data", err).WithField("genesis")
+	}
+	if genesisData.Admin.PublicKey != "" || len(genesisData.Allocations) > 0 {
+		return mcerr.New(mcerr.AlreadyExists, "Genesis is already done")
+	}
+	list, err := GetAdminList(stub)
+	if err != nil {
+		return err
+	}
+	if len(list.Admins) > 0 {
+		return mcerr.New(mcerr.AlreadyExists, "Admin list already exists, use adminAdd").WithField("admins")
+	}
+	if err = genesisAdmin(stub, genesisData.Admins, "upgrade"); err != nil {
+		return err
+	}
+	for _, admin := range genesisData.Admins {
+		AddEvent(stub, TEvent{Type: "admin_add", Key: admin.MSPID, Args: []string{admin.MSPID, admin.Subject, "upgrade"}})
+	}
+	return nil
+}
+
+// GetGenesis - genesis record
+func GetGenesis(stub shim.ChaincodeStubInterface) (TGenesis, error) {
+	var genesis TGenesis
+
+	value, err := stub.GetState(genesisKey)
+	if err != nil {
+		return genesis, mcerr.Wrap(mcerr.LedgerRead, "Hyperledger internal error", err)
+	}
+	if value == nil {
+		return genesis, mcerr.New(mcerr.NotFound, "Genesis is not done")
+	}
+	if err = json.Unmarshal(value, &genesis); err != nil {
+		return genesis, mcerr.Wrap(mcerr.InvalidData, "Genesis data is broken", err)
+	}
+	return genesis, nil
+}
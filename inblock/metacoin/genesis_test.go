@@ -0,0 +1,165 @@
+package metacoin
+
+import (
+	"encoding/json"
+	"testing"
+
+	"inblock/metacoin/mtc"
+	"inblock/metacoin/util"
+)
+
+func TestGenesis(t *testing.T) {
+	s := newTestStub()
+	admin, alice, bob := newTestKey(t), newTestKey(t), newTestKey(t)
+
+	// token 0 is registered by the genesis only
+	assertError(t, s.tx(func() error {
+		_, err := TokenRegister(s, util.JSONEncode(mtc.TMRC010{Symbol: "MTC", Name: "MTC token"}), "", "")
+		return err
+	}), "4001")
+
+	data := util.JSONEncode(TGenesisData{
+		Admin: TGenesisAccount{PublicKey: admin.PublicKey},
+		Allocations: []TGenesisAccount{
+			{PublicKey: alice.PublicKey, Amount: "1000"},
+			{PublicKey: bob.PublicKey, Amount: "500", UnlockDate: s.txTime + 3600},
+		},
+		Admins: testAdmins,
+		Params: map[string]string{"network": "test"},
+	})
+	var genesis TGenesis
+	s.mustTx(t, func() error {
+		value, err := Init(s, []string{"init", data})
+		if err == nil {
+			err = json.Unmarshal(value, &genesis)
+		}
+		return err
+	})
+	admin.Address = genesis.Admin
+	alice.Address, bob.Address = genesis.Allocations[0], genesis.Allocations[1]
+
+	tk, _, err := GetMRC010(s, "0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tk.Symbol != mtc.CoinSymbol || tk.Name != mtc.CoinName || tk.Decimal != mtc.CoinDecimals || tk.Owner != admin.Address {
+		t.Errorf("token 0 : %+v", tk)
+	}
+	if tk.TotalSupply != "800000000000000000000000000" || tk.ReservedAmount != "1500" {
+		t.Errorf("token 0 supply : total %s, reserved %s", tk.TotalSupply, tk.ReservedAmount)
+	}
+	assertBalance(t, s, admin, "0", "0")
+	assertBalance(t, s, alice, "0", "1000")
+	assertBalance(t, s, bob, "0", "0")
+	if b := bob.wallet(t, s).MRC010[0]; b.LockedList[int(s.txTime+3600)] != "500" {
+		t.Errorf("locked allocation : %+v", b)
+	}
+	if ev := s.lastEvent(t, "token_register"); len(ev.Payment) != 2 {
+		t.Errorf("token_register event payment : %+v", ev.Payment)
+	}
+	if g, err := GetGenesis(s); err != nil || g.Params["network"] != "test" {
+		t.Errorf("genesis record : %+v, %v", g, err)
+	}
+
+	// once per channel
+	assertError(t, s.tx(func() error {
+		_, err := Genesis(s, data)
+		return err
+	}), "6100")
+
+	// next token is 1
+	if tokenID := registerToken(t, s, alice, "ITEM", "10"); tokenID != "1" {
+		t.Errorf("token id after the genesis : got %s, expect 1", tokenID)
+	}
+}
+
+func TestGenesisInvalid(t *testing.T) {
+	s := newTestStub()
+	admin := newTestKey(t)
+
+	for name, data := range map[string]string{
+		"no admin":      util.JSONEncode(TGenesisData{Admins: testAdmins}),
+		"no admin list": util.JSONEncode(TGenesisData{Admin: TGenesisAccount{PublicKey: admin.PublicKey}}),
+		"empty subject": util.JSONEncode(TGenesisData{Admin: TGenesisAccount{PublicKey: admin.PublicKey}, Admins: []TAdmin{{MSPID: "Org1MSP"}}}),
+		"duplicate":     util.JSONEncode(TGenesisData{Admin: TGenesisAccount{PublicKey: admin.PublicKey}, Allocations: []TGenesisAccount{{PublicKey: admin.PublicKey}}, Admins: testAdmins}),
+		"amount":        util.JSONEncode(TGenesisData{Admin: TGenesisAccount{PublicKey: admin.PublicKey, Amount: "-1"}, Admins: testAdmins}),
+		"supply":        util.JSONEncode(TGenesisData{Admin: TGenesisAccount{PublicKey: admin.PublicKey, Amount: "800000000000000000000000001"}, Admins: testAdmins}),
+		"json":          "{",
+	} {
+		if err := s.tx(func() error { _, err := Genesis(s, data); return err }); err == nil {
+			t.Errorf("%s : no error", name)
+		}
+	}
+	if _, err := GetGenesis(s); err == nil {
+		t.Error("genesis record after the failed genesis")
+	}
+}
+
+func TestInitUpgrade(t *testing.T) {
+	s := newTestStub()
+
+	// new ledger needs the genesis data
+	for _, args := range [][]string{nil, {"init"}, {"init", ""}} {
+		assertError(t, s.tx(func() error { _, err := Init(s, args); return err }), "1000")
+	}
+
+	// ledger before the genesis, token 0 registered by tokenRegister
+	s.mustTx(t, func() error { return s.PutState("TOKEN_MAX_NO", []byte("3")) })
+	for _, args := range [][]string{nil, {"init"}} {
+		s.mustTx(t, func() error {
+			value, err := Init(s, args)
+			if err == nil && value != nil {
+				t.Errorf("upgrade Init %v : %s", args, value)
+			}
+			return err
+		})
+		if len(s.keys) != 0 {
+			t.Errorf("upgrade Init %v writes %v", args, s.keys)
+		}
+	}
+	admin := newTestKey(t)
+	data := util.JSONEncode(TGenesisData{Admin: TGenesisAccount{PublicKey: admin.PublicKey}})
+	assertError(t, s.tx(func() error { _, err := Init(s, []string{"init", data}); return err }), "6100")
+}
+
+func TestInitUpgradeAdmin(t *testing.T) {
+	s := newTestStub()
+	s.mustTx(t, func() error { return s.PutState("TOKEN_MAX_NO", []byte("3")) })
+	upgrade := func(data string) error {
+		return s.tx(func() error { _, err := Init(s, []string{"init", data}); return err })
+	}
+
+	// the submitter with the admin attribute is not the admin by itself
+	s.Creator = testCreator("Org1MSP", "operator", map[string]string{AdminAttribute: AdminAttributeValue})
+	s.mustTx(t, func() error { _, err := Init(s, []string{"init"}); return err })
+	if list, _ := GetAdminList(s); len(list.Admins) != 0 {
+		t.Fatalf("admin list by the upgrade Init without the argument : %+v", list)
+	}
+	assertError(t, s.tx(func() error { _, err := CheckAdmin(s); return err }), "6030")
+
+	// the admin list of the Init argument
+	assertError(t, upgrade(`{"admins":[]}`), "1000")
+	assertError(t, upgrade(`{"admins":[{"mspid":"Org1MSP"}]}`), "1000")
+	key := newTestKey(t)
+	assertError(t, upgrade(util.JSONEncode(TGenesisData{Admin: TGenesisAccount{PublicKey: key.PublicKey}, Admins: testAdmins})), "6100")
+	if err := upgrade(util.JSONEncode(TGenesisData{Admins: testAdmins})); err != nil {
+		t.Fatal(err)
+	}
+	list, err := GetAdminList(s)
+	if err != nil || len(list.Admins) != 1 || list.Admins[0] != (TAdmin{MSPID: "Org1MSP", Subject: "CN=admin,O=Org1MSP", RegDate: s.txTime, AddedBy: "upgrade"}) {
+		t.Fatalf("upgrade admin list : %+v, %v", list, err)
+	}
+	if ev := s.lastEvent(t, "admin_add"); ev.Key != "Org1MSP" {
+		t.Errorf("admin_add event : %+v", ev)
+	}
+	assertError(t, s.tx(func() error { _, err := CheckAdmin(s); return err }), "6030")
+	s.Creator = testCreator("Org1MSP", "admin", nil)
+	s.mustTx(t, func() error { return AdminAdd(s, "Org2MSP", AdminAnySubject, nil) })
+
+	// once, the next upgrade keeps the admin list
+	assertError(t, upgrade(`{"admins":[{"mspid":"Org3MSP","subject":"*"}]}`), "6100")
+	s.mustTx(t, func() error { _, err := Init(s, []string{"init"}); return err })
+	if len(s.keys) != 0 {
+		t.Errorf("second upgrade writes %v", s.keys)
+	}
+}
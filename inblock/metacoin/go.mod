@@ -3,14 +3,19 @@ module inblock/metacoin
 go 1.15
 
 require (
+	github.com/decred/dcrd/dcrec/secp256k1/v4 v4.2.0
+	github.com/golang/protobuf v1.3.2
 	github.com/hyperledger/fabric-chaincode-go v0.0.0-20220131132609-1476cf1d3206
-	github.com/hyperledger/fabric-protos-go v0.0.0-20220613214546-bf864f01d75e // indirect
+	github.com/hyperledger/fabric-protos-go v0.0.0-20220613214546-bf864f01d75e
 	github.com/shopspring/decimal v1.2.0
+	golang.org/x/crypto v0.0.0-20220722155217-630584e8d5aa
+	inblock/metacoin/mcerr v0.0.0-00010101000000-000000000000
 	inblock/metacoin/mtc v0.0.0-00010101000000-000000000000
 	inblock/metacoin/util v0.0.0-00010101000000-000000000000
 )
 
 replace (
+	inblock/metacoin/mcerr => ./mcerr
 	inblock/metacoin/mtc => ./mtc
 	inblock/metacoin/util => ./util
 )
@@ -0,0 +1,187 @@
+package metacoin
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"strconv"
+	"strings"
+	"testing"
+
+	"inblock/metacoin/mtc"
+	"inblock/metacoin/util"
+)
+
+// testWallet : wallet with the private key, for signing the requests
+type testWallet struct {
+	Address   string
+	PublicKey string
+	key       *ecdsa.PrivateKey
+	signer    func(data []byte) []byte // signer of the other key type, key is nil
+}
+
+// newTestKey - create P-256 key, the wallet is not created
+func newTestKey(t *testing.T) *testWallet {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testWallet{
+		PublicKey: string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})),
+		key:       key,
+	}
+}
+
+// newTestWallet - create P-256 key and the wallet
+func newTestWallet(t *testing.T, s *testStub) *testWallet {
+	t.Helper()
+	w := newTestKey(t)
+	s.mustTx(t, func() (err error) {
+		w.Address, err = NewWallet(s, w.PublicKey, "", "", "", "", "")
+		return err
+	})
+	return w
+}
+
+// testAdmins : first admin list, the default creator of the test stub
+var testAdmins = []TAdmin{{MSPID: "Org1MSP", Subject: "CN=admin,O=Org1MSP"}}
+
+// newGenesisWallet - run the genesis, the admin wallet has amount of the base coin (token 0)
+func newGenesisWallet(t *testing.T, s *testStub, amount string) *testWallet {
+	t.Helper()
+	w := newTestKey(t)
+	data := util.JSONEncode(TGenesisData{Admin: TGenesisAccount{PublicKey: w.PublicKey, Amount: amount}, Admins: testAdmins})
+	s.mustTx(t, func() error {
+		genesis, err := Genesis(s, data)
+		w.Address = genesis.Admin
+		return err
+	})
+	return w
+}
+
+// sign - signature of the canonical payload of the function, same as the client
+func (w *testWallet) sign(t *testing.T, s *testStub, function string, data ...string) string {
+	t.Helper()
+	payload, err := SignPayload(s, function, data...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return w.signData(t, payload)
+}
+
+// signLegacy - signature of the "|" joined data, the client before the canonical payload
+func (w *testWallet) signLegacy(t *testing.T, data ...string) string {
+	t.Helper()
+	return w.signData(t, strings.Join(data, "|"))
+}
+
+func (w *testWallet) signData(t *testing.T, data string) string {
+	t.Helper()
+	if w.signer != nil {
+		return base64.StdEncoding.EncodeToString(w.signer([]byte(data)))
+	}
+	h := sha256.Sum256([]byte(data))
+	sig, err := ecdsa.SignASN1(rand.Reader, w.key, h[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return base64.StdEncoding.EncodeToString(sig)
+}
+
+// nonce - current nonce of the wallet
+func (w *testWallet) nonce(t *testing.T, s *testStub) string {
+	t.Helper()
+	nonce, err := GetNonce(s, w.Address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return nonce
+}
+
+// wallet - wallet data with all balance keys
+func (w *testWallet) wallet(t *testing.T, s *testStub) mtc.TWallet {
+	t.Helper()
+	wallet, err := GetAddressInfo(s, w.Address)
+	if err == nil {
+		err = loadAssets(s, &wallet)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return wallet
+}
+
+// balance - unlocked MRC010 balance
+func (w *testWallet) balance(t *testing.T, s *testStub, token string) string {
+	t.Helper()
+	tokenNo, _ := strconv.Atoi(token)
+	if b, exists := w.wallet(t, s).MRC010[tokenNo]; exists {
+		return b.Balance
+	}
+	return "0"
+}
+
+// balance402 - MRC402 balance
+func (w *testWallet) balance402(t *testing.T, s *testStub, mrc402id string) string {
+	t.Helper()
+	if b, exists := w.wallet(t, s).MRC402[mrc402id]; exists {
+		return b.Balance
+	}
+	return "0"
+}
+
+// registerToken - register MRC010 token, all supply is reserved to the owner
+func registerToken(t *testing.T, s *testStub, owner *testWallet, symbol, supply string) string {
+	t.Helper()
+	var tokenID string
+	data := util.JSONEncode(mtc.TMRC010{
+		Owner:       owner.Address,
+		Symbol:      symbol,
+		Name:        symbol + " token",
+		TotalSupply: supply,
+		Reserve:     []mtc.TMRC010Reserve{{Address: owner.Address, Value: supply}},
+	})
+	nonce := owner.nonce(t, s)
+	s.mustTx(t, func() (err error) {
+		tokenID, err = TokenRegister(s, data, owner.sign(t, s, "tokenRegister", owner.Address, symbol+" token", nonce), nonce)
+		return err
+	})
+	return tokenID
+}
+
+// transfer - send token
+func transfer(t *testing.T, s *testStub, from, to *testWallet, token, amount string) error {
+	t.Helper()
+	nonce := from.nonce(t, s)
+	sig := from.sign(t, s, "transfer", from.Address, to.Address, token, amount, nonce)
+	args := []string{from.Address, to.Address, amount, token, sig, "0", "", "", nonce}
+	return s.tx(func() error {
+		return Transfer(s, from.Address, to.Address, amount, token, "0", sig, nonce, args)
+	})
+}
+
+func assertBalance(t *testing.T, s *testStub, w *testWallet, token, expect string) {
+	t.Helper()
+	if got := w.balance(t, s, token); got != expect {
+		t.Errorf("balance of %s token %s : got %s, expect %s", w.Address, token, got, expect)
+	}
+}
+
+func assertError(t *testing.T, err error, code string) {
+	t.Helper()
+	if err == nil {
+		t.Fatalf("expect error %s, got nil", code)
+	}
+	if !strings.HasPrefix(err.Error(), code+",") {
+		t.Fatalf("expect error %s, got %v", code, err)
+	}
+}
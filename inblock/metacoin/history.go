@@ -0,0 +1,522 @@
+package metacoin
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
+
+	"inblock/metacoin/mcerr"
+	"inblock/metacoin/mtc"
+	"inblock/metacoin/util"
+)
+
+// THistory : one version of the key
+type THistory struct {
+	TxID      string      `json:"txid"`
+	Timestamp int64       `json:"timestamp"`
+	IsDelete  bool        `json:"is_delete,omitempty"`
+	JobType   string      `json:"job_type"`
+	Args      []string    `json:"args"`
+	Balance   interface{} `json:"balance,omitempty"` // balance snapshot of the version
+}
+
+// THistoryPage : history query result
+// Bookmark is the tx id of the last record, "" is the last page.
+type THistoryPage struct {
+	Key      string     `json:"key"`
+	Records  []THistory `json:"records"`
+	Bookmark string     `json:"bookmark"`
+}
+
+// THistoryWallet : wallet balance snapshot
+// Balance is the legacy list of the version before the MRC010 map. The balance
+// saved to the balance keys is the value of the keys after the transaction,
+// the MRC010 balance includes the receive shards. (see addressHistory)
+type THistoryWallet struct {
+	Balance []mtc.TMRC010Balance         `json:"balance,omitempty"`
+	MRC010  map[int]mtc.TMRC010BalanceV2 `json:"mrc010,omitempty"`
+	MRC402  map[string]mtc.NFTBalance    `json:"mrc402,omitempty"`
+	MRC800  map[string]string            `json:"mrc800,omitempty"`
+}
+
+// THistoryDex : DEX010, DEX402 snapshot
+type THistoryDex struct {
+	Amount               string `json:"amount"`
+	RemainAmount         string `json:"remain_amount"`
+	Buyer                string `json:"buyer"`
+	AuctionCurrentPrice  string `json:"auction_current_price"`
+	AuctionCurrentBidder string `json:"auction_current_bidder"`
+}
+
+// THistoryMRC401 : MRC401 snapshot
+type THistoryMRC401 struct {
+	Owner     string `json:"owner"`
+	SellPrice string `json:"sell_price"`
+	SellToken string `json:"sell_token"`
+}
+
+// historyJob : common job fields of the state document
+type historyJob struct {
+	JobType string `json:"job_type"`
+	JobArgs string `json:"job_args"`
+}
+
+// historyKeyType - state type of the key
+func historyKeyType(key string) string {
+	switch {
+	case util.IsAddress(key):
+		return "address"
+	case isBalanceKey(key):
+		return "balance"
+	case strings.Index(key, "DEX010_") == 0 && len(key) == 40:
+		return "dex010"
+	case strings.Index(key, "DEX402_") == 0 && len(key) == 40:
+		return "dex402"
+	case strings.Index(key, "MRC400_") == 0 && len(key) == 81:
+		return "mrc401"
+	}
+	return ""
+}
+
+// History - versions of the address, balance (<address>_MRC010_<token> ...), DEX010,
+// DEX402 or MRC401 key, newest first
+//
+// pageSize is 1 to 100 (default 20), bookmark is the value of the previous page.
+// The history is read from the newest version until the page is filled, the
+// versions before the bookmark are skipped without decoding.
+func History(stub shim.ChaincodeStubInterface, key, pageSize, bookmark string) (THistoryPage, error) {
+	var err error
+	var size int32
+	var page = THistoryPage{Key: key, Records: make([]THistory, 0)}
+
+	keyType := historyKeyType(key)
+	if keyType == "" {
+		return page, mcerr.New(mcerr.InvalidData, "History supports address, balance, DEX010, DEX402 and MRC401 key").WithField("key")
+	}
+
+	if size, err = parsePageSize(pageSize); err != nil {
+		return page, err
+	}
+
+	var next func(decode bool) (THistory, bool, error)
+	if keyType == "address" {
+		h, err := newAddressHistory(stub, key)
+		if err != nil {
+			return page, err
+		}
+		defer h.close()
+		next = h.next
+	} else {
+		c, err := openHistory(stub, key)
+		if err != nil {
+			return page, err
+		}
+		defer c.close()
+		next = func(decode bool) (THistory, bool, error) {
+			return c.record(keyType, decode)
+		}
+	}
+
+	skip := bookmark != ""
+	for {
+		record, ok, err := next(!skip)
+		if err != nil {
+			return page, err
+		}
+		if !ok {
+			break
+		}
+		if skip {
+			if record.TxID == bookmark {
+				skip = false
+			}
+			continue
+		}
+		if int32(len(page.Records)) == size {
+			page.Bookmark = page.Records[size-1].TxID
+			break
+		}
+		page.Records = append(page.Records, record)
+	}
+	if skip {
+		return page, mcerr.New(mcerr.InvalidValue, "Bookmark not found").WithField("bookmark")
+	}
+	return page, nil
+}
+
+// historyCursor : versions of the key read on demand, newest first
+type historyCursor struct {
+	key  string
+	iter shim.HistoryQueryIteratorInterface
+	mods []*queryresult.KeyModification // read, not consumed yet
+}
+
+// openHistory - cursor of the key history
+func openHistory(stub shim.ChaincodeStubInterface, key string) (*historyCursor, error) {
+	iter, err := stub.GetHistoryForKey(key)
+	if err != nil {
+		return nil, mcerr.Wrap(mcerr.LedgerRead, "Hyperledger internal error", err)
+	}
+	return &historyCursor{key: key, iter: iter}, nil
+}
+
+// fill - read the versions until the cursor has n versions or the history ends
+func (c *historyCursor) fill(n int) error {
+	for len(c.mods) < n && c.iter.HasNext() {
+		mod, err := c.iter.Next()
+		if err != nil {
+			return mcerr.Wrap(mcerr.LedgerRead, "Hyperledger internal error", err)
+		}
+		c.mods = append(c.mods, mod)
+	}
+	return nil
+}
+
+// head - the newest version not consumed, nil is the end of the history
+func (c *historyCursor) head() (*queryresult.KeyModification, error) {
+	if err := c.fill(1); err != nil || len(c.mods) == 0 {
+		return nil, err
+	}
+	return c.mods[0], nil
+}
+
+// group - the versions of the same tx time as the head
+func (c *historyCursor) group() ([]*queryresult.KeyModification, error) {
+	head, err := c.head()
+	if err != nil || head == nil {
+		return nil, err
+	}
+	for n := 1; ; n++ {
+		if err = c.fill(n + 1); err != nil {
+			return nil, err
+		}
+		if len(c.mods) == n || !historySameTime(c.mods[n], head) {
+			return c.mods[:n], nil
+		}
+	}
+}
+
+// pop - consume the head
+func (c *historyCursor) pop() {
+	c.mods = c.mods[1:]
+}
+
+// record - consume the head as the record of the key
+func (c *historyCursor) record(keyType string, decode bool) (THistory, bool, error) {
+	mod, err := c.head()
+	if err != nil || mod == nil {
+		return THistory{}, false, err
+	}
+	c.pop()
+	if !decode {
+		return THistory{TxID: mod.TxId}, true, nil
+	}
+	return historyRecord(keyType, mod.TxId, mod.Timestamp.GetSeconds(), mod.IsDelete, mod.Value), true, nil
+}
+
+func (c *historyCursor) close() {
+	c.iter.Close()
+}
+
+// addressHistory : transactions of the wallet document and the balance keys, newest first
+//
+// The transaction which wrote the document or the balance key of the wallet is
+// one record, the job is the one of the document if it is written. The balance
+// keys are the assets (and the receive shards) of the document versions, the
+// key is followed from the document version which has it, so only the
+// versions down to the requested page are read.
+type addressHistory struct {
+	stub    shim.ChaincodeStubInterface
+	address string
+	cursors []*historyCursor // wallet document first, the balance keys in the key order
+	tracked map[string]bool
+	tokens  map[int]bool // MRC010 tokens of the document versions
+	shards  int
+	docTx   string                       // tx id of the document version of the balance keys
+	last    *queryresult.KeyModification // version of the last record
+	emitted map[string]bool              // tx ids of the records of the last tx time
+}
+
+// newAddressHistory - history of the wallet document, the balance keys are added on the way
+func newAddressHistory(stub shim.ChaincodeStubInterface, address string) (*addressHistory, error) {
+	doc, err := openHistory(stub, address)
+	if err != nil {
+		return nil, err
+	}
+	return &addressHistory{stub: stub, address: address, cursors: []*historyCursor{doc},
+		tracked: map[string]bool{address: true}, tokens: make(map[int]bool), emitted: make(map[string]bool)}, nil
+}
+
+func (h *addressHistory) close() {
+	for _, c := range h.cursors {
+		c.close()
+	}
+}
+
+// track - follow the balance key, the versions after the last record are
+// already in the records (the delete of the asset removed from the document)
+func (h *addressHistory) track(key string) error {
+	if h.tracked[key] {
+		return nil
+	}
+	c, err := openHistory(h.stub, key)
+	if err != nil {
+		return err
+	}
+	h.tracked[key] = true
+	for h.last != nil {
+		head, err := c.head()
+		if err != nil {
+			c.close()
+			return err
+		}
+		if head == nil || !(historyBefore(h.last, head) || h.emitted[head.TxId]) {
+			break
+		}
+		c.pop()
+	}
+	i := 1 + sort.Search(len(h.cursors)-1, func(i int) bool { return h.cursors[1+i].key >= key })
+	h.cursors = append(h.cursors, nil)
+	copy(h.cursors[i+1:], h.cursors[i:])
+	h.cursors[i] = c
+	return nil
+}
+
+// discover - follow the balance keys of the document version
+func (h *addressHistory) discover(mod *queryresult.KeyModification) error {
+	var doc mtc.TWallet
+
+	if mod == nil || mod.TxId == h.docTx {
+		return nil
+	}
+	h.docTx = mod.TxId
+	if mod.IsDelete || json.Unmarshal(mod.Value, &doc) != nil {
+		return nil
+	}
+	if doc.Shards > h.shards {
+		h.shards = doc.Shards
+	}
+	for _, asset := range doc.Assets {
+		if err := h.track(balanceKey(h.address, asset)); err != nil {
+			return err
+		}
+		if !strings.HasPrefix(asset, AssetMRC010) {
+			continue
+		}
+		if token, err := strconv.Atoi(asset[len(AssetMRC010):]); err == nil {
+			h.tokens[token] = true
+		}
+	}
+	for token := range h.tokens {
+		for n := 0; n < h.shards; n++ {
+			if err := h.track(shardKey(h.address, token, n)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// next - the newest record not returned yet, decode is false for the skipped record
+func (h *addressHistory) next(decode bool) (THistory, bool, error) {
+	doc, err := h.cursors[0].head()
+	if err != nil {
+		return THistory{}, false, err
+	}
+	if err = h.discover(doc); err != nil {
+		return THistory{}, false, err
+	}
+
+	// the newest tx time of the keys
+	var newest *queryresult.KeyModification
+	for _, c := range h.cursors {
+		head, err := c.head()
+		if err != nil {
+			return THistory{}, false, err
+		}
+		if head != nil && (newest == nil || historyBefore(newest, head)) {
+			newest = head
+		}
+	}
+	if newest == nil {
+		return THistory{}, false, nil
+	}
+
+	// the tx of the time which is the head of all keys it wrote
+	groups := make([]map[string]bool, len(h.cursors))
+	for i, c := range h.cursors {
+		if len(c.mods) == 0 || !historySameTime(c.mods[0], newest) {
+			continue
+		}
+		mods, err := c.group()
+		if err != nil {
+			return THistory{}, false, err
+		}
+		groups[i] = make(map[string]bool, len(mods))
+		for _, mod := range mods {
+			groups[i][mod.TxId] = true
+		}
+	}
+	txID := newest.TxId
+	for i, c := range h.cursors {
+		if groups[i] == nil {
+			continue
+		}
+		ready := true
+		for j := range groups {
+			if groups[j][c.mods[0].TxId] && h.cursors[j].mods[0].TxId != c.mods[0].TxId {
+				ready = false
+				break
+			}
+		}
+		if ready {
+			txID = c.mods[0].TxId
+			break
+		}
+	}
+
+	written := make([]*queryresult.KeyModification, len(h.cursors))
+	for i, c := range h.cursors {
+		if len(c.mods) > 0 && c.mods[0].TxId == txID {
+			written[i] = c.mods[0]
+			c.pop()
+		}
+	}
+	if h.last == nil || !historySameTime(h.last, newest) {
+		h.emitted = make(map[string]bool)
+	}
+	h.last = newest
+	h.emitted[txID] = true
+
+	record := THistory{TxID: txID, Timestamp: newest.Timestamp.GetSeconds(), Args: []string{}}
+	if !decode {
+		return record, true, nil
+	}
+
+	// the value of the key not written by the tx is the version before it
+	var legacy *THistoryWallet // balance saved in the document by the old chaincode
+	values := make(map[string]mtc.TMRC010BalanceV2)
+	for i, c := range h.cursors {
+		mod := written[i]
+		if mod == nil {
+			if mod, err = c.head(); err != nil {
+				return record, false, err
+			}
+			if mod == nil {
+				continue
+			}
+		}
+
+		if i == 0 {
+			r := historyRecord("address", mod.TxId, mod.Timestamp.GetSeconds(), mod.IsDelete, mod.Value)
+			if written[i] != nil {
+				record.JobType, record.Args, record.IsDelete = r.JobType, r.Args, r.IsDelete
+			}
+			if b, ok := r.Balance.(*THistoryWallet); ok && (len(b.Balance) > 0 || len(b.MRC010) > 0 || len(b.MRC402) > 0 || len(b.MRC800) > 0) {
+				legacy = b
+			}
+			continue
+		}
+		r := historyRecord("balance", mod.TxId, mod.Timestamp.GetSeconds(), mod.IsDelete, mod.Value)
+		if written[i] != nil && record.JobType == "" {
+			record.JobType, record.Args = r.JobType, r.Args
+		}
+		if b, ok := r.Balance.(*mtc.TMRC010BalanceV2); ok {
+			values[c.key] = *b
+		}
+	}
+	if legacy != nil {
+		record.Balance = legacy
+	} else {
+		record.Balance = historyWallet(h.address, values)
+	}
+	return record, true, nil
+}
+
+// historyBefore - a is committed before b by the tx time
+func historyBefore(a, b *queryresult.KeyModification) bool {
+	if a.Timestamp.GetSeconds() != b.Timestamp.GetSeconds() {
+		return a.Timestamp.GetSeconds() < b.Timestamp.GetSeconds()
+	}
+	return a.Timestamp.GetNanos() < b.Timestamp.GetNanos()
+}
+
+// historySameTime - a and b have the same tx time
+func historySameTime(a, b *queryresult.KeyModification) bool {
+	return !historyBefore(a, b) && !historyBefore(b, a)
+}
+
+// historyWallet - balance snapshot of the balance key values
+func historyWallet(address string, values map[string]mtc.TMRC010BalanceV2) *THistoryWallet {
+	var snapshot = &THistoryWallet{}
+
+	for key, b := range values {
+		asset := key[len(address)+1:]
+		switch {
+		case strings.HasPrefix(asset, AssetMRC010):
+			token, err := strconv.Atoi(strings.SplitN(asset[len(AssetMRC010):], "_", 2)[0])
+			if err != nil {
+				continue
+			}
+			if snapshot.MRC010 == nil {
+				snapshot.MRC010 = make(map[int]mtc.TMRC010BalanceV2)
+			}
+			if prev, exists := snapshot.MRC010[token]; exists {
+				b = mergeBalance(prev, b)
+			}
+			snapshot.MRC010[token] = b
+		case strings.HasPrefix(asset, AssetMRC402):
+			if snapshot.MRC402 == nil {
+				snapshot.MRC402 = make(map[string]mtc.NFTBalance)
+			}
+			snapshot.MRC402[asset[len(AssetMRC402):]] = mtc.NFTBalance{Balance: b.Balance,
+				SaleAmount: b.SaleAmount, AuctionAmount: b.AuctionAmount}
+		case strings.HasPrefix(asset, AssetMRC800):
+			if snapshot.MRC800 == nil {
+				snapshot.MRC800 = make(map[string]string)
+			}
+			snapshot.MRC800[asset[len(AssetMRC800):]] = b.Balance
+		}
+	}
+	return snapshot
+}
+
+// historyRecord - decode the state value
+func historyRecord(keyType, txID string, timestamp int64, isDelete bool, value []byte) THistory {
+	var job historyJob
+	var balance interface{}
+
+	record := THistory{TxID: txID, Timestamp: timestamp, IsDelete: isDelete, Args: []string{}}
+	if isDelete || len(value) == 0 {
+		return record
+	}
+	if err := json.Unmarshal(value, &job); err != nil {
+		return record
+	}
+
+	record.JobType = job.JobType
+	if job.JobArgs != "" {
+		if err := json.Unmarshal([]byte(job.JobArgs), &record.Args); err != nil {
+			record.Args = []string{job.JobArgs}
+		}
+	}
+
+	switch keyType {
+	case "address":
+		balance = &THistoryWallet{}
+	case "balance":
+		balance = &mtc.TMRC010BalanceV2{}
+	case "dex010", "dex402":
+		balance = &THistoryDex{}
+	case "mrc401":
+		balance = &THistoryMRC401{}
+	}
+	if err := json.Unmarshal(value, balance); err == nil {
+		record.Balance = balance
+	}
+	return record
+}
@@ -0,0 +1,133 @@
+package metacoin
+
+import (
+	"reflect"
+	"testing"
+
+	"inblock/metacoin/mtc"
+)
+
+func TestHistory(t *testing.T) {
+	s := newTestStub()
+	alice := newGenesisWallet(t, s, "1000")
+	bob := newTestWallet(t, s)
+	for _, amount := range []string{"1", "2", "3"} {
+		s.advance(60)
+		if err := transfer(t, s, alice, bob, "0", amount); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// genesis wallet (NewWallet), transfer * 3
+	var records []THistory
+	var bookmark string
+	for pages := 0; ; pages++ {
+		page, err := History(s, alice.Address, "2", bookmark)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if pages == 0 && len(page.Records) != 2 {
+			t.Fatalf("first page : got %d records, expect 2", len(page.Records))
+		}
+		records = append(records, page.Records...)
+		if bookmark = page.Bookmark; bookmark == "" {
+			break
+		}
+	}
+	if len(records) != 4 {
+		t.Fatalf("history : got %d records, expect 4", len(records))
+	}
+	if records[0].JobType != "transfer" || records[0].Args[2] != "3" || records[0].Timestamp != s.txTime {
+		t.Errorf("newest record : %+v", records[0])
+	}
+	if b, ok := records[0].Balance.(*THistoryWallet); !ok || b.MRC010[0].Balance != "994" {
+		t.Errorf("newest balance snapshot : %+v", records[0].Balance)
+	}
+	if records[3].JobType != "NewWallet" {
+		t.Errorf("oldest record : %s", records[3].JobType)
+	}
+
+	// the receive writes the balance key only
+	page, err := History(s, bob.Address, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Records) != 4 || page.Records[0].JobType != "receive" || page.Records[3].JobType != "NewWallet" {
+		t.Fatalf("receiver history : %+v", page.Records)
+	}
+	for i, expect := range []string{"6", "3", "1", "0"} {
+		if b, ok := page.Records[i].Balance.(*THistoryWallet); !ok || b.MRC010[0].Balance != expect {
+			t.Errorf("receiver balance snapshot %d : %+v, expect %s", i, page.Records[i].Balance, expect)
+		}
+	}
+
+	// balance key
+	page, err = History(s, balanceKey(alice.Address, AssetMRC010+"0"), "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Records) != 4 || page.Records[0].JobType != "transfer" {
+		t.Fatalf("balance history : %+v", page.Records)
+	}
+	if b, ok := page.Records[0].Balance.(*mtc.TMRC010BalanceV2); !ok || b.Balance != "994" {
+		t.Errorf("newest balance snapshot : %+v", page.Records[0].Balance)
+	}
+
+	if _, err := History(s, "TOKEN_DATA_0", "", ""); err == nil {
+		t.Error("history of the token key")
+	}
+	if _, err := History(s, alice.Address, "", "unknown"); err == nil {
+		t.Error("history with the unknown bookmark")
+	}
+}
+
+func TestHistoryPaging(t *testing.T) {
+	s := newTestStub()
+	alice := newGenesisWallet(t, s, "1000")
+	bob := newTestWallet(t, s)
+	for i := 0; i < 30; i++ {
+		s.advance(60)
+		if err := transfer(t, s, alice, bob, "0", "1"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the first page reads the newest versions only
+	s.histN = 0
+	page, err := History(s, alice.Address, "2", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Records) != 2 || page.Bookmark != page.Records[1].TxID {
+		t.Fatalf("first page : %+v", page)
+	}
+	if s.histN > 10 {
+		t.Errorf("first page read %d versions", s.histN)
+	}
+
+	// the pages are the same as the one page
+	all, err := History(s, alice.Address, "100", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all.Records) != 31 || all.Bookmark != "" {
+		t.Fatalf("history : got %d records, expect 31", len(all.Records))
+	}
+	var records []THistory
+	for bookmark := ""; ; {
+		page, err := History(s, alice.Address, "7", bookmark)
+		if err != nil {
+			t.Fatal(err)
+		}
+		records = append(records, page.Records...)
+		if bookmark = page.Bookmark; bookmark == "" {
+			break
+		}
+	}
+	if !reflect.DeepEqual(records, all.Records) {
+		t.Errorf("paged history differs from the one page")
+	}
+	if b, ok := all.Records[10].Balance.(*THistoryWallet); !ok || b.MRC010[0].Balance != "980" {
+		t.Errorf("balance snapshot : %+v", all.Records[10].Balance)
+	}
+}
@@ -0,0 +1,315 @@
+package metacoin
+
+import (
+	"encoding/json"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+
+	"inblock/metacoin/mcerr"
+	"inblock/metacoin/util"
+)
+
+// Composite key index list. (object type, attributes)
+//
+// The setters (setDEX010, setDEX402, setMRC401) keep the index up to date,
+// the index value is the one byte 0x00, the data is read by the last attribute key.
+const (
+	IndexDEX010Token    = "dex010~token"   // mrc010 id, dex id : open DEX010 orders of the token
+	IndexDEX010Seller   = "dex010~seller"  // seller, dex id : DEX010 orders of the seller
+	IndexDEX402Token    = "dex402~token"   // mrc402 id, dex id : open DEX402 orders of the MRC402
+	IndexDEX402Seller   = "dex402~seller"  // seller, dex id : DEX402 orders of the seller
+	IndexMRC401Project  = "mrc401~project" // mrc400 id, mrc401 id : items of the project
+	IndexMRC401Owner    = "mrc401~owner"   // owner, mrc401 id : items of the owner
+	tokenDataRangeStart = "TOKEN_DATA_"
+	tokenDataRangeEnd   = "TOKEN_DATA_~"
+)
+
+// page size of the list query
+const (
+	PageSize    = 20
+	PageSizeMax = 100
+)
+
+// TQueryPage : list query result
+// Bookmark is "" on the last page.
+type TQueryPage struct {
+	Records  []json.RawMessage `json:"records"`
+	Count    int32             `json:"count"`
+	Bookmark string            `json:"bookmark"`
+}
+
+var indexValue = []byte{0x00}
+
+// putIndex - add composite key index
+func putIndex(stub shim.ChaincodeStubInterface, objectType string, attributes ...string) error {
+	key, err := stub.CreateCompositeKey(objectType, attributes)
+	if err != nil {
+		return mcerr.Wrap(mcerr.InvalidData, "Index key error", err)
+	}
+	if err = stub.PutState(key, indexValue); err != nil {
+		return mcerr.Wrap(mcerr.LedgerWrite, "Hyperledger internal error", err)
+	}
+	return nil
+}
+
+// delIndex - remove composite key index
+func delIndex(stub shim.ChaincodeStubInterface, objectType string, attributes ...string) error {
+	key, err := stub.CreateCompositeKey(objectType, attributes)
+	if err != nil {
+		return mcerr.Wrap(mcerr.InvalidData, "Index key error", err)
+	}
+	if err = stub.DelState(key); err != nil {
+		return mcerr.Wrap(mcerr.LedgerWrite, "Hyperledger internal error", err)
+	}
+	return nil
+}
+
+// parsePageSize - "" is default page size
+func parsePageSize(pageSize string) (int32, error) {
+	if pageSize == "" {
+		return PageSize, nil
+	}
+	size, err := util.Strtoint(pageSize)
+	if err != nil || size < 1 || size > PageSizeMax {
+		return 0, mcerr.New(mcerr.InvalidValue, "Page size must be 1 to 100").WithField("pagesize")
+	}
+	return int32(size), nil
+}
+
+// QueryIndex - list the data of the composite key index
+//
+// Example
+//
+//	page, err := QueryIndex(stub, IndexDEX010Token, "1", "20", "")
+func QueryIndex(stub shim.ChaincodeStubInterface, objectType, attribute, pageSize, bookmark string) (TQueryPage, error) {
+	var page = TQueryPage{Records: make([]json.RawMessage, 0)}
+
+	if attribute == "" {
+		return page, mcerr.New(mcerr.InvalidArguments, "Key is empty")
+	}
+	size, err := parsePageSize(pageSize)
+	if err != nil {
+		return page, err
+	}
+
+	iter, meta, err := stub.GetStateByPartialCompositeKeyWithPagination(objectType, []string{attribute}, size, bookmark)
+	if err != nil {
+		return page, mcerr.Wrap(mcerr.LedgerRead, "Hyperledger internal error", err)
+	}
+	defer iter.Close()
+
+	for iter.HasNext() {
+		kv, err := iter.Next()
+		if err != nil {
+			return page, mcerr.Wrap(mcerr.LedgerRead, "Hyperledger internal error", err)
+		}
+		_, attributes, err := stub.SplitCompositeKey(kv.Key)
+		if err != nil || len(attributes) == 0 {
+			continue
+		}
+		value, err := stub.GetState(attributes[len(attributes)-1])
+		if err != nil {
+			return page, mcerr.Wrap(mcerr.LedgerRead, "Hyperledger internal error", err)
+		}
+		if value == nil {
+			continue
+		}
+		page.Records = append(page.Records, value)
+	}
+	page.Count = int32(len(page.Records))
+	if meta != nil && meta.FetchedRecordsCount == size {
+		page.Bookmark = meta.Bookmark
+	}
+	return page, nil
+}
+
+// TokenList - MRC010 token list
+func TokenList(stub shim.ChaincodeStubInterface, pageSize, bookmark string) (TQueryPage, error) {
+	var page = TQueryPage{Records: make([]json.RawMessage, 0)}
+
+	size, err := parsePageSize(pageSize)
+	if err != nil {
+		return page, err
+	}
+	iter, meta, err := stub.GetStateByRangeWithPagination(tokenDataRangeStart, tokenDataRangeEnd, size, bookmark)
+	if err != nil {
+		return page, mcerr.Wrap(mcerr.LedgerRead, "Hyperledger internal error", err)
+	}
+	defer iter.Close()
+
+	for iter.HasNext() {
+		kv, err := iter.Next()
+		if err != nil {
+			return page, mcerr.Wrap(mcerr.LedgerRead, "Hyperledger internal error", err)
+		}
+		page.Records = append(page.Records, kv.Value)
+	}
+	page.Count = int32(len(page.Records))
+	if meta != nil && meta.FetchedRecordsCount == size {
+		page.Bookmark = meta.Bookmark
+	}
+	return page, nil
+}
+
+// dex010Closed - sold out, canceled or settled
+func dex010Closed(stub shim.ChaincodeStubInterface, dex TMRC010DEX) bool {
+	switch dex010Status(stub, dex) {
+	case MRC010DS_SOLDOUT, MRC010DS_CANCLED, MRC010DS_AUCTION_FINISH:
+		return true
+	}
+	return false
+}
+
+// dex402Closed - sold out, canceled or settled
+func dex402Closed(stub shim.ChaincodeStubInterface, dex TMRC402DEX) bool {
+	switch dex402Status(stub, dex) {
+	case MRC402DS_SOLDOUT, MRC402DS_CANCLED, MRC402DS_AUCTION_FINISH:
+		return true
+	}
+	return false
+}
+
+// indexDEX010 - update DEX010 index, isNew is the first save of the order
+func indexDEX010(stub shim.ChaincodeStubInterface, dex TMRC010DEX, isNew bool) error {
+	if isNew {
+		if err := putIndex(stub, IndexDEX010Seller, dex.Seller, dex.Id); err != nil {
+			return err
+		}
+	}
+	if dex010Closed(stub, dex) {
+		return delIndex(stub, IndexDEX010Token, dex.MRC010, dex.Id)
+	} else if isNew {
+		return putIndex(stub, IndexDEX010Token, dex.MRC010, dex.Id)
+	}
+	return nil
+}
+
+// indexDEX402 - update DEX402 index, isNew is the first save of the order
+func indexDEX402(stub shim.ChaincodeStubInterface, dex TMRC402DEX, isNew bool) error {
+	if isNew {
+		if err := putIndex(stub, IndexDEX402Seller, dex.Seller, dex.Id); err != nil {
+			return err
+		}
+	}
+	if dex402Closed(stub, dex) {
+		return delIndex(stub, IndexDEX402Token, dex.MRC402, dex.Id)
+	} else if isNew {
+		return putIndex(stub, IndexDEX402Token, dex.MRC402, dex.Id)
+	}
+	return nil
+}
+
+// indexMRC401 - update MRC401 index, prevOwner is "" for the new item
+func indexMRC401(stub shim.ChaincodeStubInterface, mrc401id string, item TMRC401, prevOwner string, isNew bool) error {
+	if isNew {
+		if err := putIndex(stub, IndexMRC401Project, item.MRC400, mrc401id); err != nil {
+			return err
+		}
+	}
+	if prevOwner == item.Owner {
+		return nil
+	}
+	if prevOwner != "" {
+		if err := delIndex(stub, IndexMRC401Owner, prevOwner, mrc401id); err != nil {
+			return err
+		}
+	}
+	if item.Owner == "MELTED" {
+		return nil
+	}
+	return putIndex(stub, IndexMRC401Owner, item.Owner, mrc401id)
+}
+
+// index backfill target : key range of the data
+var reindexTargets = map[string][2]string{
+	"dex010": {"DEX010_", "DEX010_~"},
+	"dex402": {"DEX402_", "DEX402_~"},
+	"mrc401": {"MRC400_", "MRC400_~"},
+}
+
+// TReindexResult : index backfill result
+// Next is the start key of the next call, "" is done.
+type TReindexResult struct {
+	Target string `json:"target"`
+	Count  int32  `json:"count"`
+	Next   string `json:"next"`
+}
+
+// Reindex - add the index of the data saved before the index (admin)
+//
+// target is dex010, dex402 or mrc401. The data from the start key ("" is the
+// first) is indexed up to count items, call again with the next key until
+// next is "". Indexing the same data again does not change the index.
+func Reindex(stub shim.ChaincodeStubInterface, target, start, count string, args []string) (TReindexResult, error) {
+	var result = TReindexResult{Target: target}
+
+	id, err := CheckAdmin(stub)
+	if err != nil {
+		return result, err
+	}
+	keyRange, exists := reindexTargets[target]
+	if !exists {
+		return result, mcerr.New(mcerr.InvalidValue, "Target must be dex010, dex402 or mrc401").WithField("target")
+	}
+	size, err := parsePageSize(count)
+	if err != nil {
+		return result, err
+	}
+	if start == "" {
+		start = keyRange[0]
+	} else if start < keyRange[0] || start >= keyRange[1] {
+		return result, mcerr.New(mcerr.InvalidValue, "Start key is out of the "+target+" range").WithField("start")
+	}
+
+	// GetStateByRange, the pagination query is not allowed in the invoke.
+	iter, err := stub.GetStateByRange(start, keyRange[1])
+	if err != nil {
+		return result, mcerr.Wrap(mcerr.LedgerRead, "Hyperledger internal error", err)
+	}
+	defer iter.Close()
+
+	for iter.HasNext() {
+		kv, err := iter.Next()
+		if err != nil {
+			return result, mcerr.Wrap(mcerr.LedgerRead, "Hyperledger internal error", err)
+		}
+		if result.Count == size {
+			result.Next = kv.Key
+			break
+		}
+		indexed, err := reindexKey(stub, target, kv.Key)
+		if err != nil {
+			return result, err
+		}
+		if indexed {
+			result.Count++
+		}
+	}
+	AddEvent(stub, TEvent{Type: "reindex", Key: target, Args: []string{start, result.Next, id.String()}})
+	return result, nil
+}
+
+// reindexKey - add the index of the data, false is not the data of the target
+func reindexKey(stub shim.ChaincodeStubInterface, target, key string) (bool, error) {
+	switch target {
+	case "dex010":
+		dex, _, err := GetDEX010(stub, key)
+		if err != nil {
+			return false, nil
+		}
+		return true, indexDEX010(stub, dex, true)
+	case "dex402":
+		dex, _, err := GetDEX402(stub, key)
+		if err != nil {
+			return false, nil
+		}
+		return true, indexDEX402(stub, dex, true)
+	case "mrc401":
+		item, _, err := GetMRC401(stub, key) // MRC400 project key is not 81 bytes
+		if err != nil {
+			return false, nil
+		}
+		return true, indexMRC401(stub, key, item, "", true)
+	}
+	return false, nil
+}
@@ -0,0 +1,218 @@
+package metacoin
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"inblock/metacoin/util"
+)
+
+// queryIndexIDs - id list of the index query
+func queryIndexIDs(t *testing.T, s *testStub, objectType, attribute, pageSize, bookmark string) ([]string, string) {
+	t.Helper()
+	page, err := QueryIndex(s, objectType, attribute, pageSize, bookmark)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := make([]string, 0, len(page.Records))
+	for _, record := range page.Records {
+		var v struct {
+			ID    string `json:"id"`    // MRC401
+			DexID string `json:"dexid"` // DEX010, DEX402
+		}
+		if err := json.Unmarshal(record, &v); err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, v.ID+v.DexID)
+	}
+	if int(page.Count) != len(ids) {
+		t.Errorf("count : got %d, expect %d", page.Count, len(ids))
+	}
+	return ids, page.Bookmark
+}
+
+func TestIndexDEX010(t *testing.T) {
+	f := newDexFixture(t)
+	s, alice, bob := f.s, f.alice, f.bob
+
+	sell := func(amount string) string {
+		nonce := alice.nonce(t, s)
+		args := []string{alice.Address, amount, f.item, "5", f.coin, "", "", "", "", ""}
+		args = append(args, alice.sign(t, s, "mrc010sell", append(args[:9:9], nonce)...), nonce)
+		s.mustTx(t, func() error { return Mrc010Sell(s, args) })
+		return s.lastEvent(t, "mrc010_sell").Key
+	}
+	first, second := sell("10"), sell("20")
+
+	if ids, _ := queryIndexIDs(t, s, IndexDEX010Token, f.item, "", ""); len(ids) != 2 {
+		t.Fatalf("open orders : got %v, expect 2", ids)
+	}
+
+	// page size 1
+	ids, bookmark := queryIndexIDs(t, s, IndexDEX010Token, f.item, "1", "")
+	if len(ids) != 1 || bookmark == "" {
+		t.Fatalf("first page : %v, bookmark %q", ids, bookmark)
+	}
+	next, _ := queryIndexIDs(t, s, IndexDEX010Token, f.item, "1", bookmark)
+	if len(next) != 1 || next[0] == ids[0] {
+		t.Errorf("second page : %v, first page %v", next, ids)
+	}
+
+	// sold out order leaves the token index, stays in the seller index
+	nonce := bob.nonce(t, s)
+	s.mustTx(t, func() error {
+		return Mrc010Buy(s, []string{first, bob.Address, "10", bob.sign(t, s, "mrc010buy", first, bob.Address, "10", nonce), nonce})
+	})
+	if ids, _ := queryIndexIDs(t, s, IndexDEX010Token, f.item, "", ""); len(ids) != 1 || ids[0] != second {
+		t.Errorf("open orders after sold out : got %v, expect [%s]", ids, second)
+	}
+	if ids, _ := queryIndexIDs(t, s, IndexDEX010Seller, alice.Address, "", ""); len(ids) != 2 {
+		t.Errorf("seller orders : got %v, expect 2", ids)
+	}
+
+	_, err := QueryIndex(s, IndexDEX010Token, f.item, "101", "")
+	assertError(t, err, "1003")
+}
+
+func TestIndexMRC401(t *testing.T) {
+	f := newDexFixture(t)
+	s, alice, bob := f.s, f.alice, f.bob
+	mrc400id := createMRC400(t, s, alice, f.coin)
+
+	items := []TMRC401job{
+		{ItemID: "ITEM000000000000000000000000000000000001", GroupID: "g1", InititalReserve: "0", InititalToken: f.coin, MeltingFee: "0", Transferable: "Permanent", SellFee: "0"},
+		{ItemID: "ITEM000000000000000000000000000000000002", GroupID: "g1", InititalReserve: "0", InititalToken: f.coin, MeltingFee: "0", Transferable: "Permanent", SellFee: "0"},
+	}
+	itemData := util.JSONEncode(items)
+	nonce := alice.nonce(t, s)
+	s.mustTx(t, func() error {
+		return Mrc401Create(s, mrc400id, itemData, alice.sign(t, s, "mrc401create", mrc400id, itemData, nonce), nonce, nil)
+	})
+	mrc401id := mrc400id + "_" + items[0].ItemID
+
+	if ids, _ := queryIndexIDs(t, s, IndexMRC401Project, mrc400id, "", ""); len(ids) != 2 {
+		t.Fatalf("project items : got %v, expect 2", ids)
+	}
+	if ids, _ := queryIndexIDs(t, s, IndexMRC401Owner, alice.Address, "", ""); len(ids) != 2 {
+		t.Fatalf("owner items : got %v, expect 2", ids)
+	}
+
+	sellData := util.JSONEncode([]TMRC401Sell{{ItemID: mrc401id, SellPrice: "500", SellToken: f.coin}})
+	nonce = alice.nonce(t, s)
+	s.mustTx(t, func() error {
+		return Mrc401Sell(s, alice.Address, mrc400id, sellData, alice.sign(t, s, "mrc401sell", alice.Address, sellData, nonce), nonce, nil)
+	})
+	nonce = bob.nonce(t, s)
+	s.mustTx(t, func() error {
+		return Mrc401Buy(s, bob.Address, mrc401id, bob.sign(t, s, "mrc401buy", mrc401id, nonce), nonce, nil)
+	})
+	if ids, _ := queryIndexIDs(t, s, IndexMRC401Owner, alice.Address, "", ""); len(ids) != 1 {
+		t.Errorf("seller items after buy : got %v, expect 1", ids)
+	}
+	if ids, _ := queryIndexIDs(t, s, IndexMRC401Owner, bob.Address, "", ""); len(ids) != 1 || ids[0] != mrc401id {
+		t.Errorf("buyer items after buy : got %v, expect [%s]", ids, mrc401id)
+	}
+
+	// melted item has no owner
+	nonce = bob.nonce(t, s)
+	s.mustTx(t, func() error {
+		return Mrc401Melt(s, mrc401id, bob.sign(t, s, "mrc401melt", mrc401id, nonce), nonce, nil)
+	})
+	if ids, _ := queryIndexIDs(t, s, IndexMRC401Owner, bob.Address, "", ""); len(ids) != 0 {
+		t.Errorf("owner items after melt : got %v, expect none", ids)
+	}
+	if ids, _ := queryIndexIDs(t, s, IndexMRC401Project, mrc400id, "", ""); len(ids) != 2 {
+		t.Errorf("project items after melt : got %v, expect 2", ids)
+	}
+}
+
+func TestTokenList(t *testing.T) {
+	f := newDexFixture(t)
+
+	page, err := TokenList(f.s, "1", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.Count != 1 || page.Bookmark == "" {
+		t.Fatalf("first page : count %d, bookmark %q", page.Count, page.Bookmark)
+	}
+	if page, err = TokenList(f.s, "", page.Bookmark); err != nil {
+		t.Fatal(err)
+	}
+	if page.Count != 1 || page.Bookmark != "" {
+		t.Errorf("last page : count %d, bookmark %q", page.Count, page.Bookmark)
+	}
+}
+
+func TestReindex(t *testing.T) {
+	f := newDexFixture(t)
+	s, alice := f.s, f.alice
+
+	var orders []string
+	for _, amount := range []string{"10", "20", "30"} {
+		nonce := alice.nonce(t, s)
+		args := []string{alice.Address, amount, f.item, "5", f.coin, "", "", "", "", ""}
+		args = append(args, alice.sign(t, s, "mrc010sell", append(args[:9:9], nonce)...), nonce)
+		s.mustTx(t, func() error { return Mrc010Sell(s, args) })
+		orders = append(orders, s.lastEvent(t, "mrc010_sell").Key)
+	}
+	nonce := alice.nonce(t, s)
+	s.mustTx(t, func() error {
+		return Mrc010UnSell(s, []string{orders[2], alice.sign(t, s, "mrc010unsell", orders[2], nonce), nonce})
+	})
+
+	// orders saved before the index
+	for key := range s.state {
+		if strings.HasPrefix(key, "\x00"+IndexDEX010Token) || strings.HasPrefix(key, "\x00"+IndexDEX010Seller) {
+			delete(s.state, key)
+		}
+	}
+	if ids, _ := queryIndexIDs(t, s, IndexDEX010Seller, alice.Address, "", ""); len(ids) != 0 {
+		t.Fatalf("seller orders before reindex : got %v, expect none", ids)
+	}
+
+	var result TReindexResult
+	reindex := func(start, count string) {
+		s.mustTx(t, func() error {
+			var err error
+			result, err = Reindex(s, "dex010", start, count, nil)
+			return err
+		})
+	}
+	reindex("", "2")
+	if result.Count != 2 || result.Next == "" {
+		t.Fatalf("first call : %+v", result)
+	}
+	reindex(result.Next, "2")
+	if result.Count != 1 || result.Next != "" {
+		t.Fatalf("last call : %+v", result)
+	}
+	if ids, _ := queryIndexIDs(t, s, IndexDEX010Seller, alice.Address, "", ""); len(ids) != 3 {
+		t.Errorf("seller orders : got %v, expect 3", ids)
+	}
+	// canceled order is not open
+	if ids, _ := queryIndexIDs(t, s, IndexDEX010Token, f.item, "", ""); len(ids) != 2 {
+		t.Errorf("open orders : got %v, expect 2", ids)
+	}
+
+	// again, no change
+	reindex("", "")
+	if ids, _ := queryIndexIDs(t, s, IndexDEX010Token, f.item, "", ""); result.Count != 3 || len(ids) != 2 {
+		t.Errorf("second reindex : %+v, open orders %v", result, ids)
+	}
+
+	assertError(t, s.tx(func() error {
+		_, err := Reindex(s, "mrc010", "", "", nil)
+		return err
+	}), "1003")
+	assertError(t, s.tx(func() error {
+		_, err := Reindex(s, "dex402", orders[0], "", nil)
+		return err
+	}), "1003")
+	s.Creator = testCreator("Org2MSP", "user", nil)
+	assertError(t, s.tx(func() error {
+		_, err := Reindex(s, "dex010", "", "", nil)
+		return err
+	}), "6030")
+}
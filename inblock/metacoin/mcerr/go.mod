@@ -0,0 +1,3 @@
+module inblock/metacoin/mcerr
+
+go 1.15
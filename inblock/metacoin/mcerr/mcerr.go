@@ -0,0 +1,228 @@
+// Package mcerr : metacoin error code
+//
+// Error() keeps the legacy "NNNN,message" format, so old clients that split
+// the message on the first "," keep working. JSON() makes the structured
+// body used for the chaincode response.
+//
+// Example
+//
+//	err := mcerr.New(mcerr.NotEnoughBalance, "Not enough balance")
+//	errors.Is(err, mcerr.NotEnoughBalance) // true
+//	err.Error()                            // "5000,Not enough balance"
+package mcerr
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Code : numeric error code
+type Code int
+
+// Error code list. (the existing numeric codes)
+const (
+	InvalidArguments         Code = 1000 // argument count, unsupported function
+	EmptyName                Code = 1001 // token name is empty
+	EmptySymbol              Code = 1002 // token symbol is empty
+	InvalidValue             Code = 1003 // invalid parameter value
+	InvalidDecimal           Code = 1004 // token decimal is out of range
+	InvalidIndex             Code = 1100 // index is out of range
+	NotInteger               Code = 1101 // not a (positive) integer string
+	NonceError               Code = 1102 // nonce mismatch
+	InvalidPrice             Code = 1103 // price or reserve amount error
+	InvalidSubAmount         Code = 1104 // sub balance amount error
+	InvalidQtt               Code = 1105 // quantity error
+	InvalidMeltAmount        Code = 1106 // melt amount or quantity error
+	InvalidSellAmount        Code = 1107 // sell amount error
+	InvalidAuctionAmount     Code = 1108 // auction amount error
+	TokenOwner               Code = 1201 // the token owner can not be the target
+	LoggerNotFound           Code = 1202 // logger not exists
+	PricePrecision           Code = 1203 // price precision is too long
+	QttPrecision             Code = 1204 // quantity precision is too long
+	InvalidSide              Code = 1205 // order side is not SELL or BUY
+	InvalidBurnAmount        Code = 1206 // burn or increase amount error
+	PendingRemain            Code = 1300 // pending balance remain error
+	InvalidEndDate           Code = 1403 // end date is in the past
+	InvalidPlayerList        Code = 2007 // player list count error
+	InvalidSign              Code = 2010 // signature verify failed
+	InvalidFrom              Code = 3001 // invalid from address
+	InvalidTo                Code = 3002 // invalid to address
+	InvalidFeeAddress        Code = 3003 // invalid fee address
+	DexStatus                Code = 3004 // DEX item status does not allow the job
+	InvalidData              Code = 3005 // data length or format error
+	DexJobType               Code = 3014 // DEX item job type does not allow the job
+	DexAuction               Code = 3024 // DEX item is in the auction
+	DexCanceled              Code = 3034 // DEX item is canceled
+	DexSoldOut               Code = 3044 // DEX item is traded
+	DexBuy                   Code = 3054 // DEX item is the buy item
+	DexUnknown               Code = 3064 // DEX item status is unknown
+	AddressNotFound          Code = 3090 // wallet not exists
+	InvalidPublicKey         Code = 3103 // public key decode error
+	InvalidAddress           Code = 3190 // not a metacoin address
+	SameAddress              Code = 3201 // from and to address are the same
+	SameToken                Code = 3202 // from and to token are the same
+	InvalidItemData          Code = 3209 // item data format error
+	WalletData               Code = 3290 // wallet data is broken
+	TokenNotFound            Code = 4001 // MRC010 token not exists
+	TokenIDMissing           Code = 4002 // token id is empty
+	OwnItem                  Code = 4100 // trade of own item
+	InvalidPriceFormat       Code = 4101 // price is not numeric
+	InvalidQttFormat         Code = 4102 // quantity is not numeric
+	InvalidItem              Code = 4103 // invalid item data
+	InvalidTokenSN           Code = 4104 // invalid token SN
+	InvalidTokenData         Code = 4105 // invalid token data format
+	InvalidFormat            Code = 4200 // invalid data format
+	ItemNotFound             Code = 4201 // MRC011, MRC030, MRC410 item not exists
+	InvalidIDLength          Code = 4202 // id length error
+	ItemCanceled             Code = 4203 // item is canceled
+	InvalidItemFormat        Code = 4204 // invalid id or item data format
+	TargetTokenExists        Code = 4205 // token is in the target token list
+	NotItemOwner             Code = 4206 // item of the other owner
+	ExchangeNotFound         Code = 4207 // exchange item not exists
+	InvalidExchange          Code = 4208 // invalid exchange item data
+	InvalidUserList          Code = 4209 // invalid user list data
+	TokenFrozen              Code = 4300 // MRC010 token is paused or the address is frozen
+	NoDataChange             Code = 4900 // update without change
+	VoteStatus               Code = 4922 // vote status does not allow the job
+	NotEnoughBalance         Code = 5000 // not enough balance
+	FromBalance              Code = 5001 // from address balance is insufficient
+	ToBalance                Code = 5002 // to address balance is insufficient
+	NotEnoughAllow           Code = 5010 // not enough allowance of the spender
+	PendingBalance           Code = 5100 // pending balance error
+	ExchangeResultExists     Code = 6000 // exchange result already exists
+	ExchangeItemNotFound     Code = 6002 // exchange item not exists
+	MRC020Exists             Code = 6003 // MRC020 already exists
+	NotFound                 Code = 6004 // data not exists
+	MRC040NotFound           Code = 6005 // MRC040 not exists
+	MRC100Exists             Code = 6013 // MRC100 already exists
+	NoPermission             Code = 6030 // caller has no permission
+	TokenNotLoggable         Code = 6032 // token can not log
+	Expired                  Code = 6040 // pending request is expired
+	AlreadyExists            Code = 6100 // data already exists
+	InvalidDataAddress       Code = 6102 // invalid data address or id
+	InvalidMRC040Address     Code = 6103 // invalid MRC040 data address
+	InvalidMTC020Data        Code = 6204 // invalid MTC020 data format
+	WrongData                Code = 6205 // stored data is broken
+	WrongMRC040Data          Code = 6206 // stored MRC040 data is broken
+	AlreadyCompleted         Code = 6300 // item is already completed
+	AlreadyCanceled          Code = 6301 // item is already canceled
+	ExchangeBaseNotAllowed   Code = 6501 // base token can not exchange to the target token
+	ExchangeTargetNotAllowed Code = 6502 // target token can not exchange to the base token
+	InvalidExchangeSide      Code = 6600 // invalid exchange side
+	LedgerRead               Code = 8100 // GetState error
+	LedgerReadData           Code = 8110 // GetState error of the item data
+	LedgerWrite              Code = 8600 // PutState error
+	SignCheckLength          Code = 9001 // sign check data length error
+	SignCheckChars           Code = 9002 // sign check data has invalid characters
+	NotNumber                Code = 9900 // not a number
+	Unknown                  Code = 9999 // error without code
+)
+
+// Error - code error, for errors.Is(err, mcerr.NotEnoughBalance)
+func (c Code) Error() string {
+	return fmt.Sprintf("%04d", int(c))
+}
+
+// Error : metacoin error
+type Error struct {
+	Code    Code        `json:"code"`
+	Message string      `json:"message"`
+	Field   string      `json:"field"`
+	Details interface{} `json:"details"`
+	Err     error       `json:"-"`
+}
+
+// New - make error
+func New(code Code, message string) *Error {
+	return &Error{Code: code, Message: message}
+}
+
+// Newf - make error with format
+func Newf(code Code, format string, a ...interface{}) *Error {
+	return &Error{Code: code, Message: fmt.Sprintf(format, a...)}
+}
+
+// Wrap - make error from the cause, message is "message - cause"
+func Wrap(code Code, message string, err error) *Error {
+	if err == nil {
+		return New(code, message)
+	}
+	return &Error{Code: code, Message: message + " - " + err.Error(), Err: err}
+}
+
+// WithField - set parameter name
+func (e *Error) WithField(field string) *Error {
+	e.Field = field
+	return e
+}
+
+// WithDetails - set details
+func (e *Error) WithDetails(details interface{}) *Error {
+	e.Details = details
+	return e
+}
+
+// Error - legacy format, "NNNN,message"
+func (e *Error) Error() string {
+	return fmt.Sprintf("%04d,%s", int(e.Code), e.Message)
+}
+
+// Unwrap - cause error
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is - same code
+func (e *Error) Is(target error) bool {
+	switch t := target.(type) {
+	case Code:
+		return e.Code == t
+	case *Error:
+		return e.Code == t.Code && (t.Message == "" || t.Message == e.Message)
+	}
+	return false
+}
+
+// Parse - convert any error to *Error
+// legacy "NNNN,message" string errors keep their code.
+func Parse(err error) *Error {
+	var e *Error
+	if err == nil {
+		return nil
+	}
+	if errors.As(err, &e) {
+		return e
+	}
+
+	msg := err.Error()
+	if idx := strings.Index(msg, ","); idx == 4 {
+		if code, cerr := strconv.Atoi(msg[:4]); cerr == nil {
+			return &Error{Code: Code(code), Message: msg[5:], Err: err}
+		}
+	}
+	return &Error{Code: Unknown, Message: msg, Err: err}
+}
+
+// Is - err has the code. (legacy string errors included)
+func Is(err error, code Code) bool {
+	if err == nil {
+		return false
+	}
+	return Parse(err).Code == code
+}
+
+// JSON - {code, message, field, details}
+func JSON(err error) string {
+	e := Parse(err)
+	if e == nil {
+		return ""
+	}
+	data, jerr := json.Marshal(e)
+	if jerr != nil {
+		return fmt.Sprintf(`{"code":%d,"message":%q}`, int(e.Code), e.Message)
+	}
+	return string(data)
+}
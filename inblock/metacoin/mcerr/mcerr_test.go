@@ -0,0 +1,37 @@
+package mcerr
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestError(t *testing.T) {
+	err := New(NotEnoughBalance, "Not enough balance")
+	if err.Error() != "5000,Not enough balance" {
+		t.Fatalf(`Error() = %q, legacy format changed`, err.Error())
+	}
+	if !errors.Is(err, NotEnoughBalance) || errors.Is(err, FromBalance) {
+		t.Fatalf(`errors.Is(%q) wrong result`, err.Error())
+	}
+	wrapped := fmt.Errorf("transfer : %w", err)
+	if !errors.Is(wrapped, NotEnoughBalance) {
+		t.Fatalf(`errors.Is(%q) wrapped error not found`, wrapped.Error())
+	}
+}
+
+func TestParse(t *testing.T) {
+	e := Parse(errors.New("3290,Address [MT] is in the wrong data"))
+	if e.Code != WalletData || e.Message != "Address [MT] is in the wrong data" {
+		t.Fatalf(`Parse() = %d, %q`, e.Code, e.Message)
+	}
+	if !Is(errors.New("5000,Not enough balance"), NotEnoughBalance) {
+		t.Fatalf(`Is() legacy string error not matched`)
+	}
+	if e = Parse(errors.New("no code")); e.Code != Unknown {
+		t.Fatalf(`Parse() = %d, want %d`, e.Code, Unknown)
+	}
+	if s := JSON(New(InvalidData, "bad").WithField("memo")); s != `{"code":3005,"message":"bad","field":"memo","details":null}` {
+		t.Fatalf(`JSON() = %s`, s)
+	}
+}
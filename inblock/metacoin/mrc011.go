@@ -1,14 +1,13 @@
 package metacoin
 
 import (
-	"errors"
 	"strings"
-	"time"
 
 	"encoding/json"
 
 	"github.com/hyperledger/fabric-chaincode-go/shim"
 
+	"inblock/metacoin/mcerr"
 	"inblock/metacoin/mtc"
 	"inblock/metacoin/util"
 )
@@ -52,15 +51,15 @@ func Mrc011set(stub shim.ChaincodeStubInterface, MRC011ID string, tk TMRC011, Jo
 	var dat []byte
 	var err error
 	if len(MRC011ID) != 40 {
-		return errors.New("4202,MRC011 id length is must be 40")
+		return mcerr.New(mcerr.InvalidIDLength, "MRC011 id length is must be 40")
 	}
 
 	if strings.Index(MRC011ID, "MRC011_") != 0 {
-		return errors.New("4204,Invalid ID")
+		return mcerr.New(mcerr.InvalidItemFormat, "Invalid ID")
 	}
 
 	tk.JobType = JobType
-	tk.JobDate = time.Now().Unix()
+	tk.JobDate = GetTxTime(stub)
 	if len(args) > 0 {
 		if dat, err = json.Marshal(args); err == nil {
 			tk.JobArgs = string(dat)
@@ -70,10 +69,10 @@ func Mrc011set(stub shim.ChaincodeStubInterface, MRC011ID string, tk TMRC011, Jo
 	}
 
 	if dat, err = json.Marshal(tk); err != nil {
-		return errors.New("4204,Invalid MRC011 data format")
+		return mcerr.New(mcerr.InvalidItemFormat, "Invalid MRC011 data format")
 	}
 	if err = stub.PutState(MRC011ID, dat); err != nil {
-		return errors.New("8600,Hyperledger internal error - " + err.Error())
+		return mcerr.Wrap(mcerr.LedgerWrite, "Hyperledger internal error", err)
 	}
 	return nil
 }
@@ -85,20 +84,20 @@ func Mrc011get(stub shim.ChaincodeStubInterface, MRC011ID string) (TMRC011, erro
 	var err error
 
 	if len(MRC011ID) != 40 {
-		return tk, errors.New("4202,MRC011 id length is must be 40")
+		return tk, mcerr.New(mcerr.InvalidIDLength, "MRC011 id length is must be 40")
 	}
 	if strings.Index(MRC011ID, "MRC011_") != 0 {
-		return tk, errors.New("4204,Invalid ID")
+		return tk, mcerr.New(mcerr.InvalidItemFormat, "Invalid ID")
 	}
 
 	if data, err = stub.GetState(MRC011ID); err != nil {
-		return tk, errors.New("8100,Hyperledger internal error - " + err.Error())
+		return tk, mcerr.Wrap(mcerr.LedgerRead, "Hyperledger internal error", err)
 	}
 	if data == nil {
-		return tk, errors.New("4201,MRC011 " + MRC011ID + " not exists")
+		return tk, mcerr.New(mcerr.ItemNotFound, "MRC011 "+MRC011ID+" not exists")
 	}
 	if err = json.Unmarshal(data, &tk); err != nil {
-		return tk, errors.New("4204,Invalid MRC011 data format")
+		return tk, mcerr.New(mcerr.InvalidItemFormat, "Invalid MRC011 data format")
 	}
 	return tk, nil
 }
@@ -108,14 +107,14 @@ func SetMRC012(stub shim.ChaincodeStubInterface, MRC012ID string, tk TMRC012, Jo
 	var dat []byte
 	var err error
 	if len(MRC012ID) != 40 {
-		return errors.New("4202,MRC012 id length is must be 40")
+		return mcerr.New(mcerr.InvalidIDLength, "MRC012 id length is must be 40")
 	}
 	if strings.Index(MRC012ID, "MRC012_") != 0 {
-		return errors.New("4204,Invalid ID")
+		return mcerr.New(mcerr.InvalidItemFormat, "Invalid ID")
 	}
 
 	tk.JobType = JobType
-	tk.JobDate = time.Now().Unix()
+	tk.JobDate = GetTxTime(stub)
 	if len(args) > 0 {
 		if dat, err = json.Marshal(args); err == nil {
 			tk.JobArgs = string(dat)
@@ -125,10 +124,10 @@ func SetMRC012(stub shim.ChaincodeStubInterface, MRC012ID string, tk TMRC012, Jo
 	}
 
 	if dat, err = json.Marshal(tk); err != nil {
-		return errors.New("4204,Invalid MRC012 data format")
+		return mcerr.New(mcerr.InvalidItemFormat, "Invalid MRC012 data format")
 	}
 	if err = stub.PutState(MRC012ID, dat); err != nil {
-		return errors.New("8600,Hyperledger internal error - " + err.Error())
+		return mcerr.Wrap(mcerr.LedgerWrite, "Hyperledger internal error", err)
 	}
 	return nil
 }
@@ -148,10 +147,10 @@ func MRC011Create(stub shim.ChaincodeStubInterface, mrc011id, creator, name, tot
 	mrc011.Creator = creator
 	mrc011.Name = name
 	if i, err = util.Strtoint(totalsupply); err != nil {
-		return errors.New("1003,The totalsupply must be integer")
+		return mcerr.New(mcerr.InvalidValue, "The totalsupply must be integer")
 	}
 	if i < 1 {
-		return errors.New("1003,The totalsupply must be bigger then 0")
+		return mcerr.New(mcerr.InvalidValue, "The totalsupply must be bigger then 0")
 	}
 	mrc011.TotalSupply = i
 	mrc011.UsedCount = 0
@@ -163,26 +162,26 @@ func MRC011Create(stub shim.ChaincodeStubInterface, mrc011id, creator, name, tot
 
 	if validitytype == "term" {
 		if j, err = util.Strtoint64(startdate); err != nil {
-			return errors.New("1003,The Start_date must be integer")
+			return mcerr.New(mcerr.InvalidValue, "The Start_date must be integer")
 		}
 		mrc011.StartDate = j
 		if j, err = util.Strtoint64(enddate); err != nil {
-			return errors.New("1003,The end_date must be integer")
+			return mcerr.New(mcerr.InvalidValue, "The end_date must be integer")
 		}
-		if j < time.Now().Unix() {
-			return errors.New("1403,The end_date must be bigger then current timestamp")
+		if j < GetTxTime(stub) {
+			return mcerr.New(mcerr.InvalidEndDate, "The end_date must be bigger then current timestamp")
 		}
 		mrc011.EndDate = j
 	} else if validitytype == "duration" {
 		if i, err = util.Strtoint(term); err != nil {
-			return errors.New("1003,The Term must be integer")
+			return mcerr.New(mcerr.InvalidValue, "The Term must be integer")
 		}
 		if i < 1 {
-			return errors.New("1003,The term must be bigger then 0")
+			return mcerr.New(mcerr.InvalidValue, "The term must be bigger then 0")
 		}
 		mrc011.Term = i
 	} else {
-		return errors.New("1003,The Validity_type must be term or duration")
+		return mcerr.New(mcerr.InvalidValue, "The Validity_type must be term or duration")
 	}
 
 	if istransfer == "0" || istransfer == "" {
@@ -191,8 +190,8 @@ func MRC011Create(stub shim.ChaincodeStubInterface, mrc011id, creator, name, tot
 		mrc011.IsTransfer = 1
 	}
 
-	if err = NonceCheck(&creatorData, tkey,
-		strings.Join([]string{creator, name, totalsupply, validitytype, istransfer, code, data, tkey}, "|"), signature); err != nil {
+	if err = NonceCheck(stub, &creatorData, tkey, "mrc011create",
+		[]string{creator, name, totalsupply, validitytype, istransfer, code, data, tkey}, signature); err != nil {
 		return err
 	}
 
@@ -2,12 +2,11 @@ package metacoin
 
 import (
 	"encoding/json"
-	"errors"
 	"strings"
-	"time"
 
 	"github.com/hyperledger/fabric-chaincode-go/shim"
 
+	"inblock/metacoin/mcerr"
 	"inblock/metacoin/mtc"
 	"inblock/metacoin/util"
 )
@@ -41,7 +40,7 @@ func Mrc020set(stub shim.ChaincodeStubInterface, owner, algorithm, data, publick
 	var mwOwner mtc.TWallet
 
 	if currNo64, err = util.Strtoint64(opendate); err != nil {
-		return "", errors.New("1102,Invalid opendate")
+		return "", mcerr.New(mcerr.NonceError, "Invalid opendate")
 	}
 	mrc020.OpenDate = currNo64
 	mrc020.Owner = owner
@@ -50,24 +49,24 @@ func Mrc020set(stub shim.ChaincodeStubInterface, owner, algorithm, data, publick
 	mrc020.PublicKey = publickey
 	mrc020.ReferenceKey = referencekey
 	mrc020.IsOpen = 0
-	mrc020.CreateDate = time.Now().Unix()
+	mrc020.CreateDate = GetTxTime(stub)
 
 	mrc020Key = "MRC020_" + owner + "_" + referencekey
 	dat, err = stub.GetState(mrc020Key)
 	if err == nil && dat != nil {
-		return "", errors.New("6003,MRC020 already exists")
+		return "", mcerr.New(mcerr.MRC020Exists, "MRC020 already exists")
 	}
 
 	if dat, err = json.Marshal(mrc020); err != nil {
-		return "", errors.New("6204,Invalid MTC020 Data format")
+		return "", mcerr.New(mcerr.InvalidMTC020Data, "Invalid MTC020 Data format")
 	}
 
 	if mwOwner, err = GetAddressInfo(stub, owner); err != nil {
 		return "", err
 	}
 
-	if err = NonceCheck(&mwOwner, tkey,
-		strings.Join([]string{owner, data, opendate, referencekey, tkey}, "|"),
+	if err = NonceCheck(stub, &mwOwner, tkey, "mrc020",
+		[]string{owner, data, opendate, referencekey, tkey},
 		signature); err != nil {
 		return "", err
 	}
@@ -85,21 +84,21 @@ func Mrc020get(stub shim.ChaincodeStubInterface, mrc020Key string) (string, erro
 	var mrc020 TMRC020
 
 	if strings.Index(mrc020Key, "MRC020_") != 0 {
-		return "", errors.New("6102,invalid MRC020 data address")
+		return "", mcerr.New(mcerr.InvalidDataAddress, "invalid MRC020 data address")
 	}
 
 	dat, err = stub.GetState(mrc020Key)
 	if err != nil {
-		return "", errors.New("8100,Hyperledger internal error - " + err.Error())
+		return "", mcerr.Wrap(mcerr.LedgerRead, "Hyperledger internal error", err)
 	}
 	if dat == nil {
-		return "", errors.New("6004,MRC020 data not exist")
+		return "", mcerr.New(mcerr.NotFound, "MRC020 data not exist")
 	}
 	if err = json.Unmarshal(dat, &mrc020); err != nil {
-		return "", errors.New("6205,MRC020 [" + mrc020Key + "] is in the wrong data")
+		return "", mcerr.New(mcerr.WrongData, "MRC020 ["+mrc020Key+"] is in the wrong data")
 	}
 
-	if mrc020.OpenDate > time.Now().Unix() {
+	if mrc020.OpenDate > GetTxTime(stub) {
 		mrc020.PublicKey = ""
 	} else if mrc020.IsOpen == 0 {
 		mrc020.IsOpen = 1
@@ -1,18 +1,16 @@
 package metacoin
 
 import (
-	"crypto/rand"
-	"errors"
-	"math/big"
+	"sort"
 	"strconv"
 	"strings"
-	"time"
 
 	"encoding/json"
 
 	"github.com/hyperledger/fabric-chaincode-go/shim"
 	"github.com/shopspring/decimal"
 
+	"inblock/metacoin/mcerr"
 	"inblock/metacoin/mtc"
 	"inblock/metacoin/util"
 )
@@ -36,6 +34,9 @@ type TMRC030 struct {
 	Voter              map[string]int        `json:"voter"`
 	IsFinish           int                   `json:"is_finish"`
 	IsNeedSign         int                   `json:"is_need_sign"`
+	RandomSeed         string                `json:"random_seed,omitempty"` // 추첨 seed, sha256(txid|state_hash)
+	StateHash          string                `json:"state_hash,omitempty"`  // 추첨 직전 vote 데이터의 sha256
+	Winners            []string              `json:"winners,omitempty"`     // 당첨자 목록 (추첨 순서)
 	JobType            string                `json:"job_type"`
 	JobArgs            string                `json:"job_args"`
 	JobDate            int64                 `json:"jobdate"`
@@ -96,10 +97,10 @@ func MRC030Create(stub shim.ChaincodeStubInterface, mrc030id, Creator, Title, De
 	var q [20]TMRC030Question
 
 	if data, err = stub.GetState(mrc030id); err != nil {
-		return errors.New("8100,Hyperledger internal error - " + err.Error())
+		return mcerr.Wrap(mcerr.LedgerRead, "Hyperledger internal error", err)
 	}
 	if data != nil {
-		return errors.New("6100,MRC030 [" + mrc030id + "] is already exists")
+		return mcerr.New(mcerr.AlreadyExists, "MRC030 ["+mrc030id+"] is already exists")
 	}
 
 	if CreatorData, err = GetAddressInfo(stub, Creator); err != nil {
@@ -107,45 +108,45 @@ func MRC030Create(stub shim.ChaincodeStubInterface, mrc030id, Creator, Title, De
 	}
 
 	if decMaxRewardRecipient, err = util.ParsePositive(MaxRewardRecipient); err != nil {
-		return errors.New("1101,MaxRewardRecipient must be an integer string")
+		return mcerr.New(mcerr.NotInteger, "MaxRewardRecipient must be an integer string")
 	}
 	if iMaxRewardRecipient, err = strconv.Atoi(MaxRewardRecipient); err != nil {
-		return errors.New("1101,MaxRewardRecipient must be an integer string")
+		return mcerr.New(mcerr.NotInteger, "MaxRewardRecipient must be an integer string")
 	}
 
 	if iRewardType, err = strconv.Atoi(RewardType); err != nil {
-		return errors.New("1101,RewardType must be an integer string")
+		return mcerr.New(mcerr.NotInteger, "RewardType must be an integer string")
 	}
 	if iRewardType != 10 && iRewardType != 20 {
-		return errors.New("1101,RewardType must be an 10 or 20")
+		return mcerr.New(mcerr.NotInteger, "RewardType must be an 10 or 20")
 	}
 
 	if decReward, err = util.ParseNotNegative(Reward); err != nil {
-		return errors.New("1101,Reward must be an integer string")
+		return mcerr.New(mcerr.NotInteger, "Reward must be an integer string")
 	}
 
 	if iRewardType == 20 {
 		if iMaxRewardRecipient > 100 {
-			return errors.New("1101,The maximum reward recipient is 100")
+			return mcerr.New(mcerr.NotInteger, "The maximum reward recipient is 100")
 		}
 		if decReward.IsZero() {
-			return errors.New("1101, If the rewardtype is 20, the reward must be greater than 0")
+			return mcerr.New(mcerr.NotInteger, "If the rewardtype is 20, the reward must be greater than 0")
 		}
 	}
 
 	if iStartDate, err = strconv.ParseInt(StartDate, 10, 64); err != nil {
-		return errors.New("1101,StartDate must be an integer string")
+		return mcerr.New(mcerr.NotInteger, "StartDate must be an integer string")
 	}
 	if iEndDate, err = strconv.ParseInt(EndDate, 10, 64); err != nil {
-		return errors.New("1101,EndDate must be an integer string")
+		return mcerr.New(mcerr.NotInteger, "EndDate must be an integer string")
 	}
 
-	nowTime := time.Now().Unix()
+	nowTime := GetTxTime(stub)
 	if iEndDate < nowTime {
-		return errors.New("1101,The EndDate must be greater then now")
+		return mcerr.New(mcerr.NotInteger, "The EndDate must be greater then now")
 	}
 	if iEndDate < iStartDate {
-		return errors.New("1101,The EndDate must be greater then StartDate")
+		return mcerr.New(mcerr.NotInteger, "The EndDate must be greater then StartDate")
 	}
 
 	if _, iRewardToken, err = GetMRC010(stub, RewardToken); err != nil {
@@ -167,7 +168,7 @@ func MRC030Create(stub shim.ChaincodeStubInterface, mrc030id, Creator, Title, De
 	}
 
 	if err = json.Unmarshal([]byte(Question), &q); err != nil {
-		return errors.New("3290,Question is in the wrong data")
+		return mcerr.New(mcerr.WalletData, "Question is in the wrong data")
 	}
 
 	vote.QuestionCount = 0
@@ -210,11 +211,11 @@ func MRC030Create(stub shim.ChaincodeStubInterface, mrc030id, Creator, Title, De
 	}
 
 	if vote.QuestionCount == 0 {
-		return errors.New("3290,Question is empty")
+		return mcerr.New(mcerr.WalletData, "Question is empty")
 	}
 
-	if err = NonceCheck(&CreatorData, tkey,
-		strings.Join([]string{Creator, Title, Reward, RewardToken, MaxRewardRecipient, RewardType, tkey}, "|"),
+	if err = NonceCheck(stub, &CreatorData, tkey, "mrc030create",
+		[]string{Creator, Title, Reward, RewardToken, MaxRewardRecipient, RewardType, tkey},
 		signature); err != nil {
 		return err
 	}
@@ -246,6 +247,8 @@ func MRC030Create(stub shim.ChaincodeStubInterface, mrc030id, Creator, Title, De
 }
 
 // MRC030Join  Vote join
+// The voter and, when the vote needs it, the vote creator sign the canonical
+// payload of "voter|mrc030id".
 func MRC030Join(stub shim.ChaincodeStubInterface, mrc030id, Voter, Answer, voteCreatorSign, signature string, args []string) error {
 	var err error
 	var voterData, voteCreatorData mtc.TWallet
@@ -256,31 +259,31 @@ func MRC030Join(stub shim.ChaincodeStubInterface, mrc030id, Voter, Answer, voteC
 	var AnswerTemp [20]TMRC031Answer
 	var currentAnswer int
 
-	nowTime := time.Now().Unix()
+	nowTime := GetTxTime(stub)
 
 	if vote, err = Mrc030get(stub, mrc030id); err != nil {
 		return err
 	}
 	if vote.StartDate > nowTime {
-		return errors.New("8100,Voting is not start")
+		return mcerr.New(mcerr.LedgerRead, "Voting is not start")
 	}
 	if vote.EndDate < nowTime {
-		return errors.New("8100,Voting is finish")
+		return mcerr.New(mcerr.LedgerRead, "Voting is finish")
 	}
 	if vote.IsFinish != 0 {
-		return errors.New("4922,This vote has already ended")
+		return mcerr.New(mcerr.VoteStatus, "This vote has already ended")
 	}
 
 	if _, exists := vote.Voter[Voter]; exists {
-		return errors.New("6100,MRC031 [" + mrc030id + "] is already voting")
+		return mcerr.New(mcerr.AlreadyExists, "MRC031 ["+mrc030id+"] is already voting")
 	}
 
 	if vote.Creator == Answer {
-		return errors.New("6100,Vote creators cannot participate")
+		return mcerr.New(mcerr.AlreadyExists, "Vote creators cannot participate")
 	}
 
 	if vote.RewardType == 10 && len(vote.Voter) >= vote.MaxRewardRecipient {
-		return errors.New("3290,No more voting")
+		return mcerr.New(mcerr.WalletData, "No more voting")
 	}
 
 	mrc031key = mrc030id + "_" + Voter
@@ -288,12 +291,12 @@ func MRC030Join(stub shim.ChaincodeStubInterface, mrc030id, Voter, Answer, voteC
 		Regdate: nowTime,
 		Voter:   Voter,
 		JobType: "mrc031",
-		JobDate: time.Now().Unix(),
+		JobDate: GetTxTime(stub),
 		JobArgs: "",
 	}
 
 	if err = json.Unmarshal([]byte(Answer), &AnswerTemp); err != nil {
-		return errors.New("3290,Answer is in the wrong data")
+		return mcerr.New(mcerr.WalletData, "Answer is in the wrong data")
 	}
 
 	currentAnswer = 0
@@ -303,26 +306,24 @@ func MRC030Join(stub shim.ChaincodeStubInterface, mrc030id, Voter, Answer, voteC
 			break
 		}
 		if vote.QuestionInfo[i].AnswerCount > 0 && (a.Answer < 1 || a.Answer > vote.QuestionInfo[i].AnswerCount) {
-			return errors.New("3290,Answer [" + strconv.Itoa(i) + "] step 1 is out of range")
+			return mcerr.New(mcerr.WalletData, "Answer ["+strconv.Itoa(i)+"] step 1 is out of range")
 		}
 		if vote.QuestionInfo[i].SubAnswerCount[a.Answer-1] > 0 && (a.SubAnswer < 1 || a.SubAnswer > vote.QuestionInfo[i].SubAnswerCount[a.Answer-1]) {
-			return errors.New("3290,Answer [" + strconv.Itoa(i) + "] step 2 is out of range")
+			return mcerr.New(mcerr.WalletData, "Answer ["+strconv.Itoa(i)+"] step 2 is out of range")
 		}
 		voting.Answer = append(voting.Answer, a)
 		currentAnswer++
 	}
 
 	if currentAnswer < vote.QuestionCount {
-		return errors.New("3290,There must be [" + strconv.Itoa(vote.QuestionCount) + "] answers.")
+		return mcerr.New(mcerr.WalletData, "There must be ["+strconv.Itoa(vote.QuestionCount)+"] answers.")
 	}
 
 	if voterData, err = GetAddressInfo(stub, Voter); err != nil {
 		return err
 	}
 
-	if err = util.EcdsaSignVerify(voterData.Password,
-		strings.Join([]string{Voter, mrc030id}, "|"),
-		signature); err != nil {
+	if _, err = payloadSignVerify(stub, &voterData, "mrc030join", []string{Voter, mrc030id}, signature); err != nil {
 		return err
 	}
 
@@ -330,9 +331,7 @@ func MRC030Join(stub shim.ChaincodeStubInterface, mrc030id, Voter, Answer, voteC
 		if voteCreatorData, err = GetAddressInfo(stub, vote.Creator); err != nil {
 			return err
 		}
-		if err = util.EcdsaSignVerify(voteCreatorData.Password,
-			strings.Join([]string{Voter, mrc030id}, "|"),
-			voteCreatorSign); err != nil {
+		if _, err = payloadSignVerify(stub, &voteCreatorData, "mrc030join", []string{Voter, mrc030id}, voteCreatorSign); err != nil {
 			return err
 		}
 	}
@@ -356,7 +355,7 @@ func MRC030Join(stub shim.ChaincodeStubInterface, mrc030id, Voter, Answer, voteC
 		voting.JobArgs = string(data)
 	}
 	if data, err = json.Marshal(voting); err != nil {
-		return errors.New("4204,Invalid MRC031 data format")
+		return mcerr.New(mcerr.InvalidItemFormat, "Invalid MRC031 data format")
 	}
 	if err = stub.PutState(mrc031key, data); err != nil {
 		return err
@@ -364,7 +363,7 @@ func MRC030Join(stub shim.ChaincodeStubInterface, mrc030id, Voter, Answer, voteC
 
 	vote.JobType = "mrc030update"
 	if data, err = json.Marshal(vote); err != nil {
-		return errors.New("4204,Invalid MRC031 data format")
+		return mcerr.New(mcerr.InvalidItemFormat, "Invalid MRC031 data format")
 	}
 	if err = stub.PutState(mrc030id, data); err != nil {
 		return err
@@ -376,103 +375,57 @@ func MRC030Join(stub shim.ChaincodeStubInterface, mrc030id, Voter, Answer, voteC
 // MRC030Finish  Vote join
 func MRC030Finish(stub shim.ChaincodeStubInterface, mrc030id string, args []string) error {
 	var err error
-	var voterData, CreatorData mtc.TWallet
+	var CreatorData mtc.TWallet
 	var vote TMRC030
 	var data []byte
-	var JoinerList []string
 	var decRefund decimal.Decimal
-	var i int
 	var key string
+	var rnd *TxRandom
+	var payment []mtc.TDexPaymentInfo
 	if vote, err = Mrc030get(stub, mrc030id); err != nil {
 		return err
 	}
 	if vote.IsFinish != 0 {
-		return errors.New("4922,This vote has already ended")
+		return mcerr.New(mcerr.VoteStatus, "This vote has already ended")
 	}
 
-	nowTime := time.Now().Unix()
+	nowTime := GetTxTime(stub)
 	if vote.RewardType == 20 {
 		if vote.EndDate > nowTime {
-			return errors.New("4922,This is an ongoing vote")
+			return mcerr.New(mcerr.VoteStatus, "This is an ongoing vote")
 		}
 
-		// 추첨
-		JoinerList = make([]string, len(vote.Voter))
-
-		if len(vote.Voter) < vote.MaxRewardRecipient { // 모두 추첨 대상
-
-			for key = range vote.Voter {
-				if voterData, err = GetAddressInfo(stub, key); err != nil {
-					continue
-				}
-				if err = MRC010Add(stub, &voterData, strconv.Itoa(vote.RewardToken), vote.Reward, 0); err != nil {
-					continue
-				}
-				if err = SetAddressInfo(stub, voterData, "mrc030reward", []string{mrc030id, key, vote.Reward, strconv.Itoa(vote.RewardToken), "", "0", "", "", ""}); err != nil {
-					continue
-				}
-				vote.Voter[key] = 1
-			}
-		} else if len(vote.Voter) < (vote.MaxRewardRecipient * 2) { // 받지 못할 사람을 지정
-			for key = range vote.Voter {
-				JoinerList[i] = key
-				i++
-			}
-
-			for vote.MaxRewardRecipient < len(JoinerList) {
-				n, err := rand.Int(rand.Reader, big.NewInt(int64(len(JoinerList))))
-				if err != nil {
-					return err
-				}
-				JoinerList = util.RemoveElement(JoinerList, int(n.Int64()))
-			}
-			for _, key = range JoinerList {
-				if voterData, err = GetAddressInfo(stub, key); err != nil {
-					continue
-				}
-				if err = MRC010Add(stub, &voterData, strconv.Itoa(vote.RewardToken), vote.Reward, 0); err != nil {
-					continue
-				}
-				if err = SetAddressInfo(stub, voterData, "mrc030reward", []string{mrc030id, key, vote.Reward, strconv.Itoa(vote.RewardToken), "", "0", "", "", ""}); err != nil {
-					continue
-				}
-				vote.Voter[key] = 1
-			}
-		} else { // 받을 사람을 지정
-			for key := range vote.Voter {
-				JoinerList[i] = key
-				i++
+		// 추첨 - seed = sha256(txid | sha256(vote state)), 투표자 주소 정렬 후 추첨
+		if data, err = stub.GetState(mrc030id); err != nil {
+			return mcerr.Wrap(mcerr.LedgerRead, "Hyperledger internal error", err)
+		}
+		rnd = NewTxRandom(stub, data)
+		vote.RandomSeed = rnd.Seed
+		vote.StateHash = StateHash(data)
+		vote.Winners = []string{}
+
+		// 추첨 순서대로 보상, 보상 실패는 건너뛰고 다음 순서로
+		for _, key = range mrc030DrawOrder(rnd, mrc030Voters(vote), vote.MaxRewardRecipient) {
+			if len(vote.Winners) >= vote.MaxRewardRecipient {
+				break
 			}
-			i = 0
-			for i < vote.MaxRewardRecipient {
-				n, err := rand.Int(rand.Reader, big.NewInt(int64(len(JoinerList))))
-				if err != nil {
-					return err
-				}
-				key = JoinerList[int(n.Int64())]
-				JoinerList = util.RemoveElement(JoinerList, int(n.Int64()))
-				if voterData, err = GetAddressInfo(stub, key); err != nil {
-					continue
-				}
-				if err = MRC010Add(stub, &voterData, strconv.Itoa(vote.RewardToken), vote.Reward, 0); err != nil {
-					continue
-				}
-				if err = SetAddressInfo(stub, voterData, "mrc030reward",
-					[]string{mrc030id, key, vote.Reward, strconv.Itoa(vote.RewardToken), "", "0", "", "", ""}); err != nil {
-					continue
-				}
-				vote.Voter[key] = 1
-				i++
+			if mrc030Reward(stub, &vote, mrc030id, key) {
+				vote.Winners = append(vote.Winners, key)
 			}
 		}
 	} else {
 		if vote.MaxRewardRecipient > len(vote.Voter) {
 			if vote.EndDate > nowTime {
-				return errors.New("4922,This is an ongoing vote")
+				return mcerr.New(mcerr.VoteStatus, "This is an ongoing vote")
 			}
 		}
 	}
 
+	for _, key = range vote.Winners {
+		payment = append(payment, mtc.TDexPaymentInfo{FromAddr: mrc030id, ToAddr: key,
+			Amount: vote.Reward, TokenID: strconv.Itoa(vote.RewardToken), PayType: "mrc030reward"})
+	}
+
 	if vote.MaxRewardRecipient > len(vote.Voter) {
 		// 미 투표분 환불
 		iReward, _ := strconv.ParseInt(vote.Reward, 10, 64)
@@ -482,6 +435,8 @@ func MRC030Finish(stub shim.ChaincodeStubInterface, mrc030id string, args []stri
 				return err
 			}
 			MRC010Add(stub, &CreatorData, strconv.Itoa(vote.RewardToken), decRefund.String(), 0)
+			payment = append(payment, mtc.TDexPaymentInfo{FromAddr: mrc030id, ToAddr: vote.Creator,
+				Amount: decRefund.String(), TokenID: strconv.Itoa(vote.RewardToken), PayType: "mrc030refund"})
 			SetAddressInfo(stub, CreatorData, "mrc030refund",
 				[]string{mrc030id, vote.Creator, decRefund.String(), strconv.Itoa(vote.RewardToken), "", "0", "", "", ""})
 		}
@@ -489,28 +444,100 @@ func MRC030Finish(stub shim.ChaincodeStubInterface, mrc030id string, args []stri
 	vote.JobType = "mrc030finish"
 	vote.IsFinish = 1
 	if data, err = json.Marshal(vote); err != nil {
-		return errors.New("4204,Invalid MRC031 data format")
+		return mcerr.New(mcerr.InvalidItemFormat, "Invalid MRC031 data format")
 	}
 	if err = stub.PutState(mrc030id, data); err != nil {
 		return err
 	}
+	AddEvent(stub, TEvent{Type: "mrc030_finish", Key: mrc030id, Args: vote.Winners, Payment: payment})
 
 	return nil
 }
 
+// mrc030Voters - voter address list, sorted
+func mrc030Voters(vote TMRC030) []string {
+	var voters = make([]string, 0, len(vote.Voter))
+	for key := range vote.Voter {
+		voters = append(voters, key)
+	}
+	sort.Strings(voters)
+	return voters
+}
+
+// mrc030DrawOrder - reward order of the sorted voters
+//
+// voters < max       : all voters
+// voters < max * 2   : remove the random voter until max voters remain
+// voters >= max * 2  : draw the random voter one by one, all voters are drawn
+// so the next one replaces the winner whose reward failed.
+func mrc030DrawOrder(rnd *TxRandom, voters []string, max int) []string {
+	var order []string
+	var n int
+
+	list := append([]string{}, voters...)
+	if len(list) < max {
+		return list
+	}
+	if len(list) < max*2 {
+		for max < len(list) {
+			list = util.RemoveElement(list, rnd.Intn(len(list)))
+		}
+		return list
+	}
+	order = make([]string, 0, len(list))
+	for len(list) > 0 {
+		n = rnd.Intn(len(list))
+		order = append(order, list[n])
+		list = util.RemoveElement(list, n)
+	}
+	return order
+}
+
+// MRC030DrawReplay - replay the draw of the finished vote from the stored seed
+// The winners of the vote are the reward order without the failed rewards.
+func MRC030DrawReplay(stub shim.ChaincodeStubInterface, mrc030id string) ([]string, error) {
+	vote, err := Mrc030get(stub, mrc030id)
+	if err != nil {
+		return nil, err
+	}
+	if vote.IsFinish == 0 || vote.RandomSeed == "" {
+		return nil, mcerr.New(mcerr.NotFound, "MRC030 ["+mrc030id+"] has no draw")
+	}
+	return mrc030DrawOrder(NewTxRandomFromSeed(vote.RandomSeed), mrc030Voters(vote), vote.MaxRewardRecipient), nil
+}
+
+// mrc030Reward - send reward to the winner. return false if failed.
+func mrc030Reward(stub shim.ChaincodeStubInterface, vote *TMRC030, mrc030id, key string) bool {
+	var voterData mtc.TWallet
+	var err error
+
+	if voterData, err = GetAddressInfo(stub, key); err != nil {
+		return false
+	}
+	if err = MRC010Add(stub, &voterData, strconv.Itoa(vote.RewardToken), vote.Reward, 0); err != nil {
+		return false
+	}
+	if err = SetAddressInfo(stub, voterData, "mrc030reward",
+		[]string{mrc030id, key, vote.Reward, strconv.Itoa(vote.RewardToken), "", "0", "", "", ""}); err != nil {
+		return false
+	}
+	vote.Voter[key] = 1
+	return true
+}
+
 // Mrc030set : save Mrc030set
 func Mrc030set(stub shim.ChaincodeStubInterface, MRC030ID string, tk TMRC030, JobType string, args []string) error {
 	var dat []byte
 	var err error
 	if len(MRC030ID) != 40 {
-		return errors.New("4202,MRC030 id length is must be 40")
+		return mcerr.New(mcerr.InvalidIDLength, "MRC030 id length is must be 40")
 	}
 	if strings.Index(MRC030ID, "MRC030_") != 0 {
-		return errors.New("4204,Invalid ID")
+		return mcerr.New(mcerr.InvalidItemFormat, "Invalid ID")
 	}
 
 	tk.JobType = JobType
-	tk.JobDate = time.Now().Unix()
+	tk.JobDate = GetTxTime(stub)
 	if len(args) > 0 {
 		if dat, err = json.Marshal(args); err == nil {
 			tk.JobArgs = string(dat)
@@ -520,10 +547,10 @@ func Mrc030set(stub shim.ChaincodeStubInterface, MRC030ID string, tk TMRC030, Jo
 	}
 
 	if dat, err = json.Marshal(tk); err != nil {
-		return errors.New("4204,Invalid MRC030 data format")
+		return mcerr.New(mcerr.InvalidItemFormat, "Invalid MRC030 data format")
 	}
 	if err = stub.PutState(MRC030ID, dat); err != nil {
-		return errors.New("8600,Hyperledger internal error - " + err.Error())
+		return mcerr.Wrap(mcerr.LedgerWrite, "Hyperledger internal error", err)
 	}
 	return nil
 }
@@ -535,20 +562,20 @@ func Mrc030get(stub shim.ChaincodeStubInterface, MRC030ID string) (TMRC030, erro
 	var err error
 
 	if len(MRC030ID) != 40 {
-		return tk, errors.New("4202,MRC030 id length is must be 40")
+		return tk, mcerr.New(mcerr.InvalidIDLength, "MRC030 id length is must be 40")
 	}
 	if strings.Index(MRC030ID, "MRC030_") != 0 {
-		return tk, errors.New("4204,Invalid ID")
+		return tk, mcerr.New(mcerr.InvalidItemFormat, "Invalid ID")
 	}
 
 	if data, err = stub.GetState(MRC030ID); err != nil {
-		return tk, errors.New("8100,Hyperledger internal error - " + err.Error())
+		return tk, mcerr.Wrap(mcerr.LedgerRead, "Hyperledger internal error", err)
 	}
 	if data == nil {
-		return tk, errors.New("4201,MRC030 " + MRC030ID + " not exists")
+		return tk, mcerr.New(mcerr.ItemNotFound, "MRC030 "+MRC030ID+" not exists")
 	}
 	if err = json.Unmarshal(data, &tk); err != nil {
-		return tk, errors.New("4204,Invalid MRC030 data format")
+		return tk, mcerr.New(mcerr.InvalidItemFormat, "Invalid MRC030 data format")
 	}
 	return tk, nil
 }
@@ -560,20 +587,20 @@ func Mrc031get(stub shim.ChaincodeStubInterface, MRC031ID string) (TMRC031, erro
 	var err error
 
 	if len(MRC031ID) != 81 {
-		return tk, errors.New("4202,MRC031 id length is must be 81")
+		return tk, mcerr.New(mcerr.InvalidIDLength, "MRC031 id length is must be 81")
 	}
 	if strings.Index(MRC031ID, "MRC030_") != 0 {
-		return tk, errors.New("4204,Invalid ID")
+		return tk, mcerr.New(mcerr.InvalidItemFormat, "Invalid ID")
 	}
 
 	if data, err = stub.GetState(MRC031ID); err != nil {
-		return tk, errors.New("8100,Hyperledger internal error - " + err.Error())
+		return tk, mcerr.Wrap(mcerr.LedgerRead, "Hyperledger internal error", err)
 	}
 	if data == nil {
-		return tk, errors.New("4201,MRC031 " + MRC031ID + " not exists")
+		return tk, mcerr.New(mcerr.ItemNotFound, "MRC031 "+MRC031ID+" not exists")
 	}
 	if err = json.Unmarshal(data, &tk); err != nil {
-		return tk, errors.New("4204,Invalid MRC031 data format")
+		return tk, mcerr.New(mcerr.InvalidItemFormat, "Invalid MRC031 data format")
 	}
 	return tk, nil
 }
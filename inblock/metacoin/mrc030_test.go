@@ -0,0 +1,80 @@
+package metacoin
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"testing"
+)
+
+func TestMRC030Draw(t *testing.T) {
+	s := newTestStub()
+	creator := newGenesisWallet(t, s, "1000")
+	mrc030id := "MRC030_" + fmt.Sprintf("%033d", 1)
+	question := `[{"question":"q1","item":[{"answer":"yes"},{"answer":"no"}]}]`
+	end := strconv.FormatInt(s.txTime+100, 10)
+
+	nonce := creator.nonce(t, s)
+	sig := creator.sign(t, s, "mrc030create", creator.Address, "vote", "10", "0", "2", "20", nonce)
+	s.mustTx(t, func() error {
+		return MRC030Create(s, mrc030id, creator.Address, "vote", "", "0", end, "10", "0", "2", "20", "",
+			question, "0", sig, nonce, nil)
+	})
+
+	var voters = make(map[string]bool)
+	for i := 0; i < 5; i++ {
+		voter := newTestWallet(t, s)
+		if i == 0 {
+			// the legacy "|" joined fields are not accepted from the canonical wallet
+			legacy := voter.signLegacy(t, voter.Address, mrc030id)
+			assertError(t, s.tx(func() error {
+				return MRC030Join(s, mrc030id, voter.Address, `[{"answer":1}]`, "", legacy, nil)
+			}), "2010")
+		}
+		sig := voter.sign(t, s, "mrc030join", voter.Address, mrc030id)
+		s.mustTx(t, func() error {
+			return MRC030Join(s, mrc030id, voter.Address, `[{"answer":1}]`, "", sig, nil)
+		})
+		voters[voter.Address] = true
+	}
+	state := s.state[mrc030id]
+	assertError(t, s.tx(func() error { return MRC030Finish(s, mrc030id, nil) }), "4922")
+
+	s.advance(101)
+	s.mustTx(t, func() error { return MRC030Finish(s, mrc030id, nil) })
+	vote, err := Mrc030get(s, mrc030id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// seed = sha256(txid | sha256(vote state before the draw))
+	h := sha256.Sum256([]byte(fmt.Sprintf("%064x", s.txNo) + "|" + StateHash(state)))
+	if vote.RandomSeed != hex.EncodeToString(h[:]) || vote.StateHash != StateHash(state) {
+		t.Errorf("seed : %s, state hash : %s", vote.RandomSeed, vote.StateHash)
+	}
+	if len(vote.Winners) != 2 || vote.Winners[0] == vote.Winners[1] {
+		t.Fatalf("winners : %v", vote.Winners)
+	}
+	for _, winner := range vote.Winners {
+		if !voters[winner] || vote.Voter[winner] != 1 {
+			t.Errorf("winner %s is not rewarded voter", winner)
+		}
+	}
+
+	// anyone replays the draw from the stored seed
+	order, err := MRC030DrawReplay(s, mrc030id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(order) != 5 || order[0] != vote.Winners[0] || order[1] != vote.Winners[1] {
+		t.Errorf("replay order %v, winners %v", order, vote.Winners)
+	}
+	again := mrc030DrawOrder(NewTxRandomFromSeed(vote.RandomSeed), mrc030Voters(vote), 2)
+	if fmt.Sprint(again) != fmt.Sprint(order) {
+		t.Errorf("draw is not deterministic : %v, %v", again, order)
+	}
+	if ev := s.lastEvent(t, "mrc030_finish"); len(ev.Payment) != 2 {
+		t.Errorf("mrc030_finish payment : %+v", ev.Payment)
+	}
+}
@@ -1,14 +1,13 @@
 package metacoin
 
 import (
-	"errors"
 	"strings"
-	"time"
 
 	"encoding/json"
 
 	"github.com/hyperledger/fabric-chaincode-go/shim"
 
+	"inblock/metacoin/mcerr"
 	"inblock/metacoin/mtc"
 )
 
@@ -49,13 +48,13 @@ func Mrc100Payment(stub shim.ChaincodeStubInterface, to, TokenID, tag, userlist,
 	}
 
 	if err = json.Unmarshal([]byte(userlist), &playerList); err != nil {
-		return errors.New("4209,Invalid UserLIst data")
+		return mcerr.New(mcerr.InvalidUserList, "Invalid UserLIst data")
 	}
 	if len(playerList) < 1 {
-		return errors.New("2007,Playerlist need more than one")
+		return mcerr.New(mcerr.InvalidPlayerList, "Playerlist need more than one")
 	}
 	if len(playerList) > 32 {
-		return errors.New("2007,Playerlist should be less than 32")
+		return mcerr.New(mcerr.InvalidPlayerList, "Playerlist should be less than 32")
 	}
 
 	for _, elements := range playerList {
@@ -63,14 +62,14 @@ func Mrc100Payment(stub shim.ChaincodeStubInterface, to, TokenID, tag, userlist,
 			return err
 		}
 
-		if err = NonceCheck(&playerData, elements.TKey,
-			strings.Join([]string{elements.Address, to, TokenID, elements.Amount, elements.TKey}, "|"),
+		if err = NonceCheck(stub, &playerData, elements.TKey, "mrc100Payment",
+			[]string{elements.Address, to, TokenID, elements.Amount, elements.TKey},
 			elements.Signature); err != nil {
 			return err
 		}
 
 		if elements.Amount == "" {
-			return errors.New("1107,The amount must be an integer")
+			return mcerr.New(mcerr.InvalidSellAmount, "The amount must be an integer")
 		}
 
 		if elements.Amount != "0" {
@@ -103,27 +102,27 @@ func Mrc100Reward(stub shim.ChaincodeStubInterface, from, TokenID, userlist, gam
 	}
 
 	if err = json.Unmarshal([]byte(userlist), &playerList); err != nil {
-		return errors.New("4209,Invalid UserLIst data")
+		return mcerr.New(mcerr.InvalidUserList, "Invalid UserLIst data")
 	}
 	if len(playerList) == 0 {
-		return errors.New("2007,Playerlist need more than one")
+		return mcerr.New(mcerr.InvalidPlayerList, "Playerlist need more than one")
 	}
 	if len(playerList) > 32 {
-		return errors.New("2007,Playerlist should be less than 32")
+		return mcerr.New(mcerr.InvalidPlayerList, "Playerlist should be less than 32")
 	}
 
 	checkList = append(checkList, TokenID)
 
 	for _, elements := range playerList {
 		if elements.Amount == "" {
-			return errors.New("1107,The amount must be an integer")
+			return mcerr.New(mcerr.InvalidSellAmount, "The amount must be an integer")
 		}
 		checkList = append(checkList, elements.Address, elements.Amount, elements.Tag)
 	}
 	checkList = append(checkList, tkey)
 
-	if err = NonceCheck(&ownerData, tkey,
-		strings.Join(checkList, "|"),
+	if err = NonceCheck(stub, &ownerData, tkey, "mrc100Reward",
+		checkList,
 		signature); err != nil {
 		return err
 	}
@@ -164,7 +163,7 @@ func Mrc100Log(stub shim.ChaincodeStubInterface, key, token, logger, log, signat
 
 	if tk.Owner != logger {
 		if _, exists := tk.Logger[logger]; !exists {
-			return "", errors.New("6030,you do not have permission to log this token")
+			return "", mcerr.New(mcerr.NoPermission, "you do not have permission to log this token")
 		}
 	}
 	if ownerData, err = GetAddressInfo(stub, logger); err != nil {
@@ -172,16 +171,16 @@ func Mrc100Log(stub shim.ChaincodeStubInterface, key, token, logger, log, signat
 	}
 
 	if tk.Type != "100" && tk.Type != "101" {
-		return "", errors.New("6032,This token cannot log")
+		return "", mcerr.New(mcerr.TokenNotLoggable, "This token cannot log")
 	}
 
-	if err = NonceCheck(&ownerData, tkey,
-		strings.Join([]string{token, logger, log, tkey}, "|"),
+	if err = NonceCheck(stub, &ownerData, tkey, "mrc100Log",
+		[]string{token, logger, log, tkey},
 		signature); err != nil {
 		return "", err
 	}
 
-	mrcLog = TMRC100Log{Regdate: time.Now().Unix(),
+	mrcLog = TMRC100Log{Regdate: GetTxTime(stub),
 		Token:   tk.Token,
 		Logger:  logger,
 		JobType: "MRC100LOG",
@@ -189,12 +188,12 @@ func Mrc100Log(stub shim.ChaincodeStubInterface, key, token, logger, log, signat
 
 	dat, err = stub.GetState(key)
 	if err == nil && dat != nil {
-		return "", errors.New("6013,MRC100 already exists")
+		return "", mcerr.New(mcerr.MRC100Exists, "MRC100 already exists")
 	}
 
 	dat, _ = json.Marshal(mrcLog)
 	if err := stub.PutState(key, dat); err != nil {
-		return "", errors.New("8600,Hyperledger internal error - " + err.Error())
+		return "", mcerr.Wrap(mcerr.LedgerWrite, "Hyperledger internal error", err)
 	}
 
 	return key, nil
@@ -206,15 +205,15 @@ func Mrc100get(stub shim.ChaincodeStubInterface, mrc100Key string) (string, erro
 	var err error
 
 	if strings.Index(mrc100Key, "MRC100_") != 0 {
-		return "", errors.New("6102,invalid MRC100 data address")
+		return "", mcerr.New(mcerr.InvalidDataAddress, "invalid MRC100 data address")
 	}
 
 	dat, err = stub.GetState(mrc100Key)
 	if err != nil {
-		return "", errors.New("8100,Hyperledger internal error - " + err.Error())
+		return "", mcerr.Wrap(mcerr.LedgerRead, "Hyperledger internal error", err)
 	}
 	if dat == nil {
-		return "", errors.New("6004,MRC100 data not exist")
+		return "", mcerr.New(mcerr.NotFound, "MRC100 data not exist")
 	}
 	return string(dat), nil
 }
@@ -1,16 +1,15 @@
 package metacoin
 
 import (
-	"errors"
 	"fmt"
 	"strings"
-	"time"
 
 	"encoding/json"
 
 	"github.com/hyperledger/fabric-chaincode-go/shim"
 	"github.com/shopspring/decimal"
 
+	"inblock/metacoin/mcerr"
 	"inblock/metacoin/mtc"
 	"inblock/metacoin/util"
 )
@@ -110,51 +109,51 @@ func Mrc400Create(stub shim.ChaincodeStubInterface, owner, name, url, imageurl,
 	var argdat []byte
 
 	MRC400ProjectData = TMRC400{
-		CreateDate: time.Now().Unix(),
+		CreateDate: GetTxTime(stub),
 	}
 
 	if err = util.DataAssign(owner, &MRC400ProjectData.Owner, "address", 40, 40, false); err != nil {
-		return errors.New("3005,Data must be 1 to 4096 characters long")
+		return mcerr.New(mcerr.InvalidData, "Data must be 1 to 4096 characters long")
 	}
 	if err = util.DataAssign(name, &MRC400ProjectData.Name, "string", 1, 128, false); err != nil {
-		return errors.New("3005,Name must be 1 to 128 characters long")
+		return mcerr.New(mcerr.InvalidData, "Name must be 1 to 128 characters long")
 	}
 	if err = util.DataAssign(url, &MRC400ProjectData.URL, "string", 1, 1024, false); err != nil {
-		return errors.New("3005,Url must be 1 to 1024 characters long URL")
+		return mcerr.New(mcerr.InvalidData, "Url must be 1 to 1024 characters long URL")
 	}
 	if err = util.DataAssign(imageurl, &MRC400ProjectData.ImageURL, "url", 1, 256, false); err != nil {
-		return errors.New("3005,ImageURL must be 1 to 1024 characters long URL")
+		return mcerr.New(mcerr.InvalidData, "ImageURL must be 1 to 1024 characters long URL")
 	}
 	if err = util.DataAssign(category, &MRC400ProjectData.Category, "string", 1, 64, false); err != nil {
-		return errors.New("3005,Category must be 1 to 64 characters long")
+		return mcerr.New(mcerr.InvalidData, "Category must be 1 to 64 characters long")
 	}
 	if err = util.DataAssign(description, &MRC400ProjectData.Description, "string", 1, 4096, false); err != nil {
-		return errors.New("3005,Description must be 1 to 4096 characters long")
+		return mcerr.New(mcerr.InvalidData, "Description must be 1 to 4096 characters long")
 	}
 	if err = util.DataAssign(itemurl, &MRC400ProjectData.ItemURL, "url", 1, 256, false); err != nil {
-		return errors.New("3005,ItemURL must be 1 to 1024 characters long URL")
+		return mcerr.New(mcerr.InvalidData, "ItemURL must be 1 to 1024 characters long URL")
 	}
 	if err = util.DataAssign(itemimageurl, &MRC400ProjectData.ItemImageURL, "url", 1, 256, false); err != nil {
-		return errors.New("3005,ItemImageURL must be 1 to 1024 characters long URL")
+		return mcerr.New(mcerr.InvalidData, "ItemImageURL must be 1 to 1024 characters long URL")
 	}
 	if err = util.DataAssign(allowtoken, &MRC400ProjectData.AllowToken, "string", 1, 128, false); err != nil {
-		return errors.New("3005,AllowToken must be 1 to 128 characters long")
+		return mcerr.New(mcerr.InvalidData, "AllowToken must be 1 to 128 characters long")
 	}
 	if err = util.DataAssign(data, &MRC400ProjectData.Data, "string", 0, 4096, false); err != nil {
-		return errors.New("3005,Data must be 0 to 4096 characters long")
+		return mcerr.New(mcerr.InvalidData, "Data must be 0 to 4096 characters long")
 	}
 
 	// allow token error
 	if _, _, err = GetMRC010(stub, allowtoken); err != nil {
-		return errors.New("3005,Token id " + allowtoken + " error : " + err.Error())
+		return mcerr.New(mcerr.InvalidData, "Token id "+allowtoken+" error : "+err.Error())
 	}
 
 	if ownerWallet, err = GetAddressInfo(stub, owner); err != nil {
 		return err
 	}
 
-	if err = NonceCheck(&ownerWallet, tkey,
-		strings.Join([]string{owner, name, url, imageurl, category, itemurl, itemimageurl, data, tkey}, "|"),
+	if err = NonceCheck(stub, &ownerWallet, tkey, "mrc400create",
+		[]string{owner, name, url, imageurl, category, itemurl, itemimageurl, data, tkey},
 		signature); err != nil {
 		return err
 	}
@@ -165,7 +164,7 @@ func Mrc400Create(stub shim.ChaincodeStubInterface, owner, name, url, imageurl,
 		mrc400id = fmt.Sprintf("%39s%1d", temp, i)
 		argdat, err = stub.GetState(mrc400id)
 		if err != nil {
-			return errors.New("8600,Hyperledger internal error - " + err.Error())
+			return mcerr.Wrap(mcerr.LedgerWrite, "Hyperledger internal error", err)
 		}
 
 		if argdat != nil { // key already exists
@@ -177,19 +176,19 @@ func Mrc400Create(stub shim.ChaincodeStubInterface, owner, name, url, imageurl,
 	}
 
 	if !isSuccess {
-		return errors.New("3005,Data generate error, retry again")
+		return mcerr.New(mcerr.InvalidData, "Data generate error, retry again")
 	}
 
 	MRC400ProjectData.JobType = "mrc400_create"
-	MRC400ProjectData.JobDate = time.Now().Unix()
+	MRC400ProjectData.JobDate = GetTxTime(stub)
 	if argdat, err = json.Marshal([]string{mrc400id, owner, name, url, imageurl, allowtoken, category, description, itemurl, itemimageurl, data, signature, tkey}); err == nil {
 		MRC400ProjectData.JobArgs = string(argdat)
 	}
 	if argdat, err = json.Marshal(MRC400ProjectData); err != nil {
-		return errors.New("3209,Invalid mrc400 data format")
+		return mcerr.New(mcerr.InvalidItemData, "Invalid mrc400 data format")
 	}
 	if err := stub.PutState(mrc400id, argdat); err != nil {
-		return errors.New("8600,Hyperledger internal error - " + err.Error() + mrc400id)
+		return mcerr.New(mcerr.LedgerWrite, "Hyperledger internal error - "+err.Error()+mrc400id)
 	}
 
 	if err = SetAddressInfo(stub, ownerWallet, "mrc400create", []string{mrc400id, owner, name, url, imageurl, allowtoken, category, description, itemurl, itemimageurl, data, signature, tkey}); err != nil {
@@ -210,59 +209,59 @@ func Mrc400Update(stub shim.ChaincodeStubInterface, mrc400id, name, url, imageur
 	}
 
 	if err = util.DataAssign(name, &MRC400.Name, "string", 1, 128, true); err != nil {
-		return errors.New("3005,Name must be 1 to 128 characters long")
+		return mcerr.New(mcerr.InvalidData, "Name must be 1 to 128 characters long")
 	}
 	if err = util.DataAssign(url, &MRC400.URL, "url", 1, 255, true); err != nil {
-		return errors.New("3005,Url must be 1 to 1024 characters long URL")
+		return mcerr.New(mcerr.InvalidData, "Url must be 1 to 1024 characters long URL")
 	}
 	if err = util.DataAssign(imageurl, &MRC400.ImageURL, "url", 1, 255, true); err != nil {
-		return errors.New("3005,ImageURL must be 1 to 1024 characters long URL")
+		return mcerr.New(mcerr.InvalidData, "ImageURL must be 1 to 1024 characters long URL")
 	}
 	if err = util.DataAssign(category, &MRC400.Category, "string", 1, 64, true); err != nil {
-		return errors.New("3005,Category must be 1 to 64 characters long")
+		return mcerr.New(mcerr.InvalidData, "Category must be 1 to 64 characters long")
 	}
 	if err = util.DataAssign(description, &MRC400.Description, "string", 1, 4096, true); err != nil {
-		return errors.New("3005,Description must be 1 to 4096 characters long")
+		return mcerr.New(mcerr.InvalidData, "Description must be 1 to 4096 characters long")
 	}
 	if err = util.DataAssign(itemurl, &MRC400.ItemURL, "url", 1, 255, true); err != nil {
-		return errors.New("3005,ItemURL must be 1 to 1024 characters long URL")
+		return mcerr.New(mcerr.InvalidData, "ItemURL must be 1 to 1024 characters long URL")
 	}
 	if err = util.DataAssign(itemimageurl, &MRC400.ItemImageURL, "url", 1, 255, true); err != nil {
-		return errors.New("3005,ItemImageURL must be 1 to 1024 characters long URL")
+		return mcerr.New(mcerr.InvalidData, "ItemImageURL must be 1 to 1024 characters long URL")
 	}
 	if err = util.DataAssign(allowtoken, &MRC400.AllowToken, "string", 1, 128, false); err != nil {
-		return errors.New("3005,AllowToken must be 1 to 128 characters long")
+		return mcerr.New(mcerr.InvalidData, "AllowToken must be 1 to 128 characters long")
 	}
 	if err = util.DataAssign(data, &MRC400.Data, "string", 1, 4096, true); err != nil {
-		return errors.New("3005,Data must be 1 to 4096 characters long")
+		return mcerr.New(mcerr.InvalidData, "Data must be 1 to 4096 characters long")
 	}
 
 	// allow token error
 	if _, _, err = GetMRC010(stub, allowtoken); err != nil {
-		return errors.New("3005,Token id " + allowtoken + " error : " + err.Error())
+		return mcerr.New(mcerr.InvalidData, "Token id "+allowtoken+" error : "+err.Error())
 	}
 
 	if ownerWallet, err = GetAddressInfo(stub, MRC400.Owner); err != nil {
 		return err
 	}
 
-	if err = NonceCheck(&ownerWallet, tkey,
-		strings.Join([]string{mrc400id, name, url, imageurl, category, description, itemurl, itemimageurl, data, tkey}, "|"),
+	if err = NonceCheck(stub, &ownerWallet, tkey, "mrc400update",
+		[]string{mrc400id, name, url, imageurl, category, description, itemurl, itemimageurl, data, tkey},
 		signature); err != nil {
 		return err
 	}
 
 	MRC400.JobType = "mrc400_update"
-	MRC400.JobDate = time.Now().Unix()
+	MRC400.JobDate = GetTxTime(stub)
 	if argdat, err = json.Marshal([]string{mrc400id, MRC400.Owner, name, url, imageurl, allowtoken, category, description, itemurl, itemimageurl, data, signature, tkey}); err == nil {
 		MRC400.JobArgs = string(argdat)
 	}
 
 	if argdat, err = json.Marshal(MRC400); err != nil {
-		return errors.New("3209,Invalid address data format")
+		return mcerr.New(mcerr.InvalidItemData, "Invalid address data format")
 	}
 	if err := stub.PutState(mrc400id, argdat); err != nil {
-		return errors.New("8600,Hyperledger internal error - " + err.Error() + mrc400id)
+		return mcerr.New(mcerr.LedgerWrite, "Hyperledger internal error - "+err.Error()+mrc400id)
 	}
 
 	if err = SetAddressInfo(stub, ownerWallet, "mrc400update", []string{mrc400id, MRC400.Owner, name, url, imageurl, allowtoken, category, description, itemurl, itemimageurl, data, signature, tkey}); err != nil {
@@ -278,18 +277,18 @@ func GetMRC400(stub shim.ChaincodeStubInterface, mrc400id string) (TMRC400, []by
 	var mrc400 TMRC400
 
 	if strings.Index(mrc400id, "MRC400_") != 0 || len(mrc400id) != 40 {
-		return mrc400, dat, errors.New("6102,invalid MRC400 data address")
+		return mrc400, dat, mcerr.New(mcerr.InvalidDataAddress, "invalid MRC400 data address")
 	}
 
 	dat, err = stub.GetState(mrc400id)
 	if err != nil {
-		return mrc400, dat, errors.New("8110,Hyperledger internal error - " + err.Error())
+		return mrc400, dat, mcerr.Wrap(mcerr.LedgerReadData, "Hyperledger internal error", err)
 	}
 	if dat == nil {
-		return mrc400, dat, errors.New("6004,MRC400 [" + mrc400id + "] not exist")
+		return mrc400, dat, mcerr.New(mcerr.NotFound, "MRC400 ["+mrc400id+"] not exist")
 	}
 	if err = json.Unmarshal(dat, &mrc400); err != nil {
-		return mrc400, dat, errors.New("3004,MRC400 [" + mrc400id + "] is in the wrong data")
+		return mrc400, dat, mcerr.New(mcerr.DexStatus, "MRC400 ["+mrc400id+"] is in the wrong data")
 	}
 	if mrc400.Id == "" {
 		mrc400.Id = mrc400id
@@ -302,21 +301,21 @@ func setMRC400(stub shim.ChaincodeStubInterface, MRC400 TMRC400, jobType string,
 	var argdat []byte
 
 	if strings.Index(MRC400.Id, "MRC400_") != 0 || len(MRC400.Id) != 40 {
-		return errors.New("6102,invalid MRC401 data address")
+		return mcerr.New(mcerr.InvalidDataAddress, "invalid MRC401 data address")
 	}
 
 	MRC400.JobType = jobType
-	MRC400.JobDate = time.Now().Unix()
+	MRC400.JobDate = GetTxTime(stub)
 	if argdat, err = json.Marshal(jobArgs); err == nil {
 		MRC400.JobArgs = string(argdat)
 	}
 
 	if argdat, err = json.Marshal(MRC400); err != nil {
-		return errors.New("3209,Invalid MRC401ItemData data format")
+		return mcerr.New(mcerr.InvalidItemData, "Invalid MRC401ItemData data format")
 	}
 
 	if err := stub.PutState(MRC400.Id, argdat); err != nil {
-		return errors.New("8600,Mrc401Create stub.PutState [" + MRC400.Id + "] Error " + err.Error())
+		return mcerr.New(mcerr.LedgerWrite, "Mrc401Create stub.PutState ["+MRC400.Id+"] Error "+err.Error())
 	}
 	return nil
 }
@@ -328,18 +327,18 @@ func GetMRC401(stub shim.ChaincodeStubInterface, mrc401id string) (TMRC401, []by
 	var mrc401 TMRC401
 
 	if strings.Index(mrc401id, "MRC400_") != 0 || len(mrc401id) != 81 {
-		return mrc401, nil, errors.New("6102,invalid MRC401 data address")
+		return mrc401, nil, mcerr.New(mcerr.InvalidDataAddress, "invalid MRC401 data address")
 	}
 
 	dat, err = stub.GetState(mrc401id)
 	if err != nil {
-		return mrc401, nil, errors.New("8110,Hyperledger internal error - " + err.Error())
+		return mrc401, nil, mcerr.Wrap(mcerr.LedgerReadData, "Hyperledger internal error", err)
 	}
 	if dat == nil {
-		return mrc401, nil, errors.New("6004,MRC401 [" + mrc401id + "] not exist")
+		return mrc401, nil, mcerr.New(mcerr.NotFound, "MRC401 ["+mrc401id+"] not exist")
 	}
 	if err = json.Unmarshal(dat, &mrc401); err != nil {
-		return mrc401, nil, errors.New("3004,MRC401 [" + mrc401id + "] is in the wrong data")
+		return mrc401, nil, mcerr.New(mcerr.DexStatus, "MRC401 ["+mrc401id+"] is in the wrong data")
 	}
 	if mrc401.Id == "" {
 		mrc401.Id = mrc401id
@@ -347,27 +346,43 @@ func GetMRC401(stub shim.ChaincodeStubInterface, mrc401id string) (TMRC401, []by
 	return mrc401, dat, nil
 }
 
-func setMRC401(stub shim.ChaincodeStubInterface, mrc401id string, MRC401 TMRC401, jobType string, jobArgs []string) error {
+func setMRC401(stub shim.ChaincodeStubInterface, mrc401id string, MRC401 TMRC401, jobType string, jobArgs []string, payment ...mtc.TDexPaymentInfo) error {
 	var err error
 	var argdat []byte
 
 	if strings.Index(mrc401id, "MRC400_") != 0 || len(mrc401id) != 81 {
-		return errors.New("6102,invalid MRC401 data address")
+		return mcerr.New(mcerr.InvalidDataAddress, "invalid MRC401 data address")
+	}
+
+	// previous owner, for the owner index
+	var prev TMRC401
+	if argdat, err = stub.GetState(mrc401id); err != nil {
+		return mcerr.Wrap(mcerr.LedgerRead, "Hyperledger internal error", err)
+	}
+	isNew := argdat == nil
+	if !isNew {
+		if err = json.Unmarshal(argdat, &prev); err != nil {
+			return mcerr.New(mcerr.DexStatus, "MRC401 ["+mrc401id+"] is in the wrong data")
+		}
 	}
 
 	MRC401.JobType = jobType
-	MRC401.JobDate = time.Now().Unix()
+	MRC401.JobDate = GetTxTime(stub)
 	if argdat, err = json.Marshal(jobArgs); err == nil {
 		MRC401.JobArgs = string(argdat)
 	}
 
 	if argdat, err = json.Marshal(MRC401); err != nil {
-		return errors.New("3209,Invalid MRC401ItemData data format")
+		return mcerr.New(mcerr.InvalidItemData, "Invalid MRC401ItemData data format")
 	}
 
 	if err := stub.PutState(mrc401id, argdat); err != nil {
-		return errors.New("8600,Mrc401Create stub.PutState [" + mrc401id + "] Error " + err.Error())
+		return mcerr.New(mcerr.LedgerWrite, "Mrc401Create stub.PutState ["+mrc401id+"] Error "+err.Error())
+	}
+	if err = indexMRC401(stub, mrc401id, MRC401, prev.Owner, isNew); err != nil {
+		return err
 	}
+	AddEvent(stub, TEvent{Type: jobType, Key: mrc401id, Args: jobArgs, Payment: payment})
 	return nil
 }
 
@@ -395,40 +410,40 @@ func Mrc401Create(stub shim.ChaincodeStubInterface, mrc400id, itemData, signatur
 		return err
 	}
 	// sign check
-	if err = NonceCheck(&projectOwnerWallet, tkey,
-		strings.Join([]string{mrc400id, itemData, tkey}, "|"),
+	if err = NonceCheck(stub, &projectOwnerWallet, tkey, "mrc401create",
+		[]string{mrc400id, itemData, tkey},
 		signature); err != nil {
 		return err
 	}
 
 	if err = json.Unmarshal([]byte(itemData), &MRC401Job); err != nil {
-		return errors.New("6205,MRC401 Data is in the wrong data - " + err.Error())
+		return mcerr.Wrap(mcerr.WrongData, "MRC401 Data is in the wrong data", err)
 	}
 	if len(MRC401Job) > 100 {
-		return errors.New("3002,There must be 100 or fewer create item")
+		return mcerr.New(mcerr.InvalidTo, "There must be 100 or fewer create item")
 	}
 	if len(MRC401Job) < 1 {
-		return errors.New("3002,There is no item information")
+		return mcerr.New(mcerr.InvalidTo, "There is no item information")
 	}
 	createTotal = make(map[string]decimal.Decimal)
-	now = time.Now().Unix()
+	now = GetTxTime(stub)
 	logData = make([]TMRC401Sell, 0, len(MRC401Job))
 	keyCheck = make(map[string]int)
 
 	for index := range MRC401Job {
 
 		if _, exists := keyCheck[MRC401Job[index].ItemID]; exists {
-			return errors.New("3004,MRC401 [" + MRC401Job[index].ItemID + "] is duplicate")
+			return mcerr.New(mcerr.DexStatus, "MRC401 ["+MRC401Job[index].ItemID+"] is duplicate")
 		}
 		keyCheck[MRC401Job[index].ItemID] = 0
 
 		data, err = stub.GetState(mrc400id + "_" + MRC401Job[index].ItemID)
 		if err != nil {
-			return errors.New("8600,Hyperledger internal error - " + err.Error())
+			return mcerr.Wrap(mcerr.LedgerWrite, "Hyperledger internal error", err)
 		}
 
 		if data != nil {
-			return errors.New("8600,Item ID " + MRC401Job[index].ItemID + " already exists in project " + mrc400id)
+			return mcerr.New(mcerr.LedgerWrite, "Item ID "+MRC401Job[index].ItemID+" already exists in project "+mrc400id)
 		}
 
 		// init data
@@ -467,55 +482,55 @@ func Mrc401Create(stub shim.ChaincodeStubInterface, mrc400id, itemData, signatur
 
 		// param check
 		if err = util.DataAssign(MRC401Job[index].ItemID, &MRC401Job[index].ItemID, "id", 40, 40, false); err != nil {
-			return errors.New("3005," + util.GetOrdNumber(index) + " item ItemID error : " + err.Error())
+			return mcerr.New(mcerr.InvalidData, util.GetOrdNumber(index)+" item ItemID error : "+err.Error())
 		}
 
 		if err = util.DataAssign(MRC401Job[index].ItemURL, &MRC401ItemData.ItemURL, "url", 1, 255, true); err != nil {
-			return errors.New("3005," + util.GetOrdNumber(index) + " item ItemURL error : " + err.Error())
+			return mcerr.New(mcerr.InvalidData, util.GetOrdNumber(index)+" item ItemURL error : "+err.Error())
 		}
 
 		if err = util.DataAssign(MRC401Job[index].ItemImageURL, &MRC401ItemData.ItemImageURL, "url", 1, 255, true); err != nil {
-			return errors.New("3005," + util.GetOrdNumber(index) + " item ItemImageURL error : " + err.Error())
+			return mcerr.New(mcerr.InvalidData, util.GetOrdNumber(index)+" item ItemImageURL error : "+err.Error())
 		}
 
 		if err = util.DataAssign(MRC401Job[index].GroupID, &MRC401ItemData.GroupID, "string", 1, 40, false); err != nil {
-			return errors.New("3005," + util.GetOrdNumber(index) + " item GroupID error : " + err.Error())
+			return mcerr.New(mcerr.InvalidData, util.GetOrdNumber(index)+" item GroupID error : "+err.Error())
 		}
 
 		if err = util.NumericDataCheck(MRC401Job[index].InititalReserve, &MRC401ItemData.InititalReserve, "0", "9999999999999999999999999999999999999999", 0, false); err != nil {
-			return errors.New("3005," + util.GetOrdNumber(index) + " item InititalReserve error : " + err.Error())
+			return mcerr.New(mcerr.InvalidData, util.GetOrdNumber(index)+" item InititalReserve error : "+err.Error())
 		}
 
 		if err = util.DataAssign(MRC401Job[index].InititalToken, &MRC401ItemData.InititalToken, "string", 1, 40, false); err != nil {
-			return errors.New("3005," + util.GetOrdNumber(index) + " item InititalToken error : " + err.Error())
+			return mcerr.New(mcerr.InvalidData, util.GetOrdNumber(index)+" item InititalToken error : "+err.Error())
 		}
 
 		if err = util.NumericDataCheck(MRC401Job[index].MeltingFee, &MRC401ItemData.MeltingFee, "0", "99.9999", 4, false); err != nil {
-			return errors.New("3005," + util.GetOrdNumber(index) + " item MeltingFee error : " + err.Error())
+			return mcerr.New(mcerr.InvalidData, util.GetOrdNumber(index)+" item MeltingFee error : "+err.Error())
 		}
 
 		if err = util.DataAssign(MRC401Job[index].Transferable, &MRC401ItemData.Transferable, "string", 1, 128, false); err != nil {
-			return errors.New("3005," + util.GetOrdNumber(index) + " item Transferable error : " + err.Error())
+			return mcerr.New(mcerr.InvalidData, util.GetOrdNumber(index)+" item Transferable error : "+err.Error())
 		}
 
 		if MRC401ItemData.Transferable != "Permanent" && MRC401ItemData.Transferable != "Bound" && MRC401ItemData.Transferable != "Temprary" {
-			return errors.New("3005," + util.GetOrdNumber(index) + " item Transferable value is Permanent, Bound, Temprary ")
+			return mcerr.New(mcerr.InvalidData, util.GetOrdNumber(index)+" item Transferable value is Permanent, Bound, Temprary ")
 		}
 
 		if err = util.NumericDataCheck(MRC401Job[index].SellFee, &MRC401ItemData.SellFee, "0", "99.9999", 4, false); err != nil {
-			return errors.New("3005," + util.GetOrdNumber(index) + " item SellFee error : " + err.Error())
+			return mcerr.New(mcerr.InvalidData, util.GetOrdNumber(index)+" item SellFee error : "+err.Error())
 		}
 
 		// Initital token check
 		if MRC401ItemData.InititalToken != MRC400.AllowToken && MRC401ItemData.InititalToken != "0" {
 			if MRC400.AllowToken != "0" {
-				return errors.New("3005," + util.GetOrdNumber(index) + " item Token is must " + MRC400.AllowToken + " or metacoin")
+				return mcerr.New(mcerr.InvalidData, util.GetOrdNumber(index)+" item Token is must "+MRC400.AllowToken+" or metacoin")
 			}
-			return errors.New("3005," + util.GetOrdNumber(index) + " item Token is must " + MRC400.AllowToken)
+			return mcerr.New(mcerr.InvalidData, util.GetOrdNumber(index)+" item Token is must "+MRC400.AllowToken)
 		}
 
 		if tempPrice, err = decimal.NewFromString(MRC401ItemData.InititalReserve); err != nil {
-			return errors.New("3209," + util.GetOrdNumber(index) + " item Invalid InititalReserve")
+			return mcerr.New(mcerr.InvalidItemData, util.GetOrdNumber(index)+" item Invalid InititalReserve")
 		}
 		if tempPrice.IsPositive() {
 			createTotal[MRC401ItemData.InititalToken] = createTotal[MRC401ItemData.InititalToken].Add(tempPrice).Truncate(0)
@@ -569,27 +584,27 @@ func Mrc401Update(stub shim.ChaincodeStubInterface, mrc400id, itemData, signatur
 		return err
 	}
 	// sign check
-	if err = NonceCheck(&projectOwnerWallet, tkey,
-		strings.Join([]string{mrc400id, itemData, tkey}, "|"),
+	if err = NonceCheck(stub, &projectOwnerWallet, tkey, "mrc401update",
+		[]string{mrc400id, itemData, tkey},
 		signature); err != nil {
 		return err
 	}
 
 	if err = json.Unmarshal([]byte(itemData), &MRC401Job); err != nil {
-		return errors.New("6205,MRC401 Data is in the wrong data - " + err.Error())
+		return mcerr.Wrap(mcerr.WrongData, "MRC401 Data is in the wrong data", err)
 	}
 	if len(MRC401Job) > 100 {
-		return errors.New("3002,There must be 100 or fewer update item")
+		return mcerr.New(mcerr.InvalidTo, "There must be 100 or fewer update item")
 	}
 	if len(MRC401Job) < 1 {
-		return errors.New("3002,There is no item information")
+		return mcerr.New(mcerr.InvalidTo, "There is no item information")
 	}
 
 	keyCheck = make(map[string]int)
 	logData = make([]string, 0, len(MRC401Job))
 	for index := range MRC401Job {
 		if _, exists := keyCheck[MRC401Job[index].ItemID]; exists {
-			return errors.New("3004,MRC401 [" + MRC401Job[index].ItemID + "] is duplicate")
+			return mcerr.New(mcerr.DexStatus, "MRC401 ["+MRC401Job[index].ItemID+"] is duplicate")
 		}
 		keyCheck[MRC401Job[index].ItemID] = 0
 
@@ -598,56 +613,56 @@ func Mrc401Update(stub shim.ChaincodeStubInterface, mrc400id, itemData, signatur
 		}
 
 		if err = util.DataAssign(MRC401Job[index].ItemURL, &MRC401ItemData.ItemURL, "url", 0, 255, false); err != nil {
-			return errors.New("3005," + MRC401Job[index].ItemID + " item ItemURL error : " + err.Error())
+			return mcerr.New(mcerr.InvalidData, MRC401Job[index].ItemID+" item ItemURL error : "+err.Error())
 		}
 
 		if err = util.DataAssign(MRC401Job[index].ItemImageURL, &MRC401ItemData.ItemImageURL, "url", 0, 255, false); err != nil {
-			return errors.New("3005," + MRC401Job[index].ItemID + " item ItemImageURL error : " + err.Error())
+			return mcerr.New(mcerr.InvalidData, MRC401Job[index].ItemID+" item ItemImageURL error : "+err.Error())
 		}
 
 		if err = util.DataAssign(MRC401Job[index].GroupID, &MRC401ItemData.GroupID, "string", 0, 40, false); err != nil {
-			return errors.New("3005," + MRC401Job[index].ItemID + " item GroupID error : " + err.Error())
+			return mcerr.New(mcerr.InvalidData, MRC401Job[index].ItemID+" item GroupID error : "+err.Error())
 		}
 
 		if MRC401Job[index].SellFee != MRC401ItemData.SellFee {
 			if MRC401ItemData.SellDate > 0 {
-				return errors.New("3004,MRC401 [" + MRC401Job[index].ItemID + "] is already sale")
+				return mcerr.New(mcerr.DexStatus, "MRC401 ["+MRC401Job[index].ItemID+"] is already sale")
 			}
 
 			if MRC401ItemData.AuctionDate > 0 {
-				return errors.New("3004,MRC401 [" + MRC401Job[index].ItemID + "] is already auction")
+				return mcerr.New(mcerr.DexStatus, "MRC401 ["+MRC401Job[index].ItemID+"] is already auction")
 			}
 		}
 
 		if err = util.NumericDataCheck(MRC401Job[index].SellFee, &MRC401ItemData.SellFee, "0", "99.9999", 4, false); err != nil {
-			return errors.New("3005," + MRC401Job[index].ItemID + " item SellFee error : " + err.Error())
+			return mcerr.New(mcerr.InvalidData, MRC401Job[index].ItemID+" item SellFee error : "+err.Error())
 		}
 
 		if MRC401Job[index].MeltingFee != MRC401ItemData.MeltingFee {
 			if MRC401ItemData.SellDate > 0 {
-				return errors.New("3004,MRC401 [" + MRC401Job[index].ItemID + "] is already sale")
+				return mcerr.New(mcerr.DexStatus, "MRC401 ["+MRC401Job[index].ItemID+"] is already sale")
 			}
 
 			if MRC401ItemData.AuctionDate > 0 {
-				return errors.New("3004,MRC401 [" + MRC401Job[index].ItemID + "] is already auction")
+				return mcerr.New(mcerr.DexStatus, "MRC401 ["+MRC401Job[index].ItemID+"] is already auction")
 			}
 		}
 
 		if err = util.NumericDataCheck(MRC401Job[index].MeltingFee, &MRC401ItemData.MeltingFee, "0", "99.9999", 4, false); err != nil {
-			return errors.New("3005," + MRC401Job[index].ItemID + " item MeltingFee error : " + err.Error())
+			return mcerr.New(mcerr.InvalidData, MRC401Job[index].ItemID+" item MeltingFee error : "+err.Error())
 		}
 
 		if MRC401ItemData.Transferable != "Temprary" {
 			if MRC401Job[index].Transferable != MRC401ItemData.Transferable {
-				return errors.New("3005," + MRC401Job[index].ItemID + " item Transferable value cannot be change")
+				return mcerr.New(mcerr.InvalidData, MRC401Job[index].ItemID+" item Transferable value cannot be change")
 			}
 		} else {
 			if err = util.DataAssign(MRC401Job[index].Transferable, &MRC401ItemData.Transferable, "string", 1, 128, false); err != nil {
-				return errors.New("3005," + MRC401Job[index].ItemID + " item Transferable error : " + err.Error())
+				return mcerr.New(mcerr.InvalidData, MRC401Job[index].ItemID+" item Transferable error : "+err.Error())
 			}
 
 			if MRC401ItemData.Transferable != "Permanent" && MRC401ItemData.Transferable != "Bound" && MRC401ItemData.Transferable != "Temprary" {
-				return errors.New("3005," + MRC401Job[index].ItemID + " item Transferable value is Permanent, Bound, Temprary ")
+				return mcerr.New(mcerr.InvalidData, MRC401Job[index].ItemID+" item Transferable value is Permanent, Bound, Temprary ")
 			}
 		}
 
@@ -697,24 +712,24 @@ func Mrc401Transfer(stub shim.ChaincodeStubInterface, mrc401id, fromAddr, toAddr
 			return err
 		}
 		if MRC401.Owner != MRC400.Owner {
-			return errors.New("5002,MRC401 [" + mrc401id + "] is not transferable")
+			return mcerr.New(mcerr.ToBalance, "MRC401 ["+mrc401id+"] is not transferable")
 		}
 	}
 
 	if MRC401.SellDate > 0 {
-		return errors.New("3004,MRC401 [" + mrc401id + "] is already sale")
+		return mcerr.New(mcerr.DexStatus, "MRC401 ["+mrc401id+"] is already sale")
 	}
 
 	if MRC401.AuctionDate > 0 {
-		return errors.New("3004,MRC401 [" + mrc401id + "] is already auction")
+		return mcerr.New(mcerr.DexStatus, "MRC401 ["+mrc401id+"] is already auction")
 	}
 
 	if MRC401.Owner != fromAddr {
-		return errors.New("3004,MRC401 [" + mrc401id + "] is not your item")
+		return mcerr.New(mcerr.DexStatus, "MRC401 ["+mrc401id+"] is not your item")
 	}
 
 	if toAddr == fromAddr {
-		return errors.New("3005,From address and to address must be different values")
+		return mcerr.New(mcerr.InvalidData, "From address and to address must be different values")
 	}
 
 	// get owner info
@@ -728,8 +743,8 @@ func Mrc401Transfer(stub shim.ChaincodeStubInterface, mrc401id, fromAddr, toAddr
 	}
 
 	// sign check
-	if err = NonceCheck(&ownerWallet, tkey,
-		strings.Join([]string{fromAddr, toAddr, mrc401id, tkey}, "|"),
+	if err = NonceCheck(stub, &ownerWallet, tkey, "mrc401transfer",
+		[]string{fromAddr, toAddr, mrc401id, tkey},
 		signature); err != nil {
 		return err
 	}
@@ -759,13 +774,13 @@ func Mrc401Sell(stub shim.ChaincodeStubInterface, seller, mrc400id, itemData, si
 	var keyCheck map[string]int
 
 	if err = json.Unmarshal([]byte(itemData), &MRC401SellData); err != nil {
-		return errors.New("3004,Selldata is in the wrong data " + err.Error())
+		return mcerr.New(mcerr.DexStatus, "Selldata is in the wrong data "+err.Error())
 	}
 	if len(MRC401SellData) > 100 {
-		return errors.New("3002,There must be 100 or fewer sell item")
+		return mcerr.New(mcerr.InvalidTo, "There must be 100 or fewer sell item")
 	}
 	if len(MRC401SellData) < 1 {
-		return errors.New("3002,There is no item information")
+		return mcerr.New(mcerr.InvalidTo, "There is no item information")
 	}
 	// get seller info
 	if sellerData, err = GetAddressInfo(stub, seller); err != nil {
@@ -773,27 +788,27 @@ func Mrc401Sell(stub shim.ChaincodeStubInterface, seller, mrc400id, itemData, si
 	}
 
 	// sign check
-	if err = NonceCheck(&sellerData, tkey,
-		strings.Join([]string{seller, itemData, tkey}, "|"),
+	if err = NonceCheck(stub, &sellerData, tkey, "mrc401sell",
+		[]string{seller, itemData, tkey},
 		signature); err != nil {
 		return err
 	}
 
 	logData = make([]TMRC401Sell, 0, len(MRC401SellData))
 	keyCheck = make(map[string]int)
-	now = time.Now().Unix()
+	now = GetTxTime(stub)
 	for index := range MRC401SellData {
 		if _, exists := keyCheck[MRC401SellData[index].ItemID]; exists {
-			return errors.New("3004,MRC401 [" + MRC401SellData[index].ItemID + "] is duplicate")
+			return mcerr.New(mcerr.DexStatus, "MRC401 ["+MRC401SellData[index].ItemID+"] is duplicate")
 		}
 		keyCheck[MRC401SellData[index].ItemID] = 0
 
 		if MRC401, _, err = GetMRC401(stub, MRC401SellData[index].ItemID); err != nil {
-			return errors.New("3004,MRC401 [" + MRC401SellData[index].ItemID + "] - " + err.Error())
+			return mcerr.Wrap(mcerr.DexStatus, "MRC401 ["+MRC401SellData[index].ItemID+"]", err)
 		}
 
 		if mrc400id != MRC401.MRC400 {
-			return errors.New("3004,MRC401 [" + MRC401SellData[index].ItemID + "] is not MRC400 " + mrc400id + " item")
+			return mcerr.New(mcerr.DexStatus, "MRC401 ["+MRC401SellData[index].ItemID+"] is not MRC400 "+mrc400id+" item")
 		}
 
 		// get project
@@ -802,36 +817,36 @@ func Mrc401Sell(stub shim.ChaincodeStubInterface, seller, mrc400id, itemData, si
 		}
 		// item owner check.
 		if MRC401.Owner != seller {
-			return errors.New("3004,MRC401 [" + MRC401SellData[index].ItemID + "] is not your item")
+			return mcerr.New(mcerr.DexStatus, "MRC401 ["+MRC401SellData[index].ItemID+"] is not your item")
 		}
 
 		// item is sell or auction ?
 		if MRC401.SellDate > 0 {
-			return errors.New("3004,MRC401 [" + MRC401SellData[index].ItemID + "] is already sale")
+			return mcerr.New(mcerr.DexStatus, "MRC401 ["+MRC401SellData[index].ItemID+"] is already sale")
 		}
 		if MRC401.AuctionDate > 0 {
-			return errors.New("3004,MRC401 [" + MRC401SellData[index].ItemID + "] is already auction")
+			return mcerr.New(mcerr.DexStatus, "MRC401 ["+MRC401SellData[index].ItemID+"] is already auction")
 		}
 
 		// item transferable ?
 		if MRC401.Transferable == "Bound" {
 			// allow owner sale.
 			if MRC401.Owner != MRC400.Owner {
-				return errors.New("5002,MRC401 [" + MRC401SellData[index].ItemID + "] is cannot be sold")
+				return mcerr.New(mcerr.ToBalance, "MRC401 ["+MRC401SellData[index].ItemID+"] is cannot be sold")
 			}
 		}
 
 		// sell price check
 		if err = util.NumericDataCheck(MRC401SellData[index].SellPrice, &MRC401.SellPrice, "1", "99999999999999999999999999999999999999999999999999999999999999999999999999999999", 0, false); err != nil {
-			return errors.New("3005," + util.GetOrdNumber(index) + " item SellPrice error : " + err.Error())
+			return mcerr.New(mcerr.InvalidData, util.GetOrdNumber(index)+" item SellPrice error : "+err.Error())
 		}
 
 		//  token check
 		if MRC401SellData[index].SellToken != MRC400.AllowToken && MRC401SellData[index].SellToken != "0" {
 			if MRC400.AllowToken != "0" {
-				return errors.New("3005," + util.GetOrdNumber(index) + " item SellToken is must " + MRC400.AllowToken + " or metacoin")
+				return mcerr.New(mcerr.InvalidData, util.GetOrdNumber(index)+" item SellToken is must "+MRC400.AllowToken+" or metacoin")
 			}
-			return errors.New("3005," + util.GetOrdNumber(index) + " item SellToken is must " + MRC400.AllowToken)
+			return mcerr.New(mcerr.InvalidData, util.GetOrdNumber(index)+" item SellToken is must "+MRC400.AllowToken)
 		}
 		MRC401.SellToken = MRC401SellData[index].SellToken
 
@@ -861,13 +876,13 @@ func Mrc401UnSell(stub shim.ChaincodeStubInterface, seller, mrc400id, itemData,
 	var keyCheck map[string]int
 
 	if err = json.Unmarshal([]byte(itemData), &MRC401list); err != nil {
-		return errors.New("3004,Selldata is in the wrong data " + err.Error())
+		return mcerr.New(mcerr.DexStatus, "Selldata is in the wrong data "+err.Error())
 	}
 	if len(MRC401list) > 100 {
-		return errors.New("3002,There must be 100 or fewer unsell item")
+		return mcerr.New(mcerr.InvalidTo, "There must be 100 or fewer unsell item")
 	}
 	if len(MRC401list) < 1 {
-		return errors.New("3002,There is no item information")
+		return mcerr.New(mcerr.InvalidTo, "There is no item information")
 	}
 
 	// get seller info
@@ -876,8 +891,8 @@ func Mrc401UnSell(stub shim.ChaincodeStubInterface, seller, mrc400id, itemData,
 	}
 
 	// sign check
-	if err = NonceCheck(&ownerWallet, tkey,
-		strings.Join([]string{seller, itemData, tkey}, "|"),
+	if err = NonceCheck(stub, &ownerWallet, tkey, "mrc401unsell",
+		[]string{seller, itemData, tkey},
 		signature); err != nil {
 		return err
 	}
@@ -887,26 +902,26 @@ func Mrc401UnSell(stub shim.ChaincodeStubInterface, seller, mrc400id, itemData,
 	for index := range MRC401list {
 
 		if _, exists := keyCheck[MRC401list[index]]; exists {
-			return errors.New("3004,MRC401 [" + MRC401list[index] + "] is duplicate")
+			return mcerr.New(mcerr.DexStatus, "MRC401 ["+MRC401list[index]+"] is duplicate")
 		}
 		keyCheck[MRC401list[index]] = 0
 
 		if MRC401, _, err = GetMRC401(stub, MRC401list[index]); err != nil {
-			return errors.New("3004,MRC401 [" + MRC401list[index] + "] - " + err.Error())
+			return mcerr.Wrap(mcerr.DexStatus, "MRC401 ["+MRC401list[index]+"]", err)
 		}
 
 		if mrc400id != MRC401.MRC400 {
-			return errors.New("3004,MRC401 [" + MRC401list[index] + "] is not MRC400 " + mrc400id + " item")
+			return mcerr.New(mcerr.DexStatus, "MRC401 ["+MRC401list[index]+"] is not MRC400 "+mrc400id+" item")
 		}
 
 		// item owner check.
 		if MRC401.Owner != seller {
-			return errors.New("3004,MRC401 [" + MRC401list[index] + "] is not your item")
+			return mcerr.New(mcerr.DexStatus, "MRC401 ["+MRC401list[index]+"] is not your item")
 		}
 
 		// item is sell ?
 		if MRC401.SellDate == 0 {
-			return errors.New("3004,MRC401 [" + MRC401list[index] + "] is not sale")
+			return mcerr.New(mcerr.DexStatus, "MRC401 ["+MRC401list[index]+"] is not sale")
 		}
 
 		// save item
@@ -951,11 +966,11 @@ func Mrc401Buy(stub shim.ChaincodeStubInterface, buyer, mrc401id, signature, tke
 	}
 	// item is sell ??
 	if MRC401ItemData.SellDate == 0 {
-		return errors.New("3004,MRC401 [" + mrc401id + "] is not for sale")
+		return mcerr.New(mcerr.DexStatus, "MRC401 ["+mrc401id+"] is not for sale")
 	}
 	// block self trade
 	if buyer == MRC401ItemData.Owner {
-		return errors.New("3004,You cannot purchase items sold by yourself")
+		return mcerr.New(mcerr.DexStatus, "You cannot purchase items sold by yourself")
 	}
 
 	seller = MRC401ItemData.Owner
@@ -965,8 +980,8 @@ func Mrc401Buy(stub shim.ChaincodeStubInterface, buyer, mrc401id, signature, tke
 	if buyerWallet, err = GetAddressInfo(stub, buyer); err != nil {
 		return err
 	}
-	if err = NonceCheck(&buyerWallet, tkey,
-		strings.Join([]string{mrc401id, tkey}, "|"),
+	if err = NonceCheck(stub, &buyerWallet, tkey, "mrc401buy",
+		[]string{mrc401id, tkey},
 		signature); err != nil {
 		return err
 	}
@@ -1058,7 +1073,7 @@ func Mrc401Buy(stub shim.ChaincodeStubInterface, buyer, mrc401id, signature, tke
 	MRC401ItemData.Owner = buyer
 
 	// set last trade info
-	MRC401ItemData.LastTradeDate = time.Now().Unix()
+	MRC401ItemData.LastTradeDate = GetTxTime(stub)
 	MRC401ItemData.LastTradeAmount = MRC401ItemData.SellPrice
 	MRC401ItemData.LastTradeToken = MRC401ItemData.SellToken
 	MRC401ItemData.LastTradeType = "Sell"
@@ -1068,7 +1083,7 @@ func Mrc401Buy(stub shim.ChaincodeStubInterface, buyer, mrc401id, signature, tke
 	MRC401ItemData.SellPrice = "0"
 	MRC401ItemData.SellToken = "0"
 
-	if err = setMRC401(stub, mrc401id, MRC401ItemData, "mrc401_buy", []string{mrc401id, seller, buyer, util.JSONEncode(PaymentInfo), signature, tkey}); err != nil {
+	if err = setMRC401(stub, mrc401id, MRC401ItemData, "mrc401_buy", []string{mrc401id, seller, buyer, util.JSONEncode(PaymentInfo), signature, tkey}, PaymentInfo...); err != nil {
 		return err
 	}
 
@@ -1100,16 +1115,16 @@ func Mrc401Melt(stub shim.ChaincodeStubInterface, mrc401id, signature, tkey stri
 	}
 
 	if MRC401ItemData.SellDate > 0 {
-		return errors.New("3004,MRC401 [" + mrc401id + "] is already sale")
+		return mcerr.New(mcerr.DexStatus, "MRC401 ["+mrc401id+"] is already sale")
 	}
 
 	if MRC401ItemData.AuctionDate > 0 {
-		return errors.New("3004,MRC401 [" + mrc401id + "] is already auction")
+		return mcerr.New(mcerr.DexStatus, "MRC401 ["+mrc401id+"] is already auction")
 	}
 
 	itemOwner = MRC401ItemData.Owner
 	if itemOwner == "MELTED" {
-		return errors.New("3004,MRC401 [" + mrc401id + "] is already melted")
+		return mcerr.New(mcerr.DexStatus, "MRC401 ["+mrc401id+"] is already melted")
 	}
 
 	// get item owner info
@@ -1118,8 +1133,8 @@ func Mrc401Melt(stub shim.ChaincodeStubInterface, mrc401id, signature, tkey stri
 	}
 
 	// sign check.
-	if err = NonceCheck(&itemOwnerWallet, tkey,
-		strings.Join([]string{mrc401id, tkey}, "|"),
+	if err = NonceCheck(stub, &itemOwnerWallet, tkey, "mrc401melt",
+		[]string{mrc401id, tkey},
 		signature); err != nil {
 		return err
 	}
@@ -1179,8 +1194,8 @@ func Mrc401Melt(stub shim.ChaincodeStubInterface, mrc401id, signature, tkey stri
 
 	// item owner change for MELTED
 	MRC401ItemData.Owner = "MELTED"
-	MRC401ItemData.MeltingDate = time.Now().Unix()
-	if err = setMRC401(stub, mrc401id, MRC401ItemData, "mrc401_melt", []string{mrc401id, itemOwner, util.JSONEncode(PaymentInfo), signature, tkey}); err != nil {
+	MRC401ItemData.MeltingDate = GetTxTime(stub)
+	if err = setMRC401(stub, mrc401id, MRC401ItemData, "mrc401_melt", []string{mrc401id, itemOwner, util.JSONEncode(PaymentInfo), signature, tkey}, PaymentInfo...); err != nil {
 		return err
 	}
 
@@ -1204,13 +1219,13 @@ func Mrc401Auction(stub shim.ChaincodeStubInterface, seller, mrc400id, itemData,
 	var keyCheck map[string]int
 
 	if err = json.Unmarshal([]byte(itemData), &MRC401AuctionData); err != nil {
-		return errors.New("3004,Selldata is in the wrong data " + err.Error())
+		return mcerr.New(mcerr.DexStatus, "Selldata is in the wrong data "+err.Error())
 	}
 	if len(MRC401AuctionData) > 100 {
-		return errors.New("3002,There must be 100 or fewer sell item")
+		return mcerr.New(mcerr.InvalidTo, "There must be 100 or fewer sell item")
 	}
 	if len(MRC401AuctionData) < 1 {
-		return errors.New("3002,There is no item information")
+		return mcerr.New(mcerr.InvalidTo, "There is no item information")
 	}
 	// get seller info
 	if sellerWallet, err = GetAddressInfo(stub, seller); err != nil {
@@ -1218,27 +1233,27 @@ func Mrc401Auction(stub shim.ChaincodeStubInterface, seller, mrc400id, itemData,
 	}
 
 	// sign check
-	if err = NonceCheck(&sellerWallet, tkey,
-		strings.Join([]string{seller, itemData, tkey}, "|"),
+	if err = NonceCheck(stub, &sellerWallet, tkey, "mrc401auction",
+		[]string{seller, itemData, tkey},
 		signature); err != nil {
 		return err
 	}
 	// auction item check.
-	now = time.Now().Unix()
+	now = GetTxTime(stub)
 
 	keyCheck = make(map[string]int)
 	for index := range MRC401AuctionData {
 		if _, exists := keyCheck[MRC401AuctionData[index].ItemID]; exists {
-			return errors.New("3004,MRC401 [" + MRC401AuctionData[index].ItemID + "] is duplicate")
+			return mcerr.New(mcerr.DexStatus, "MRC401 ["+MRC401AuctionData[index].ItemID+"] is duplicate")
 		}
 		keyCheck[MRC401AuctionData[index].ItemID] = 0
 
 		if MRC401ItemData, _, err = GetMRC401(stub, MRC401AuctionData[index].ItemID); err != nil {
-			return errors.New("3004,MRC401 [" + MRC401AuctionData[index].ItemID + "] - " + err.Error())
+			return mcerr.Wrap(mcerr.DexStatus, "MRC401 ["+MRC401AuctionData[index].ItemID+"]", err)
 		}
 
 		if mrc400id != MRC401ItemData.MRC400 {
-			return errors.New("3004,MRC401 [" + MRC401AuctionData[index].ItemID + "] is not MRC400 " + mrc400id + " item")
+			return mcerr.New(mcerr.DexStatus, "MRC401 ["+MRC401AuctionData[index].ItemID+"] is not MRC400 "+mrc400id+" item")
 		}
 
 		// get project
@@ -1247,55 +1262,55 @@ func Mrc401Auction(stub shim.ChaincodeStubInterface, seller, mrc400id, itemData,
 		}
 
 		if err = json.Unmarshal([]byte(buffer), &MRC400ProjectData); err != nil {
-			return errors.New("3004,MRC400 [" + MRC401ItemData.MRC400 + "] is in the wrong data")
+			return mcerr.New(mcerr.DexStatus, "MRC400 ["+MRC401ItemData.MRC400+"] is in the wrong data")
 		}
 		// item owner check.
 		if MRC401ItemData.Owner != seller {
-			return errors.New("3004,MRC401 [" + MRC401AuctionData[index].ItemID + "] is not your item")
+			return mcerr.New(mcerr.DexStatus, "MRC401 ["+MRC401AuctionData[index].ItemID+"] is not your item")
 		}
 
 		// sale or auction item ?
 		if MRC401ItemData.SellDate > 0 {
-			return errors.New("3004,MRC401 [" + MRC401AuctionData[index].ItemID + "] is already sale")
+			return mcerr.New(mcerr.DexStatus, "MRC401 ["+MRC401AuctionData[index].ItemID+"] is already sale")
 		}
 		if MRC401ItemData.AuctionDate > 0 {
-			return errors.New("3004,MRC401 [" + MRC401AuctionData[index].ItemID + "] is already auction")
+			return mcerr.New(mcerr.DexStatus, "MRC401 ["+MRC401AuctionData[index].ItemID+"] is already auction")
 		}
 
 		// item transferable ?
 		if MRC401ItemData.Transferable == "Bound" {
 			if MRC401ItemData.Owner != MRC400ProjectData.Owner {
-				return errors.New("5002,MRC401 [" + MRC401AuctionData[index].ItemID + "] is cannot be sold")
+				return mcerr.New(mcerr.ToBalance, "MRC401 ["+MRC401AuctionData[index].ItemID+"] is cannot be sold")
 			}
 		}
 
 		// start price check
 		if err = util.NumericDataCheck(MRC401AuctionData[index].AuctionStartPrice, &MRC401ItemData.AuctionStartPrice, "1", "99999999999999999999999999999999999999999999999999999999999999999999999999999999", 0, false); err != nil {
-			return errors.New("3005," + util.GetOrdNumber(index) + " item auction_start_price error : " + err.Error())
+			return mcerr.New(mcerr.InvalidData, util.GetOrdNumber(index)+" item auction_start_price error : "+err.Error())
 		}
 
 		// buynow price check
 		if err = util.NumericDataCheck(MRC401AuctionData[index].AuctionBuyNowPrice, &MRC401ItemData.AuctionBuyNowPrice, "0", "99999999999999999999999999999999999999999999999999999999999999999999999999999999", 0, true); err != nil {
-			return errors.New("3005," + util.GetOrdNumber(index) + " item auction_buynow_price error : " + err.Error())
+			return mcerr.New(mcerr.InvalidData, util.GetOrdNumber(index)+" item auction_buynow_price error : "+err.Error())
 		}
 
 		// bidding unit price check
 		if err = util.NumericDataCheck(MRC401AuctionData[index].AuctionBiddingUnit, &MRC401ItemData.AuctionBiddingUnit, "1", "99999999999999999999999999999999999999999999999999999999999999999999999999999999", 0, false); err != nil {
-			return errors.New("3005," + util.GetOrdNumber(index) + " item auction_bidding_unit error : " + err.Error())
+			return mcerr.New(mcerr.InvalidData, util.GetOrdNumber(index)+" item auction_bidding_unit error : "+err.Error())
 		}
 
 		auctionStart, _ = decimal.NewFromString(MRC401ItemData.AuctionStartPrice)
 		auctionBuynow, _ = decimal.NewFromString(MRC401ItemData.AuctionBuyNowPrice)
 		if !auctionBuynow.IsZero() && auctionBuynow.Cmp(auctionStart) < 0 {
-			return errors.New("3005," + util.GetOrdNumber(index) + " item buynow price is must be greater then auction start price")
+			return mcerr.New(mcerr.InvalidData, util.GetOrdNumber(index)+" item buynow price is must be greater then auction start price")
 		}
 
 		//  token check
 		if MRC401AuctionData[index].AuctionToken != MRC400ProjectData.AllowToken && MRC401AuctionData[index].AuctionToken != "0" {
 			if MRC400ProjectData.AllowToken != "0" {
-				return errors.New("3005," + util.GetOrdNumber(index) + " item auction_token is must " + MRC400ProjectData.AllowToken + " or metacoin")
+				return mcerr.New(mcerr.InvalidData, util.GetOrdNumber(index)+" item auction_token is must "+MRC400ProjectData.AllowToken+" or metacoin")
 			}
-			return errors.New("3005," + util.GetOrdNumber(index) + " item auction_token is must " + MRC400ProjectData.AllowToken)
+			return mcerr.New(mcerr.InvalidData, util.GetOrdNumber(index)+" item auction_token is must "+MRC400ProjectData.AllowToken)
 		}
 		MRC401ItemData.AuctionToken = MRC401AuctionData[index].AuctionToken
 
@@ -1328,13 +1343,13 @@ func Mrc401UnAuction(stub shim.ChaincodeStubInterface, seller, mrc400id, itemDat
 	var keyCheck map[string]int
 
 	if err = json.Unmarshal([]byte(itemData), &MRC401list); err != nil {
-		return errors.New("3004,Selldata is in the wrong data " + err.Error())
+		return mcerr.New(mcerr.DexStatus, "Selldata is in the wrong data "+err.Error())
 	}
 	if len(MRC401list) > 100 {
-		return errors.New("3002,There must be 100 or fewer unauction item")
+		return mcerr.New(mcerr.InvalidTo, "There must be 100 or fewer unauction item")
 	}
 	if len(MRC401list) < 1 {
-		return errors.New("3002,There is no item information")
+		return mcerr.New(mcerr.InvalidTo, "There is no item information")
 	}
 	// get seller info
 	if sellerWallet, err = GetAddressInfo(stub, seller); err != nil {
@@ -1342,8 +1357,8 @@ func Mrc401UnAuction(stub shim.ChaincodeStubInterface, seller, mrc400id, itemDat
 	}
 
 	// sign check
-	if err = NonceCheck(&sellerWallet, tkey,
-		strings.Join([]string{seller, itemData, tkey}, "|"),
+	if err = NonceCheck(stub, &sellerWallet, tkey, "mrc401unauction",
+		[]string{seller, itemData, tkey},
 		signature); err != nil {
 		return err
 	}
@@ -1351,31 +1366,31 @@ func Mrc401UnAuction(stub shim.ChaincodeStubInterface, seller, mrc400id, itemDat
 	keyCheck = make(map[string]int)
 	for index := range MRC401list {
 		if _, exists := keyCheck[MRC401list[index]]; exists {
-			return errors.New("3004,MRC401 [" + MRC401list[index] + "] is duplicate")
+			return mcerr.New(mcerr.DexStatus, "MRC401 ["+MRC401list[index]+"] is duplicate")
 		}
 		keyCheck[MRC401list[index]] = 0
 
 		if MRC401ItemData, _, err = GetMRC401(stub, MRC401list[index]); err != nil {
-			return errors.New("3004,MRC401 [" + MRC401list[index] + "] - " + err.Error())
+			return mcerr.Wrap(mcerr.DexStatus, "MRC401 ["+MRC401list[index]+"]", err)
 		}
 
 		if mrc400id != MRC401ItemData.MRC400 {
-			return errors.New("3004,MRC401 [" + MRC401list[index] + "] is not MRC400 " + mrc400id + " item")
+			return mcerr.New(mcerr.DexStatus, "MRC401 ["+MRC401list[index]+"] is not MRC400 "+mrc400id+" item")
 		}
 
 		// item owner check.
 		if MRC401ItemData.Owner != seller {
-			return errors.New("3004,MRC401 [" + MRC401list[index] + "] is not your item")
+			return mcerr.New(mcerr.DexStatus, "MRC401 ["+MRC401list[index]+"] is not your item")
 		}
 
 		// is auction item ?
 		if MRC401ItemData.AuctionDate == 0 {
-			return errors.New("3004,MRC401 [" + MRC401list[index] + "] is not auction item")
+			return mcerr.New(mcerr.DexStatus, "MRC401 ["+MRC401list[index]+"] is not auction item")
 		}
 
 		// bidder exists ?
 		if MRC401ItemData.AuctionCurrentBidder != "" {
-			return errors.New("3004,MRC401 [" + MRC401list[index] + "] there is a bidder, so the auction cannot be canceled")
+			return mcerr.New(mcerr.DexStatus, "MRC401 ["+MRC401list[index]+"] there is a bidder, so the auction cannot be canceled")
 		}
 
 		// clear auction data
@@ -1417,7 +1432,7 @@ func Mrc401AuctionBid(stub shim.ChaincodeStubInterface, buyer, mrc401id, amount,
 
 	var isBuynow bool
 
-	now = time.Now().Unix()
+	now = GetTxTime(stub)
 	PaymentInfo = make([]mtc.TDexPaymentInfo, 0, 4)
 
 	// get item info
@@ -1427,33 +1442,33 @@ func Mrc401AuctionBid(stub shim.ChaincodeStubInterface, buyer, mrc401id, amount,
 
 	// is auction ?
 	if MRC401ItemData.AuctionDate == 0 {
-		return errors.New("3004,MRC401 [" + mrc401id + "] is not for auction")
+		return mcerr.New(mcerr.DexStatus, "MRC401 ["+mrc401id+"] is not for auction")
 	}
 	if MRC401ItemData.AuctionEnd < now {
-		return errors.New("3004,MRC401 [" + mrc401id + "] has completed auction")
+		return mcerr.New(mcerr.DexStatus, "MRC401 ["+mrc401id+"] has completed auction")
 	}
 
 	// buyer check.
 	if MRC401ItemData.AuctionCurrentBidder == buyer {
-		return errors.New("3004,You are already the highest bidder")
+		return mcerr.New(mcerr.DexStatus, "You are already the highest bidder")
 	}
 	if MRC401ItemData.Owner == buyer {
-		return errors.New("3004,Owners cannot bid on auctions")
+		return mcerr.New(mcerr.DexStatus, "Owners cannot bid on auctions")
 	}
 
 	// sign check
 	if buyerWallet, err = GetAddressInfo(stub, buyer); err != nil {
 		return err
 	}
-	if err = NonceCheck(&buyerWallet, tkey,
-		strings.Join([]string{mrc401id, amount, token, tkey}, "|"),
+	if err = NonceCheck(stub, &buyerWallet, tkey, "mrc401bid",
+		[]string{mrc401id, amount, token, tkey},
 		signature); err != nil {
 		return err
 	}
 
 	// token check.
 	if MRC401ItemData.AuctionToken != token {
-		return errors.New("3004,Only " + MRC401ItemData.AuctionToken + " tokens can be bid")
+		return mcerr.New(mcerr.DexStatus, "Only "+MRC401ItemData.AuctionToken+" tokens can be bid")
 	}
 
 	// sell price check
@@ -1462,7 +1477,7 @@ func Mrc401AuctionBid(stub shim.ChaincodeStubInterface, buyer, mrc401id, amount,
 	}
 
 	if bidAmount, err = decimal.NewFromString(amount); err != nil {
-		return errors.New("3004,The bid amount is incorrect. " + err.Error())
+		return mcerr.New(mcerr.DexStatus, "The bid amount is incorrect. "+err.Error())
 	}
 
 	// get current price
@@ -1473,19 +1488,19 @@ func Mrc401AuctionBid(stub shim.ChaincodeStubInterface, buyer, mrc401id, amount,
 	if MRC401ItemData.AuctionCurrentBidder == "" {
 		currentPrice, _ = decimal.NewFromString(MRC401ItemData.AuctionStartPrice)
 		if bidAmount.Cmp(currentPrice) < 0 {
-			return errors.New("3004,The bid amount must be equal to or greater than the starting price")
+			return mcerr.New(mcerr.DexStatus, "The bid amount must be equal to or greater than the starting price")
 		}
 	} else {
 		currentPrice, _ = decimal.NewFromString(MRC401ItemData.AuctionCurrentPrice)
 		if bidAmount.Cmp(currentPrice) < 1 {
-			return errors.New("3004,The bid amount must be greater than the current price")
+			return mcerr.New(mcerr.DexStatus, "The bid amount must be greater than the current price")
 		}
 	}
 
 	// check new bid price
 	if !buyNow.IsZero() {
 		if bidAmount.Cmp(buyNow) > 0 {
-			return errors.New("3004,The bid amount must be less than or equal to the purchase buynow price")
+			return mcerr.New(mcerr.DexStatus, "The bid amount must be less than or equal to the purchase buynow price")
 		}
 	}
 
@@ -1513,7 +1528,7 @@ func Mrc401AuctionBid(stub shim.ChaincodeStubInterface, buyer, mrc401id, amount,
 		}
 
 		if err = json.Unmarshal([]byte(buffer), &MRC400ProjectData); err != nil {
-			return errors.New("6205,MRC400 [" + mrc401id + "] is in the wrong data")
+			return mcerr.New(mcerr.WrongData, "MRC400 ["+mrc401id+"] is in the wrong data")
 		}
 
 		if MRC400ProjectData.Owner == buyer {
@@ -1564,10 +1579,10 @@ func Mrc401AuctionBid(stub shim.ChaincodeStubInterface, buyer, mrc401id, amount,
 	bidUnit, _ = decimal.NewFromString(MRC401ItemData.AuctionBiddingUnit)
 	diffPrice = bidAmount.Sub(currentPrice)
 	if diffPrice.Div(bidUnit).Floor().Mul(bidUnit).Cmp(diffPrice) != 0 {
-		return errors.New("3004,The bid amount must be greater than the current amount plus the bid units")
+		return mcerr.New(mcerr.DexStatus, "The bid amount must be greater than the current amount plus the bid units")
 	}
 
-	if err = setMRC401(stub, mrc401id, MRC401ItemData, "mrc401_auctionbid", []string{mrc401id, buyer, util.JSONEncode(PaymentInfo), signature, tkey}); err != nil {
+	if err = setMRC401(stub, mrc401id, MRC401ItemData, "mrc401_auctionbid", []string{mrc401id, buyer, util.JSONEncode(PaymentInfo), signature, tkey}, PaymentInfo...); err != nil {
 		return err
 	}
 	return nil
@@ -1606,16 +1621,16 @@ func auctionFinish(stub shim.ChaincodeStubInterface, mrc401id string, MRC401Item
 	var receivePrice decimal.Decimal // The amount the owner will receive
 	var feePrice decimal.Decimal     // The amount the creator will receive
 
-	now = time.Now().Unix()
+	now = GetTxTime(stub)
 
 	if isBuynow {
 		if MRC401ItemData.AuctionBuyNowPrice == "0" || MRC401ItemData.AuctionBuyNowPrice != MRC401ItemData.AuctionCurrentPrice {
-			return errors.New("3004,MRC401 [" + mrc401id + "] is not buynow item.")
+			return mcerr.New(mcerr.DexStatus, "MRC401 ["+mrc401id+"] is not buynow item.")
 		}
 	} else {
 		// auction not expire ?
 		if MRC401ItemData.AuctionEnd > now {
-			return errors.New("3004,MRC401 [" + mrc401id + "] is under auction.")
+			return mcerr.New(mcerr.DexStatus, "MRC401 ["+mrc401id+"] is under auction.")
 		}
 	}
 
@@ -1626,7 +1641,7 @@ func auctionFinish(stub shim.ChaincodeStubInterface, mrc401id string, MRC401Item
 	// auction fail.
 	if buyer == "" {
 		if MRC401ItemData.AuctionDate == 0 || MRC401ItemData.AuctionEnd == 0 {
-			return errors.New("3004,MRC401 [" + mrc401id + "] is not auction")
+			return mcerr.New(mcerr.DexStatus, "MRC401 ["+mrc401id+"] is not auction")
 		}
 
 		// clear auction data
@@ -1638,7 +1653,7 @@ func auctionFinish(stub shim.ChaincodeStubInterface, mrc401id string, MRC401Item
 		MRC401ItemData.AuctionBuyNowPrice = "0"
 		MRC401ItemData.AuctionCurrentPrice = "0"
 		MRC401ItemData.AuctionCurrentBidder = ""
-		if err = setMRC401(stub, mrc401id, MRC401ItemData, "mrc401_auctionfailure", []string{mrc401id, seller, "", util.JSONEncode(PaymentInfo), "", ""}); err != nil {
+		if err = setMRC401(stub, mrc401id, MRC401ItemData, "mrc401_auctionfailure", []string{mrc401id, seller, "", util.JSONEncode(PaymentInfo), "", ""}, PaymentInfo...); err != nil {
 			return err
 		}
 		return nil
@@ -1705,7 +1720,7 @@ func auctionFinish(stub shim.ChaincodeStubInterface, mrc401id string, MRC401Item
 	MRC401ItemData.Owner = buyer
 
 	// set last trade info
-	MRC401ItemData.LastTradeDate = time.Now().Unix()
+	MRC401ItemData.LastTradeDate = GetTxTime(stub)
 	MRC401ItemData.LastTradeAmount = MRC401ItemData.AuctionCurrentPrice
 	MRC401ItemData.LastTradeToken = MRC401ItemData.AuctionToken
 	MRC401ItemData.LastTradeType = "Auction"
@@ -1725,7 +1740,7 @@ func auctionFinish(stub shim.ChaincodeStubInterface, mrc401id string, MRC401Item
 	} else {
 		jobType = "mrc401_auctionwinning"
 	}
-	if err = setMRC401(stub, mrc401id, MRC401ItemData, jobType, []string{mrc401id, buyer, util.JSONEncode(PaymentInfo), "", ""}); err != nil {
+	if err = setMRC401(stub, mrc401id, MRC401ItemData, jobType, []string{mrc401id, buyer, util.JSONEncode(PaymentInfo), "", ""}, PaymentInfo...); err != nil {
 		return err
 	}
 	return nil
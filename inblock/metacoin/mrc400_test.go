@@ -0,0 +1,86 @@
+package metacoin
+
+import (
+	"testing"
+
+	"inblock/metacoin/util"
+)
+
+// createMRC400 - create project, allow token is coin
+func createMRC400(t *testing.T, s *testStub, owner *testWallet, coin string) string {
+	t.Helper()
+	nonce := owner.nonce(t, s)
+	sig := owner.sign(t, s, "mrc400create", owner.Address, "Test project", "https://example.com", "https://example.com/p.png",
+		"art", "https://example.com/item", "https://example.com/item.png", "", nonce)
+	s.mustTx(t, func() error {
+		return Mrc400Create(s, owner.Address, "Test project", "https://example.com", "https://example.com/p.png",
+			coin, "art", "project for the test", "https://example.com/item", "https://example.com/item.png", "",
+			sig, nonce, nil)
+	})
+	return s.lastKey(t, "MRC400_")
+}
+
+func TestMrc401CreateBuyMelt(t *testing.T) {
+	f := newDexFixture(t)
+	s, alice, bob := f.s, f.alice, f.bob
+	mrc400id := createMRC400(t, s, alice, f.coin)
+
+	// 2 items, initial reserve 100 coin, melting fee 10%
+	items := []TMRC401job{
+		{ItemID: "ITEM000000000000000000000000000000000001", GroupID: "g1", InititalReserve: "100", InititalToken: f.coin, MeltingFee: "10", Transferable: "Permanent", SellFee: "0"},
+		{ItemID: "ITEM000000000000000000000000000000000002", GroupID: "g1", InititalReserve: "100", InititalToken: f.coin, MeltingFee: "10", Transferable: "Permanent", SellFee: "0"},
+	}
+	itemData := util.JSONEncode(items)
+	nonce := alice.nonce(t, s)
+	s.mustTx(t, func() error {
+		return Mrc401Create(s, mrc400id, itemData, alice.sign(t, s, "mrc401create", mrc400id, itemData, nonce), nonce, nil)
+	})
+	mrc401id := mrc400id + "_" + items[0].ItemID
+	assertBalance(t, s, alice, f.coin, "979800")
+	if s.lastEvent(t, "mrc401_create").Key == "" {
+		t.Error("mrc401_create event without key")
+	}
+
+	// same item id again
+	nonce = alice.nonce(t, s)
+	assertError(t, s.tx(func() error {
+		return Mrc401Create(s, mrc400id, itemData, alice.sign(t, s, "mrc401create", mrc400id, itemData, nonce), nonce, nil)
+	}), "8600")
+
+	// alice sell, bob buy
+	sellData := util.JSONEncode([]TMRC401Sell{{ItemID: mrc401id, SellPrice: "500", SellToken: f.coin}})
+	nonce = alice.nonce(t, s)
+	s.mustTx(t, func() error {
+		return Mrc401Sell(s, alice.Address, mrc400id, sellData, alice.sign(t, s, "mrc401sell", alice.Address, sellData, nonce), nonce, nil)
+	})
+	nonce = bob.nonce(t, s)
+	s.mustTx(t, func() error {
+		return Mrc401Buy(s, bob.Address, mrc401id, bob.sign(t, s, "mrc401buy", mrc401id, nonce), nonce, nil)
+	})
+	assertBalance(t, s, bob, f.coin, "9500")
+	assertBalance(t, s, alice, f.coin, "980300")
+
+	item, _, err := GetMRC401(s, mrc401id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if item.Owner != bob.Address || item.SellDate != 0 || item.LastTradeType != "Sell" {
+		t.Errorf("item after buy : owner %s, sell date %d, last trade %s", item.Owner, item.SellDate, item.LastTradeType)
+	}
+
+	// bob melt, 10% of the initial reserve to the project owner
+	nonce = bob.nonce(t, s)
+	s.mustTx(t, func() error {
+		return Mrc401Melt(s, mrc401id, bob.sign(t, s, "mrc401melt", mrc401id, nonce), nonce, nil)
+	})
+	assertBalance(t, s, bob, f.coin, "9590")
+	assertBalance(t, s, alice, f.coin, "980310")
+	if item, _, _ = GetMRC401(s, mrc401id); item.Owner != "MELTED" {
+		t.Errorf("item owner after melt : %s", item.Owner)
+	}
+
+	nonce = bob.nonce(t, s)
+	assertError(t, s.tx(func() error {
+		return Mrc401Melt(s, mrc401id, bob.sign(t, s, "mrc401melt", mrc401id, nonce), nonce, nil)
+	}), "3004")
+}
@@ -3,16 +3,15 @@
 package metacoin
 
 import (
-	"errors"
 	"fmt"
 	"strings"
-	"time"
 
 	"encoding/json"
 
 	"github.com/hyperledger/fabric-chaincode-go/shim"
 	"github.com/shopspring/decimal"
 
+	"inblock/metacoin/mcerr"
 	"inblock/metacoin/mtc"
 	"inblock/metacoin/util"
 )
@@ -115,15 +114,15 @@ func GetMRC402(stub shim.ChaincodeStubInterface, mrc402id string) (TMRC402, []by
 	var mrc402 TMRC402
 
 	if strings.Index(mrc402id, "MRC402_") != 0 || len(mrc402id) != 40 {
-		return mrc402, nil, errors.New("6102,invalid MRC402 ID")
+		return mrc402, nil, mcerr.New(mcerr.InvalidDataAddress, "invalid MRC402 ID")
 	}
 
 	byte_data, err = stub.GetState(mrc402id)
 	if err != nil {
-		return mrc402, nil, errors.New("8110,Hyperledger internal error - " + err.Error())
+		return mrc402, nil, mcerr.Wrap(mcerr.LedgerReadData, "Hyperledger internal error", err)
 	}
 	if byte_data == nil {
-		return mrc402, nil, errors.New("6004,MRC402 [" + mrc402id + "] not exist")
+		return mrc402, nil, mcerr.New(mcerr.NotFound, "MRC402 ["+mrc402id+"] not exist")
 	}
 	if err = json.Unmarshal(byte_data, &mrc402); err != nil {
 		return mrc402, nil, err
@@ -142,22 +141,23 @@ func setMRC402(stub shim.ChaincodeStubInterface, MRC402ItemData TMRC402, jobType
 	var byte_data []byte
 
 	if strings.Index(MRC402ItemData.Id, "MRC402_") != 0 || len(MRC402ItemData.Id) != 40 {
-		return errors.New("6102,invalid MRC402 data address")
+		return mcerr.New(mcerr.InvalidDataAddress, "invalid MRC402 data address")
 	}
 
 	MRC402ItemData.JobType = jobType
-	MRC402ItemData.JobDate = time.Now().Unix()
+	MRC402ItemData.JobDate = GetTxTime(stub)
 	if byte_data, err = json.Marshal(jobArgs); err == nil {
 		MRC402ItemData.JobArgs = string(byte_data)
 	}
 
 	if byte_data, err = json.Marshal(MRC402ItemData); err != nil {
-		return errors.New("3209,Invalid MRC402ItemData data format")
+		return mcerr.New(mcerr.InvalidItemData, "Invalid MRC402ItemData data format")
 	}
 
 	if err := stub.PutState(MRC402ItemData.Id, byte_data); err != nil {
-		return errors.New("8600,Mrc402Set stub.PutState [" + MRC402ItemData.Id + "] Error " + err.Error())
+		return mcerr.New(mcerr.LedgerWrite, "Mrc402Set stub.PutState ["+MRC402ItemData.Id+"] Error "+err.Error())
 	}
+	AddEvent(stub, TEvent{Type: jobType, Key: MRC402ItemData.Id, Args: jobArgs})
 	return nil
 }
 
@@ -173,15 +173,15 @@ func GetDEX402(stub shim.ChaincodeStubInterface, dexid string) (TMRC402DEX, []by
 	var mrc402dex TMRC402DEX
 
 	if strings.Index(dexid, "DEX402_") != 0 || len(dexid) != 40 {
-		return mrc402dex, nil, errors.New("6102,invalid DEX ID")
+		return mrc402dex, nil, mcerr.New(mcerr.InvalidDataAddress, "invalid DEX ID")
 	}
 
 	byte_data, err = stub.GetState(dexid)
 	if err != nil {
-		return mrc402dex, nil, errors.New("8110,Hyperledger internal error - " + err.Error())
+		return mrc402dex, nil, mcerr.Wrap(mcerr.LedgerReadData, "Hyperledger internal error", err)
 	}
 	if byte_data == nil {
-		return mrc402dex, nil, errors.New("6004,MRC402 [" + dexid + "] not exist")
+		return mrc402dex, nil, mcerr.New(mcerr.NotFound, "MRC402 ["+dexid+"] not exist")
 	}
 	if err = json.Unmarshal(byte_data, &mrc402dex); err != nil {
 		return mrc402dex, nil, err
@@ -195,21 +195,22 @@ func GetDEX402(stub shim.ChaincodeStubInterface, dexid string) (TMRC402DEX, []by
 //
 //		err := setDEX402(stub, mtc.MRC402DEX, "MRC402Dex ITEM ID", "jobtype", arguments)
 //
-func setDEX402(stub shim.ChaincodeStubInterface, MRC402DexItem TMRC402DEX, jobType string, jobArgs []string) error {
+func setDEX402(stub shim.ChaincodeStubInterface, MRC402DexItem TMRC402DEX, jobType string, jobArgs []string, payment ...mtc.TDexPaymentInfo) error {
 	var err error
 	var byte_data []byte
 
 	if strings.Index(MRC402DexItem.Id, "DEX402_") != 0 || len(MRC402DexItem.Id) != 40 {
-		return errors.New("6102,invalid DEX402 data address")
+		return mcerr.New(mcerr.InvalidDataAddress, "invalid DEX402 data address")
 	}
 
 	MRC402DexItem.JobType = jobType
-	MRC402DexItem.JobDate = time.Now().Unix()
+	MRC402DexItem.JobDate = GetTxTime(stub)
 	if byte_data, err = json.Marshal(jobArgs); err == nil {
 		MRC402DexItem.JobArgs = string(byte_data)
 	}
 
-	if MRC402DexItem.RegDate == 0 {
+	isNew := MRC402DexItem.RegDate == 0
+	if isNew {
 		MRC402DexItem.RegDate = MRC402DexItem.JobDate
 	}
 
@@ -237,12 +238,16 @@ func setDEX402(stub shim.ChaincodeStubInterface, MRC402DexItem TMRC402DEX, jobTy
 	}
 
 	if byte_data, err = json.Marshal(MRC402DexItem); err != nil {
-		return errors.New("3209,Invalid MRC402DexItem data format")
+		return mcerr.New(mcerr.InvalidItemData, "Invalid MRC402DexItem data format")
 	}
 
 	if err := stub.PutState(MRC402DexItem.Id, byte_data); err != nil {
-		return errors.New("8600,dex402set stub.PutState [" + MRC402DexItem.Id + "] Error " + err.Error())
+		return mcerr.New(mcerr.LedgerWrite, "dex402set stub.PutState ["+MRC402DexItem.Id+"] Error "+err.Error())
 	}
+	if err = indexDEX402(stub, MRC402DexItem, isNew); err != nil {
+		return err
+	}
+	AddEvent(stub, TEvent{Type: jobType, Key: MRC402DexItem.Id, Args: jobArgs, Payment: payment})
 	return nil
 }
 
@@ -262,12 +267,15 @@ func mrc402Add(stub shim.ChaincodeStubInterface, wallet *mtc.TWallet, mrc402id s
 	var exists bool
 
 	if addAmount, err = util.ParsePositive(amount); err != nil {
-		return errors.New("1102," + amount + " is not positive integer")
+		return mcerr.New(mcerr.NonceError, amount+" is not positive integer")
 	}
 
 	if _, _, err = GetMRC402(stub, mrc402id); err != nil {
 		return err
 	}
+	if err = loadAsset(stub, wallet, AssetMRC402+mrc402id); err != nil {
+		return err
+	}
 	if wallet.MRC402 == nil {
 		wallet.MRC402 = make(map[string]mtc.NFTBalance)
 	}
@@ -313,24 +321,27 @@ func mrc402Subtract(stub shim.ChaincodeStubInterface,
 	var exists bool
 
 	if subtractAmount, err = util.ParsePositive(amount); err != nil {
-		return errors.New("1103," + amount + " is not positive integer")
+		return mcerr.New(mcerr.InvalidPrice, amount+" is not positive integer")
 	}
 
 	if _, _, err = GetMRC402(stub, mrc402id); err != nil {
 		return err
 	}
+	if err = loadAsset(stub, wallet, AssetMRC402+mrc402id); err != nil {
+		return err
+	}
 
 	if wallet.MRC402 == nil {
-		return errors.New("5000,Not enough balance")
+		return mcerr.New(mcerr.NotEnoughBalance, "Not enough balance")
 	}
 	if balance, exists = wallet.MRC402[mrc402id]; !exists {
-		return errors.New("5000,Not enough balance")
+		return mcerr.New(mcerr.NotEnoughBalance, "Not enough balance")
 	}
 
 	toCoin, _ = decimal.NewFromString(balance.Balance)
 	toCoin = toCoin.Sub(subtractAmount).Truncate(0)
 	if toCoin.IsNegative() {
-		return errors.New("5000,Not enough balance")
+		return mcerr.New(mcerr.NotEnoughBalance, "Not enough balance")
 	}
 	balance.Balance = toCoin.String()
 	if SubtractType == MRC402MT_Sell {
@@ -364,18 +375,21 @@ func mrc402SubtractSubBalance(stub shim.ChaincodeStubInterface, wallet *mtc.TWal
 	var exists bool
 
 	if subtractAmount, err = util.ParsePositive(amount); err != nil {
-		return errors.New("1104," + amount + " is not positive integer")
+		return mcerr.New(mcerr.InvalidSubAmount, amount+" is not positive integer")
 	}
 
 	if _, _, err = GetMRC402(stub, mrc402id); err != nil {
 		return err
 	}
+	if err = loadAsset(stub, wallet, AssetMRC402+mrc402id); err != nil {
+		return err
+	}
 
 	if wallet.MRC402 == nil {
-		return errors.New("5000,Not enough balance")
+		return mcerr.New(mcerr.NotEnoughBalance, "Not enough balance")
 	}
 	if balance, exists = wallet.MRC402[mrc402id]; !exists {
-		return errors.New("5000,Not enough balance")
+		return mcerr.New(mcerr.NotEnoughBalance, "Not enough balance")
 	}
 
 	if SubtractType == MRC402MT_Sell {
@@ -400,23 +414,29 @@ func mrc402Move(stub shim.ChaincodeStubInterface, fromwallet *mtc.TWallet, towal
 	var exists bool
 
 	if moveAmount, err = util.ParsePositive(amount); err != nil {
-		return errors.New("1105," + amount + " is not positive integer")
+		return mcerr.New(mcerr.InvalidQtt, amount+" is not positive integer")
 	}
 
 	if _, _, err = GetMRC402(stub, mrc402id); err != nil {
 		return err
 	}
+	if err = loadAsset(stub, fromwallet, AssetMRC402+mrc402id); err != nil {
+		return err
+	}
+	if err = loadAsset(stub, towallet, AssetMRC402+mrc402id); err != nil {
+		return err
+	}
 	if fromwallet.MRC402 == nil {
-		return errors.New("5000,Not enough balance")
+		return mcerr.New(mcerr.NotEnoughBalance, "Not enough balance")
 	}
 	if balance, exists = fromwallet.MRC402[mrc402id]; !exists {
-		return errors.New("5000,Not enough balance")
+		return mcerr.New(mcerr.NotEnoughBalance, "Not enough balance")
 	}
 
 	balanceCheck, _ = decimal.NewFromString(balance.Balance)
 	balanceCheck = balanceCheck.Sub(moveAmount).Truncate(0)
 	if balanceCheck.IsNegative() {
-		return errors.New("5000,Not enough balance")
+		return mcerr.New(mcerr.NotEnoughBalance, "Not enough balance")
 	}
 	balance.Balance = balanceCheck.String()
 
@@ -461,10 +481,10 @@ func Mrc402Create(stub shim.ChaincodeStubInterface, args []string) error {
 	var argdat []byte
 
 	if len(args) < 18 {
-		return errors.New("1000,mrc402create operation must include four arguments : " +
-			"creator, name, creatorcommission, totalsupply, decimal, " +
-			"url, imageurl, shareholder, initialreserve, expiredate, " +
-			"data, information, socialmedia, copyright_registration_country, copyright_registrar, " +
+		return mcerr.New(mcerr.InvalidArguments, "mrc402create operation must include four arguments : "+
+			"creator, name, creatorcommission, totalsupply, decimal, "+
+			"url, imageurl, shareholder, initialreserve, expiredate, "+
+			"data, information, socialmedia, copyright_registration_country, copyright_registrar, "+
 			"copyright_registration_number, signature, nonce")
 	}
 
@@ -472,84 +492,84 @@ func Mrc402Create(stub shim.ChaincodeStubInterface, args []string) error {
 		MeltedAmount: "0",
 		JobType:      "",
 		JobArgs:      "",
-		JobDate:      time.Now().Unix(),
+		JobDate:      GetTxTime(stub),
 	}
 
 	// 0 Creator
 	if err = util.DataAssign(args[0], &MRC402.Creator, "string", 1, 128, true); err != nil {
-		return errors.New("3005,Name must be 1 to 128 characters long")
+		return mcerr.New(mcerr.InvalidData, "Name must be 1 to 128 characters long")
 	}
 	if MRC402Creator, err = GetAddressInfo(stub, MRC402.Creator); err != nil {
 		return err
 	}
 	// sign check
-	if err = NonceCheck(&MRC402Creator, args[17],
-		strings.Join([]string{args[0], args[1], args[2], args[3], args[4],
+	if err = NonceCheck(stub, &MRC402Creator, args[17], "mrc402create",
+		[]string{args[0], args[1], args[2], args[3], args[4],
 			args[5], args[6], args[7], args[8], args[9],
 			args[10], args[11], args[12], args[13], args[14],
-			args[15], args[17]}, "|"),
+			args[15], args[17]},
 		args[16]); err != nil {
 		return err
 	}
 
 	// 1 Name
 	if err = util.DataAssign(args[1], &MRC402.Name, "string", 1, 128, false); err != nil {
-		return errors.New("3005,Name value error : " + err.Error())
+		return mcerr.New(mcerr.InvalidData, "Name value error : "+err.Error())
 	}
 
 	// 2 CreatorCommission
 	if err = util.NumericDataCheck(args[2], &MRC402.CreatorCommission, "0", "10.0", 2, false); err != nil {
-		return errors.New("3005,Creatorcommission value error : " + err.Error())
+		return mcerr.New(mcerr.InvalidData, "Creatorcommission value error : "+err.Error())
 	}
 
 	// 3 TotalSupply
 	if err = util.NumericDataCheck(args[3], &MRC402.TotalSupply, "1", "99999999", 0, false); err != nil {
-		return errors.New("3005,TotalSupply value error : " + err.Error())
+		return mcerr.New(mcerr.InvalidData, "TotalSupply value error : "+err.Error())
 	}
 	if totalSupply, err = util.ParsePositive(MRC402.TotalSupply); err != nil {
-		return errors.New("3005,TotalSupply value error : " + err.Error())
+		return mcerr.New(mcerr.InvalidData, "TotalSupply value error : "+err.Error())
 	}
 
 	// 4 Decimal
 	if args[4] != "0" {
-		return errors.New("3005,Decimal is must be zero")
+		return mcerr.New(mcerr.InvalidData, "Decimal is must be zero")
 	}
 	if MRC402.Decimal, err = util.Strtoint(args[4]); err != nil {
-		return errors.New("3005,Decimal value error : " + err.Error())
+		return mcerr.New(mcerr.InvalidData, "Decimal value error : "+err.Error())
 	}
 	if MRC402.Decimal < 0 {
-		return errors.New("3005,The decimal number must be bigger then 0")
+		return mcerr.New(mcerr.InvalidData, "The decimal number must be bigger then 0")
 	}
 
 	if MRC402.Decimal > 8 {
-		return errors.New("3005,The decimal number must be less than 18")
+		return mcerr.New(mcerr.InvalidData, "The decimal number must be less than 18")
 	}
 
 	// 5 URL
 	if err = util.DataAssign(args[5], &MRC402.URL, "url", 1, 255, false); err != nil {
-		return errors.New("3005,Url value error : " + err.Error())
+		return mcerr.New(mcerr.InvalidData, "Url value error : "+err.Error())
 	}
 
 	// 6 ImageURL
 	if err = util.DataAssign(args[6], &MRC402.ImageURL, "url", 1, 255, false); err != nil {
-		return errors.New("3005,ImageUril value error : " + err.Error())
+		return mcerr.New(mcerr.InvalidData, "ImageUril value error : "+err.Error())
 	}
 
 	// 7 shareholder
 	if args[7] != "" {
 		if err = json.Unmarshal([]byte(args[7]), &MRC402.ShareHolder); err != nil {
-			return errors.New("3005,shareholder Data value error : " + err.Error())
+			return mcerr.New(mcerr.InvalidData, "shareholder Data value error : "+err.Error())
 		}
 		if len(MRC402.ShareHolder) > 5 {
-			return errors.New("3002,There must be 5 or fewer copyrighter")
+			return mcerr.New(mcerr.InvalidTo, "There must be 5 or fewer copyrighter")
 		}
 		index := 0
 		for shareholder, commission := range MRC402.ShareHolder {
 			if _, err = GetAddressInfo(stub, shareholder); err != nil {
-				return errors.New("3005," + util.GetOrdNumber(index) + " shareholder item error : " + err.Error())
+				return mcerr.New(mcerr.InvalidData, util.GetOrdNumber(index)+" shareholder item error : "+err.Error())
 			}
 			if err = util.NumericDataCheck(commission, &buf, "0", "10.0", 2, false); err != nil {
-				return errors.New("3005," + util.GetOrdNumber(index) + " shareholder item commission error : " + err.Error())
+				return mcerr.New(mcerr.InvalidData, util.GetOrdNumber(index)+" shareholder item commission error : "+err.Error())
 			}
 			index++
 		}
@@ -558,19 +578,19 @@ func Mrc402Create(stub shim.ChaincodeStubInterface, args []string) error {
 	// 8 InitialReserve
 	if args[8] != "" {
 		if err = json.Unmarshal([]byte(args[8]), &MRC402.InitialReserve); err != nil {
-			return errors.New("3005,InitialReserve Data value error : " + err.Error())
+			return mcerr.New(mcerr.InvalidData, "InitialReserve Data value error : "+err.Error())
 		}
 		if len(MRC402.InitialReserve) > 5 {
-			return errors.New("3002,There must be 5 or fewer InitialReserve")
+			return mcerr.New(mcerr.InvalidTo, "There must be 5 or fewer InitialReserve")
 		}
 		index := 0
 		for initTokenID, initTokenAmount := range MRC402.InitialReserve {
 			if _, _, err = GetMRC010(stub, initTokenID); err != nil {
-				return errors.New("3005," + util.GetOrdNumber(index) + " InitialReserve item token error : " + err.Error())
+				return mcerr.New(mcerr.InvalidData, util.GetOrdNumber(index)+" InitialReserve item token error : "+err.Error())
 			}
 
 			if reserveAmount, err = util.ParsePositive(initTokenAmount); err != nil {
-				return errors.New("3005," + util.GetOrdNumber(index) + " InitialReserve item amount error : " + err.Error())
+				return mcerr.New(mcerr.InvalidData, util.GetOrdNumber(index)+" InitialReserve item amount error : "+err.Error())
 			}
 
 			reserveAmount = totalSupply.Mul(reserveAmount)
@@ -583,27 +603,27 @@ func Mrc402Create(stub shim.ChaincodeStubInterface, args []string) error {
 
 	// 9 ExpireDate
 	if MRC402.ExpireDate, err = util.Strtoint64(args[9]); err != nil {
-		return errors.New("3005,ExpireDate value error : " + err.Error())
+		return mcerr.New(mcerr.InvalidData, "ExpireDate value error : "+err.Error())
 	}
 
 	// 10 Data
 	if err = util.DataAssign(args[10], &MRC402.Data, "string", 0, 40960, true); err != nil {
-		return errors.New("3005,Data value error : " + err.Error())
+		return mcerr.New(mcerr.InvalidData, "Data value error : "+err.Error())
 	}
 
 	// 11 Info
 	if err = util.DataAssign(args[11], &MRC402.Info, "string", 0, 40960, true); err != nil {
-		return errors.New("3005,Data value error : " + err.Error())
+		return mcerr.New(mcerr.InvalidData, "Data value error : "+err.Error())
 	}
 
 	// 12 SocialMedia
 	if err = util.DataAssign(args[12], &MRC402.SocialMedia, "string", 0, 40960, true); err != nil {
-		return errors.New("3005,Data value error : " + err.Error())
+		return mcerr.New(mcerr.InvalidData, "Data value error : "+err.Error())
 	}
 
 	// 13 Coyright Registration country
 	if err = util.DataAssign(args[13], &MRC402.CopyrightRegCountry, "string", 0, 2, true); err != nil {
-		return errors.New("3005,Data value error : " + err.Error())
+		return mcerr.New(mcerr.InvalidData, "Data value error : "+err.Error())
 	}
 
 	if err = util.ISO3166Check(MRC402.CopyrightRegCountry); err != nil {
@@ -612,12 +632,12 @@ func Mrc402Create(stub shim.ChaincodeStubInterface, args []string) error {
 
 	// 14 Copyright Registrar
 	if err = util.DataAssign(args[14], &MRC402.CopyrightRegistrar, "string", 0, 128, true); err != nil {
-		return errors.New("3005,Data value error : " + err.Error())
+		return mcerr.New(mcerr.InvalidData, "Data value error : "+err.Error())
 	}
 
 	// 15 Copyright Registration number
 	if err = util.DataAssign(args[15], &MRC402.CopyrightRegNumber, "string", 0, 64, true); err != nil {
-		return errors.New("3005,Data value error : " + err.Error())
+		return mcerr.New(mcerr.InvalidData, "Data value error : "+err.Error())
 	}
 
 	// generate MRC402 ID
@@ -627,7 +647,7 @@ func Mrc402Create(stub shim.ChaincodeStubInterface, args []string) error {
 		MRC402.Id = fmt.Sprintf("%39s%1d", temp, i)
 		argdat, err = stub.GetState(MRC402.Id)
 		if err != nil {
-			return errors.New("8600,Hyperledger internal error - " + err.Error())
+			return mcerr.Wrap(mcerr.LedgerWrite, "Hyperledger internal error", err)
 		}
 
 		if argdat != nil { // key already exists
@@ -638,7 +658,7 @@ func Mrc402Create(stub shim.ChaincodeStubInterface, args []string) error {
 		}
 	}
 	if !isSuccess {
-		return errors.New("3005,Data generate error, retry again")
+		return mcerr.New(mcerr.InvalidData, "Data generate error, retry again")
 	}
 
 	setMRC402(stub, MRC402, "mrc402_create", []string{MRC402.Id,
@@ -679,8 +699,8 @@ func Mrc402Update(stub shim.ChaincodeStubInterface, args []string) error {
 	var isUpdate bool
 
 	if len(args) < 7 {
-		return errors.New("1000,mrc402update operation must include four arguments : " +
-			"MRC402ID, Url, Data, Info, SocialMedia, " +
+		return mcerr.New(mcerr.InvalidArguments, "mrc402update operation must include four arguments : "+
+			"MRC402ID, Url, Data, Info, SocialMedia, "+
 			"copyright_registration_country, copyright_registrar, copyright_registration_number, Signature, Nonce")
 	}
 
@@ -692,8 +712,8 @@ func Mrc402Update(stub shim.ChaincodeStubInterface, args []string) error {
 		return err
 	}
 
-	if err = NonceCheck(&creatorWallet, args[9],
-		strings.Join([]string{args[0], args[1], args[2], args[3], args[4], args[5], args[6], args[7], args[9]}, "|"),
+	if err = NonceCheck(stub, &creatorWallet, args[9], "mrc402update",
+		[]string{args[0], args[1], args[2], args[3], args[4], args[5], args[6], args[7], args[9]},
 		args[8]); err != nil {
 		return err
 	}
@@ -717,13 +737,13 @@ func Mrc402Update(stub shim.ChaincodeStubInterface, args []string) error {
 		if len(MRC402.CopyrightRegCountry) == 0 {
 			isUpdate = true
 			if err = util.DataAssign(args[5], &MRC402.CopyrightRegCountry, "string", 0, 2, true); err != nil {
-				return errors.New("3005,Data value error : " + err.Error())
+				return mcerr.New(mcerr.InvalidData, "Data value error : "+err.Error())
 			}
 			if err = util.ISO3166Check(MRC402.CopyrightRegCountry); err != nil {
 				return err
 			}
 		} else {
-			return errors.New("3005,Copyright registration country cannot be changed")
+			return mcerr.New(mcerr.InvalidData, "Copyright registration country cannot be changed")
 		}
 	}
 
@@ -732,10 +752,10 @@ func Mrc402Update(stub shim.ChaincodeStubInterface, args []string) error {
 		if len(MRC402.CopyrightRegistrar) == 0 {
 			isUpdate = true
 			if err = util.DataAssign(args[6], &MRC402.CopyrightRegistrar, "string", 0, 128, true); err != nil {
-				return errors.New("3005,Data value error : " + err.Error())
+				return mcerr.New(mcerr.InvalidData, "Data value error : "+err.Error())
 			}
 		} else {
-			return errors.New("3005,Copyright registrar cannot be changed")
+			return mcerr.New(mcerr.InvalidData, "Copyright registrar cannot be changed")
 		}
 	}
 
@@ -744,35 +764,35 @@ func Mrc402Update(stub shim.ChaincodeStubInterface, args []string) error {
 		if len(MRC402.CopyrightRegNumber) == 0 {
 			isUpdate = true
 			if err = util.DataAssign(args[7], &MRC402.CopyrightRegNumber, "string", 0, 64, true); err != nil {
-				return errors.New("3005,Data value error : " + err.Error())
+				return mcerr.New(mcerr.InvalidData, "Data value error : "+err.Error())
 			}
 		} else {
-			return errors.New("3005,Copyright registration number cannot be changed")
+			return mcerr.New(mcerr.InvalidData, "Copyright registration number cannot be changed")
 		}
 	}
 
 	if !isUpdate {
-		return errors.New("4900,No data change")
+		return mcerr.New(mcerr.NoDataChange, "No data change")
 	}
 
 	// 1 URL
 	if err = util.DataAssign(args[1], &MRC402.URL, "url", 1, 255, true); err != nil {
-		return errors.New("3005,Url value error : " + err.Error())
+		return mcerr.New(mcerr.InvalidData, "Url value error : "+err.Error())
 	}
 
 	// 2 Data
 	if err = util.DataAssign(args[2], &MRC402.Data, "string", 0, 40960, true); err != nil {
-		return errors.New("3005,Data value error : " + err.Error())
+		return mcerr.New(mcerr.InvalidData, "Data value error : "+err.Error())
 	}
 
 	// 3 Info
 	if err = util.DataAssign(args[3], &MRC402.Info, "string", 0, 40960, true); err != nil {
-		return errors.New("3005,Data value error : " + err.Error())
+		return mcerr.New(mcerr.InvalidData, "Data value error : "+err.Error())
 	}
 
 	// 4 SocialMedia
 	if err = util.DataAssign(args[4], &MRC402.SocialMedia, "string", 0, 40960, true); err != nil {
-		return errors.New("3005,Data value error : " + err.Error())
+		return mcerr.New(mcerr.InvalidData, "Data value error : "+err.Error())
 	}
 
 	params := []string{MRC402.Id, MRC402.Creator, args[1], args[2], args[3], args[4], args[5], args[6], args[7], args[8], args[9]}
@@ -799,7 +819,7 @@ func Mrc402Burn(stub shim.ChaincodeStubInterface, args []string) error {
 	var buf string
 
 	if len(args) < 5 {
-		return errors.New("1000,mrc402burning operation must include four arguments : " +
+		return mcerr.New(mcerr.InvalidArguments, "mrc402burning operation must include four arguments : "+
 			"MRC402ID, amount, memo, Signature, Nonce")
 	}
 
@@ -808,7 +828,7 @@ func Mrc402Burn(stub shim.ChaincodeStubInterface, args []string) error {
 	}
 
 	if BurnAmount, err = util.ParsePositive(args[1]); err != nil {
-		return errors.New("1206,The amount must be an integer")
+		return mcerr.New(mcerr.InvalidBurnAmount, "The amount must be an integer")
 	}
 
 	if MRC402Creator, err = GetAddressInfo(stub, mrc402.Creator); err != nil {
@@ -816,11 +836,11 @@ func Mrc402Burn(stub shim.ChaincodeStubInterface, args []string) error {
 	}
 
 	if err = util.DataAssign(args[2], &buf, "string", 0, 1024, true); err != nil {
-		return errors.New("3005,Data value error : " + err.Error())
+		return mcerr.New(mcerr.InvalidData, "Data value error : "+err.Error())
 	}
 
-	if err = NonceCheck(&MRC402Creator, args[4],
-		strings.Join([]string{args[0], args[1], args[2], args[4]}, "|"),
+	if err = NonceCheck(stub, &MRC402Creator, args[4], "mrc402burn",
+		[]string{args[0], args[1], args[2], args[4]},
 		args[3]); err != nil {
 		return err
 	}
@@ -834,18 +854,18 @@ func Mrc402Burn(stub shim.ChaincodeStubInterface, args []string) error {
 	BurnableAmount = TotalSupply.Sub(MeltedAmount) // total supply - melted amount = BurnableAmount
 	TotalSupply = BurnableAmount.Sub(BurnAmount)   // BurnableAmount - burn amount = new total supply
 	if err = util.NumericDataCheck(TotalSupply.String(), &mrc402.TotalSupply, "0", "99999999", 0, false); err != nil {
-		return errors.New("3005,The maximum amount that can be burn is " + BurnableAmount.String())
+		return mcerr.New(mcerr.InvalidData, "The maximum amount that can be burn is "+BurnableAmount.String())
 	}
 
 	// 8 InitialReserve
 	index := 0
 	for initTokenID, initTokenAmount := range mrc402.InitialReserve {
 		if _, _, err = GetMRC010(stub, initTokenID); err != nil {
-			return errors.New("3005," + util.GetOrdNumber(index) + " InitialReserve item token error : " + err.Error())
+			return mcerr.New(mcerr.InvalidData, util.GetOrdNumber(index)+" InitialReserve item token error : "+err.Error())
 		}
 
 		if reserveAmount, err = util.ParsePositive(initTokenAmount); err != nil {
-			return errors.New("3005," + util.GetOrdNumber(index) + " InitialReserve item amount error : " + err.Error())
+			return mcerr.New(mcerr.InvalidData, util.GetOrdNumber(index)+" InitialReserve item amount error : "+err.Error())
 		}
 
 		reserveAmount = BurnAmount.Mul(reserveAmount)
@@ -880,7 +900,7 @@ func Mrc402Mint(stub shim.ChaincodeStubInterface, args []string) error {
 	var buf string
 
 	if len(args) < 5 {
-		return errors.New("1000,mrc402burning operation must include four arguments : " +
+		return mcerr.New(mcerr.InvalidArguments, "mrc402burning operation must include four arguments : "+
 			"MRC402ID, amount, memo, Signature, Nonce")
 	}
 
@@ -889,7 +909,7 @@ func Mrc402Mint(stub shim.ChaincodeStubInterface, args []string) error {
 	}
 
 	if MintAmount, err = util.ParsePositive(args[1]); err != nil {
-		return errors.New("1206,The amount must be an integer")
+		return mcerr.New(mcerr.InvalidBurnAmount, "The amount must be an integer")
 	}
 
 	if MRC402Creator, err = GetAddressInfo(stub, MRC402.Creator); err != nil {
@@ -897,11 +917,11 @@ func Mrc402Mint(stub shim.ChaincodeStubInterface, args []string) error {
 	}
 
 	if err = util.DataAssign(args[2], &buf, "string", 0, 1024, true); err != nil {
-		return errors.New("3005,Data value error : " + err.Error())
+		return mcerr.New(mcerr.InvalidData, "Data value error : "+err.Error())
 	}
 
-	if err = NonceCheck(&MRC402Creator, args[4],
-		strings.Join([]string{args[0], args[1], args[2], args[4]}, "|"),
+	if err = NonceCheck(stub, &MRC402Creator, args[4], "mrc402mint",
+		[]string{args[0], args[1], args[2], args[4]},
 		args[3]); err != nil {
 		return err
 	}
@@ -913,17 +933,17 @@ func Mrc402Mint(stub shim.ChaincodeStubInterface, args []string) error {
 	TotalSupply, _ = decimal.NewFromString(MRC402.TotalSupply)
 	TotalSupply = TotalSupply.Add(MintAmount)
 	if err = util.NumericDataCheck(TotalSupply.String(), &MRC402.TotalSupply, "1", "99999999", 0, false); err != nil {
-		return errors.New("3005,After the mint, the value of TotalSupply is less than 99999999")
+		return mcerr.New(mcerr.InvalidData, "After the mint, the value of TotalSupply is less than 99999999")
 	}
 
 	index := 0
 	for initTokenID, initTokenAmount := range MRC402.InitialReserve {
 		if _, _, err = GetMRC010(stub, initTokenID); err != nil {
-			return errors.New("3005," + util.GetOrdNumber(index) + " InitialReserve item token error : " + err.Error())
+			return mcerr.New(mcerr.InvalidData, util.GetOrdNumber(index)+" InitialReserve item token error : "+err.Error())
 		}
 
 		if reserveAmount, err = util.ParsePositive(initTokenAmount); err != nil {
-			return errors.New("3005," + util.GetOrdNumber(index) + " InitialReserve item amount error : " + err.Error())
+			return mcerr.New(mcerr.InvalidData, util.GetOrdNumber(index)+" InitialReserve item amount error : "+err.Error())
 		}
 
 		reserveAmount = MintAmount.Mul(reserveAmount)
@@ -956,33 +976,33 @@ func Mrc402Transfer(stub shim.ChaincodeStubInterface, args []string) error {
 	var TransferAmount decimal.Decimal
 
 	if len(args) < 8 {
-		return errors.New("1000,mrc402burning operation must include four arguments : " +
+		return mcerr.New(mcerr.InvalidArguments, "mrc402burning operation must include four arguments : "+
 			"fromAddr, toAddr, amount, MRC402ID, tag, memo, signature, Nonce")
 
 	}
 
 	if fromWallet, err = GetAddressInfo(stub, args[0]); err != nil {
-		return errors.New("3001,Invalid from address")
+		return mcerr.New(mcerr.InvalidFrom, "Invalid from address")
 	}
 
 	if toWallet, err = GetAddressInfo(stub, args[1]); err != nil {
-		return errors.New("3001,Invalid to address")
+		return mcerr.New(mcerr.InvalidFrom, "Invalid to address")
 	}
 
 	if args[0] == args[1] {
-		return errors.New("3201,From address and to address must be different values")
+		return mcerr.New(mcerr.SameAddress, "From address and to address must be different values")
 	}
 
 	if TransferAmount, err = util.ParsePositive(args[2]); err != nil {
-		return errors.New("1206,The amount must be an integer")
+		return mcerr.New(mcerr.InvalidBurnAmount, "The amount must be an integer")
 	}
 
 	if MRC402, _, err = GetMRC402(stub, args[3]); err != nil {
 		return err
 	}
 
-	if err = NonceCheck(&fromWallet, args[7],
-		strings.Join([]string{args[0], args[1], args[2], args[3], args[7]}, "|"),
+	if err = NonceCheck(stub, &fromWallet, args[7], "mrc402transfer",
+		[]string{args[0], args[1], args[2], args[3], args[7]},
 		args[6]); err != nil {
 		return err
 	}
@@ -998,6 +1018,8 @@ func Mrc402Transfer(stub shim.ChaincodeStubInterface, args []string) error {
 	if err = SetAddressInfo(stub, toWallet, "receive_mrc402", args); err != nil {
 		return err
 	}
+	AddEvent(stub, TEvent{Type: "transfer_mrc402", Key: MRC402.Id, Args: args,
+		Payment: []mtc.TDexPaymentInfo{{FromAddr: args[0], ToAddr: args[1], Amount: TransferAmount.String(), TokenID: MRC402.Id, PayType: "transfer_mrc402"}}})
 
 	return nil
 }
@@ -1012,7 +1034,7 @@ func Mrc402Melt(stub shim.ChaincodeStubInterface, args []string) error {
 	var reserveAmount decimal.Decimal
 
 	if len(args) < 5 {
-		return errors.New("1000,mrc402melt operation must include four arguments : " +
+		return mcerr.New(mcerr.InvalidArguments, "mrc402melt operation must include four arguments : "+
 			"mrc402id, address, amount, signature, nonce")
 	}
 
@@ -1021,8 +1043,8 @@ func Mrc402Melt(stub shim.ChaincodeStubInterface, args []string) error {
 		return err
 	}
 
-	if mrc402.ExpireDate != 0 && mrc402.ExpireDate > time.Now().Unix() {
-		return errors.New("3004,It is not a meltable date")
+	if mrc402.ExpireDate != 0 && mrc402.ExpireDate > GetTxTime(stub) {
+		return mcerr.New(mcerr.DexStatus, "It is not a meltable date")
 	}
 
 	// 1 Melter
@@ -1032,11 +1054,11 @@ func Mrc402Melt(stub shim.ChaincodeStubInterface, args []string) error {
 
 	// 2 amount
 	if meltAmount, err = util.ParsePositive(args[2]); err != nil {
-		return errors.New("1106," + args[2] + " is not positive integer")
+		return mcerr.New(mcerr.InvalidMeltAmount, args[2]+" is not positive integer")
 	}
 
-	if err = NonceCheck(&melterWallet, args[4],
-		strings.Join([]string{args[0], args[1], args[2], args[4]}, "|"),
+	if err = NonceCheck(stub, &melterWallet, args[4], "mrc402melt",
+		[]string{args[0], args[1], args[2], args[4]},
 		args[3]); err != nil {
 		return err
 	}
@@ -1053,11 +1075,11 @@ func Mrc402Melt(stub shim.ChaincodeStubInterface, args []string) error {
 	index := 0
 	for initTokenID, initTokenAmount := range mrc402.InitialReserve {
 		if _, _, err = GetMRC010(stub, initTokenID); err != nil {
-			return errors.New("3005," + util.GetOrdNumber(index) + " InitialReserve item token error : " + err.Error())
+			return mcerr.New(mcerr.InvalidData, util.GetOrdNumber(index)+" InitialReserve item token error : "+err.Error())
 		}
 
 		if reserveAmount, err = util.ParsePositive(initTokenAmount); err != nil {
-			return errors.New("3005," + util.GetOrdNumber(index) + " InitialReserve item amount error : " + err.Error())
+			return mcerr.New(mcerr.InvalidData, util.GetOrdNumber(index)+" InitialReserve item amount error : "+err.Error())
 		}
 
 		reserveAmount = meltAmount.Mul(reserveAmount)
@@ -1077,8 +1099,8 @@ func Mrc402Melt(stub shim.ChaincodeStubInterface, args []string) error {
 	return nil
 }
 
-func dex402Status(dex TMRC402DEX) MRC402DexStatus {
-	var now = time.Now().Unix()
+func dex402Status(stub shim.ChaincodeStubInterface, dex TMRC402DEX) MRC402DexStatus {
+	var now = GetTxTime(stub)
 	if dex.CancelDate > 0 {
 		return MRC402DS_CANCLED // Sale or auction canceled
 	}
@@ -1114,9 +1136,9 @@ func Mrc402Sell(stub shim.ChaincodeStubInterface, args []string) error {
 	var argdat []byte
 
 	if len(args) < 11 {
-		return errors.New("1000,mrc402sell operation must include four arguments : " +
-			"seller, amount, mrc402id, sellPrice, selltoken, " +
-			"platformName, platformURL, platformAddress, platformCommission, " +
+		return mcerr.New(mcerr.InvalidArguments, "mrc402sell operation must include four arguments : "+
+			"seller, amount, mrc402id, sellPrice, selltoken, "+
+			"platformName, platformURL, platformAddress, platformCommission, "+
 			"signature, nonce")
 	}
 
@@ -1127,7 +1149,7 @@ func Mrc402Sell(stub shim.ChaincodeStubInterface, args []string) error {
 
 	// 1 amount
 	if sellAmount, err = util.ParsePositive(args[1]); err != nil {
-		return errors.New("1107," + args[1] + " is not positive integer")
+		return mcerr.New(mcerr.InvalidSellAmount, args[1]+" is not positive integer")
 	}
 
 	// 2 mrc402id
@@ -1167,33 +1189,33 @@ func Mrc402Sell(stub shim.ChaincodeStubInterface, args []string) error {
 
 	// 5 platformname
 	if err = util.DataAssign(args[5], &dex.PlatformName, "", 1, 255, true); err != nil {
-		return errors.New("3005,Url value error : " + err.Error())
+		return mcerr.New(mcerr.InvalidData, "Url value error : "+err.Error())
 	}
 
 	// 6 PlatformURL
 	if err = util.DataAssign(args[6], &dex.PlatformURL, "url", 1, 255, true); err != nil {
-		return errors.New("3005,Url value error : " + err.Error())
+		return mcerr.New(mcerr.InvalidData, "Url value error : "+err.Error())
 	}
 
 	// 7 PlatformAddress
 	if err = util.DataAssign(args[7], &dex.PlatformAddress, "address", 40, 40, true); err != nil {
-		return errors.New("3005,Data value error : " + err.Error())
+		return mcerr.New(mcerr.InvalidData, "Data value error : "+err.Error())
 	}
 	if util.IsAddress(dex.PlatformAddress) {
 		if _, err = GetAddressInfo(stub, dex.PlatformAddress); err != nil {
-			return errors.New("3005," + "PlatformAddress not found : " + err.Error())
+			return mcerr.New(mcerr.InvalidData, "PlatformAddress not found : "+err.Error())
 		}
 	}
 
 	// 8 PlatformCommission
 	if err = util.NumericDataCheck(args[8], &dex.PlatformCommission, "0.00", "10.00", 2, true); err != nil {
-		return errors.New("3005,Data value error : " + err.Error())
+		return mcerr.New(mcerr.InvalidData, "Data value error : "+err.Error())
 	}
 
-	if err = NonceCheck(&sellerWallet, args[10],
-		strings.Join([]string{args[0], args[1], args[2], args[3], args[4],
+	if err = NonceCheck(stub, &sellerWallet, args[10], "mrc402sell",
+		[]string{args[0], args[1], args[2], args[3], args[4],
 			args[5], args[6], args[7], args[8],
-			args[10]}, "|"),
+			args[10]},
 		args[9]); err != nil {
 		return err
 	}
@@ -1209,7 +1231,7 @@ func Mrc402Sell(stub shim.ChaincodeStubInterface, args []string) error {
 		dex.Id = fmt.Sprintf("%39s%1d", temp, i)
 		argdat, err = stub.GetState(dex.Id)
 		if err != nil {
-			return errors.New("8600,Hyperledger internal error - " + err.Error())
+			return mcerr.Wrap(mcerr.LedgerWrite, "Hyperledger internal error", err)
 		}
 
 		if argdat != nil { // key already exists
@@ -1220,7 +1242,7 @@ func Mrc402Sell(stub shim.ChaincodeStubInterface, args []string) error {
 		}
 	}
 	if !isSuccess {
-		return errors.New("3005,Data generate error, retry again")
+		return mcerr.New(mcerr.InvalidData, "Data generate error, retry again")
 	}
 
 	params := []string{dex.Id, args[0], args[1], args[2], args[3],
@@ -1243,7 +1265,7 @@ func Mrc402UnSell(stub shim.ChaincodeStubInterface, args []string) error {
 	var dex TMRC402DEX
 
 	if len(args) < 3 {
-		return errors.New("1000,mrc402unsell operation must include four arguments : " +
+		return mcerr.New(mcerr.InvalidArguments, "mrc402unsell operation must include four arguments : "+
 			"dexid, signature, nonce")
 	}
 
@@ -1252,19 +1274,19 @@ func Mrc402UnSell(stub shim.ChaincodeStubInterface, args []string) error {
 		return err
 	}
 	if dex.AuctionStartDate > 0 {
-		return errors.New("3004,DEX Item is not sell item")
+		return mcerr.New(mcerr.DexStatus, "DEX Item is not sell item")
 	}
-	switch dex402Status(dex) {
+	switch dex402Status(stub, dex) {
 	case MRC402DS_SALE:
 		// OK
 	case MRC402DS_AUCTION_WAIT, MRC402DS_AUCTION, MRC402DS_AUCTION_END, MRC402DS_AUCTION_FINISH:
-		return errors.New("3004,DEX Item is not sell item")
+		return mcerr.New(mcerr.DexStatus, "DEX Item is not sell item")
 	case MRC402DS_CANCLED:
-		return errors.New("3004,DEX Item is already canceled")
+		return mcerr.New(mcerr.DexStatus, "DEX Item is already canceled")
 	case MRC402DS_SOLDOUT:
-		return errors.New("3004,DEX Item is already traded")
+		return mcerr.New(mcerr.DexStatus, "DEX Item is already traded")
 	default:
-		return errors.New("3004,DEX Item status is unknown")
+		return mcerr.New(mcerr.DexStatus, "DEX Item status is unknown")
 	}
 
 	// 1 seller
@@ -1272,8 +1294,8 @@ func Mrc402UnSell(stub shim.ChaincodeStubInterface, args []string) error {
 		return err
 	}
 
-	if err = NonceCheck(&sellerWallet, args[2],
-		strings.Join([]string{args[0], args[2]}, "|"),
+	if err = NonceCheck(stub, &sellerWallet, args[2], "mrc402unsell",
+		[]string{args[0], args[2]},
 		args[1]); err != nil {
 		return err
 	}
@@ -1310,7 +1332,7 @@ func Mrc402Buy(stub shim.ChaincodeStubInterface, args []string) error {
 
 	// argument check
 	if len(args) < 5 {
-		return errors.New("1000,mrc402unsell operation must include four arguments : " +
+		return mcerr.New(mcerr.InvalidArguments, "mrc402unsell operation must include four arguments : "+
 			"dexid, buyer, amount, signature, nonce")
 	}
 
@@ -1320,28 +1342,28 @@ func Mrc402Buy(stub shim.ChaincodeStubInterface, args []string) error {
 	}
 
 	if dex.Seller == args[1] {
-		return errors.New("3004,Seller is don't buy")
+		return mcerr.New(mcerr.DexStatus, "Seller is don't buy")
 	}
 
-	switch dex402Status(dex) {
+	switch dex402Status(stub, dex) {
 	case MRC402DS_SALE:
 		// OK
 	case MRC402DS_CANCLED:
-		return errors.New("3004,DEX Item is already canceled")
+		return mcerr.New(mcerr.DexStatus, "DEX Item is already canceled")
 	case MRC402DS_SOLDOUT:
-		return errors.New("3004,DEX Item is already traded")
+		return mcerr.New(mcerr.DexStatus, "DEX Item is already traded")
 	case MRC402DS_AUCTION_WAIT, MRC402DS_AUCTION, MRC402DS_AUCTION_END, MRC402DS_AUCTION_FINISH:
-		return errors.New("3004,DEX Item is not sell item")
+		return mcerr.New(mcerr.DexStatus, "DEX Item is not sell item")
 	default:
-		return errors.New("3004,DEX Item status is unknown")
+		return mcerr.New(mcerr.DexStatus, "DEX Item status is unknown")
 	}
 
 	// 1 buyer & sign check
 	if buyerWallet, err = GetAddressInfo(stub, args[1]); err != nil {
 		return err
 	}
-	if err = NonceCheck(&buyerWallet, args[4],
-		strings.Join([]string{args[0], args[1], args[2], args[4]}, "|"),
+	if err = NonceCheck(stub, &buyerWallet, args[4], "mrc402buy",
+		[]string{args[0], args[1], args[2], args[4]},
 		args[3]); err != nil {
 		return err
 	}
@@ -1354,7 +1376,7 @@ func Mrc402Buy(stub shim.ChaincodeStubInterface, args []string) error {
 		return err
 	}
 	if remainAmount.Cmp(buyAmount) < 0 {
-		return errors.New("3004,The quantity available for purchase is " + remainAmount.String() + " pieces.")
+		return mcerr.New(mcerr.DexStatus, "The quantity available for purchase is "+remainAmount.String()+" pieces.")
 	}
 	dex.RemainAmount = remainAmount.Sub(buyAmount).String()
 
@@ -1389,12 +1411,12 @@ func Mrc402Auction(stub shim.ChaincodeStubInterface, args []string) error {
 	var now int64
 
 	if len(args) < 15 {
-		return errors.New("1000,mrc402auction operation must include four arguments : " +
-			"address, amount, mrc402id, auction_start_price, selltoken, " +
-			"auction_bidding_unit, auction_buynow_price, auction_start_date, auction_end_date, platformName, " +
+		return mcerr.New(mcerr.InvalidArguments, "mrc402auction operation must include four arguments : "+
+			"address, amount, mrc402id, auction_start_price, selltoken, "+
+			"auction_bidding_unit, auction_buynow_price, auction_start_date, auction_end_date, platformName, "+
 			"platformURL, platformAddress, platformCommission, signature, nonce")
 	}
-	now = time.Now().Unix()
+	now = GetTxTime(stub)
 
 	// 0 seller
 	if sellerWallet, err = GetAddressInfo(stub, args[0]); err != nil {
@@ -1403,7 +1425,7 @@ func Mrc402Auction(stub shim.ChaincodeStubInterface, args []string) error {
 
 	// 1 amount
 	if sellAmount, err = util.ParsePositive(args[1]); err != nil {
-		return errors.New("1108," + args[1] + " is not positive integer")
+		return mcerr.New(mcerr.InvalidAuctionAmount, args[1]+" is not positive integer")
 	}
 
 	// 2 mrc402id
@@ -1458,7 +1480,7 @@ func Mrc402Auction(stub shim.ChaincodeStubInterface, args []string) error {
 
 	if buyNowPrice.Cmp(decimal.Zero) > 0 {
 		if buyNowPrice.Cmp(startPrice) < 1 {
-			return errors.New("3005,Auction buynow is too small")
+			return mcerr.New(mcerr.InvalidData, "Auction buynow is too small")
 		}
 	}
 
@@ -1472,9 +1494,9 @@ func Mrc402Auction(stub shim.ChaincodeStubInterface, args []string) error {
 	if dex.AuctionStartDate <= 0 {
 		dex.AuctionStartDate = now
 	} else if dex.AuctionStartDate < now {
-		return errors.New("3005,The auction start time is in the past")
+		return mcerr.New(mcerr.InvalidData, "The auction start time is in the past")
 	} else if (dex.AuctionStartDate - now) > 1814400 {
-		return errors.New("3005,Auction start time must be within 7 days")
+		return mcerr.New(mcerr.InvalidData, "Auction start time must be within 7 days")
 	}
 
 	if len(args[8]) == 0 {
@@ -1485,40 +1507,40 @@ func Mrc402Auction(stub shim.ChaincodeStubInterface, args []string) error {
 	if dex.AuctionEndDate <= 0 {
 		dex.AuctionEndDate = dex.AuctionStartDate + 86400
 	} else if (dex.AuctionEndDate - now) < 3600 {
-		return errors.New("3005,Auction duration is at least 1 hour")
+		return mcerr.New(mcerr.InvalidData, "Auction duration is at least 1 hour")
 	} else if (dex.AuctionEndDate - now) > 1814400 {
-		return errors.New("3005,The auction period is up to 7 days")
+		return mcerr.New(mcerr.InvalidData, "The auction period is up to 7 days")
 	}
 
 	// 8 platformname
 	if err = util.DataAssign(args[9], &dex.PlatformName, "", 1, 255, true); err != nil {
-		return errors.New("3005,Url value error : " + err.Error())
+		return mcerr.New(mcerr.InvalidData, "Url value error : "+err.Error())
 	}
 
 	// 9 PlatformURL
 	if err = util.DataAssign(args[10], &dex.PlatformURL, "url", 1, 255, true); err != nil {
-		return errors.New("3005,Url value error : " + err.Error())
+		return mcerr.New(mcerr.InvalidData, "Url value error : "+err.Error())
 	}
 
 	// 10 PlatformAddress
 	if err = util.DataAssign(args[11], &dex.PlatformAddress, "address", 40, 40, true); err != nil {
-		return errors.New("3005,Data value error : " + err.Error())
+		return mcerr.New(mcerr.InvalidData, "Data value error : "+err.Error())
 	}
 	if util.IsAddress(dex.PlatformAddress) {
 		if _, err = GetAddressInfo(stub, dex.PlatformAddress); err != nil {
-			return errors.New("3005," + "PlatformAddress not found : " + err.Error())
+			return mcerr.New(mcerr.InvalidData, "PlatformAddress not found : "+err.Error())
 		}
 	}
 
 	// 11 PlatformCommission
 	if err = util.NumericDataCheck(args[12], &dex.PlatformCommission, "0.00", "10.00", 2, true); err != nil {
-		return errors.New("3005,Data value error : " + err.Error())
+		return mcerr.New(mcerr.InvalidData, "Data value error : "+err.Error())
 	}
 
-	if err = NonceCheck(&sellerWallet, args[14],
-		strings.Join([]string{args[0], args[1], args[2], args[3], args[4],
+	if err = NonceCheck(stub, &sellerWallet, args[14], "mrc402auction",
+		[]string{args[0], args[1], args[2], args[3], args[4],
 			args[5], args[6], args[7], args[8], args[9],
-			args[10], args[11], args[12], args[14]}, "|"),
+			args[10], args[11], args[12], args[14]},
 		args[13]); err != nil {
 		return err
 	}
@@ -1534,7 +1556,7 @@ func Mrc402Auction(stub shim.ChaincodeStubInterface, args []string) error {
 		dex.Id = fmt.Sprintf("%39s%1d", temp, i)
 		argdat, err = stub.GetState(dex.Id)
 		if err != nil {
-			return errors.New("8600,Hyperledger internal error - " + err.Error())
+			return mcerr.Wrap(mcerr.LedgerWrite, "Hyperledger internal error", err)
 		}
 
 		if argdat != nil { // key already exists
@@ -1545,7 +1567,7 @@ func Mrc402Auction(stub shim.ChaincodeStubInterface, args []string) error {
 		}
 	}
 	if !isSuccess {
-		return errors.New("3005,Data generate error, retry again")
+		return mcerr.New(mcerr.InvalidData, "Data generate error, retry again")
 	}
 
 	// 	"seller, amount, mrc402id, auction_start_price, selltoken, " +
@@ -1575,7 +1597,7 @@ func Mrc402UnAuction(stub shim.ChaincodeStubInterface, args []string) error {
 	var dex TMRC402DEX
 
 	if len(args) < 3 {
-		return errors.New("1000,mrc402unauction operation must include four arguments : " +
+		return mcerr.New(mcerr.InvalidArguments, "mrc402unauction operation must include four arguments : "+
 			"mrc402dexid, signature, nonce")
 	}
 
@@ -1584,28 +1606,28 @@ func Mrc402UnAuction(stub shim.ChaincodeStubInterface, args []string) error {
 		return err
 	}
 	if dex.AuctionStartDate == 0 {
-		return errors.New("3004,DEX Item is not sell item")
+		return mcerr.New(mcerr.DexStatus, "DEX Item is not sell item")
 	}
 
-	switch dex402Status(dex) {
+	switch dex402Status(stub, dex) {
 	case MRC402DS_AUCTION, MRC402DS_AUCTION_WAIT:
 		// ok
 	case MRC402DS_SALE, MRC402DS_SOLDOUT:
-		return errors.New("3004,DEX Item is not auction item")
+		return mcerr.New(mcerr.DexStatus, "DEX Item is not auction item")
 	case MRC402DS_CANCLED:
-		return errors.New("3004,DEX Item is already canceled")
+		return mcerr.New(mcerr.DexStatus, "DEX Item is already canceled")
 	case MRC402DS_AUCTION_END:
-		return errors.New("3004,DEX Item is already end, use auction finish")
+		return mcerr.New(mcerr.DexStatus, "DEX Item is already end, use auction finish")
 	case MRC402DS_AUCTION_FINISH:
-		return errors.New("3004,DEX Item is already finished")
+		return mcerr.New(mcerr.DexStatus, "DEX Item is already finished")
 	default:
-		return errors.New("3004,DEX Item status is unknown")
+		return mcerr.New(mcerr.DexStatus, "DEX Item status is unknown")
 
 	}
 
 	// bidder exists ?
 	if dex.AuctionCurrentBidder != "" {
-		return errors.New("3004,DEX Item there is a bidder, so the auction cannot be canceled")
+		return mcerr.New(mcerr.DexStatus, "DEX Item there is a bidder, so the auction cannot be canceled")
 	}
 
 	// sign check.
@@ -1613,8 +1635,8 @@ func Mrc402UnAuction(stub shim.ChaincodeStubInterface, args []string) error {
 		return err
 	}
 
-	if err = NonceCheck(&sellerWallet, args[2],
-		strings.Join([]string{args[0], args[2]}, "|"),
+	if err = NonceCheck(stub, &sellerWallet, args[2], "mrc402unauction",
+		[]string{args[0], args[2]},
 		args[1]); err != nil {
 		return err
 	}
@@ -1656,7 +1678,7 @@ func Mrc402AuctionBid(stub shim.ChaincodeStubInterface, args []string) error {
 	var isBuynow bool
 
 	if len(args) < 5 {
-		return errors.New("1000,mrc402bid operation must include four arguments : " +
+		return mcerr.New(mcerr.InvalidArguments, "mrc402bid operation must include four arguments : "+
 			"mrc402dexid, address, amount, signature, nonce")
 	}
 	buyerAddress = args[1]
@@ -1666,26 +1688,26 @@ func Mrc402AuctionBid(stub shim.ChaincodeStubInterface, args []string) error {
 		return err
 	}
 
-	switch dex402Status(dex) {
+	switch dex402Status(stub, dex) {
 	case MRC402DS_AUCTION:
 		// OK
 	case MRC402DS_SALE, MRC402DS_SOLDOUT:
-		return errors.New("3004,DEX Item is not auction item")
+		return mcerr.New(mcerr.DexStatus, "DEX Item is not auction item")
 	case MRC402DS_AUCTION_WAIT:
-		return errors.New("3004,This is not the auction bidding period")
+		return mcerr.New(mcerr.DexStatus, "This is not the auction bidding period")
 	case MRC402DS_AUCTION_END:
-		return errors.New("3004,DEX Item is already end, use auction finish")
+		return mcerr.New(mcerr.DexStatus, "DEX Item is already end, use auction finish")
 	case MRC402DS_AUCTION_FINISH:
-		return errors.New("3004,DEX Item is already finished")
+		return mcerr.New(mcerr.DexStatus, "DEX Item is already finished")
 	case MRC402DS_CANCLED:
-		return errors.New("3004,DEX Item is already canceled")
+		return mcerr.New(mcerr.DexStatus, "DEX Item is already canceled")
 	default:
-		return errors.New("3004,DEX Item status is unknown")
+		return mcerr.New(mcerr.DexStatus, "DEX Item status is unknown")
 	}
 
 	// buyer is seller ?
 	if dex.Seller == buyerAddress {
-		return errors.New("3004,Seller is don't buy")
+		return mcerr.New(mcerr.DexStatus, "Seller is don't buy")
 	}
 
 	// 1 buyer
@@ -1693,8 +1715,8 @@ func Mrc402AuctionBid(stub shim.ChaincodeStubInterface, args []string) error {
 		return err
 	}
 
-	if err = NonceCheck(&buyerWallet, args[4],
-		strings.Join([]string{args[0], buyerAddress, args[2], args[4]}, "|"),
+	if err = NonceCheck(stub, &buyerWallet, args[4], "mrc402bid",
+		[]string{args[0], buyerAddress, args[2], args[4]},
 		args[3]); err != nil {
 		return err
 	}
@@ -1704,7 +1726,7 @@ func Mrc402AuctionBid(stub shim.ChaincodeStubInterface, args []string) error {
 		return err
 	}
 	if newBidPrice, err = decimal.NewFromString(args[2]); err != nil {
-		return errors.New("3004,The bid amount is incorrect. " + err.Error())
+		return mcerr.New(mcerr.DexStatus, "The bid amount is incorrect. "+err.Error())
 	}
 
 	// get price info
@@ -1715,13 +1737,13 @@ func Mrc402AuctionBid(stub shim.ChaincodeStubInterface, args []string) error {
 		if newBidPrice.Cmp(buyNow) == 0 {
 			isBuynow = true
 		} else if newBidPrice.Cmp(buyNow) > 0 {
-			return errors.New("3004,The bid amount must be less than or equal to the purchase buynow price")
+			return mcerr.New(mcerr.DexStatus, "The bid amount must be less than or equal to the purchase buynow price")
 		}
 	}
 
 	if !isBuynow {
 		if dex.AuctionCurrentBidder == buyerAddress {
-			return errors.New("3004,You are already the highest bidder")
+			return mcerr.New(mcerr.DexStatus, "You are already the highest bidder")
 		}
 	}
 
@@ -1729,7 +1751,7 @@ func Mrc402AuctionBid(stub shim.ChaincodeStubInterface, args []string) error {
 		oldBidPrice, _ = decimal.NewFromString(dex.AuctionCurrentPrice)
 		if !isBuynow {
 			if newBidPrice.Sub(bidUnit).Cmp(oldBidPrice) < 0 {
-				return errors.New("3004,The bid amount must be greater than the current bid amount plus the bid unit")
+				return mcerr.New(mcerr.DexStatus, "The bid amount must be greater than the current bid amount plus the bid unit")
 			}
 		}
 
@@ -1740,7 +1762,7 @@ func Mrc402AuctionBid(stub shim.ChaincodeStubInterface, args []string) error {
 	} else {
 		oldBidPrice, _ = decimal.NewFromString(dex.AuctionStartPrice)
 		if newBidPrice.Cmp(oldBidPrice) < 0 {
-			return errors.New("3004,The bid amount must be equal to or greater than the starting price")
+			return mcerr.New(mcerr.DexStatus, "The bid amount must be equal to or greater than the starting price")
 		}
 		refunderAddress = ""
 	}
@@ -1784,7 +1806,7 @@ func Mrc402AuctionBid(stub shim.ChaincodeStubInterface, args []string) error {
 	}
 
 	// save bid info
-	if err = setDEX402(stub, dex, "mrc402_auctionbid", []string{dex.Id, dex.Seller, buyerAddress, util.JSONEncode(PaymentInfo), args[3], args[4]}); err != nil {
+	if err = setDEX402(stub, dex, "mrc402_auctionbid", []string{dex.Id, dex.Seller, buyerAddress, util.JSONEncode(PaymentInfo), args[3], args[4]}, PaymentInfo...); err != nil {
 		return err
 	}
 	return nil
@@ -1810,7 +1832,7 @@ func Mrc402AuctionFinish(stub shim.ChaincodeStubInterface, args []string) error
 	var PaymentInfo = make([]mtc.TDexPaymentInfo, 0, 12)
 
 	if len(args) < 1 {
-		return errors.New("1000,mrc402auctionfinish operation must include four arguments : " +
+		return mcerr.New(mcerr.InvalidArguments, "mrc402auctionfinish operation must include four arguments : "+
 			"mrc402dexid")
 	}
 	// get item info
@@ -1818,19 +1840,19 @@ func Mrc402AuctionFinish(stub shim.ChaincodeStubInterface, args []string) error
 		return err
 	}
 
-	switch dex402Status(dex) {
+	switch dex402Status(stub, dex) {
 	case MRC402DS_AUCTION_END:
 		// OK
 	case MRC402DS_SALE, MRC402DS_SOLDOUT:
-		return errors.New("3004,DEX Item is not auction item")
+		return mcerr.New(mcerr.DexStatus, "DEX Item is not auction item")
 	case MRC402DS_AUCTION_WAIT, MRC402DS_AUCTION:
-		return errors.New("3004,It cannot be closed while the auction is pending")
+		return mcerr.New(mcerr.DexStatus, "It cannot be closed while the auction is pending")
 	case MRC402DS_AUCTION_FINISH:
-		return errors.New("3004,DEX Item is already finished")
+		return mcerr.New(mcerr.DexStatus, "DEX Item is already finished")
 	case MRC402DS_CANCLED:
-		return errors.New("3004,DEX Item is already canceled")
+		return mcerr.New(mcerr.DexStatus, "DEX Item is already canceled")
 	default:
-		return errors.New("3004,DEX Item status is unknown")
+		return mcerr.New(mcerr.DexStatus, "DEX Item status is unknown")
 	}
 
 	if dex.AuctionCurrentBidder != "" {
@@ -1898,6 +1920,13 @@ func mrc402DexProcess(stub shim.ChaincodeStubInterface, dex TMRC402DEX, buyerWal
 		receiveAmount = paymentAmount
 	}
 
+	// the auction bid is in the DEX, check before the payment moves
+	if tradeType == MRC402MT_Auction {
+		if err = mrc010MoveCheckByID(stub, dex.SellToken, buyerAddress); err != nil {
+			return err
+		}
+	}
+
 	// total payment price.
 	if mrc402, _, err = GetMRC402(stub, dex.MRC402); err != nil {
 		return err
@@ -2055,7 +2084,7 @@ func mrc402DexProcess(stub shim.ChaincodeStubInterface, dex TMRC402DEX, buyerWal
 	// dex save
 	dex.Buyer = buyerAddress
 	addrParams = []string{dex.Id, dex.Seller, buyerAddress, util.JSONEncode(PaymentInfo), dex.MRC402}
-	if err = setDEX402(stub, dex, dexType, addrParams); err != nil {
+	if err = setDEX402(stub, dex, dexType, addrParams, PaymentInfo...); err != nil {
 		return err
 	}
 
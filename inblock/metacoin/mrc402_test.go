@@ -0,0 +1,103 @@
+package metacoin
+
+import (
+	"testing"
+)
+
+// createMRC402 - create MRC402 without creator commission and initial reserve
+func createMRC402(t *testing.T, s *testStub, creator *testWallet, supply string) string {
+	t.Helper()
+	nonce := creator.nonce(t, s)
+	args := []string{creator.Address, "Test NFT", "0", supply, "0",
+		"https://example.com", "https://example.com/nft.png", "", "", "0",
+		"", "", "", "KR", "", ""}
+	args = append(args, creator.sign(t, s, "mrc402create", append(args[:16:16], nonce)...), nonce)
+	s.mustTx(t, func() error { return Mrc402Create(s, args) })
+	return s.lastEvent(t, "mrc402_create").Key
+}
+
+func TestMrc402Mint(t *testing.T) {
+	f := newDexFixture(t)
+	s, alice, bob := f.s, f.alice, f.bob
+	mrc402id := createMRC402(t, s, alice, "100")
+
+	if got := alice.balance402(t, s, mrc402id); got != "100" {
+		t.Fatalf("balance after create : got %s, expect 100", got)
+	}
+
+	nonce := alice.nonce(t, s)
+	s.mustTx(t, func() error {
+		return Mrc402Mint(s, []string{mrc402id, "50", "mint", alice.sign(t, s, "mrc402mint", mrc402id, "50", "mint", nonce), nonce})
+	})
+	if got := alice.balance402(t, s, mrc402id); got != "150" {
+		t.Errorf("balance after mint : got %s, expect 150", got)
+	}
+	if mrc402, _, _ := GetMRC402(s, mrc402id); mrc402.TotalSupply != "150" {
+		t.Errorf("total supply after mint : got %s, expect 150", mrc402.TotalSupply)
+	}
+
+	// creator only
+	nonce = bob.nonce(t, s)
+	assertError(t, s.tx(func() error {
+		return Mrc402Mint(s, []string{mrc402id, "50", "mint", bob.sign(t, s, "mrc402mint", mrc402id, "50", "mint", nonce), nonce})
+	}), "1102")
+}
+
+func TestMrc402SellAuction(t *testing.T) {
+	f := newDexFixture(t)
+	s, alice, bob, carol := f.s, f.alice, f.bob, f.carol
+	mrc402id := createMRC402(t, s, alice, "100")
+
+	// alice sell 30, 20 coin per item. bob buy 10
+	nonce := alice.nonce(t, s)
+	args := []string{alice.Address, "30", mrc402id, "20", f.coin, "", "", "", ""}
+	args = append(args, alice.sign(t, s, "mrc402sell", append(args[:9:9], nonce)...), nonce)
+	s.mustTx(t, func() error { return Mrc402Sell(s, args) })
+	dexid := s.lastEvent(t, "mrc402_sell").Key
+
+	nonce = bob.nonce(t, s)
+	s.mustTx(t, func() error {
+		return Mrc402Buy(s, []string{dexid, bob.Address, "10", bob.sign(t, s, "mrc402buy", dexid, bob.Address, "10", nonce), nonce})
+	})
+	assertBalance(t, s, bob, f.coin, "9800")
+	assertBalance(t, s, alice, f.coin, "980200")
+	if got := bob.balance402(t, s, mrc402id); got != "10" {
+		t.Errorf("buyer MRC402 balance : got %s, expect 10", got)
+	}
+
+	// alice auction 20, start price 100, bidding unit 10
+	nonce = alice.nonce(t, s)
+	args = []string{alice.Address, "20", mrc402id, "100", f.coin, "10", "0", "", "", "", "", "", ""}
+	args = append(args, alice.sign(t, s, "mrc402auction", append(args[:13:13], nonce)...), nonce)
+	s.mustTx(t, func() error { return Mrc402Auction(s, args) })
+	dexid = s.lastEvent(t, "mrc402_auction").Key
+	if got := alice.balance402(t, s, mrc402id); got != "50" {
+		t.Errorf("seller MRC402 balance : got %s, expect 50", got)
+	}
+
+	bid := func(w *testWallet, amount string) error {
+		nonce := w.nonce(t, s)
+		return s.tx(func() error {
+			return Mrc402AuctionBid(s, []string{dexid, w.Address, amount, w.sign(t, s, "mrc402bid", dexid, w.Address, amount, nonce), nonce})
+		})
+	}
+	if err := bid(bob, "100"); err != nil {
+		t.Fatal(err)
+	}
+	if err := bid(carol, "120"); err != nil {
+		t.Fatal(err)
+	}
+	assertBalance(t, s, bob, f.coin, "9800")
+	assertBalance(t, s, carol, f.coin, "9880")
+
+	s.advance(86400)
+	assertError(t, bid(bob, "130"), "3004")
+	s.mustTx(t, func() error { return Mrc402AuctionFinish(s, []string{dexid}) })
+	assertBalance(t, s, alice, f.coin, "980320")
+	if got := carol.balance402(t, s, mrc402id); got != "20" {
+		t.Errorf("winner MRC402 balance : got %s, expect 20", got)
+	}
+	if len(s.lastEvent(t, "mrc402_auctionwinning").Payment) == 0 {
+		t.Error("mrc402_auctionwinning event without payment")
+	}
+}
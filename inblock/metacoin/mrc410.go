@@ -1,14 +1,13 @@
 package metacoin
 
 import (
-	"errors"
 	"strings"
-	"time"
 
 	"encoding/json"
 
 	"github.com/hyperledger/fabric-chaincode-go/shim"
 
+	"inblock/metacoin/mcerr"
 	"inblock/metacoin/mtc"
 	"inblock/metacoin/util"
 )
@@ -47,15 +46,15 @@ func setMRC410(stub shim.ChaincodeStubInterface, MRC410ID string, tk TMRC410, Jo
 	var dat []byte
 	var err error
 	if len(MRC410ID) != 40 {
-		return errors.New("4202,MRC410 id length is must be 40")
+		return mcerr.New(mcerr.InvalidIDLength, "MRC410 id length is must be 40")
 	}
 
 	if strings.Index(MRC410ID, "MRC410_") != 0 {
-		return errors.New("4204,Invalid ID")
+		return mcerr.New(mcerr.InvalidItemFormat, "Invalid ID")
 	}
 
 	tk.JobType = JobType
-	tk.JobDate = time.Now().Unix()
+	tk.JobDate = GetTxTime(stub)
 	if len(args) > 0 {
 		if dat, err = json.Marshal(args); err == nil {
 			tk.JobArgs = string(dat)
@@ -65,10 +64,10 @@ func setMRC410(stub shim.ChaincodeStubInterface, MRC410ID string, tk TMRC410, Jo
 	}
 
 	if dat, err = json.Marshal(tk); err != nil {
-		return errors.New("4204,Invalid MRC410 data format")
+		return mcerr.New(mcerr.InvalidItemFormat, "Invalid MRC410 data format")
 	}
 	if err = stub.PutState(MRC410ID, dat); err != nil {
-		return errors.New("8600,Hyperledger internal error - " + err.Error())
+		return mcerr.Wrap(mcerr.LedgerWrite, "Hyperledger internal error", err)
 	}
 	return nil
 }
@@ -80,20 +79,20 @@ func GetMRC410(stub shim.ChaincodeStubInterface, MRC410ID string) (TMRC410, erro
 	var err error
 
 	if len(MRC410ID) != 40 {
-		return tk, errors.New("4202,MRC410 id length is must be 40")
+		return tk, mcerr.New(mcerr.InvalidIDLength, "MRC410 id length is must be 40")
 	}
 	if strings.Index(MRC410ID, "MRC410_") != 0 {
-		return tk, errors.New("4204,Invalid ID")
+		return tk, mcerr.New(mcerr.InvalidItemFormat, "Invalid ID")
 	}
 
 	if data, err = stub.GetState(MRC410ID); err != nil {
-		return tk, errors.New("8100,Hyperledger internal error - " + err.Error())
+		return tk, mcerr.Wrap(mcerr.LedgerRead, "Hyperledger internal error", err)
 	}
 	if data == nil {
-		return tk, errors.New("4201,MRC410 " + MRC410ID + " not exists")
+		return tk, mcerr.New(mcerr.ItemNotFound, "MRC410 "+MRC410ID+" not exists")
 	}
 	if err = json.Unmarshal(data, &tk); err != nil {
-		return tk, errors.New("4204,Invalid MRC410 data format")
+		return tk, mcerr.New(mcerr.InvalidItemFormat, "Invalid MRC410 data format")
 	}
 	return tk, nil
 }
@@ -103,14 +102,14 @@ func SetMRC411(stub shim.ChaincodeStubInterface, MRC411ID string, tk TMRC411, Jo
 	var dat []byte
 	var err error
 	if len(MRC411ID) != 40 {
-		return errors.New("4202,MRC411 id length is must be 40")
+		return mcerr.New(mcerr.InvalidIDLength, "MRC411 id length is must be 40")
 	}
 	if strings.Index(MRC411ID, "MRC411_") != 0 {
-		return errors.New("4204,Invalid ID")
+		return mcerr.New(mcerr.InvalidItemFormat, "Invalid ID")
 	}
 
 	tk.JobType = JobType
-	tk.JobDate = time.Now().Unix()
+	tk.JobDate = GetTxTime(stub)
 	if len(args) > 0 {
 		if dat, err = json.Marshal(args); err == nil {
 			tk.JobArgs = string(dat)
@@ -120,10 +119,10 @@ func SetMRC411(stub shim.ChaincodeStubInterface, MRC411ID string, tk TMRC411, Jo
 	}
 
 	if dat, err = json.Marshal(tk); err != nil {
-		return errors.New("4204,Invalid MRC411 data format")
+		return mcerr.New(mcerr.InvalidItemFormat, "Invalid MRC411 data format")
 	}
 	if err = stub.PutState(MRC411ID, dat); err != nil {
-		return errors.New("8600,Hyperledger internal error - " + err.Error())
+		return mcerr.Wrap(mcerr.LedgerWrite, "Hyperledger internal error", err)
 	}
 	return nil
 }
@@ -148,26 +147,26 @@ func MRC410Create(stub shim.ChaincodeStubInterface, creator, name, validitytype,
 
 	if validitytype == "term" {
 		if j, err = util.Strtoint64(startdate); err != nil {
-			return errors.New("1003,The Start_date must be integer")
+			return mcerr.New(mcerr.InvalidValue, "The Start_date must be integer")
 		}
 		mrc410.StartDate = j
 		if j, err = util.Strtoint64(enddate); err != nil {
-			return errors.New("1003,The end_date must be integer")
+			return mcerr.New(mcerr.InvalidValue, "The end_date must be integer")
 		}
-		if j < time.Now().Unix() {
-			return errors.New("1403,The end_date must be bigger then current timestamp")
+		if j < GetTxTime(stub) {
+			return mcerr.New(mcerr.InvalidEndDate, "The end_date must be bigger then current timestamp")
 		}
 		mrc410.EndDate = j
 	} else if validitytype == "duration" {
 		if i, err = util.Strtoint(term); err != nil {
-			return errors.New("1003,The Term must be integer")
+			return mcerr.New(mcerr.InvalidValue, "The Term must be integer")
 		}
 		if i < 1 {
-			return errors.New("1003,The term must be bigger then 0")
+			return mcerr.New(mcerr.InvalidValue, "The term must be bigger then 0")
 		}
 		mrc410.Term = i
 	} else {
-		return errors.New("1003,The Validity_type must be term or duration")
+		return mcerr.New(mcerr.InvalidValue, "The Validity_type must be term or duration")
 	}
 
 	if istransfer == "0" || istransfer == "" {
@@ -176,7 +175,7 @@ func MRC410Create(stub shim.ChaincodeStubInterface, creator, name, validitytype,
 		mrc410.IsTransfer = 1
 	}
 
-	if err = NonceCheck(&creatorData, tkey, strings.Join([]string{creator, name, validitytype, istransfer, code, data, tkey}, "|"), signature); err != nil {
+	if err = NonceCheck(stub, &creatorData, tkey, "mrc410create", []string{creator, name, validitytype, istransfer, code, data, tkey}, signature); err != nil {
 		return err
 	}
 
@@ -1,16 +1,15 @@
 package metacoin
 
 import (
-	"errors"
 	"fmt"
 	"strings"
-	"time"
 
 	"encoding/json"
 
 	"github.com/hyperledger/fabric-chaincode-go/shim"
 	"github.com/shopspring/decimal"
 
+	"inblock/metacoin/mcerr"
 	"inblock/metacoin/mtc"
 	"inblock/metacoin/util"
 )
@@ -38,34 +37,34 @@ func Mrc800Create(stub shim.ChaincodeStubInterface, owner, name, url, imageurl,
 	var argdat []byte
 
 	MRC800ProjectData = TMRC800{
-		CreateDate: time.Now().Unix(),
+		CreateDate: GetTxTime(stub),
 	}
 
 	if err = util.DataAssign(owner, &MRC800ProjectData.Owner, "address", 40, 40, false); err != nil {
-		return errors.New("3005,Data must be 1 to 4096 characters long")
+		return mcerr.New(mcerr.InvalidData, "Data must be 1 to 4096 characters long")
 	}
 	if err = util.DataAssign(name, &MRC800ProjectData.Name, "string", 1, 128, false); err != nil {
-		return errors.New("3005,Name must be 1 to 128 characters long")
+		return mcerr.New(mcerr.InvalidData, "Name must be 1 to 128 characters long")
 	}
 	if err = util.DataAssign(url, &MRC800ProjectData.URL, "string", 1, 1024, false); err != nil {
-		return errors.New("3005,Url must be 1 to 1024 characters long URL")
+		return mcerr.New(mcerr.InvalidData, "Url must be 1 to 1024 characters long URL")
 	}
 	if err = util.DataAssign(imageurl, &MRC800ProjectData.ImageURL, "url", 1, 256, false); err != nil {
-		return errors.New("3005,ImageURL must be 1 to 1024 characters long URL")
+		return mcerr.New(mcerr.InvalidData, "ImageURL must be 1 to 1024 characters long URL")
 	}
 	if err = util.DataAssign(transferable, &MRC800ProjectData.Transferable, "bool", 1, 1, false); err != nil {
-		return errors.New("3005,transferable must be '1' or '0'")
+		return mcerr.New(mcerr.InvalidData, "transferable must be '1' or '0'")
 	}
 	if err = util.DataAssign(description, &MRC800ProjectData.Description, "string", 1, 4096, false); err != nil {
-		return errors.New("3005,Description must be 1 to 4096 characters long")
+		return mcerr.New(mcerr.InvalidData, "Description must be 1 to 4096 characters long")
 	}
 
 	if ownerWallet, err = GetAddressInfo(stub, owner); err != nil {
 		return err
 	}
 
-	if err = NonceCheck(&ownerWallet, tkey,
-		strings.Join([]string{owner, name, url, imageurl, tkey}, "|"),
+	if err = NonceCheck(stub, &ownerWallet, tkey, "mrc800create",
+		[]string{owner, name, url, imageurl, tkey},
 		signature); err != nil {
 		return err
 	}
@@ -76,7 +75,7 @@ func Mrc800Create(stub shim.ChaincodeStubInterface, owner, name, url, imageurl,
 		mrc800id = fmt.Sprintf("%39s%1d", temp, i)
 		argdat, err = stub.GetState(mrc800id)
 		if err != nil {
-			return errors.New("8600,Hyperledger internal error - " + err.Error())
+			return mcerr.Wrap(mcerr.LedgerWrite, "Hyperledger internal error", err)
 		}
 
 		if argdat != nil { // key already exists
@@ -88,19 +87,19 @@ func Mrc800Create(stub shim.ChaincodeStubInterface, owner, name, url, imageurl,
 	}
 
 	if !isSuccess {
-		return errors.New("3005,Data generate error, retry again")
+		return mcerr.New(mcerr.InvalidData, "Data generate error, retry again")
 	}
 
 	MRC800ProjectData.JobType = "mrc800_create"
-	MRC800ProjectData.JobDate = time.Now().Unix()
+	MRC800ProjectData.JobDate = GetTxTime(stub)
 	if argdat, err = json.Marshal([]string{mrc800id, owner, name, url, imageurl, description, signature, tkey}); err == nil {
 		MRC800ProjectData.JobArgs = string(argdat)
 	}
 	if argdat, err = json.Marshal(MRC800ProjectData); err != nil {
-		return errors.New("3209,Invalid mrc800 data format")
+		return mcerr.New(mcerr.InvalidItemData, "Invalid mrc800 data format")
 	}
 	if err := stub.PutState(mrc800id, argdat); err != nil {
-		return errors.New("8600,Hyperledger internal error - " + err.Error() + mrc800id)
+		return mcerr.New(mcerr.LedgerWrite, "Hyperledger internal error - "+err.Error()+mrc800id)
 	}
 
 	if err = SetAddressInfo(stub, ownerWallet, "mrc800create", []string{mrc800id, owner, name, url, imageurl, description, signature, tkey}); err != nil {
@@ -122,46 +121,46 @@ func Mrc800Update(stub shim.ChaincodeStubInterface, mrc800id, name, url, imageur
 	}
 
 	if err = json.Unmarshal([]byte(mrc800), &MRC800ProjectData); err != nil {
-		return errors.New("6205,MRC800 [" + mrc800id + "] is in the wrong data")
+		return mcerr.New(mcerr.WrongData, "MRC800 ["+mrc800id+"] is in the wrong data")
 	}
 
 	if err = util.DataAssign(name, &MRC800ProjectData.Name, "string", 1, 128, true); err != nil {
-		return errors.New("3005,Name must be 1 to 128 characters long")
+		return mcerr.New(mcerr.InvalidData, "Name must be 1 to 128 characters long")
 	}
 	if err = util.DataAssign(url, &MRC800ProjectData.URL, "url", 1, 255, true); err != nil {
-		return errors.New("3005,Url must be 1 to 1024 characters long URL")
+		return mcerr.New(mcerr.InvalidData, "Url must be 1 to 1024 characters long URL")
 	}
 	if err = util.DataAssign(imageurl, &MRC800ProjectData.ImageURL, "url", 1, 255, true); err != nil {
-		return errors.New("3005,ImageURL must be 1 to 1024 characters long URL")
+		return mcerr.New(mcerr.InvalidData, "ImageURL must be 1 to 1024 characters long URL")
 	}
 	if err = util.DataAssign(transferable, &MRC800ProjectData.Transferable, "bool", 1, 1, false); err != nil {
-		return errors.New("3005,transferable must be '1' or '0'")
+		return mcerr.New(mcerr.InvalidData, "transferable must be '1' or '0'")
 	}
 	if err = util.DataAssign(description, &MRC800ProjectData.Description, "string", 1, 4096, true); err != nil {
-		return errors.New("3005,Description must be 1 to 4096 characters long")
+		return mcerr.New(mcerr.InvalidData, "Description must be 1 to 4096 characters long")
 	}
 
 	if ownerWallet, err = GetAddressInfo(stub, MRC800ProjectData.Owner); err != nil {
 		return err
 	}
 
-	if err = NonceCheck(&ownerWallet, tkey,
-		strings.Join([]string{mrc800id, name, url, imageurl, description, tkey}, "|"),
+	if err = NonceCheck(stub, &ownerWallet, tkey, "mrc800update",
+		[]string{mrc800id, name, url, imageurl, description, tkey},
 		signature); err != nil {
 		return err
 	}
 
 	MRC800ProjectData.JobType = "mrc800_update"
-	MRC800ProjectData.JobDate = time.Now().Unix()
+	MRC800ProjectData.JobDate = GetTxTime(stub)
 	if argdat, err = json.Marshal([]string{mrc800id, MRC800ProjectData.Owner, name, url, imageurl, description, signature, tkey}); err == nil {
 		MRC800ProjectData.JobArgs = string(argdat)
 	}
 
 	if argdat, err = json.Marshal(MRC800ProjectData); err != nil {
-		return errors.New("3209,Invalid address data format")
+		return mcerr.New(mcerr.InvalidItemData, "Invalid address data format")
 	}
 	if err := stub.PutState(mrc800id, argdat); err != nil {
-		return errors.New("8600,Hyperledger internal error - " + err.Error() + mrc800id)
+		return mcerr.New(mcerr.LedgerWrite, "Hyperledger internal error - "+err.Error()+mrc800id)
 	}
 
 	if err = SetAddressInfo(stub, ownerWallet, "mrc800update", []string{mrc800id, MRC800ProjectData.Owner, name, url, imageurl, description, signature, tkey}); err != nil {
@@ -176,15 +175,15 @@ func Mrc800get(stub shim.ChaincodeStubInterface, mrc800id string) (string, error
 	var err error
 
 	if strings.Index(mrc800id, "MRC800_") != 0 || len(mrc800id) != 40 {
-		return "", errors.New("6102,invalid MRC800 data address")
+		return "", mcerr.New(mcerr.InvalidDataAddress, "invalid MRC800 data address")
 	}
 
 	dat, err = stub.GetState(mrc800id)
 	if err != nil {
-		return "", errors.New("8110,Hyperledger internal error - " + err.Error())
+		return "", mcerr.Wrap(mcerr.LedgerReadData, "Hyperledger internal error", err)
 	}
 	if dat == nil {
-		return "", errors.New("6004,MRC800 [" + mrc800id + "] not exist")
+		return "", mcerr.New(mcerr.NotFound, "MRC800 ["+mrc800id+"] not exist")
 	}
 	return string(dat), nil
 }
@@ -201,13 +200,13 @@ func Mrc800give(stub shim.ChaincodeStubInterface, mrc800id, toAddr, amount, memo
 		return err
 	}
 	if err = json.Unmarshal([]byte(mrc800), &mrc800Token); err != nil {
-		return errors.New("3004,MRC800 [" + mrc800id + "] is in the wrong data")
+		return mcerr.New(mcerr.DexStatus, "MRC800 ["+mrc800id+"] is in the wrong data")
 	}
 	if tokenOwnerWallet, err = GetAddressInfo(stub, mrc800Token.Owner); err != nil {
 		return err
 	}
-	if err = NonceCheck(&tokenOwnerWallet, tkey,
-		strings.Join([]string{mrc800id, toAddr, amount, tkey}, "|"),
+	if err = NonceCheck(stub, &tokenOwnerWallet, tkey, "mrc800give",
+		[]string{mrc800id, toAddr, amount, tkey},
 		signature); err != nil {
 		return err
 	}
@@ -216,11 +215,14 @@ func Mrc800give(stub shim.ChaincodeStubInterface, mrc800id, toAddr, amount, memo
 		return err
 	}
 
+	if err = loadAsset(stub, &toAddrWallet, AssetMRC800+mrc800id); err != nil {
+		return err
+	}
 	if toAddrWallet.MRC800 == nil {
 		toAddrWallet.MRC800 = make(map[string]string, 0)
 	}
 	if addAmount, err = util.ParsePositive(amount); err != nil {
-		return errors.New("1101," + amount + " is not positive integer")
+		return mcerr.New(mcerr.NotInteger, amount+" is not positive integer")
 	}
 
 	if _, exists := toAddrWallet.MRC800[mrc800id]; exists {
@@ -245,13 +247,13 @@ func Mrc800take(stub shim.ChaincodeStubInterface, mrc800id, fromAddr, amount, me
 		return err
 	}
 	if err = json.Unmarshal([]byte(mrc800), &mrc800Token); err != nil {
-		return errors.New("3004,MRC800 [" + mrc800id + "] is in the wrong data")
+		return mcerr.New(mcerr.DexStatus, "MRC800 ["+mrc800id+"] is in the wrong data")
 	}
 	if tokenOwnerWallet, err = GetAddressInfo(stub, mrc800Token.Owner); err != nil {
 		return err
 	}
-	if err = NonceCheck(&tokenOwnerWallet, tkey,
-		strings.Join([]string{mrc800id, fromAddr, amount, tkey}, "|"),
+	if err = NonceCheck(stub, &tokenOwnerWallet, tkey, "mrc800take",
+		[]string{mrc800id, fromAddr, amount, tkey},
 		signature); err != nil {
 		return err
 	}
@@ -260,19 +262,22 @@ func Mrc800take(stub shim.ChaincodeStubInterface, mrc800id, fromAddr, amount, me
 		return err
 	}
 
+	if err = loadAsset(stub, &fromAddrWallet, AssetMRC800+mrc800id); err != nil {
+		return err
+	}
 	if fromAddrWallet.MRC800 == nil {
 		fromAddrWallet.MRC800 = make(map[string]string, 0)
 	}
 	if subAmount, err = util.ParsePositive(amount); err != nil {
-		return errors.New("1101," + amount + " is not positive integer")
+		return mcerr.New(mcerr.NotInteger, amount+" is not positive integer")
 	}
 
 	if _, exists := fromAddrWallet.MRC800[mrc800id]; exists {
-		return errors.New("5000,Not enough balance")
+		return mcerr.New(mcerr.NotEnoughBalance, "Not enough balance")
 	}
 	currentAmount, _ = decimal.NewFromString(fromAddrWallet.MRC800[mrc800id])
 	if currentAmount.Cmp(subAmount) < 0 {
-		return errors.New("5000,Not enough balance")
+		return mcerr.New(mcerr.NotEnoughBalance, "Not enough balance")
 	}
 
 	fromAddrWallet.MRC800[mrc800id] = currentAmount.Sub(subAmount).String()
@@ -292,34 +297,37 @@ func Mrc800transfer(stub shim.ChaincodeStubInterface, fromAddr, toAddr, mrc800id
 		return err
 	}
 	if err = json.Unmarshal([]byte(mrc800), &mrc800Token); err != nil {
-		return errors.New("3004,MRC800 [" + mrc800id + "] is in the wrong data")
+		return mcerr.New(mcerr.DexStatus, "MRC800 ["+mrc800id+"] is in the wrong data")
 	}
 	if mrc800Token.Transferable == "0" {
-		return errors.New("3004,MRC800 [" + mrc800id + "] is not transferable")
+		return mcerr.New(mcerr.DexStatus, "MRC800 ["+mrc800id+"] is not transferable")
 	}
 
 	if fromAddrWallet, err = GetAddressInfo(stub, fromAddr); err != nil {
 		return err
 	}
-	if err = NonceCheck(&fromAddrWallet, tkey,
-		strings.Join([]string{mrc800id, fromAddr, toAddr, mrc800id, amount, tkey}, "|"),
+	if err = NonceCheck(stub, &fromAddrWallet, tkey, "mrc800transfer",
+		[]string{mrc800id, fromAddr, toAddr, mrc800id, amount, tkey},
 		signature); err != nil {
 		return err
 	}
 
+	if err = loadAsset(stub, &fromAddrWallet, AssetMRC800+mrc800id); err != nil {
+		return err
+	}
 	if fromAddrWallet.MRC800 == nil {
 		fromAddrWallet.MRC800 = make(map[string]string, 0)
 	}
 	if transferAmount, err = util.ParsePositive(amount); err != nil {
-		return errors.New("1101," + amount + " is not positive integer")
+		return mcerr.New(mcerr.NotInteger, amount+" is not positive integer")
 	}
 
 	if _, exists := fromAddrWallet.MRC800[mrc800id]; exists {
-		return errors.New("5000,Not enough balance")
+		return mcerr.New(mcerr.NotEnoughBalance, "Not enough balance")
 	}
 	currentAmount, _ = decimal.NewFromString(fromAddrWallet.MRC800[mrc800id])
 	if currentAmount.Cmp(transferAmount) < 0 {
-		return errors.New("5000,Not enough balance")
+		return mcerr.New(mcerr.NotEnoughBalance, "Not enough balance")
 	}
 
 	fromAddrWallet.MRC800[mrc800id] = currentAmount.Sub(transferAmount).String()
@@ -330,6 +338,9 @@ func Mrc800transfer(stub shim.ChaincodeStubInterface, fromAddr, toAddr, mrc800id
 	if toAddrWallet, err = GetAddressInfo(stub, toAddr); err != nil {
 		return err
 	}
+	if err = loadAsset(stub, &toAddrWallet, AssetMRC800+mrc800id); err != nil {
+		return err
+	}
 
 	if _, exists := toAddrWallet.MRC800[mrc800id]; exists {
 		toAddrWallet.MRC800[mrc800id] = transferAmount.String()
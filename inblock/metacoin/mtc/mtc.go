@@ -14,19 +14,75 @@ const InitSupply = 800000000
 
 // MetaWallet - wallet data.
 type TWallet struct {
-	Id       string                   `json:"id"`
-	Regdate  int64                    `json:"regdate"`
-	Password string                   `json:"password"`
-	Addinfo  string                   `json:"addinfo"`
-	JobType  string                   `json:"job_type"`
-	JobArgs  string                   `json:"job_args"`
-	JobDate  int64                    `json:"jobdate"`
-	Balance  []TMRC010Balance         `json:"balance"`
-	MRC010   map[int]TMRC010BalanceV2 `json:"mrc010"`
-	MRC402   map[string]NFTBalance    `json:"mrc402"`
-	MRC800   map[string]string        `json:"mrc800"`
-	Pending  map[int]string           `json:"pending"`
-	Nonce    string                   `json:"nonce"`
+	Id         string                   `json:"id"`
+	Regdate    int64                    `json:"regdate"`
+	Password   string                   `json:"password"`
+	KeyType    string                   `json:"keytype,omitempty"`    // public key type, "" is the legacy ECDSA wallet
+	Hash       string                   `json:"hash,omitempty"`       // signature hash of the key type
+	SignFormat string                   `json:"signformat,omitempty"` // signing payload format, "" accepts the legacy payload
+	Addinfo    string                   `json:"addinfo"`
+	JobType    string                   `json:"job_type"`
+	JobArgs    string                   `json:"job_args"`
+	JobDate    int64                    `json:"jobdate"`
+	Balance    []TMRC010Balance         `json:"balance,omitempty"` // legacy, moved to MRC010 on read
+	MRC010     map[int]TMRC010BalanceV2 `json:"mrc010,omitempty"`  // saved to the <address>_MRC010_<token> key
+	MRC402     map[string]NFTBalance    `json:"mrc402,omitempty"`  // saved to the <address>_MRC402_<id> key
+	MRC800     map[string]string        `json:"mrc800,omitempty"`  // saved to the <address>_MRC800_<id> key
+	Pending    map[int]string           `json:"pending"`
+	Nonce      string                   `json:"nonce"`
+	Assets     []string                 `json:"assets,omitempty"`     // balance key list, ex) MRC010_0
+	Shards     int                      `json:"shards,omitempty"`     // MRC010 receive shard count, 0 is no shard
+	Policy     []string                 `json:"policy,omitempty"`     // endorsement orgs (MSP ID) of the wallet keys
+	KeyDate    int64                    `json:"keydate,omitempty"`    // tx time of the key rotation, 0 is the regdate
+	Keys       []TWalletKey             `json:"keys,omitempty"`       // replaced keys, the oldest first
+	PendingKey *TWalletKey              `json:"pendingkey,omitempty"` // new key waiting for the countersign
+	Signers    []TWalletSigner          `json:"signers,omitempty"`    // keys of the multisig wallet
+	Threshold  int                      `json:"threshold,omitempty"`  // signatures required for the multisig wallet
+	Delegates  []TWalletDelegate        `json:"delegates,omitempty"`  // session keys with the scope
+	State      *TWalletState            `json:"-"`
+}
+
+// TWalletKey : public key of the wallet, replaced by rotateKey
+// From ~ To is the time the key signs for the wallet, To of the pending key is the expire time.
+type TWalletKey struct {
+	PublicKey string          `json:"publickey"`
+	KeyType   string          `json:"keytype,omitempty"`
+	Hash      string          `json:"hash,omitempty"`
+	Signers   []TWalletSigner `json:"signers,omitempty"`   // keys of the multisig wallet, PublicKey is ""
+	Threshold int             `json:"threshold,omitempty"` // threshold of the multisig wallet
+	From      int64           `json:"from"`
+	To        int64           `json:"to"`
+	TxID      string          `json:"txid"`
+}
+
+// TWalletDelegate : delegate key of the wallet, signs the listed functions until Expire
+type TWalletDelegate struct {
+	PublicKey string         `json:"publickey"`
+	KeyType   string         `json:"keytype"`
+	Hash      string         `json:"hash"`
+	Functions []string       `json:"functions"`
+	Caps      map[int]string `json:"caps,omitempty"`  // MRC010 token : spend cap
+	Spent     map[int]string `json:"spent,omitempty"` // MRC010 token : spent amount
+	Expire    int64          `json:"expire"`
+	Regdate   int64          `json:"regdate"`
+}
+
+// TWalletSigner : one public key of the multisig wallet
+type TWalletSigner struct {
+	PublicKey string `json:"publickey"`
+	KeyType   string `json:"keytype"`
+	Hash      string `json:"hash"`
+}
+
+// TWalletState : last saved value of the wallet keys (runtime only)
+// SetAddressInfo writes the key with the changed value only.
+type TWalletState struct {
+	Doc    string                   // wallet document without the job
+	Loaded map[string]string        // balance key : balance
+	Read   map[string]bool          // balance key read by loadAsset
+	Shard  map[string]string        // shard key : balance, "" is deleted
+	Credit map[int]TMRC010BalanceV2 // received amount of the sharded wallet, not saved yet
+	Spent  map[int]string           // MRC010 unlocked balance spent after the last save
 }
 
 type NFTBalance struct {
@@ -72,11 +128,14 @@ type TMRC010 struct {
 	Decimal        int              `json:"decimal"`
 	Reserve        []TMRC010Reserve `json:"reserve"`
 	Tier           []TMTC010ICOTier `json:"tier"`
-	Status         string           `json:"status"` // editable - wait, iter-n, pause,
+	Status         string           `json:"status"`               // editable - wait, iter-n, pause,
+	PrevStatus     string           `json:"prevstatus,omitempty"` // status before the pause, restored by the unpause
 	TargetToken    map[int]int64    `json:"targettoken"`
 	BaseToken      int              `json:"basetoken"`
 	Type           string           `json:"type"`
 	Logger         map[string]int64 `json:"logger"`
+	Frozen         map[string]int64 `json:"frozen,omitempty"` // frozen address, freeze date
+	Policy         []string         `json:"policy,omitempty"` // endorsement orgs (MSP ID) of TOKEN_DATA key
 	JobType        string           `json:"job_type"`
 	JobArgs        string           `json:"job_args"`
 	JobDate        int64            `json:"jobdate"`
@@ -0,0 +1,161 @@
+package metacoin
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+
+	"inblock/metacoin/mcerr"
+	"inblock/metacoin/mtc"
+	"inblock/metacoin/util"
+)
+
+// Multisig wallet
+//
+// The multisig wallet has the signer keys and the threshold instead of the
+// public key. The signature of the wallet is the JSON array of the signatures,
+// ex) ["MEUCIQ...", "0x4f1a..."], and every function which takes the signature
+// works with it. Each signer is counted once, the same key in the other
+// encoding is the same signer. The repeated signature or the signature which
+// is not of a signer is the error.
+//
+// setSigners changes the signers and the threshold, the wallet of the one key
+// becomes the multisig wallet with it. rotateKey makes the multisig wallet the
+// wallet of the one key again.
+const (
+	KeyTypeMultisig = "multisig"
+	MultisigMax     = 16
+)
+
+// TSignerRequest : signer of setSigners, hash "" is the default hash of the key type (see parseWalletKey)
+type TSignerRequest struct {
+	PublicKey string `json:"publickey"`
+	Hash      string `json:"hash"`
+}
+
+// parseSigners - JSON array of TSignerRequest
+func parseSigners(signers string) ([]mtc.TWalletSigner, error) {
+	var req []TSignerRequest
+	var list []mtc.TWalletSigner
+	var exists = make(map[string]bool)
+
+	if err := json.Unmarshal([]byte(signers), &req); err != nil {
+		return nil, mcerr.Wrap(mcerr.InvalidData, "Signers is in the wrong data", err).WithField("signers")
+	}
+	if len(req) == 0 || len(req) > MultisigMax {
+		return nil, mcerr.New(mcerr.InvalidValue, "Signers must be 1 ~ "+strconv.Itoa(MultisigMax)).WithField("signers")
+	}
+	for _, r := range req {
+		pub, hash, err := parseWalletKey(r.PublicKey, r.Hash)
+		if err != nil {
+			return nil, err
+		}
+		id := string(pub.ID())
+		if exists[id] {
+			return nil, mcerr.New(mcerr.InvalidValue, "Duplicate signer key").WithField("signers")
+		}
+		exists[id] = true
+		list = append(list, mtc.TWalletSigner{PublicKey: r.PublicKey, KeyType: pub.KeyType, Hash: hash})
+	}
+	return list, nil
+}
+
+// sameSigners - signers and the threshold are not changed
+func sameSigners(wallet mtc.TWallet, signers []mtc.TWalletSigner, threshold int) bool {
+	if wallet.Threshold != threshold || len(wallet.Signers) != len(signers) {
+		return false
+	}
+	for i := range signers {
+		if wallet.Signers[i] != signers[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// multisigVerify - the signatures of the threshold signers
+// signature is the JSON array of the signatures, the one signature is accepted too.
+func multisigVerify(wallet mtc.TWallet, data, signature string) error {
+	var signatures []string
+
+	if strings.HasPrefix(signature, "[") {
+		if err := json.Unmarshal([]byte(signature), &signatures); err != nil {
+			return mcerr.Wrap(mcerr.InvalidSign, "Signature format error", err)
+		}
+	} else {
+		signatures = []string{signature}
+	}
+	if len(signatures) > len(wallet.Signers) {
+		return mcerr.New(mcerr.InvalidSign, "Too many signatures")
+	}
+
+	var used = make([]bool, len(wallet.Signers))
+	var seen = make(map[string]bool)
+	var signed int
+	for _, sig := range signatures {
+		var matched bool
+		if seen[sig] {
+			return mcerr.New(mcerr.InvalidSign, "Duplicate signature")
+		}
+		seen[sig] = true
+		for i, signer := range wallet.Signers {
+			if used[i] {
+				continue
+			}
+			if util.SignVerify(signer.PublicKey, signer.KeyType, signer.Hash, data, sig) == nil {
+				used[i], matched = true, true
+				break
+			}
+		}
+		if !matched {
+			return mcerr.New(mcerr.InvalidSign, "Invalid signature")
+		}
+		signed++
+	}
+	if signed < wallet.Threshold {
+		return mcerr.New(mcerr.InvalidSign, "Not enough signatures").
+			WithDetails(map[string]interface{}{"signed": signed, "threshold": wallet.Threshold})
+	}
+	return nil
+}
+
+// SetSigners - set the signers and the threshold of the multisig wallet
+//
+// signers is the JSON array of TSignerRequest, threshold is 1 ~ signer count.
+// The current key (or the current signers) signs "address|signers|threshold|nonce".
+func SetSigners(stub shim.ChaincodeStubInterface, address, signers, threshold, signature, tkey string, args []string) error {
+	list, err := parseSigners(signers)
+	if err != nil {
+		return err
+	}
+	count, err := util.Strtoint(threshold)
+	if err != nil || count < 1 || count > len(list) {
+		return mcerr.New(mcerr.InvalidValue, "Threshold must be 1 ~ "+strconv.Itoa(len(list))).WithField("threshold")
+	}
+	wallet, err := GetAddressInfo(stub, address)
+	if err != nil {
+		return err
+	}
+	if wallet.KeyType == KeyTypeMultisig && sameSigners(wallet, list, count) {
+		return mcerr.New(mcerr.NoDataChange, "Signers are not changed").WithField("signers")
+	}
+	if err = NonceCheck(stub, &wallet, tkey, "setSigners",
+		[]string{address, signers, threshold, tkey},
+		signature); err != nil {
+		return err
+	}
+
+	pushKey(stub, &wallet)
+	wallet.Password = ""
+	wallet.KeyType = KeyTypeMultisig
+	wallet.Hash = ""
+	wallet.Signers = list
+	wallet.Threshold = count
+	if err = SetAddressInfo(stub, wallet, "setSigners", args); err != nil {
+		return err
+	}
+	AddEvent(stub, TEvent{Type: "set_signers", Key: address, Args: []string{address, signers, threshold}})
+	return nil
+}
@@ -0,0 +1,117 @@
+package metacoin
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+)
+
+// multiSign - JSON array of the signatures of the signers
+func multiSign(t *testing.T, s *testStub, signers []*testWallet, function string, data ...string) string {
+	t.Helper()
+	var list []string
+	for _, w := range signers {
+		list = append(list, w.sign(t, s, function, data...))
+	}
+	value, _ := json.Marshal(list)
+	return string(value)
+}
+
+// signersJSON - setSigners request of the keys
+func signersJSON(keys ...*testWallet) string {
+	var req []TSignerRequest
+	for _, k := range keys {
+		req = append(req, TSignerRequest{PublicKey: k.PublicKey})
+	}
+	value, _ := json.Marshal(req)
+	return string(value)
+}
+
+func TestMultisig(t *testing.T) {
+	s := newTestStub()
+	alice := newGenesisWallet(t, s, "1000")
+	bob := newTestWallet(t, s)
+	item := registerToken(t, s, alice, "ITEM", "100")
+	k1, k2, k3 := newTestKey(t), newTestKey(t), newTestKey(t)
+
+	setSigners := func(sign func(nonce, signers, threshold string) string, signers, threshold string) error {
+		nonce := alice.nonce(t, s)
+		sig := sign(nonce, signers, threshold)
+		return s.tx(func() error { return SetSigners(s, alice.Address, signers, threshold, sig, nonce, nil) })
+	}
+	byKeys := func(keys ...*testWallet) func(nonce, signers, threshold string) string {
+		return func(nonce, signers, threshold string) string {
+			return multiSign(t, s, keys, "setSigners", alice.Address, signers, threshold, nonce)
+		}
+	}
+	byAlice := func(nonce, signers, threshold string) string {
+		return alice.sign(t, s, "setSigners", alice.Address, signers, threshold, nonce)
+	}
+	increase := func(keys ...*testWallet) error {
+		nonce := alice.nonce(t, s)
+		sig := multiSign(t, s, keys, "tokenIncrease", item, "10", nonce)
+		return s.tx(func() error { return TokenIncrease(s, []string{item, "10", "", sig, nonce}) })
+	}
+
+	// 2 of 3, signed by the current key
+	assertError(t, setSigners(byAlice, signersJSON(k1, k2, k3), "4"), "1003")
+	assertError(t, setSigners(byAlice, signersJSON(k1, k1), "1"), "1003")
+	// the same key in the other encoding is the same signer
+	key, _ := secp256k1.GeneratePrivateKey()
+	reencoded, _ := json.Marshal([]TSignerRequest{
+		{PublicKey: hex.EncodeToString(key.PubKey().SerializeCompressed())},
+		{PublicKey: "0x" + hex.EncodeToString(key.PubKey().SerializeUncompressed())},
+	})
+	assertError(t, setSigners(byAlice, string(reencoded), "2"), "1003")
+	if err := setSigners(byAlice, signersJSON(k1, k2, k3), "2"); err != nil {
+		t.Fatal(err)
+	}
+	if w := alice.wallet(t, s); w.KeyType != KeyTypeMultisig || w.Threshold != 2 || len(w.Signers) != 3 {
+		t.Fatalf("multisig wallet : %+v", w)
+	}
+
+	// owner functions take the signature array
+	assertError(t, increase(alice), "2010")
+	assertError(t, increase(k1), "2010")
+	assertError(t, increase(k1, k1), "2010")
+	nonce := alice.nonce(t, s)
+	one := k1.sign(t, s, "tokenIncrease", item, "10", nonce)
+	repeated, _ := json.Marshal([]string{one, one})
+	err := s.tx(func() error { return TokenIncrease(s, []string{item, "10", "", string(repeated), nonce}) })
+	if err == nil || err.Error() != "2010,Duplicate signature" {
+		t.Errorf("repeated signature : %v", err)
+	}
+	if err := increase(k3, k1); err != nil {
+		t.Fatal(err)
+	}
+	assertBalance(t, s, alice, item, "110")
+
+	nonce = alice.nonce(t, s)
+	sig := multiSign(t, s, []*testWallet{k1, k2}, "transfer", alice.Address, bob.Address, item, "5", nonce)
+	s.mustTx(t, func() error { return Transfer(s, alice.Address, bob.Address, "5", item, "0", sig, nonce, nil) })
+	assertBalance(t, s, bob, item, "5")
+
+	// signers are changed by the threshold signers
+	assertError(t, setSigners(byKeys(k1, k2), signersJSON(k1, k2, k3), "2"), "4900")
+	assertError(t, setSigners(byKeys(k1), signersJSON(k1, k2), "1"), "2010")
+	if err := setSigners(byKeys(k1, k2), signersJSON(k1, k2), "1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := increase(k2); err != nil {
+		t.Fatal(err)
+	}
+
+	// rotateKey makes the wallet of one key again
+	nonce = alice.nonce(t, s)
+	sig = multiSign(t, s, []*testWallet{k1}, "rotateKey", alice.Address, alice.PublicKey, "", "", nonce)
+	s.mustTx(t, func() error { return RotateKey(s, alice.Address, alice.PublicKey, "", "", sig, nonce, nil) })
+	if err := transfer(t, s, alice, bob, item, "5"); err != nil {
+		t.Fatal(err)
+	}
+	history, _ := KeyHistory(s, alice.Address)
+	if len(history) != 4 || history[1].Threshold != 2 || history[2].Threshold != 1 || len(history[3].Signers) != 0 {
+		t.Errorf("key history : %+v", history)
+	}
+}
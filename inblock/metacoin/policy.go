@@ -0,0 +1,296 @@
+package metacoin
+
+import (
+	"encoding/json"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hyperledger/fabric-chaincode-go/pkg/statebased"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+
+	"inblock/metacoin/mcerr"
+	"inblock/metacoin/mtc"
+	"inblock/metacoin/util"
+)
+
+// Key-level endorsement policy
+//
+// The wallet and the MRC010 token can have the state-based endorsement policy.
+// A member of every org in the policy must endorse the change of the key, in
+// addition to the chaincode endorsement policy.
+//
+// The wallet policy is set to the wallet document, the balance keys and the
+// receive shard keys (AddressShards), so the transfer to the wallet is endorsed
+// by the orgs too.
+//
+// The org of the policy must be in the policy org list of the admin
+// (setPolicyOrgs), the policy of the unknown MSP ID can not be endorsed.
+const (
+	PolicyOrgMax  = 16
+	policyOrgsKey = "POLICY_ORGS"
+)
+
+// TPolicyOrgs : MSP IDs allowed in the key policy, saved to the POLICY_ORGS key
+type TPolicyOrgs struct {
+	Orgs    []string `json:"orgs"`
+	JobType string   `json:"job_type"`
+	JobArgs string   `json:"job_args"`
+	JobDate int64    `json:"jobdate"`
+}
+
+var mspIDPattern = regexp.MustCompile(`^[a-zA-Z0-9._-]{1,64}$`)
+
+// parsePolicyOrgs - "," separated MSP ID list, sorted, "" is no policy
+func parsePolicyOrgs(orgs string) ([]string, error) {
+	var list []string
+	var exists = make(map[string]bool)
+
+	for _, org := range strings.Split(orgs, ",") {
+		if org = strings.TrimSpace(org); org == "" || exists[org] {
+			continue
+		}
+		if !mspIDPattern.MatchString(org) {
+			return nil, mcerr.New(mcerr.InvalidValue, "Invalid MSP ID ["+org+"]").WithField("orgs")
+		}
+		exists[org] = true
+		list = append(list, org)
+	}
+	if len(list) > PolicyOrgMax {
+		return nil, mcerr.New(mcerr.InvalidValue, "Too many orgs").WithField("orgs")
+	}
+	sort.Strings(list)
+	return list, nil
+}
+
+// GetPolicyOrgs - MSP IDs allowed in the key policy, empty list if not set
+func GetPolicyOrgs(stub shim.ChaincodeStubInterface) (TPolicyOrgs, error) {
+	var po TPolicyOrgs
+
+	value, err := stub.GetState(policyOrgsKey)
+	if err != nil {
+		return po, mcerr.Wrap(mcerr.LedgerRead, "Hyperledger internal error", err)
+	}
+	if value == nil {
+		po.Orgs = make([]string, 0)
+		return po, nil
+	}
+	if err = json.Unmarshal(value, &po); err != nil {
+		return po, mcerr.Wrap(mcerr.InvalidData, "Policy org list data is broken", err)
+	}
+	return po, nil
+}
+
+// SetPolicyOrgs - replace the MSP IDs allowed in the key policy (admin)
+//
+// orgs is the "," separated MSP ID list of the channel. The policy already set
+// is not changed when the org is removed from the list.
+func SetPolicyOrgs(stub shim.ChaincodeStubInterface, orgs string, args []string) error {
+	var po TPolicyOrgs
+	var dat []byte
+
+	id, err := CheckAdmin(stub)
+	if err != nil {
+		return err
+	}
+	if po.Orgs, err = parsePolicyOrgs(orgs); err != nil {
+		return err
+	}
+	if po.Orgs == nil {
+		po.Orgs = make([]string, 0)
+	}
+
+	po.JobType = "setPolicyOrgs"
+	po.JobDate = GetTxTime(stub)
+	if len(args) > 0 {
+		if dat, err = json.Marshal(args); err == nil {
+			po.JobArgs = string(dat)
+		}
+	}
+	if dat, err = json.Marshal(po); err != nil {
+		return mcerr.Wrap(mcerr.InvalidData, "Invalid Data format", err)
+	}
+	if err = stub.PutState(policyOrgsKey, dat); err != nil {
+		return mcerr.Wrap(mcerr.LedgerWrite, "Hyperledger internal error", err)
+	}
+	AddEvent(stub, TEvent{Type: "set_policy_orgs", Key: policyOrgsKey, Args: []string{strings.Join(po.Orgs, ","), id.String()}})
+	return nil
+}
+
+// checkPolicyOrgs - the orgs are in the policy org list
+func checkPolicyOrgs(stub shim.ChaincodeStubInterface, orgs []string) error {
+	po, err := GetPolicyOrgs(stub)
+	if err != nil {
+		return err
+	}
+	allowed := make(map[string]bool, len(po.Orgs))
+	for _, org := range po.Orgs {
+		allowed[org] = true
+	}
+	for _, org := range orgs {
+		if !allowed[org] {
+			return mcerr.New(mcerr.InvalidValue, "MSP ID ["+org+"] is not in the policy org list").WithField("orgs")
+		}
+	}
+	return nil
+}
+
+// keyPolicy - endorsement policy of the orgs, nil is no policy
+func keyPolicy(orgs []string) ([]byte, error) {
+	if len(orgs) == 0 {
+		return nil, nil
+	}
+	ep, err := statebased.NewStateEP(nil)
+	if err != nil {
+		return nil, mcerr.Wrap(mcerr.InvalidData, "Endorsement policy error", err)
+	}
+	if err = ep.AddOrgs(statebased.RoleTypeMember, orgs...); err != nil {
+		return nil, mcerr.Wrap(mcerr.InvalidData, "Endorsement policy error", err)
+	}
+	policy, err := ep.Policy()
+	if err != nil {
+		return nil, mcerr.Wrap(mcerr.InvalidData, "Endorsement policy error", err)
+	}
+	return policy, nil
+}
+
+// setKeyPolicies - set the policy of the orgs to the keys
+func setKeyPolicies(stub shim.ChaincodeStubInterface, orgs []string, keys ...string) error {
+	policy, err := keyPolicy(orgs)
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		if err = stub.SetStateValidationParameter(key, policy); err != nil {
+			return mcerr.Wrap(mcerr.LedgerWrite, "Hyperledger internal error", err)
+		}
+	}
+	return nil
+}
+
+// walletPolicyKeys - wallet document and the balance keys
+// The balance key is in the asset list, or in the map when it is not saved yet.
+func walletPolicyKeys(wallet mtc.TWallet) []string {
+	var keys = []string{wallet.Id}
+	var assets = make(map[string]bool)
+	for _, asset := range wallet.Assets {
+		assets[asset] = true
+	}
+	for asset := range walletBalances(wallet) {
+		assets[asset] = true
+	}
+	for asset := range assets {
+		keys = append(keys, balanceKey(wallet.Id, asset))
+	}
+	sort.Strings(keys[1:])
+	return keys
+}
+
+// walletShardKeys - receive shard keys of the wallet which exist
+func walletShardKeys(stub shim.ChaincodeStubInterface, wallet mtc.TWallet) ([]string, error) {
+	var keys []string
+	if wallet.Shards == 0 {
+		return keys, nil
+	}
+	for _, key := range walletPolicyKeys(wallet)[1:] {
+		asset := key[len(wallet.Id)+1:]
+		if !strings.HasPrefix(asset, AssetMRC010) {
+			continue
+		}
+		token, err := strconv.Atoi(asset[len(AssetMRC010):])
+		if err != nil {
+			continue
+		}
+		for n := 0; n < wallet.Shards; n++ {
+			key := shardKey(wallet.Id, token, n)
+			value, err := stub.GetState(key)
+			if err != nil {
+				return nil, mcerr.Wrap(mcerr.LedgerRead, "Hyperledger internal error", err)
+			}
+			if value != nil {
+				keys = append(keys, key)
+			}
+		}
+	}
+	return keys, nil
+}
+
+// SetKeyPolicy - set the endorsement policy of the wallet or the MRC010 token
+//
+// target is the address or the token id, orgs is the "," separated MSP ID list
+// and "" removes the policy.
+// The wallet signs "address|orgs|nonce", the token owner signs "TokenID|orgs|nonce".
+func SetKeyPolicy(stub shim.ChaincodeStubInterface, target, orgs, signature, tkey string, args []string) error {
+	list, err := parsePolicyOrgs(orgs)
+	if err != nil {
+		return err
+	}
+	if err = checkPolicyOrgs(stub, list); err != nil {
+		return err
+	}
+	orgs = strings.Join(list, ",")
+
+	if util.IsAddress(target) {
+		wallet, err := GetAddressInfo(stub, target)
+		if err != nil {
+			return err
+		}
+		if strings.Join(wallet.Policy, ",") == orgs {
+			return mcerr.New(mcerr.NoDataChange, "Policy is not changed").WithField("orgs")
+		}
+		if err = NonceCheck(stub, &wallet, tkey, "setKeyPolicy",
+			[]string{target, orgs, tkey},
+			signature); err != nil {
+			return err
+		}
+		wallet.Policy = list
+		if err = SetAddressInfo(stub, wallet, "setKeyPolicy", args); err != nil {
+			return err
+		}
+		keys, err := walletShardKeys(stub, wallet)
+		if err != nil {
+			return err
+		}
+		if err = setKeyPolicies(stub, list, append(walletPolicyKeys(wallet), keys...)...); err != nil {
+			return err
+		}
+		AddEvent(stub, TEvent{Type: "set_key_policy", Key: target, Args: []string{target, orgs}})
+		return nil
+	}
+
+	tk, _, err := GetMRC010(stub, target)
+	if err != nil {
+		return err
+	}
+	if strings.Join(tk.Policy, ",") == orgs {
+		return mcerr.New(mcerr.NoDataChange, "Policy is not changed").WithField("orgs")
+	}
+	if err = tokenOwnerSign(stub, tk, "setKeyPolicy", []string{tk.Id, orgs, tkey}, signature, tkey); err != nil {
+		return err
+	}
+	tk.Policy = list
+	if err = setMRC010(stub, tk, "setKeyPolicy", args); err != nil {
+		return err
+	}
+	return setKeyPolicies(stub, list, "TOKEN_DATA_"+tk.Id)
+}
+
+// KeyPolicy - orgs of the endorsement policy of the state key
+// key is the address, the balance key or TOKEN_DATA_<id>.
+func KeyPolicy(stub shim.ChaincodeStubInterface, key string) ([]string, error) {
+	policy, err := stub.GetStateValidationParameter(key)
+	if err != nil {
+		return nil, mcerr.Wrap(mcerr.LedgerRead, "Hyperledger internal error", err)
+	}
+	if len(policy) == 0 {
+		return []string{}, nil
+	}
+	ep, err := statebased.NewStateEP(policy)
+	if err != nil {
+		return nil, mcerr.Wrap(mcerr.InvalidData, "Endorsement policy error", err)
+	}
+	orgs := ep.ListOrgs()
+	sort.Strings(orgs)
+	return orgs, nil
+}
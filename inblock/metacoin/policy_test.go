@@ -0,0 +1,108 @@
+package metacoin
+
+import (
+	"strings"
+	"testing"
+)
+
+// assertPolicy - orgs of the key policy
+func assertPolicy(t *testing.T, s *testStub, key, expect string) {
+	t.Helper()
+	orgs, err := KeyPolicy(s, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(orgs, ","); got != expect {
+		t.Errorf("policy of %s : got [%s], expect [%s]", key, got, expect)
+	}
+}
+
+func TestKeyPolicy(t *testing.T) {
+	s := newTestStub()
+	alice := newGenesisWallet(t, s, "1000")
+	bob := newTestWallet(t, s)
+	item := registerToken(t, s, alice, "ITEM", "100")
+
+	// signer signs with the nonce of the wallet
+	setPolicyBy := func(signer, w *testWallet, target, orgs string) error {
+		nonce := w.nonce(t, s)
+		sig := signer.sign(t, s, "setKeyPolicy", target, orgs, nonce)
+		return s.tx(func() error { return SetKeyPolicy(s, target, orgs, sig, nonce, nil) })
+	}
+	setPolicy := func(w *testWallet, target, orgs string) error {
+		return setPolicyBy(w, w, target, orgs)
+	}
+
+	// orgs of the admin list
+	admin := s.Creator
+	s.Creator = testCreator("Org2MSP", "user", nil)
+	assertError(t, s.tx(func() error { return SetPolicyOrgs(s, "Org1MSP,Org2MSP", nil) }), "6030")
+	s.Creator = admin
+	assertError(t, setPolicy(bob, bob.Address, "Org1MSP"), "1003")
+	s.mustTx(t, func() error { return SetPolicyOrgs(s, "Org2MSP,Org1MSP", nil) })
+	if po, err := GetPolicyOrgs(s); err != nil || strings.Join(po.Orgs, ",") != "Org1MSP,Org2MSP" {
+		t.Errorf("policy org list : %+v, %v", po, err)
+	}
+	assertError(t, setPolicy(bob, bob.Address, "Org1MSP,Org9MSP"), "1003")
+
+	// wallet policy, orgs are sorted
+	assertError(t, setPolicy(bob, bob.Address, "Org2MSP,Org 1"), "1003")
+	assertError(t, setPolicyBy(alice, bob, bob.Address, "Org1MSP,Org2MSP"), "2010")
+	if err := setPolicy(bob, bob.Address, "Org1MSP,Org2MSP"); err != nil {
+		t.Fatal(err)
+	}
+	assertError(t, setPolicy(bob, bob.Address, "Org2MSP, Org1MSP"), "4900")
+	assertPolicy(t, s, bob.Address, "Org1MSP,Org2MSP")
+	assertPolicy(t, s, balanceKey(bob.Address, AssetMRC010+"0"), "Org1MSP,Org2MSP")
+
+	// the wallet works with the policy, the new balance key has the policy
+	if err := transfer(t, s, alice, bob, item, "10"); err != nil {
+		t.Fatal(err)
+	}
+	if err := transfer(t, s, bob, alice, item, "4"); err != nil {
+		t.Fatal(err)
+	}
+	assertBalance(t, s, bob, item, "6")
+	assertPolicy(t, s, balanceKey(bob.Address, AssetMRC010+item), "Org1MSP,Org2MSP")
+	assertPolicy(t, s, balanceKey(alice.Address, AssetMRC010+item), "")
+
+	// receive shard key has the policy
+	s.mustTx(t, func() error { return AddressShards(s, bob.Address, "2", nil) })
+	if err := transfer(t, s, alice, bob, item, "1"); err != nil {
+		t.Fatal(err)
+	}
+	var shard string
+	for _, key := range s.keys {
+		if strings.HasPrefix(key, balanceKey(bob.Address, AssetMRC010+item)+"_") {
+			shard = key
+		}
+	}
+	if shard == "" {
+		t.Fatalf("keys of the sharded receive : %v", s.keys)
+	}
+	assertPolicy(t, s, shard, "Org1MSP,Org2MSP")
+
+	// remove
+	if err := setPolicy(bob, bob.Address, ""); err != nil {
+		t.Fatal(err)
+	}
+	assertPolicy(t, s, bob.Address, "")
+	assertPolicy(t, s, balanceKey(bob.Address, AssetMRC010+item), "")
+	assertPolicy(t, s, shard, "")
+
+	// token policy is set by the owner
+	assertError(t, setPolicyBy(bob, alice, item, "Org1MSP"), "2010")
+	if err := setPolicy(alice, item, "Org1MSP"); err != nil {
+		t.Fatal(err)
+	}
+	assertPolicy(t, s, "TOKEN_DATA_"+item, "Org1MSP")
+	if tk, _, err := GetMRC010(s, item); err != nil || len(tk.Policy) != 1 {
+		t.Errorf("token policy : %+v, %v", tk.Policy, err)
+	}
+
+	// token functions work with the policy
+	nonce := alice.nonce(t, s)
+	sig := alice.sign(t, s, "tokenPause", item, "tokenPause", "", nonce)
+	s.mustTx(t, func() error { return TokenPause(s, item, sig, nonce, nil) })
+	assertPolicy(t, s, "TOKEN_DATA_"+item, "Org1MSP")
+}
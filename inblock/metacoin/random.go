@@ -0,0 +1,55 @@
+package metacoin
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"math/big"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+)
+
+// TxRandom : deterministic random source for one transaction.
+//
+// Every endorser must draw the same numbers, so the source is seeded from
+// data all peers share (tx id and the current state of the record).
+//
+//	seed    = hex(sha256(txid + "|" + stateHash))
+//	value_n = sha256(seed + uint64be(n))   n = 0, 1, 2 ...
+//	Intn(m) = value_n mod m
+//
+// Anyone holding seed can replay the draw.
+type TxRandom struct {
+	Seed    string
+	counter uint64
+}
+
+// NewTxRandom - make random source from tx id and the raw state of the record
+func NewTxRandom(stub shim.ChaincodeStubInterface, state []byte) *TxRandom {
+	stateHash := StateHash(state)
+	h := sha256.Sum256([]byte(stub.GetTxID() + "|" + stateHash))
+	return &TxRandom{Seed: hex.EncodeToString(h[:])}
+}
+
+// NewTxRandomFromSeed - replay random source from a stored seed
+func NewTxRandomFromSeed(seed string) *TxRandom {
+	return &TxRandom{Seed: seed}
+}
+
+// StateHash - hex sha256 of the state value
+func StateHash(state []byte) string {
+	h := sha256.Sum256(state)
+	return hex.EncodeToString(h[:])
+}
+
+// Intn - next number in [0, n)
+func (r *TxRandom) Intn(n int) int {
+	var buf [8]byte
+	if n <= 0 {
+		return 0
+	}
+	binary.BigEndian.PutUint64(buf[:], r.counter)
+	r.counter++
+	h := sha256.Sum256(append([]byte(r.Seed), buf[:]...))
+	return int(new(big.Int).Mod(new(big.Int).SetBytes(h[:]), big.NewInt(int64(n))).Int64())
+}
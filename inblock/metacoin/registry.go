@@ -0,0 +1,176 @@
+package metacoin
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+
+	"inblock/metacoin/mcerr"
+)
+
+// FuncType : read (query) or write (invoke)
+type FuncType string
+
+// FuncType list
+const (
+	FuncRead  FuncType = "read"
+	FuncWrite FuncType = "write"
+)
+
+// FuncHandler : chaincode function body
+// args is padded with "" up to the number of declared parameters.
+type FuncHandler func(stub shim.ChaincodeStubInterface, args []string) ([]byte, error)
+
+// TFuncParam : function parameter
+type TFuncParam struct {
+	Name     string `json:"name"`
+	Optional bool   `json:"optional,omitempty"`
+}
+
+// TFunc : registered chaincode function
+// Admin function is run by the admin only. (see CheckAdmin)
+type TFunc struct {
+	Name    string       `json:"name"`
+	Type    FuncType     `json:"type"`
+	Admin   bool         `json:"admin,omitempty"`
+	Params  []TFuncParam `json:"params"`
+	Handler FuncHandler  `json:"-"`
+}
+
+var funcRegistry = make(map[string]TFunc)
+
+// Params - make parameter list, name with "?" suffix is optional
+//
+// Example
+//
+//	Params("fromAddr", "toAddr", "memo?")
+func Params(names ...string) []TFuncParam {
+	params := make([]TFuncParam, len(names))
+	for i, name := range names {
+		if strings.HasSuffix(name, "?") {
+			params[i] = TFuncParam{Name: strings.TrimSuffix(name, "?"), Optional: true}
+		} else {
+			params[i] = TFuncParam{Name: name}
+		}
+	}
+	return params
+}
+
+// RegisterFunction - add function to the registry
+func RegisterFunction(f TFunc) {
+	if f.Name == "" || f.Handler == nil {
+		panic("metacoin: invalid function registration")
+	}
+	if _, exists := funcRegistry[f.Name]; exists {
+		panic("metacoin: function " + f.Name + " already registered")
+	}
+	if f.Type != FuncRead {
+		f.Type = FuncWrite
+	}
+	funcRegistry[f.Name] = f
+}
+
+// GetFunction - get registered function
+func GetFunction(name string) (TFunc, bool) {
+	f, exists := funcRegistry[name]
+	return f, exists
+}
+
+// FunctionList - registered function list, sort by name
+func FunctionList() []TFunc {
+	list := make([]TFunc, 0, len(funcRegistry))
+	for _, f := range funcRegistry {
+		list = append(list, f)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// RequiredCount - number of required parameters
+func (f TFunc) RequiredCount() int {
+	n := 0
+	for i, p := range f.Params {
+		if !p.Optional {
+			n = i + 1
+		}
+	}
+	return n
+}
+
+// CheckArgs - check argument count
+func (f TFunc) CheckArgs(args []string) error {
+	n := f.RequiredCount()
+	if len(args) >= n {
+		return nil
+	}
+	names := make([]string, len(f.Params))
+	for i, p := range f.Params {
+		names[i] = p.Name
+		if p.Optional {
+			names[i] = "[" + p.Name + "]"
+		}
+	}
+	return mcerr.New(mcerr.InvalidArguments,
+		f.Name+" operation must include "+strconv.Itoa(n)+" arguments : "+strings.Join(names, ", ")).WithDetails(f.Params)
+}
+
+// CallFunction - check the arguments and run the registered function
+func CallFunction(stub shim.ChaincodeStubInterface, name string, args []string) ([]byte, error) {
+	f, exists := funcRegistry[name]
+	if !exists {
+		return nil, mcerr.New(mcerr.InvalidArguments, "Unsupported operation ["+name+"]")
+	}
+
+	for idx, arg := range args {
+		args[idx] = strings.TrimSpace(arg)
+	}
+	if err := f.CheckArgs(args); err != nil {
+		return nil, err
+	}
+	for len(args) < len(f.Params) {
+		args = append(args, "")
+	}
+	if f.Admin {
+		if _, err := CheckAdmin(stub); err != nil {
+			return nil, err
+		}
+	}
+	if f.Type == FuncRead {
+		return f.Handler(stub, args)
+	}
+
+	// protocol fee of the write function, charged after the function
+	// reads the wallets written by the function. (see batchStub)
+	fs, err := GetFeeSchedule(stub)
+	if err != nil {
+		return nil, err
+	}
+	if _, isBatch := stub.(*batchStub); !isBatch && len(fs.Fees[name]) > 0 {
+		stub = &batchStub{ChaincodeStubInterface: stub, writes: make(map[string][]byte)}
+	}
+	takeTxSigner(stub)
+	value, err := f.Handler(stub, args)
+	signer := takeTxSigner(stub)
+	if err != nil {
+		return nil, err
+	}
+	if err = chargeFee(stub, fs, name, signer); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// Invoke - run the function and send the events of the transaction
+func Invoke(stub shim.ChaincodeStubInterface, function string, args []string) ([]byte, error) {
+	stub = newTxStub(stub)
+	value, err := CallFunction(stub, function, args)
+	if err != nil {
+		return nil, err
+	}
+	if err = FlushEvents(stub); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
@@ -0,0 +1,123 @@
+package metacoin
+
+import (
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/shopspring/decimal"
+
+	"inblock/metacoin/mcerr"
+	"inblock/metacoin/mtc"
+	"inblock/metacoin/util"
+)
+
+// Relayed transfer (fee-payer meta transaction)
+//
+// The user signs the transfer intent with the max fee, the fee token and the
+// relayer, and the relayer submits it, so the other relayer can not take the
+// signed request. The relayer signs the fee it takes, so the fee can
+// not be changed after the relayer signed, and the fee over the max fee of the
+// user fails. The transfer and the fee move in the one transaction with the
+// nonce of the user. The protocol fee of relayedTransfer is charged to the
+// relayer, which takes the fee of the user.
+//
+// The user signs "from|to|token|amount|feeToken|maxFee|relayer|nonce", the relayer
+// signs "relayer|from|to|token|amount|feeToken|maxFee|fee|nonce" with the
+// nonce of the user.
+
+// RelayedTransfer - transfer of the user submitted by the relayer, the relayer receives the fee
+func RelayedTransfer(stub shim.ChaincodeStubInterface, fromAddr, toAddr, token, amount, feeToken, maxFee, relayer, fee, signature, relayerSignature, tkey string, args []string) error {
+	var value, feeValue, maxFeeValue decimal.Decimal
+	var err error
+	var fromData, toData, relayerData mtc.TWallet
+
+	if !util.IsAddress(fromAddr) {
+		return mcerr.New(mcerr.InvalidFrom, "Invalid from address").WithField("from")
+	}
+	if !util.IsAddress(toAddr) {
+		return mcerr.New(mcerr.InvalidTo, "Invalid to address").WithField("to")
+	}
+	if !util.IsAddress(relayer) {
+		return mcerr.New(mcerr.InvalidAddress, "Invalid relayer address").WithField("relayer")
+	}
+	if fromAddr == toAddr || fromAddr == relayer {
+		return mcerr.New(mcerr.SameAddress, "From address must be different from the to address and the relayer")
+	}
+	if value, err = util.ParsePositive(amount); err != nil {
+		return mcerr.New(mcerr.NotInteger, "Amount must be a positive integer").WithField("amount")
+	}
+	if maxFeeValue, err = util.ParseNotNegative(maxFee); err != nil {
+		return mcerr.New(mcerr.NotInteger, "Max fee must be an integer string").WithField("maxFee")
+	}
+	if feeValue, err = util.ParseNotNegative(fee); err != nil {
+		return mcerr.New(mcerr.NotInteger, "Fee must be an integer string").WithField("fee")
+	}
+	if feeValue.GreaterThan(maxFeeValue) {
+		return mcerr.New(mcerr.InvalidValue, "Fee is over the max fee").
+			WithField("fee").WithDetails(map[string]interface{}{"maxFee": maxFee})
+	}
+	if _, _, err = GetMRC010(stub, token); err != nil {
+		return err
+	}
+	if _, _, err = GetMRC010(stub, feeToken); err != nil {
+		return err
+	}
+
+	if relayerData, err = GetAddressInfo(stub, relayer); err != nil {
+		return err
+	}
+	if _, err = payloadSignVerify(stub, &relayerData, "relayedTransfer",
+		[]string{relayer, fromAddr, toAddr, token, amount, feeToken, maxFee, fee, tkey},
+		relayerSignature); err != nil {
+		return mcerr.Wrap(mcerr.InvalidSign, "Invalid relayer signature", err).WithField("relayerSignature")
+	}
+	// the relayer pays the protocol fee, not the user
+	setTxSigner(stub, relayer)
+
+	if fromData, err = GetAddressInfo(stub, fromAddr); err != nil {
+		return err
+	}
+	if err = NonceCheck(stub, &fromData, tkey, "relayedTransfer",
+		[]string{fromAddr, toAddr, token, amount, feeToken, maxFee, relayer, tkey},
+		signature); err != nil {
+		return err
+	}
+
+	// the relayer can be the receiver of the transfer
+	var feeTo = &relayerData
+	if toAddr == relayer {
+		toData = relayerData
+		feeTo = &toData
+	} else if toData, err = GetAddressInfo(stub, toAddr); err != nil {
+		return err
+	}
+
+	if err = MoveToken(stub, &fromData, &toData, token, value.String(), 0); err != nil {
+		if mcerr.Is(err, mcerr.NotEnoughBalance) {
+			return mcerr.New(mcerr.FromBalance, "The balance of fromuser is insufficient")
+		}
+		return err
+	}
+	payment := []mtc.TDexPaymentInfo{{FromAddr: fromAddr, ToAddr: toAddr, Amount: value.String(), TokenID: token, PayType: "transfer"}}
+	if feeValue.IsPositive() {
+		if err = MoveToken(stub, &fromData, feeTo, feeToken, feeValue.String(), 0); err != nil {
+			if mcerr.Is(err, mcerr.NotEnoughBalance) {
+				return mcerr.New(mcerr.FromBalance, "The balance of fromuser is insufficient for the fee")
+			}
+			return err
+		}
+		payment = append(payment, mtc.TDexPaymentInfo{FromAddr: fromAddr, ToAddr: relayer, Amount: feeValue.String(), TokenID: feeToken, PayType: "relay_fee"})
+	}
+
+	if err = SetAddressInfo(stub, fromData, "transfer", args); err != nil {
+		return err
+	}
+	if err = SetAddressInfo(stub, toData, "receive", args); err != nil {
+		return err
+	}
+	if toAddr != relayer && feeValue.IsPositive() {
+		if err = SetAddressInfo(stub, relayerData, "relay_fee", args); err != nil {
+			return err
+		}
+	}
+	AddEvent(stub, TEvent{Type: "relayed_transfer", Key: fromAddr, Args: args, Payment: payment})
+	return nil
+}
@@ -0,0 +1,70 @@
+package metacoin
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestRelayedTransfer(t *testing.T) {
+	s := newTestStub()
+	alice := newGenesisWallet(t, s, "1000")
+	bob := newTestWallet(t, s)
+	relayer := newTestWallet(t, s)
+	item := registerToken(t, s, alice, "ITEM", "100")
+
+	relayBy := func(submitter, to *testWallet, amount, maxFee, fee string, relayerSigner *testWallet) error {
+		nonce := alice.nonce(t, s)
+		sig := alice.sign(t, s, "relayedTransfer", alice.Address, to.Address, item, amount, "0", maxFee, relayer.Address, nonce)
+		rsig := relayerSigner.sign(t, s, "relayedTransfer", submitter.Address, alice.Address, to.Address, item, amount, "0", maxFee, fee, nonce)
+		return s.tx(func() error {
+			return RelayedTransfer(s, alice.Address, to.Address, item, amount, "0", maxFee, submitter.Address, fee, sig, rsig, nonce, nil)
+		})
+	}
+	relay := func(to *testWallet, amount, maxFee, fee string, relayerSigner *testWallet) error {
+		return relayBy(relayer, to, amount, maxFee, fee, relayerSigner)
+	}
+
+	assertError(t, relay(bob, "10", "5", "6", relayer), "1003")
+	assertError(t, relay(bob, "10", "5", "5", bob), "2010")
+	// the request signed for the relayer can not be submitted by the other relayer
+	other := newTestWallet(t, s)
+	assertError(t, relayBy(other, bob, "10", "5", "5", other), "2010")
+	if err := relay(bob, "10", "5", "3", relayer); err != nil {
+		t.Fatal(err)
+	}
+	assertBalance(t, s, alice, item, "90")
+	assertBalance(t, s, bob, item, "10")
+	assertBalance(t, s, alice, "0", "997")
+	assertBalance(t, s, relayer, "0", "3")
+
+	// the relayer receives the transfer and the fee
+	if err := relay(relayer, "20", "5", "5", relayer); err != nil {
+		t.Fatal(err)
+	}
+	assertBalance(t, s, relayer, item, "20")
+	assertBalance(t, s, relayer, "0", "8")
+
+	// the fee is moved with the transfer or not at all
+	assertError(t, relay(bob, "100", "5", "5", relayer), "5001")
+	assertBalance(t, s, alice, item, "70")
+	assertBalance(t, s, alice, "0", "992")
+
+	// the relayer pays the protocol fee of relayedTransfer
+	treasury := newTestWallet(t, s)
+	value, _ := json.Marshal(TFeeSchedule{Treasury: treasury.Address,
+		Fees: map[string][]TFeeAmount{"relayedTransfer": {{Token: "0", Amount: "2"}}}})
+	s.mustTx(t, func() error { return SetFeeSchedule(s, string(value), nil) })
+	nonce := alice.nonce(t, s)
+	sig := alice.sign(t, s, "relayedTransfer", alice.Address, bob.Address, item, "10", "0", "5", relayer.Address, nonce)
+	rsig := relayer.sign(t, s, "relayedTransfer", relayer.Address, alice.Address, bob.Address, item, "10", "0", "5", "4", nonce)
+	s.mustTx(t, func() error {
+		_, err := CallFunction(s, "relayedTransfer", []string{alice.Address, bob.Address, item, "10", "0", "5", relayer.Address, "4", sig, rsig, nonce})
+		return err
+	})
+	assertBalance(t, s, alice, "0", "988")
+	assertBalance(t, s, relayer, "0", "10")
+	assertBalance(t, s, treasury, "0", "2")
+	if ev := s.lastEvent(t, "fee"); ev.Key != relayer.Address {
+		t.Errorf("fee event : %+v", ev)
+	}
+}
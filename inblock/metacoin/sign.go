@@ -0,0 +1,142 @@
+package metacoin
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-protos-go/peer"
+
+	"inblock/metacoin/mcerr"
+	"inblock/metacoin/mtc"
+	"inblock/metacoin/util"
+)
+
+// Signing payload
+//
+// The wallet signs the canonical payload of the request
+//
+//	MTC1|<channel>|<chaincode>|<function>|<field 1>|...|<field n>
+//
+// Every item after the version is "<byte length>:<value>", ex)
+//
+//	MTC1|9:mychannel|8:metacoin|8:transfer|36:MT...|36:MT...|1:0|2:10|40:3fa2...
+//
+// The fields are the same fields of the legacy signature. The channel, the
+// chaincode and the function bind the signature to one operation, and the
+// length prefix keeps "|" in a field from shifting the other fields.
+//
+// The wallet made before the format (SignFormat "") accepts the legacy "|"
+// joined fields too, setSignFormat turns it off. New wallets use SignFormatV1.
+const (
+	SignFormatLegacy = ""   // canonical payload and the legacy "|" joined fields
+	SignFormatV1     = "v1" // canonical payload only
+
+	signPayloadVersion = "MTC1"
+)
+
+// ChaincodeName - chaincode name of the payload when the transaction has no
+// signed proposal (dev mode, unit test).
+var ChaincodeName = "metacoin"
+
+// chaincodeName - invoked chaincode name in the signed proposal
+func chaincodeName(stub shim.ChaincodeStubInterface) (string, error) {
+	sp, err := stub.GetSignedProposal()
+	if err != nil {
+		return "", mcerr.Wrap(mcerr.LedgerRead, "Hyperledger internal error", err)
+	}
+	if sp == nil {
+		return ChaincodeName, nil
+	}
+
+	var prop peer.Proposal
+	var payload peer.ChaincodeProposalPayload
+	var spec peer.ChaincodeInvocationSpec
+	if err = proto.Unmarshal(sp.ProposalBytes, &prop); err != nil {
+		return "", mcerr.Wrap(mcerr.InvalidData, "Proposal decode error", err)
+	}
+	if err = proto.Unmarshal(prop.Payload, &payload); err != nil {
+		return "", mcerr.Wrap(mcerr.InvalidData, "Proposal decode error", err)
+	}
+	if err = proto.Unmarshal(payload.Input, &spec); err != nil {
+		return "", mcerr.Wrap(mcerr.InvalidData, "Proposal decode error", err)
+	}
+	if name := spec.GetChaincodeSpec().GetChaincodeId().GetName(); name != "" {
+		return name, nil
+	}
+	return ChaincodeName, nil
+}
+
+// SignPayload - canonical payload of the function and the fields
+func SignPayload(stub shim.ChaincodeStubInterface, function string, fields ...string) (string, error) {
+	name, err := chaincodeName(stub)
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	sb.WriteString(signPayloadVersion)
+	for _, v := range append([]string{stub.GetChannelID(), name, function}, fields...) {
+		sb.WriteString("|")
+		sb.WriteString(strconv.Itoa(len(v)))
+		sb.WriteString(":")
+		sb.WriteString(v)
+	}
+	return sb.String(), nil
+}
+
+// payloadSignVerify - verify the signature of the function, returns the signed data
+// The legacy "|" joined fields are tried when the wallet accepts the legacy
+// format, the delegate key in the scope of the function is tried last.
+func payloadSignVerify(stub shim.ChaincodeStubInterface, wallet *mtc.TWallet, function string, fields []string, signature string) (string, error) {
+	data, err := SignPayload(stub, function, fields...)
+	if err != nil {
+		return "", err
+	}
+	if err = WalletSignVerify(*wallet, data, signature); err == nil {
+		setTxDelegate(stub, wallet.Id, "")
+		return data, nil
+	}
+	if wallet.SignFormat == SignFormatLegacy {
+		legacy := strings.Join(fields, "|")
+		if WalletSignVerify(*wallet, legacy, signature) == nil {
+			setTxDelegate(stub, wallet.Id, "")
+			return legacy, nil
+		}
+	}
+	if delegate := delegateVerify(stub, *wallet, function, data, signature); delegate != "" {
+		setTxDelegate(stub, wallet.Id, delegate)
+		return data, nil
+	}
+	return "", err
+}
+
+// SetSignFormat - change the signing payload format of the wallet
+// The wallet signs the canonical payload of "address|format|nonce".
+func SetSignFormat(stub shim.ChaincodeStubInterface, address, format, signature, tkey string, args []string) error {
+	if format != SignFormatLegacy && format != SignFormatV1 {
+		return mcerr.New(mcerr.InvalidValue, "Sign format must be \"\" or \""+SignFormatV1+"\"").WithField("format")
+	}
+	if !util.IsAddress(address) {
+		return mcerr.New(mcerr.InvalidAddress, "["+address+"] is not Metacoin address")
+	}
+	wallet, err := GetAddressInfo(stub, address)
+	if err != nil {
+		return err
+	}
+	if wallet.SignFormat == format {
+		return mcerr.New(mcerr.NoDataChange, "Sign format is not changed").WithField("format")
+	}
+	if err = NonceCheck(stub, &wallet, tkey, "setSignFormat",
+		[]string{address, format, tkey},
+		signature); err != nil {
+		return err
+	}
+	wallet.SignFormat = format
+	if err = SetAddressInfo(stub, wallet, "setSignFormat", args); err != nil {
+		return err
+	}
+	AddEvent(stub, TEvent{Type: "set_sign_format", Key: address, Args: []string{address, format}})
+	return nil
+}
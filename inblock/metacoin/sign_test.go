@@ -0,0 +1,78 @@
+package metacoin
+
+import (
+	"testing"
+)
+
+func TestSignPayload(t *testing.T) {
+	s := newTestStub()
+	payload, err := SignPayload(s, "transfer", "MTa|b", "", "10")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expect := "MTC1|11:testchannel|8:metacoin|8:transfer|5:MTa|b|0:|2:10"; payload != expect {
+		t.Errorf("payload : got %s, expect %s", payload, expect)
+	}
+
+	// the fields are not shifted by "|"
+	other, _ := SignPayload(s, "transfer", "MTa", "b|", "10")
+	if other == payload {
+		t.Error("payload of the other fields is the same")
+	}
+
+	// chaincode name of the signed proposal
+	s.signed = testProposal("mtc")
+	if payload, _ = SignPayload(s, "transfer"); payload != "MTC1|11:testchannel|3:mtc|8:transfer" {
+		t.Errorf("payload of the proposal : %s", payload)
+	}
+}
+
+func TestSignFormat(t *testing.T) {
+	s := newTestStub()
+	alice := newGenesisWallet(t, s, "1000")
+	bob := newTestWallet(t, s)
+
+	send := func(sign func(nonce string) string) error {
+		nonce := alice.nonce(t, s)
+		sig := sign(nonce)
+		return s.tx(func() error {
+			return Transfer(s, alice.Address, bob.Address, "10", "0", "0", sig, nonce, nil)
+		})
+	}
+	legacy := func(nonce string) string {
+		return alice.signLegacy(t, alice.Address, bob.Address, "0", "10", nonce)
+	}
+
+	// new wallet accepts the canonical payload of the function only
+	assertError(t, send(legacy), "2010")
+	assertError(t, send(func(nonce string) string {
+		return alice.sign(t, s, "mrc010sell", alice.Address, bob.Address, "0", "10", nonce)
+	}), "2010")
+	if err := send(func(nonce string) string {
+		return alice.sign(t, s, "transfer", alice.Address, bob.Address, "0", "10", nonce)
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// wallet before the canonical payload
+	setFormat := func(format string) error {
+		nonce := alice.nonce(t, s)
+		sig := alice.sign(t, s, "setSignFormat", alice.Address, format, nonce)
+		return s.tx(func() error { return SetSignFormat(s, alice.Address, format, sig, nonce, nil) })
+	}
+	assertError(t, setFormat("v2"), "1003")
+	if err := setFormat(SignFormatLegacy); err != nil {
+		t.Fatal(err)
+	}
+	assertError(t, setFormat(SignFormatLegacy), "4900")
+	if err := send(legacy); err != nil {
+		t.Fatal(err)
+	}
+	assertBalance(t, s, bob, "0", "20")
+
+	// legacy format off
+	if err := setFormat(SignFormatV1); err != nil {
+		t.Fatal(err)
+	}
+	assertError(t, send(legacy), "2010")
+}
@@ -2,16 +2,15 @@ package metacoin
 
 import (
 	"errors"
-	"fmt"
 	"strconv"
 	"strings"
-	"time"
 
 	"encoding/json"
 
 	"github.com/hyperledger/fabric-chaincode-go/shim"
 	"github.com/shopspring/decimal"
 
+	"inblock/metacoin/mcerr"
 	"inblock/metacoin/mtc"
 	"inblock/metacoin/util"
 )
@@ -63,18 +62,18 @@ func Mrc040get(stub shim.ChaincodeStubInterface, mrc040Key string) (string, erro
 	var mrc040 TSTODEXItem
 
 	if strings.Index(mrc040Key, "MRC040_") != 0 {
-		return "", errors.New("6103,invalid MRC040 data address")
+		return "", mcerr.New(mcerr.InvalidMRC040Address, "invalid MRC040 data address")
 	}
 
 	dat, err = stub.GetState(mrc040Key)
 	if err != nil {
-		return "", errors.New("8100,Hyperledger internal error - " + err.Error())
+		return "", mcerr.Wrap(mcerr.LedgerRead, "Hyperledger internal error", err)
 	}
 	if dat == nil {
-		return "", errors.New("6005,MRC040 data not exist")
+		return "", mcerr.New(mcerr.MRC040NotFound, "MRC040 data not exist")
 	}
 	if err = json.Unmarshal(dat, &mrc040); err != nil {
-		return "", errors.New("6206,MRC040 [" + mrc040Key + "] is in the wrong data")
+		return "", mcerr.New(mcerr.WrongMRC040Data, "MRC040 ["+mrc040Key+"] is in the wrong data")
 	}
 
 	return string(dat), nil
@@ -96,14 +95,14 @@ func StodexRegister(stub shim.ChaincodeStubInterface,
 	var data []byte
 
 	if strings.Index(exchangeItemPK, "MRC040_") != 0 {
-		return errors.New("6103,invalid MRC040 data address")
+		return mcerr.New(mcerr.InvalidMRC040Address, "invalid MRC040 data address")
 	}
 
 	if data, err = stub.GetState(exchangeItemPK); err != nil {
-		return errors.New("8100,Hyperledger internal error - " + err.Error())
+		return mcerr.Wrap(mcerr.LedgerRead, "Hyperledger internal error", err)
 	}
 	if data != nil {
-		return errors.New("6100,MRC040 [" + exchangeItemPK + "] is already exists")
+		return mcerr.New(mcerr.AlreadyExists, "MRC040 ["+exchangeItemPK+"] is already exists")
 	}
 
 	// get owner info.
@@ -111,8 +110,8 @@ func StodexRegister(stub shim.ChaincodeStubInterface,
 		return err
 	}
 
-	if err = NonceCheck(&ownerData, tkey,
-		strings.Join([]string{owner, BaseToken, TargetToken, price, qtt, tkey}, "|"),
+	if err = NonceCheck(stub, &ownerData, tkey, "stodexRegister",
+		[]string{owner, BaseToken, TargetToken, price, qtt, tkey},
 		signature); err != nil {
 		return err
 	}
@@ -127,24 +126,24 @@ func StodexRegister(stub shim.ChaincodeStubInterface,
 
 	// token pair check.
 	if BaseTokenData.TargetToken == nil {
-		return errors.New("6501,Base token is not allow exchange to target token")
+		return mcerr.New(mcerr.ExchangeBaseNotAllowed, "Base token is not allow exchange to target token")
 	}
 
 	if _, exists := BaseTokenData.TargetToken[TargetTokenSN]; !exists {
-		return errors.New("6501,Base token is not allow exchange to target token")
+		return mcerr.New(mcerr.ExchangeBaseNotAllowed, "Base token is not allow exchange to target token")
 	}
 
 	if TargetTokenData.BaseToken != BaseTokenSN {
-		return errors.New("6502,Exchange token is not allow exchange to base token")
+		return mcerr.New(mcerr.ExchangeTargetNotAllowed, "Exchange token is not allow exchange to base token")
 	}
 
 	// price, qtt format check.
 	if Price, err = util.ParsePositive(price); err != nil {
-		return errors.New("1103,Price must be an integer string")
+		return mcerr.New(mcerr.InvalidPrice, "Price must be an integer string")
 	}
 
 	if Qtt, err = util.ParsePositive(qtt); err != nil {
-		return errors.New("1105,Qtt must be an integer string")
+		return mcerr.New(mcerr.InvalidQtt, "Qtt must be an integer string")
 	}
 
 	if side == "SELL" {
@@ -155,12 +154,12 @@ func StodexRegister(stub shim.ChaincodeStubInterface,
 		Divider, _ := decimal.NewFromString("10")
 		TotalAmount = Price.Mul(Qtt).Div(Divider.Pow(decimal.New(int64(TargetTokenData.Decimal), 0)))
 	} else {
-		return errors.New("1205,Side must SELL or BUY")
+		return mcerr.New(mcerr.InvalidSide, "Side must SELL or BUY")
 	}
 
 	// total amount, qtt precision check.
 	if TotalAmount.Cmp(TotalAmount.Truncate(0)) != 0 {
-		return errors.New("1203,Price precision is too long")
+		return mcerr.New(mcerr.PricePrecision, "Price precision is too long")
 	}
 
 	// collect token balance
@@ -190,11 +189,11 @@ func StodexRegister(stub shim.ChaincodeStubInterface,
 	item.Price = Price.String()
 	item.Qtt = Qtt.String()
 	item.RemainQtt = Qtt.String()
-	item.Regdate = time.Now().Unix()
+	item.Regdate = GetTxTime(stub)
 	item.CompleteDate = 0
 	item.Status = "WAIT"
 	item.Type = "MRC040"
-	item.JobDate = time.Now().Unix()
+	item.JobDate = GetTxTime(stub)
 	item.JobType = "stodexRegister"
 	if len(args) > 0 {
 		if data, err = json.Marshal(args); err == nil {
@@ -206,10 +205,10 @@ func StodexRegister(stub shim.ChaincodeStubInterface,
 
 	buf, _ := json.Marshal(item)
 	if _, err = stub.GetState(exchangeItemPK); err != nil {
-		return errors.New("8100,Hyperledger internal error - " + err.Error())
+		return mcerr.Wrap(mcerr.LedgerRead, "Hyperledger internal error", err)
 	}
 	if err = stub.PutState(exchangeItemPK, buf); err != nil {
-		return errors.New("8600,Hyperledger internal error - " + err.Error())
+		return mcerr.Wrap(mcerr.LedgerWrite, "Hyperledger internal error", err)
 	}
 	return nil
 }
@@ -217,35 +216,34 @@ func StodexRegister(stub shim.ChaincodeStubInterface,
 // StodexUnRegister - STO token unregister for DEX.
 func StodexUnRegister(stub shim.ChaincodeStubInterface, owner, exchangeItemPK, signature, tkey string, args []string) error {
 	var err error
-	var Price, Qtt, TotalAmount, tempCoin decimal.Decimal
+	var Price, Qtt, TotalAmount decimal.Decimal
 	var ownerData mtc.TWallet
 	var tokenSN int
-	var balance mtc.TMRC010Balance
 	var item TSTODEXItem
 	var data []byte
 	var TargetTokenData mtc.TMRC010
 
 	if strings.Index(exchangeItemPK, "MRC040_") != 0 {
-		return errors.New("6103,invalid MRC040 data address")
+		return mcerr.New(mcerr.InvalidMRC040Address, "invalid MRC040 data address")
 	}
 
 	if data, err = stub.GetState(exchangeItemPK); err != nil {
-		return errors.New("8100,Hyperledger internal error - " + err.Error())
+		return mcerr.Wrap(mcerr.LedgerRead, "Hyperledger internal error", err)
 	}
 	if data == nil {
-		return errors.New("4207,Exchange item not found")
+		return mcerr.New(mcerr.ExchangeNotFound, "Exchange item not found")
 	}
 
 	if err = json.Unmarshal(data, &item); err != nil {
-		return errors.New("4208,Invalid exchange item data")
+		return mcerr.New(mcerr.InvalidExchange, "Invalid exchange item data")
 	}
 
 	if item.Owner != owner {
-		return errors.New("4206,Is not your MRC040 ITEM")
+		return mcerr.New(mcerr.NotItemOwner, "Is not your MRC040 ITEM")
 	}
 
 	if item.Status == "CANCEL" {
-		return errors.New("4203,Item is already canceled")
+		return mcerr.New(mcerr.ItemCanceled, "Item is already canceled")
 	}
 
 	// get owner info.
@@ -253,18 +251,18 @@ func StodexUnRegister(stub shim.ChaincodeStubInterface, owner, exchangeItemPK, s
 		return err
 	}
 
-	if err = NonceCheck(&ownerData, tkey,
-		strings.Join([]string{owner, exchangeItemPK, tkey}, "|"),
+	if err = NonceCheck(stub, &ownerData, tkey, "stodexUnRegister",
+		[]string{owner, exchangeItemPK, tkey},
 		signature); err != nil {
 		return err
 	}
 
 	// price, qtt format check.
 	if Price, err = decimal.NewFromString(item.Price); err != nil {
-		return errors.New("4101,Invalid Price format, Price must numeric only")
+		return mcerr.New(mcerr.InvalidPriceFormat, "Invalid Price format, Price must numeric only")
 	}
 	if Qtt, err = util.ParsePositive(item.RemainQtt); err != nil {
-		return errors.New("4102,Invalid Qtt format, Qtt must numeric only")
+		return mcerr.New(mcerr.InvalidQttFormat, "Invalid Qtt format, Qtt must numeric only")
 	}
 
 	if item.Side == "SELL" {
@@ -279,37 +277,14 @@ func StodexUnRegister(stub shim.ChaincodeStubInterface, owner, exchangeItemPK, s
 		}
 		TotalAmount = Price.Mul(Qtt).Div(Divider.Pow(decimal.New(int64(TargetTokenData.Decimal), 0)))
 	} else {
-		return errors.New("4103,Invalid item data")
+		return mcerr.New(mcerr.InvalidItem, "Invalid item data")
 	}
 
 	// collect token balance
-	isBalanceFound := false
-	nowTime := time.Now().Unix()
-	for index, element := range ownerData.Balance {
-		if element.Token != tokenSN {
-			continue
-		}
-
-		if nowTime <= element.UnlockDate {
-			continue
-		}
-
-		if tempCoin, err = decimal.NewFromString(element.Balance); err != nil {
-			continue
-		}
-
-		ownerData.Balance[index].Balance = TotalAmount.Add(tempCoin).String()
-		isBalanceFound = true
-		break
-	}
-
-	// remainAmount > 0 ?
-	if !isBalanceFound {
-		balance.Balance = TotalAmount.String()
-		balance.Token = tokenSN
-		balance.UnlockDate = 0
-		ownerData.Balance = append(ownerData.Balance, balance)
+	if err = loadMRC010(stub, &ownerData, tokenSN); err != nil {
+		return err
 	}
+	mrc010AddBalance(&ownerData, tokenSN, TotalAmount, 0, GetTxTime(stub))
 
 	if ownerData.Pending == nil {
 		ownerData.Pending = make(map[int]string)
@@ -327,10 +302,10 @@ func StodexUnRegister(stub shim.ChaincodeStubInterface, owner, exchangeItemPK, s
 		return err
 	}
 
-	item.CancelDate = time.Now().Unix()
+	item.CancelDate = GetTxTime(stub)
 	item.Status = "CANCEL"
 	item.Type = "MRC040"
-	item.JobDate = time.Now().Unix()
+	item.JobDate = GetTxTime(stub)
 	item.JobType = "stodexUnRegister"
 	if len(args) > 0 {
 		if data, err = json.Marshal(args); err == nil {
@@ -349,7 +324,7 @@ func StodexUnRegister(stub shim.ChaincodeStubInterface, owner, exchangeItemPK, s
 // StodexExchange - STO token exchange using DEX.
 func StodexExchange(stub shim.ChaincodeStubInterface, requester, qtt, exchangeItemPK, exchangePK, signature, tkey string, args []string) error {
 	var err error
-	var Price, Qtt, ownerPlusAmount, ownerMinusAmount, remainAmount, tAmount decimal.Decimal
+	var Price, Qtt, ownerPlusAmount, ownerMinusAmount, tAmount decimal.Decimal
 	var ownerData, requesterData mtc.TWallet
 	var BaseTokenData, TargetTokenData mtc.TMRC010
 	var ownerPlusToken, ownerMinusToken int
@@ -358,42 +333,40 @@ func StodexExchange(stub shim.ChaincodeStubInterface, requester, qtt, exchangeIt
 	var exchangeResult TSTODEXResult
 	var data []byte
 	var targs []string
-	var balance mtc.TMRC010Balance
-	var balanceList []mtc.TMRC010Balance
 	var requesterSide string
 
-	now = time.Now().Unix()
+	now = GetTxTime(stub)
 	if _, err = stub.GetState(exchangePK); err != nil {
-		return errors.New("8100,Hyperledger internal error - " + err.Error())
+		return mcerr.Wrap(mcerr.LedgerRead, "Hyperledger internal error", err)
 	}
 	if strings.Index(exchangeItemPK, "MRC040_") != 0 {
-		return errors.New("6103,invalid MRC040 data address")
+		return mcerr.New(mcerr.InvalidMRC040Address, "invalid MRC040 data address")
 	}
 	if data, err = stub.GetState(exchangeItemPK); err != nil {
-		return errors.New("8100,Hyperledger internal error - " + err.Error())
+		return mcerr.Wrap(mcerr.LedgerRead, "Hyperledger internal error", err)
 	}
 	if data == nil {
-		return errors.New("6002,ExchangeItem not found - " + exchangeItemPK)
+		return mcerr.New(mcerr.ExchangeItemNotFound, "ExchangeItem not found - "+exchangeItemPK)
 	}
 	json.Unmarshal(data, &item)
 	if item.Status == "COMPLETE" {
-		return errors.New("6300,Already completed item")
+		return mcerr.New(mcerr.AlreadyCompleted, "Already completed item")
 	}
 
 	if item.Status == "CANCEL" {
-		return errors.New("6301,Already canceled item")
+		return mcerr.New(mcerr.AlreadyCanceled, "Already canceled item")
 	}
 
 	if item.Owner == requester {
-		return errors.New("4100,You can't trade your own ITEM")
+		return mcerr.New(mcerr.OwnItem, "You can't trade your own ITEM")
 	}
 	// get owner info.
 	if requesterData, err = GetAddressInfo(stub, requester); err != nil {
 		return err
 	}
 
-	if err = NonceCheck(&requesterData, tkey,
-		strings.Join([]string{requester, exchangeItemPK, qtt, tkey}, "|"),
+	if err = NonceCheck(stub, &requesterData, tkey, "stodexExchange",
+		[]string{requester, exchangeItemPK, qtt, tkey},
 		signature); err != nil {
 		return err
 	}
@@ -410,37 +383,37 @@ func StodexExchange(stub shim.ChaincodeStubInterface, requester, qtt, exchangeIt
 
 	// token pair check.
 	if BaseTokenData.TargetToken == nil {
-		return errors.New("6501,Base token is not allow exchange to target token")
+		return mcerr.New(mcerr.ExchangeBaseNotAllowed, "Base token is not allow exchange to target token")
 	}
 
 	if _, exists := BaseTokenData.TargetToken[item.TargetToken]; !exists {
 		targs = nil
 		targs = append(targs, item.Owner, exchangeItemPK, "Base token is not allow exchange to target token")
 		StodexUnRegister(stub, item.Owner, exchangeItemPK, "", "", targs)
-		return errors.New("6501,Base token is not allow exchange to target token")
+		return mcerr.New(mcerr.ExchangeBaseNotAllowed, "Base token is not allow exchange to target token")
 	}
 
 	if TargetTokenData.BaseToken != item.BaseToken {
 		targs = nil
 		targs = append(targs, item.Owner, exchangeItemPK, "Exchange token is not allow exchange to base token")
 		StodexUnRegister(stub, item.Owner, exchangeItemPK, "", "", targs)
-		return errors.New("6502,Exchange token is not allow exchange to base token")
+		return mcerr.New(mcerr.ExchangeTargetNotAllowed, "Exchange token is not allow exchange to base token")
 	}
 
 	// price, qtt format check.
 	if Price, err = util.ParsePositive(item.Price); err != nil {
-		return errors.New("1103,Price must be an integer string")
+		return mcerr.New(mcerr.InvalidPrice, "Price must be an integer string")
 	}
 	if Qtt, err = util.ParsePositive(qtt); err != nil {
-		return errors.New("1105,Qtt must be an integer string")
+		return mcerr.New(mcerr.InvalidQtt, "Qtt must be an integer string")
 	}
 
 	if tAmount, err = decimal.NewFromString(item.RemainQtt); err != nil {
-		return errors.New("1105,Qtt must be an integer string")
+		return mcerr.New(mcerr.InvalidQtt, "Qtt must be an integer string")
 	}
 
 	if Qtt.Cmp(tAmount) > 0 {
-		return errors.New("1106,Qtt must be greater then zero")
+		return mcerr.New(mcerr.InvalidMeltAmount, "Qtt must be greater then zero")
 	}
 	item.RemainQtt = tAmount.Sub(Qtt).String()
 
@@ -456,7 +429,7 @@ func StodexExchange(stub shim.ChaincodeStubInterface, requester, qtt, exchangeIt
 		ownerMinusAmount = Qtt
 		requesterSide = "BUY"
 		if ownerPlusAmount.Cmp(ownerPlusAmount.Truncate(0)) != 0 {
-			return errors.New("1204,QTT precision is too long")
+			return mcerr.New(mcerr.QttPrecision, "QTT precision is too long")
 		}
 	} else if item.Side == "BUY" {
 		ownerPlusToken = item.TargetToken
@@ -466,58 +439,23 @@ func StodexExchange(stub shim.ChaincodeStubInterface, requester, qtt, exchangeIt
 		ownerMinusAmount = Price.Mul(Qtt).Div(Divider.Pow(decimal.New(int64(TargetTokenData.Decimal), 0)))
 		requesterSide = "SELL"
 		if ownerMinusAmount.Cmp(ownerMinusAmount.Truncate(0)) != 0 {
-			return errors.New("1204,QTT precision is too long")
+			return mcerr.New(mcerr.QttPrecision, "QTT precision is too long")
 		}
 	} else {
-		return errors.New("6600,Exchange item side is invalid")
+		return mcerr.New(mcerr.InvalidExchangeSide, "Exchange item side is invalid")
 	}
 
-	// owner plus check.
-	isBalanceClean := false
-	nowTime := time.Now().Unix()
-	remainAmount = ownerPlusAmount
 	// requester balance check.
-	for index, element := range requesterData.Balance {
-		if element.Token != ownerPlusToken {
-			continue
-		}
-
-		if nowTime <= element.UnlockDate {
-			continue
-		}
-
-		if tAmount, err = decimal.NewFromString(element.Balance); err != nil {
-			continue
-		}
-
-		if tAmount.Cmp(remainAmount) < 1 {
-			remainAmount = remainAmount.Sub(tAmount)
-			requesterData.Balance[index].Balance = "0"
-			if ownerPlusToken > 0 {
-				isBalanceClean = true
-			}
-		} else {
-			requesterData.Balance[index].Balance = tAmount.Sub(remainAmount).String()
-			remainAmount = remainAmount.Sub(remainAmount)
-			break
-		}
+	if err = loadMRC010(stub, &requesterData, ownerPlusToken); err != nil {
+		return err
 	}
-
-	// remainAmount > 0 ?
-	if remainAmount.IsPositive() {
-		return errors.New("5000,Not enough balance")
+	if err = mrc010ShardSum(stub, &requesterData, ownerPlusToken, true); err != nil {
+		return err
 	}
-
-	// balance 0 token clean up.
-	if isBalanceClean {
-		for _, element := range requesterData.Balance {
-			if element.Token > 0 && element.Balance == "0" {
-				continue
-			}
-			balanceList = append(balanceList, element)
-		}
-		requesterData.Balance = balanceList
+	if err = mrc010SubtractBalance(&requesterData, ownerPlusToken, ownerPlusAmount, now); err != nil {
+		return err
 	}
+	compactBalance(&requesterData, ownerPlusToken)
 
 	// get owner info.
 	if ownerData, err = GetAddressInfo(stub, item.Owner); err != nil {
@@ -525,37 +463,19 @@ func StodexExchange(stub shim.ChaincodeStubInterface, requester, qtt, exchangeIt
 	}
 
 	// requester -> owner
-	isPlusProcess := false
-	for index, element := range ownerData.Balance {
-		if element.Token != ownerPlusToken {
-			continue
-		}
-
-		if nowTime <= element.UnlockDate {
-			continue
-		}
-
-		if tAmount, err = decimal.NewFromString(element.Balance); err != nil {
-			continue
-		}
-		ownerData.Balance[index].Balance = tAmount.Add(ownerPlusAmount).String()
-		isPlusProcess = true
-	}
-	if !isPlusProcess {
-		balance.Balance = ownerPlusAmount.String()
-		balance.Token = ownerPlusToken
-		balance.UnlockDate = 0
-		ownerData.Balance = append(ownerData.Balance, balance)
+	if err = loadMRC010(stub, &ownerData, ownerPlusToken); err != nil {
+		return err
 	}
+	mrc010AddBalance(&ownerData, ownerPlusToken, ownerPlusAmount, 0, now)
 
 	// owner pending check.
 	if tAmount, err = decimal.NewFromString(ownerData.Pending[ownerMinusToken]); err != nil {
-		return errors.New("5100,Owner pending balance error - " + err.Error())
+		return mcerr.Wrap(mcerr.PendingBalance, "Owner pending balance error", err)
 	}
 
 	tAmount = tAmount.Sub(ownerMinusAmount)
 	if tAmount.IsNegative() {
-		return errors.New("1300," + fmt.Sprintf("Owner pending balance remain error - remain %s, need %s", tAmount.Add(ownerMinusAmount).String(), ownerMinusAmount.String()))
+		return mcerr.Newf(mcerr.PendingRemain, "Owner pending balance remain error - remain %s, need %s", tAmount.Add(ownerMinusAmount).String(), ownerMinusAmount.String())
 	}
 
 	if tAmount.IsZero() {
@@ -581,29 +501,10 @@ func StodexExchange(stub shim.ChaincodeStubInterface, requester, qtt, exchangeIt
 	}
 
 	// owner -> requester
-	isMunusProcess := false
-	for index, element := range requesterData.Balance {
-		if element.Token != ownerMinusToken {
-			continue
-		}
-
-		if nowTime <= element.UnlockDate {
-			continue
-		}
-
-		if tAmount, err = decimal.NewFromString(element.Balance); err != nil {
-			continue
-		}
-		requesterData.Balance[index].Balance = tAmount.Add(ownerMinusAmount).String()
-		isMunusProcess = true
-	}
-
-	if !isMunusProcess {
-		balance.Balance = ownerMinusAmount.String()
-		balance.Token = ownerMinusToken
-		balance.UnlockDate = 0
-		requesterData.Balance = append(requesterData.Balance, balance)
+	if err = loadMRC010(stub, &requesterData, ownerMinusToken); err != nil {
+		return err
 	}
+	mrc010AddBalance(&requesterData, ownerMinusToken, ownerMinusAmount, 0, now)
 
 	// save exchange requester data.
 	targs = nil
@@ -639,7 +540,7 @@ func StodexExchange(stub shim.ChaincodeStubInterface, requester, qtt, exchangeIt
 	exchangeResult.Qtt = qtt
 	exchangeResult.Regdate = now
 	exchangeResult.Type = "MRC040_RESULT"
-	exchangeResult.JobDate = time.Now().Unix()
+	exchangeResult.JobDate = GetTxTime(stub)
 	exchangeResult.JobType = "stodexExchange"
 	if len(args) > 0 {
 		if data, err = json.Marshal(args); err == nil {
@@ -650,10 +551,10 @@ func StodexExchange(stub shim.ChaincodeStubInterface, requester, qtt, exchangeIt
 	}
 
 	if data, err = stub.GetState(exchangePK); err != nil {
-		return errors.New("8100,Hyperledger internal error - " + err.Error())
+		return mcerr.Wrap(mcerr.LedgerRead, "Hyperledger internal error", err)
 	}
 	if data != nil {
-		return errors.New("6000,Exchange result item is already exists")
+		return mcerr.New(mcerr.ExchangeResultExists, "Exchange result item is already exists")
 	}
 
 	data, _ = json.Marshal(exchangeResult)
@@ -662,13 +563,13 @@ func StodexExchange(stub shim.ChaincodeStubInterface, requester, qtt, exchangeIt
 	}
 
 	if item.RemainQtt == "0" {
-		item.CompleteDate = time.Now().Unix()
+		item.CompleteDate = GetTxTime(stub)
 		item.Status = "COMPLETE"
 	} else {
 		item.Status = "TRADING"
 	}
 	item.Type = "MRC040"
-	item.JobDate = time.Now().Unix()
+	item.JobDate = GetTxTime(stub)
 	item.JobType = "stodexExchange"
 	if len(args) > 0 {
 		if data, err = json.Marshal(args); err == nil {
@@ -696,16 +597,16 @@ func Exchange(stub shim.ChaincodeStubInterface,
 
 	// addr check
 	if !util.IsAddress(fromAddr) {
-		return errors.New("3001,Invalid from address")
+		return mcerr.New(mcerr.InvalidFrom, "Invalid from address")
 	}
 	if !util.IsAddress(toAddr) {
-		return errors.New("3002,Invalid to address")
+		return mcerr.New(mcerr.InvalidTo, "Invalid to address")
 	}
 	if fromAddr == toAddr {
-		return errors.New("3201,From address and to address must be different values")
+		return mcerr.New(mcerr.SameAddress, "From address and to address must be different values")
 	}
 	if fromToken == toToken {
-		return errors.New("3202,From token and to token must be different values")
+		return mcerr.New(mcerr.SameToken, "From token and to token must be different values")
 	}
 
 	// token check
@@ -740,7 +641,7 @@ func Exchange(stub shim.ChaincodeStubInterface,
 		PmwFromfee = PmwTo
 	default:
 		if !util.IsAddress(fromFeeAddr) {
-			return errors.New("3003,Invalid from fee address")
+			return mcerr.New(mcerr.InvalidFeeAddress, "Invalid from fee address")
 		}
 
 		if mwFromfee, err = GetAddressInfo(stub, fromFeeAddr); err != nil {
@@ -759,7 +660,7 @@ func Exchange(stub shim.ChaincodeStubInterface,
 		PmwTofee = PmwFromfee
 	default:
 		if !util.IsAddress(toFeeAddr) {
-			return errors.New("3004,Invalid to fee address")
+			return mcerr.New(mcerr.DexStatus, "Invalid to fee address")
 		}
 		if mwTofee, err = GetAddressInfo(stub, toFeeAddr); err != nil {
 			return err
@@ -767,30 +668,30 @@ func Exchange(stub shim.ChaincodeStubInterface,
 		PmwTofee = &mwTofee
 	}
 
-	if err = NonceCheck(&mwFrom, fromTKey,
-		strings.Join([]string{fromAddr, fromAmount, fromToken, fromFeeAddr, fromFeeAmount, fromFeeToken, toAddr, toAmount, toToken, fromTKey}, "|"),
+	if err = NonceCheck(stub, &mwFrom, fromTKey, "exchange",
+		[]string{fromAddr, fromAmount, fromToken, fromFeeAddr, fromFeeAmount, fromFeeToken, toAddr, toAmount, toToken, fromTKey},
 		fromSignature); err != nil {
 		return err
 	}
 
-	if err = NonceCheck(&mwTo, toTKey,
-		strings.Join([]string{toAddr, toAmount, toToken, toFeeAddr, toFeeAmount, toFeeToken, fromAddr, fromAmount, fromToken, toTKey}, "|"),
+	if err = NonceCheck(stub, &mwTo, toTKey, "exchange",
+		[]string{toAddr, toAmount, toToken, toFeeAddr, toFeeAmount, toFeeToken, fromAddr, fromAmount, fromToken, toTKey},
 		toSignature); err != nil {
 		return err
 	}
 
 	// from -> to
 	if err = MoveToken(stub, PmwFrom, PmwTo, fromToken, fromAmount, 0); err != nil {
-		if strings.Index(err.Error(), "5000,") == 0 {
-			return errors.New("5001,The balance of fromuser is insufficient")
+		if errors.Is(err, mcerr.NotEnoughBalance) {
+			return mcerr.New(mcerr.FromBalance, "The balance of fromuser is insufficient")
 		}
 		return err
 	}
 
 	// to -> from
 	if err = MoveToken(stub, PmwTo, PmwFrom, toToken, toAmount, 0); err != nil {
-		if strings.Index(err.Error(), "5000,") == 0 {
-			return errors.New("5002,The balance of touser is insufficient")
+		if errors.Is(err, mcerr.NotEnoughBalance) {
+			return mcerr.New(mcerr.ToBalance, "The balance of touser is insufficient")
 		}
 		return err
 	}
@@ -802,8 +703,8 @@ func Exchange(stub shim.ChaincodeStubInterface,
 				return err
 			}
 			if err = MoveToken(stub, PmwFrom, PmwFromfee, fromFeeToken, fromFeeAmount, 0); err != nil {
-				if strings.Index(err.Error(), "5000,") == 0 {
-					return errors.New("5001,The balance of fromuser is insufficient")
+				if errors.Is(err, mcerr.NotEnoughBalance) {
+					return mcerr.New(mcerr.FromBalance, "The balance of fromuser is insufficient")
 				}
 				return err
 			}
@@ -817,8 +718,8 @@ func Exchange(stub shim.ChaincodeStubInterface,
 				return err
 			}
 			if err = MoveToken(stub, PmwTo, PmwTofee, toFeeToken, toFeeAmount, 0); err != nil {
-				if strings.Index(err.Error(), "5000,") == 0 {
-					return errors.New("5002,The balance of touser is insufficient")
+				if errors.Is(err, mcerr.NotEnoughBalance) {
+					return mcerr.New(mcerr.ToBalance, "The balance of touser is insufficient")
 				}
 				return err
 			}
@@ -0,0 +1,334 @@
+package metacoin
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/hyperledger/fabric-chaincode-go/pkg/attrmgr"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
+	"github.com/hyperledger/fabric-protos-go/msp"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+)
+
+// testStub : in-memory shim.ChaincodeStubInterface for the tests.
+//
+// Reads see the committed state only and writes are kept in the write set
+// until the transaction ends, the same as the endorser does.
+// Functions not overridden here are served by shimtest.MockStub.
+type testStub struct {
+	*shimtest.MockStub
+	state  map[string][]byte
+	writes map[string][]byte // nil value = delete
+	reads  map[string]bool   // keys read by the current or the last transaction
+	keys   []string          // keys written by the last committed transaction
+	hist   map[string][]*queryresult.KeyModification
+	histN  int // history versions read by GetHistoryForKey iterators
+	txID   string
+	txNo   int
+	txTime int64 // tx timestamp (unix seconds)
+	tsErr  error // GetTxTimestamp error
+	events []TEventEnvelope
+	signed *pb.SignedProposal // signed proposal of the tx, nil is no proposal
+	ctx    *txContext         // context of the current transaction
+}
+
+func newTestStub() *testStub {
+	s := &testStub{
+		MockStub: shimtest.NewMockStub("metacoin", nil),
+		state:    make(map[string][]byte),
+		writes:   make(map[string][]byte),
+		hist:     make(map[string][]*queryresult.KeyModification),
+		txTime:   1700000000,
+	}
+	s.ChannelID = "testchannel"
+	s.Creator = testCreator("Org1MSP", "admin", nil)
+	return s
+}
+
+// testCreator - serialized identity of the self-signed certificate, subject is "CN=<cn>,O=<mspid>"
+// attrs is the Fabric CA attribute of the certificate.
+func testCreator(mspid, cn string, attrs map[string]string) []byte {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		panic(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: cn, Organization: []string{mspid}},
+		NotBefore:    time.Unix(0, 0),
+		NotAfter:     time.Unix(4000000000, 0),
+	}
+	if attrs != nil {
+		value, _ := json.Marshal(map[string]interface{}{"attrs": attrs})
+		tmpl.ExtraExtensions = []pkix.Extension{{Id: attrmgr.AttrOID, Value: value}}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		panic(err)
+	}
+	id, err := proto.Marshal(&msp.SerializedIdentity{Mspid: mspid,
+		IdBytes: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})})
+	if err != nil {
+		panic(err)
+	}
+	return id
+}
+
+// GetSignedProposal - signed proposal of the tx
+func (s *testStub) GetSignedProposal() (*pb.SignedProposal, error) {
+	return s.signed, nil
+}
+
+// testProposal - signed proposal which invokes the chaincode
+func testProposal(chaincode string) *pb.SignedProposal {
+	input, _ := proto.Marshal(&pb.ChaincodeInvocationSpec{
+		ChaincodeSpec: &pb.ChaincodeSpec{ChaincodeId: &pb.ChaincodeID{Name: chaincode}}})
+	payload, _ := proto.Marshal(&pb.ChaincodeProposalPayload{Input: input})
+	prop, _ := proto.Marshal(&pb.Proposal{Payload: payload})
+	return &pb.SignedProposal{ProposalBytes: prop}
+}
+
+// GetTxID - current tx id
+func (s *testStub) GetTxID() string {
+	return s.txID
+}
+
+// GetTxTimestamp - current tx time
+func (s *testStub) GetTxTimestamp() (*timestamp.Timestamp, error) {
+	if s.tsErr != nil {
+		return nil, s.tsErr
+	}
+	return &timestamp.Timestamp{Seconds: s.txTime}, nil
+}
+
+// GetState - committed value
+func (s *testStub) GetState(key string) ([]byte, error) {
+	if s.txID != "" {
+		s.reads[key] = true
+	}
+	return s.state[key], nil
+}
+
+// PutState - add to the write set
+func (s *testStub) PutState(key string, value []byte) error {
+	if key == "" {
+		return fmt.Errorf("empty key")
+	}
+	if s.txID == "" {
+		return fmt.Errorf("PutState outside of the transaction")
+	}
+	s.writes[key] = append([]byte{}, value...)
+	return nil
+}
+
+// DelState - add delete to the write set
+func (s *testStub) DelState(key string) error {
+	if s.txID == "" {
+		return fmt.Errorf("DelState outside of the transaction")
+	}
+	s.writes[key] = nil
+	return nil
+}
+
+// GetHistoryForKey - committed versions, newest first
+func (s *testStub) GetHistoryForKey(key string) (shim.HistoryQueryIteratorInterface, error) {
+	list := s.hist[key]
+	iter := &testHistoryIterator{read: &s.histN}
+	for i := len(list) - 1; i >= 0; i-- {
+		iter.list = append(iter.list, list[i])
+	}
+	return iter, nil
+}
+
+// GetStateByRangeWithPagination - committed keys in [startKey, endKey), bookmark is the first key of the page
+func (s *testStub) GetStateByRangeWithPagination(startKey, endKey string, pageSize int32,
+	bookmark string) (shim.StateQueryIteratorInterface, *pb.QueryResponseMetadata, error) {
+	if bookmark > startKey {
+		startKey = bookmark
+	}
+	keys := make([]string, 0)
+	for key := range s.state {
+		if key >= startKey && (endKey == "" || key < endKey) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	iter := &testStateIterator{}
+	meta := &pb.QueryResponseMetadata{}
+	for _, key := range keys {
+		if pageSize > 0 && int32(len(iter.list)) == pageSize {
+			meta.Bookmark = key
+			break
+		}
+		iter.list = append(iter.list, &queryresult.KV{Key: key, Value: s.state[key]})
+	}
+	meta.FetchedRecordsCount = int32(len(iter.list))
+	return iter, meta, nil
+}
+
+// GetStateByRange - all committed keys in [startKey, endKey)
+func (s *testStub) GetStateByRange(startKey, endKey string) (shim.StateQueryIteratorInterface, error) {
+	iter, _, err := s.GetStateByRangeWithPagination(startKey, endKey, 0, "")
+	return iter, err
+}
+
+// GetStateByPartialCompositeKeyWithPagination - composite keys with the prefix
+func (s *testStub) GetStateByPartialCompositeKeyWithPagination(objectType string, keys []string,
+	pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *pb.QueryResponseMetadata, error) {
+	prefix, err := s.CreateCompositeKey(objectType, keys)
+	if err != nil {
+		return nil, nil, err
+	}
+	return s.GetStateByRangeWithPagination(prefix, prefix+string(rune(utf8.MaxRune)), pageSize, bookmark)
+}
+
+// SetEvent - keep the event envelope
+func (s *testStub) SetEvent(name string, payload []byte) error {
+	var env TEventEnvelope
+	if name != EventName {
+		return fmt.Errorf("unknown event name %s", name)
+	}
+	if err := json.Unmarshal(payload, &env); err != nil {
+		return err
+	}
+	s.events = append(s.events, env)
+	return nil
+}
+
+// advance - move the clock
+func (s *testStub) advance(seconds int64) {
+	s.txTime += seconds
+}
+
+// txContext - the stub is the stub of the invocation in the tests
+func (s *testStub) txContext() *txContext {
+	return s.ctx
+}
+
+// tx - run fn as one transaction
+// the write set is committed when fn returns nil, dropped on error.
+func (s *testStub) tx(fn func() error) error {
+	s.txNo++
+	s.txID = fmt.Sprintf("%064x", s.txNo)
+	s.writes = make(map[string][]byte)
+	s.reads = make(map[string]bool)
+	s.ctx = &txContext{}
+	defer func() { s.txID, s.ctx = "", nil }()
+
+	if err := fn(); err != nil {
+		return err
+	}
+	if err := FlushEvents(s); err != nil {
+		return err
+	}
+	s.keys = s.keys[:0]
+	for key, value := range s.writes {
+		s.keys = append(s.keys, key)
+		s.hist[key] = append(s.hist[key], &queryresult.KeyModification{TxId: s.txID, Value: value,
+			Timestamp: &timestamp.Timestamp{Seconds: s.txTime}, IsDelete: value == nil})
+		if value == nil {
+			delete(s.state, key)
+		} else {
+			s.state[key] = value
+		}
+	}
+	return nil
+}
+
+// mustTx - tx, fail the test on error
+func (s *testStub) mustTx(t *testing.T, fn func() error) {
+	t.Helper()
+	if err := s.tx(fn); err != nil {
+		t.Fatalf("tx %d : %v", s.txNo, err)
+	}
+}
+
+// lastKey - key with the prefix written by the last transaction
+func (s *testStub) lastKey(t *testing.T, prefix string) string {
+	t.Helper()
+	for _, key := range s.keys {
+		if strings.HasPrefix(key, prefix) {
+			return key
+		}
+	}
+	t.Fatalf("key %s* not written", prefix)
+	return ""
+}
+
+// lastEvent - find the event of the last transaction
+func (s *testStub) lastEvent(t *testing.T, eventType string) TEvent {
+	t.Helper()
+	if len(s.events) > 0 {
+		env := s.events[len(s.events)-1]
+		for _, ev := range env.Events {
+			if ev.Type == eventType {
+				return ev
+			}
+		}
+	}
+	t.Fatalf("event %s not found", eventType)
+	return TEvent{}
+}
+
+// testHistoryIterator : shim.HistoryQueryIteratorInterface
+type testHistoryIterator struct {
+	list []*queryresult.KeyModification
+	read *int
+}
+
+func (i *testHistoryIterator) HasNext() bool {
+	return len(i.list) > 0
+}
+
+func (i *testHistoryIterator) Next() (*queryresult.KeyModification, error) {
+	if len(i.list) == 0 {
+		return nil, fmt.Errorf("no more history")
+	}
+	mod := i.list[0]
+	i.list = i.list[1:]
+	*i.read++
+	return mod, nil
+}
+
+func (i *testHistoryIterator) Close() error {
+	return nil
+}
+
+// testStateIterator : shim.StateQueryIteratorInterface
+type testStateIterator struct {
+	list []*queryresult.KV
+}
+
+func (i *testStateIterator) HasNext() bool {
+	return len(i.list) > 0
+}
+
+func (i *testStateIterator) Next() (*queryresult.KV, error) {
+	if len(i.list) == 0 {
+		return nil, fmt.Errorf("no more data")
+	}
+	kv := i.list[0]
+	i.list = i.list[1:]
+	return kv, nil
+}
+
+func (i *testStateIterator) Close() error {
+	return nil
+}
@@ -1,17 +1,16 @@
 package metacoin
 
 import (
-	"errors"
 	"fmt"
 	"strconv"
 	"strings"
-	"time"
 
 	"encoding/json"
 
 	"github.com/hyperledger/fabric-chaincode-go/shim"
 	"github.com/shopspring/decimal"
 
+	"inblock/metacoin/mcerr"
 	"inblock/metacoin/mtc"
 	"inblock/metacoin/util"
 )
@@ -55,6 +54,9 @@ type TMRC010DEX struct {
 	JobDate int64  `json:"jobdate"`
 }
 
+// MRC010StatusPause : TMRC010.Status of the paused token, no balance movement
+const MRC010StatusPause = "pause"
+
 type MRC010ModifyType int
 
 const (
@@ -80,12 +82,13 @@ const (
 )
 
 // setMRC010 : save token info
-func setMRC010(stub shim.ChaincodeStubInterface, tk mtc.TMRC010, JobType string, args []string) error {
+// payment is the balance movement of the event.
+func setMRC010(stub shim.ChaincodeStubInterface, tk mtc.TMRC010, JobType string, args []string, payment ...mtc.TDexPaymentInfo) error {
 	var dat []byte
 	var err error
 
 	tk.JobType = JobType
-	tk.JobDate = time.Now().Unix()
+	tk.JobDate = GetTxTime(stub)
 	if len(args) > 0 {
 		if dat, err = json.Marshal(args); err == nil {
 			tk.JobArgs = string(dat)
@@ -95,11 +98,12 @@ func setMRC010(stub shim.ChaincodeStubInterface, tk mtc.TMRC010, JobType string,
 	}
 
 	if dat, err = json.Marshal(tk); err != nil {
-		return errors.New("4204,Invalid token data format")
+		return mcerr.New(mcerr.InvalidItemFormat, "Invalid token data format")
 	}
 	if err = stub.PutState("TOKEN_DATA_"+tk.Id, dat); err != nil {
-		return errors.New("8600,Hyperledger internal error - " + err.Error())
+		return mcerr.Wrap(mcerr.LedgerWrite, "Hyperledger internal error", err)
 	}
+	AddEvent(stub, TEvent{Type: JobType, Key: tk.Id, Args: args, Payment: payment})
 	return nil
 }
 
@@ -111,19 +115,19 @@ func GetMRC010(stub shim.ChaincodeStubInterface, TokenID string) (mtc.TMRC010, i
 	var TokenSN int
 
 	if len(TokenID) == 0 {
-		return tk, 0, errors.New("4002,Token id missing")
+		return tk, 0, mcerr.New(mcerr.TokenIDMissing, "Token id missing")
 	}
 	if TokenSN, err = util.Strtoint(TokenID); err != nil {
-		return tk, 0, errors.New("4104,Invalid toekn SN")
+		return tk, 0, mcerr.New(mcerr.InvalidTokenSN, "Invalid toekn SN")
 	}
 	if data, err = stub.GetState("TOKEN_DATA_" + TokenID); err != nil {
-		return tk, TokenSN, errors.New("8100,Hyperledger internal error - " + err.Error())
+		return tk, TokenSN, mcerr.Wrap(mcerr.LedgerRead, "Hyperledger internal error", err)
 	}
 	if data == nil {
-		return tk, TokenSN, errors.New("4001,Token " + TokenID + " not exists")
+		return tk, TokenSN, mcerr.New(mcerr.TokenNotFound, "Token "+TokenID+" not exists")
 	}
 	if err = json.Unmarshal(data, &tk); err != nil {
-		return tk, TokenSN, errors.New("4204,Invalid token data format - " + err.Error())
+		return tk, TokenSN, mcerr.Wrap(mcerr.InvalidItemFormat, "Invalid token data format", err)
 	}
 	if tk.Id == "" {
 		tk.Id = TokenID
@@ -141,48 +145,47 @@ func TokenRegister(stub shim.ChaincodeStubInterface, data, signature, tkey strin
 	var reserveInfo mtc.TMRC010Reserve
 	var OwnerData, reserveAddr mtc.TWallet
 	var t, RemainSupply decimal.Decimal
+	var payment []mtc.TDexPaymentInfo
 
 	// unmarshal data.
 	if err = json.Unmarshal([]byte(data), &tk); err != nil {
-		return "", errors.New("4105,Invalid token Data format")
+		return "", mcerr.New(mcerr.InvalidTokenData, "Invalid token Data format")
 	}
 
 	// data check
 	if len(tk.Symbol) < 1 {
-		return "", errors.New("1002,Symbol is empty")
+		return "", mcerr.New(mcerr.EmptySymbol, "Symbol is empty")
 	}
 
 	if len(tk.Name) < 1 {
-		return "", errors.New("1001,Name is empty")
+		return "", mcerr.New(mcerr.EmptyName, "Name is empty")
 	}
 
 	if tk.Decimal < 0 {
-		return "", errors.New("1003,The decimal number must be bigger then 0")
+		return "", mcerr.New(mcerr.InvalidValue, "The decimal number must be bigger then 0")
 	}
 
 	if tk.Decimal > 18 {
-		return "", errors.New("1004,The decimal number must be less than 18")
+		return "", mcerr.New(mcerr.InvalidDecimal, "The decimal number must be less than 18")
 	}
 
 	if value, err = stub.GetState("TOKEN_MAX_NO"); err != nil {
-		return "", errors.New("8100,Hyperledger internal error - " + err.Error())
+		return "", mcerr.Wrap(mcerr.LedgerRead, "Hyperledger internal error", err)
 	}
 
-	// set Token ID
+	// set Token ID, token 0 is registered by the genesis
 	if value == nil {
-		currNo = 0
-	} else {
-		currNo64, _ := strconv.ParseInt(string(value), 10, 32)
-		currNo = int(currNo64)
-		currNo = currNo + 1
+		return "", mcerr.New(mcerr.TokenNotFound, "Base coin is not registered, run the genesis first")
 	}
+	currNo64, _ := strconv.ParseInt(string(value), 10, 32)
+	currNo = int(currNo64) + 1
 
 	if OwnerData, err = GetAddressInfo(stub, tk.Owner); err != nil {
 		return "", err
 	}
 
-	if err = NonceCheck(&OwnerData, tkey,
-		strings.Join([]string{tk.Owner, tk.Name, tkey}, "|"),
+	if err = NonceCheck(stub, &OwnerData, tkey, "tokenRegister",
+		[]string{tk.Owner, tk.Name, tkey},
 		signature); err != nil {
 		return "", err
 	}
@@ -191,17 +194,17 @@ func TokenRegister(stub shim.ChaincodeStubInterface, data, signature, tkey strin
 		tk.Type = "010"
 	}
 	tk.Token = currNo
-	tk.JobDate = time.Now().Unix()
-	tk.CreateDate = time.Now().Unix()
+	tk.JobDate = GetTxTime(stub)
+	tk.CreateDate = GetTxTime(stub)
 	tk.JobType = "tokenRegister"
 	tk.Id = strconv.Itoa(currNo)
 
 	if dat, err = json.Marshal(tk); err != nil {
-		return "", errors.New("4200,Invalid Data format")
+		return "", mcerr.New(mcerr.InvalidFormat, "Invalid Data format")
 	}
 	tk.JobArgs = string(dat)
 	if dat, err = json.Marshal(tk); err != nil {
-		return "", errors.New("4200,Invalid Data format")
+		return "", mcerr.New(mcerr.InvalidFormat, "Invalid Data format")
 	}
 
 	if err = stub.PutState("TOKEN_DATA_"+strconv.Itoa(currNo), dat); err != nil {
@@ -209,37 +212,36 @@ func TokenRegister(stub shim.ChaincodeStubInterface, data, signature, tkey strin
 	}
 
 	if RemainSupply, err = util.ParsePositive(tk.TotalSupply); err != nil {
-		return "", errors.New("1101,TotalSupply is not positive integer")
+		return "", mcerr.New(mcerr.NotInteger, "TotalSupply is not positive integer")
 	}
 
 	for _, reserveInfo = range tk.Reserve {
 		if t, err = util.ParsePositive(reserveInfo.Value); err != nil {
-			return "", errors.New("1102,Reserve amount " + reserveInfo.Value + " is not interger")
+			return "", mcerr.New(mcerr.NonceError, "Reserve amount "+reserveInfo.Value+" is not interger")
 		}
 		if reserveAddr, err = GetAddressInfo(stub, reserveInfo.Address); err != nil {
-			return "", errors.New("1102,Token reserve address " + reserveInfo.Address + " not found")
-		}
-		if currNo == 0 {
-			reserveAddr.Balance[0].Balance = reserveInfo.Value
-		} else {
-			reserveAddr.Balance = append(reserveAddr.Balance,
-				mtc.TMRC010Balance{Balance: reserveInfo.Value, Token: currNo, UnlockDate: reserveInfo.UnlockDate})
+			return "", mcerr.New(mcerr.NonceError, "Token reserve address "+reserveInfo.Address+" not found")
 		}
+		mrc010AddBalance(&reserveAddr, currNo, t, reserveInfo.UnlockDate, GetTxTime(stub))
 
 		RemainSupply := RemainSupply.Sub(t)
 		if RemainSupply.IsNegative() {
-			return "", errors.New("1103,The reserve amount is greater than totalsupply")
+			return "", mcerr.New(mcerr.InvalidPrice, "The reserve amount is greater than totalsupply")
 		}
 		if err = SetAddressInfo(stub, reserveAddr, "token_reserve",
 			[]string{tk.Owner, reserveInfo.Address, reserveInfo.Value, strconv.Itoa(currNo)}); err != nil {
 			return "", err
 		}
+		payment = append(payment, mtc.TDexPaymentInfo{FromAddr: "TOKEN_DATA_" + strconv.Itoa(currNo), ToAddr: reserveInfo.Address,
+			Amount: reserveInfo.Value, TokenID: strconv.Itoa(currNo), PayType: "token_reserve"})
 	}
 
 	if err = stub.PutState("TOKEN_MAX_NO", []byte(strconv.Itoa(currNo))); err != nil {
 		return "", err
 	}
 
+	AddEvent(stub, TEvent{Type: "token_register", Key: strconv.Itoa(currNo),
+		Args: []string{tk.Owner, tk.Name, tk.Symbol, tk.TotalSupply}, Payment: payment})
 	return strconv.Itoa(currNo), nil
 }
 
@@ -254,31 +256,31 @@ func TokenAddLogger(stub shim.ChaincodeStubInterface, TokenID, logger, signature
 	}
 
 	if _, err = GetAddressInfo(stub, logger); err != nil {
-		return errors.New("1202,The Logger is not exists")
+		return mcerr.New(mcerr.LoggerNotFound, "The Logger is not exists")
 	}
 
 	if mwOwner, err = GetAddressInfo(stub, tk.Owner); err != nil {
 		return err
 	}
 
-	if err = NonceCheck(&mwOwner, tkey,
-		strings.Join([]string{tk.Owner, TokenID, logger, tkey}, "|"),
+	if err = NonceCheck(stub, &mwOwner, tkey, "tokenAddLogger",
+		[]string{tk.Owner, TokenID, logger, tkey},
 		signature); err != nil {
 		return err
 	}
 
 	if logger == tk.Owner {
-		return errors.New("1201,The Logger is the same as the token owner")
+		return mcerr.New(mcerr.TokenOwner, "The Logger is the same as the token owner")
 	}
 
 	if tk.Logger == nil {
 		tk.Logger = make(map[string]int64)
 	} else {
 		if _, exists := tk.Logger[logger]; exists {
-			return errors.New("4205,Target token are in the target token list")
+			return mcerr.New(mcerr.TargetTokenExists, "Target token are in the target token list")
 		}
 	}
-	tk.Logger[logger] = time.Now().Unix()
+	tk.Logger[logger] = GetTxTime(stub)
 
 	return setMRC010(stub, tk, "tokenAddLogger", args)
 }
@@ -294,18 +296,18 @@ func TokenRemoveLogger(stub shim.ChaincodeStubInterface, TokenID, logger, signat
 	}
 
 	if tk.Logger == nil {
-		return errors.New("4202,Could not find logger in the logger list")
+		return mcerr.New(mcerr.InvalidIDLength, "Could not find logger in the logger list")
 	}
 	if _, exists := tk.Logger[logger]; !exists {
-		return errors.New("4202,Could not find logger in the logger list")
+		return mcerr.New(mcerr.InvalidIDLength, "Could not find logger in the logger list")
 	}
 
 	if mwOwner, err = GetAddressInfo(stub, tk.Owner); err != nil {
 		return err
 	}
 
-	if err = NonceCheck(&mwOwner, tkey,
-		strings.Join([]string{tk.Owner, TokenID, logger, tkey}, "|"),
+	if err = NonceCheck(stub, &mwOwner, tkey, "tokenRemoveLogger",
+		[]string{tk.Owner, TokenID, logger, tkey},
 		signature); err != nil {
 		return err
 	}
@@ -315,6 +317,119 @@ func TokenRemoveLogger(stub shim.ChaincodeStubInterface, TokenID, logger, signat
 	return setMRC010(stub, tk, "tokenRemoveLogger", args)
 }
 
+// mrc010MoveCheck - the token is not paused and the address is not frozen
+func mrc010MoveCheck(tk mtc.TMRC010, address string) error {
+	if tk.Status == MRC010StatusPause {
+		return mcerr.New(mcerr.TokenFrozen, "Token "+tk.Id+" is paused").WithField("token")
+	}
+	if _, exists := tk.Frozen[address]; exists {
+		return mcerr.New(mcerr.TokenFrozen, "Address "+address+" is frozen for token "+tk.Id).WithField("address")
+	}
+	return nil
+}
+
+// mrc010MoveCheckByID - mrc010MoveCheck with the token id
+func mrc010MoveCheckByID(stub shim.ChaincodeStubInterface, TokenID, address string) error {
+	tk, _, err := GetMRC010(stub, TokenID)
+	if err != nil {
+		return err
+	}
+	return mrc010MoveCheck(tk, address)
+}
+
+// tokenOwnerCheck - token owner signature of "TokenID|function|target|nonce"
+func tokenOwnerCheck(stub shim.ChaincodeStubInterface, tk mtc.TMRC010, function, target, signature, tkey string) error {
+	return tokenOwnerSign(stub, tk, function, []string{tk.Id, function, target, tkey}, signature, tkey)
+}
+
+// tokenOwnerSign - token owner signature of the fields
+func tokenOwnerSign(stub shim.ChaincodeStubInterface, tk mtc.TMRC010, function string, fields []string, signature, tkey string) error {
+	ownerData, err := GetAddressInfo(stub, tk.Owner)
+	if err != nil {
+		return err
+	}
+	if err = NonceCheck(stub, &ownerData, tkey, function, fields, signature); err != nil {
+		return err
+	}
+	return SetAddressInfo(stub, ownerData, function, fields[:len(fields)-1])
+}
+
+// TokenPause - stop all movement of the token, signed by the token owner
+func TokenPause(stub shim.ChaincodeStubInterface, TokenID, signature, tkey string, args []string) error {
+	tk, _, err := GetMRC010(stub, TokenID)
+	if err != nil {
+		return err
+	}
+	if tk.Status == MRC010StatusPause {
+		return mcerr.New(mcerr.NoDataChange, "Token is already paused")
+	}
+	if err = tokenOwnerCheck(stub, tk, "tokenPause", "", signature, tkey); err != nil {
+		return err
+	}
+	tk.PrevStatus = tk.Status
+	tk.Status = MRC010StatusPause
+	return setMRC010(stub, tk, "tokenPause", args)
+}
+
+// TokenUnpause - resume the paused token, signed by the token owner
+// The status before the pause is restored.
+func TokenUnpause(stub shim.ChaincodeStubInterface, TokenID, signature, tkey string, args []string) error {
+	tk, _, err := GetMRC010(stub, TokenID)
+	if err != nil {
+		return err
+	}
+	if tk.Status != MRC010StatusPause {
+		return mcerr.New(mcerr.NoDataChange, "Token is not paused")
+	}
+	if err = tokenOwnerCheck(stub, tk, "tokenUnpause", "", signature, tkey); err != nil {
+		return err
+	}
+	tk.Status = tk.PrevStatus
+	tk.PrevStatus = ""
+	return setMRC010(stub, tk, "tokenUnpause", args)
+}
+
+// TokenFreezeAddress - stop the token movement of the address, signed by the token owner
+func TokenFreezeAddress(stub shim.ChaincodeStubInterface, TokenID, address, signature, tkey string, args []string) error {
+	tk, _, err := GetMRC010(stub, TokenID)
+	if err != nil {
+		return err
+	}
+	if !util.IsAddress(address) {
+		return mcerr.New(mcerr.InvalidAddress, "Address is not metacoin address").WithField("address")
+	}
+	if address == tk.Owner {
+		return mcerr.New(mcerr.TokenOwner, "The token owner can not be frozen")
+	}
+	if _, exists := tk.Frozen[address]; exists {
+		return mcerr.New(mcerr.NoDataChange, "Address is already frozen")
+	}
+	if err = tokenOwnerCheck(stub, tk, "tokenFreezeAddress", address, signature, tkey); err != nil {
+		return err
+	}
+	if tk.Frozen == nil {
+		tk.Frozen = make(map[string]int64)
+	}
+	tk.Frozen[address] = GetTxTime(stub)
+	return setMRC010(stub, tk, "tokenFreezeAddress", args)
+}
+
+// TokenUnfreezeAddress - release the frozen address, signed by the token owner
+func TokenUnfreezeAddress(stub shim.ChaincodeStubInterface, TokenID, address, signature, tkey string, args []string) error {
+	tk, _, err := GetMRC010(stub, TokenID)
+	if err != nil {
+		return err
+	}
+	if _, exists := tk.Frozen[address]; !exists {
+		return mcerr.New(mcerr.NotFound, "Address is not frozen").WithField("address")
+	}
+	if err = tokenOwnerCheck(stub, tk, "tokenUnfreezeAddress", address, signature, tkey); err != nil {
+		return err
+	}
+	delete(tk.Frozen, address)
+	return setMRC010(stub, tk, "tokenUnfreezeAddress", args)
+}
+
 // TokenUpdate - Token Information update.
 func TokenUpdate(stub shim.ChaincodeStubInterface, TokenID, url, info, image, signature, tkey string, args []string) error {
 	var tk mtc.TMRC010
@@ -330,8 +445,8 @@ func TokenUpdate(stub shim.ChaincodeStubInterface, TokenID, url, info, image, si
 		return err
 	}
 
-	if err = NonceCheck(&ownerData, tkey,
-		strings.Join([]string{TokenID, url, info, image, tkey}, "|"),
+	if err = NonceCheck(stub, &ownerData, tkey, "tokenUpdate",
+		[]string{TokenID, url, info, image, tkey},
 		signature); err != nil {
 		return err
 	}
@@ -354,7 +469,7 @@ func TokenUpdate(stub shim.ChaincodeStubInterface, TokenID, url, info, image, si
 	}
 
 	if !isUpdate {
-		return errors.New("4900,No data change")
+		return mcerr.New(mcerr.NoDataChange, "No data change")
 	}
 
 	return setMRC010(stub, tk, "tokenUpdate", args)
@@ -370,7 +485,7 @@ func TokenBurning(stub shim.ChaincodeStubInterface, args []string) error {
 	var BurnningAmount, BurnAmount decimal.Decimal
 
 	if len(args) < 4 {
-		return errors.New("1000,tokenBurn operation must include four arguments : TokenID, amount, memo, sign, tkey")
+		return mcerr.New(mcerr.InvalidArguments, "tokenBurn operation must include four arguments : TokenID, amount, memo, sign, tkey")
 	}
 
 	if tk, _, err = GetMRC010(stub, args[0]); err != nil {
@@ -378,18 +493,18 @@ func TokenBurning(stub shim.ChaincodeStubInterface, args []string) error {
 	}
 
 	if BurnAmount, err = util.ParsePositive(args[1]); err != nil {
-		return errors.New("1206,The amount must be an integer")
+		return mcerr.New(mcerr.InvalidBurnAmount, "The amount must be an integer")
 	}
 	if err = util.DataAssign(args[2], &memo, "string", 0, 4096, true); err != nil {
-		return errors.New("3005,Memo must be 1 to 4096 characters long")
+		return mcerr.New(mcerr.InvalidData, "Memo must be 1 to 4096 characters long")
 	}
 
 	if ownerData, err = GetAddressInfo(stub, tk.Owner); err != nil {
 		return err
 	}
 
-	if err = NonceCheck(&ownerData, args[4],
-		strings.Join([]string{args[0], args[1], args[4]}, "|"),
+	if err = NonceCheck(stub, &ownerData, args[4], "tokenBurning",
+		[]string{args[0], args[1], args[4]},
 		args[3]); err != nil {
 		return err
 	}
@@ -406,7 +521,8 @@ func TokenBurning(stub shim.ChaincodeStubInterface, args []string) error {
 	}
 	tk.BurnningAmount = BurnningAmount.Add(BurnAmount).String()
 
-	return setMRC010(stub, tk, "tokenBurning", args)
+	return setMRC010(stub, tk, "tokenBurning", args, mtc.TDexPaymentInfo{FromAddr: tk.Owner, ToAddr: "TOKEN_DATA_" + tk.Id,
+		Amount: BurnAmount.String(), TokenID: tk.Id, PayType: "token_burn"})
 }
 
 // TokenIncrease - Token Information update.
@@ -418,7 +534,7 @@ func TokenIncrease(stub shim.ChaincodeStubInterface, args []string) error {
 	var TotalAmount, IncrAmount decimal.Decimal
 
 	if len(args) < 5 {
-		return errors.New("1000,tokenIncrease operation must include four arguments : TokenID, amount, memo, sign, tkey")
+		return mcerr.New(mcerr.InvalidArguments, "tokenIncrease operation must include four arguments : TokenID, amount, memo, sign, tkey")
 	}
 
 	if tk, _, err = GetMRC010(stub, args[0]); err != nil {
@@ -426,19 +542,19 @@ func TokenIncrease(stub shim.ChaincodeStubInterface, args []string) error {
 	}
 
 	if IncrAmount, err = util.ParsePositive(args[1]); err != nil {
-		return errors.New("1206,amount must be a positive integer")
+		return mcerr.New(mcerr.InvalidBurnAmount, "amount must be a positive integer")
 	}
 
 	if err = util.DataAssign(args[2], &memo, "string", 0, 4096, true); err != nil {
-		return errors.New("3005,Memo must be 1 to 4096 characters long")
+		return mcerr.New(mcerr.InvalidData, "Memo must be 1 to 4096 characters long")
 	}
 
 	if ownerData, err = GetAddressInfo(stub, tk.Owner); err != nil {
 		return err
 	}
 
-	if err = NonceCheck(&ownerData, args[4],
-		strings.Join([]string{args[0], args[1], args[4]}, "|"),
+	if err = NonceCheck(stub, &ownerData, args[4], "tokenIncrease",
+		[]string{args[0], args[1], args[4]},
 		args[3]); err != nil {
 		return err
 	}
@@ -455,7 +571,8 @@ func TokenIncrease(stub shim.ChaincodeStubInterface, args []string) error {
 	}
 	tk.TotalSupply = TotalAmount.Add(IncrAmount).String()
 
-	return setMRC010(stub, tk, "tokenIncrease", args)
+	return setMRC010(stub, tk, "tokenIncrease", args, mtc.TDexPaymentInfo{FromAddr: "TOKEN_DATA_" + tk.Id, ToAddr: tk.Owner,
+		Amount: IncrAmount.String(), TokenID: tk.Id, PayType: "token_increase"})
 }
 
 // 잔액 감소
@@ -469,32 +586,30 @@ func mrc010SubtractSubBalance(stub shim.ChaincodeStubInterface,
 	var tokenID int
 
 	if subtractAmount, err = util.ParsePositive(amount); err != nil {
-		return errors.New("1104," + amount + " is not positive integer")
+		return mcerr.New(mcerr.InvalidSubAmount, amount+" is not positive integer")
 	}
 
 	if _, tokenID, err = GetMRC010(stub, mrc010id); err != nil {
 		return err
 	}
+	if err = loadMRC010(stub, wallet, tokenID); err != nil {
+		return err
+	}
 
-	for index, element := range wallet.Balance {
-		// is empty elements ?
-		if element.Token != tokenID {
-			continue
-		}
-		if element.UnlockDate != 0 {
-			continue
-		}
+	normalizeBalance(wallet, GetTxTime(stub))
+	b, exists := wallet.MRC010[tokenID]
+	if !exists {
+		return mcerr.New(mcerr.NotEnoughBalance, "Not enough balance")
+	}
 
-		if SubtractType == MRC010MT_Sell {
-			sa, _ := decimal.NewFromString(wallet.Balance[index].SaleAmount)
-			wallet.Balance[index].SaleAmount = sa.Sub(subtractAmount).String()
-		} else if SubtractType == MRC010MT_Auction {
-			sa, _ := decimal.NewFromString(wallet.Balance[index].AuctionAmount)
-			wallet.Balance[index].AuctionAmount = sa.Sub(subtractAmount).String()
-		}
-		return nil
+	if SubtractType == MRC010MT_Sell {
+		b.SaleAmount = decimalOf(b.SaleAmount).Sub(subtractAmount).String()
+	} else if SubtractType == MRC010MT_Auction {
+		b.AuctionAmount = decimalOf(b.AuctionAmount).Sub(subtractAmount).String()
 	}
-	return errors.New("5000,Not enough balance")
+	wallet.MRC010[tokenID] = b
+	compactBalance(wallet, tokenID)
+	return nil
 }
 
 // GetDEX010 get MRC010 Dex item
@@ -508,15 +623,15 @@ func GetDEX010(stub shim.ChaincodeStubInterface, dexid string) (TMRC010DEX, []by
 	var mrc010dex TMRC010DEX
 
 	if strings.Index(dexid, "DEX010_") != 0 || len(dexid) != 40 {
-		return mrc010dex, nil, errors.New("6102,invalid DEX010 ID")
+		return mrc010dex, nil, mcerr.New(mcerr.InvalidDataAddress, "invalid DEX010 ID")
 	}
 
 	byte_data, err = stub.GetState(dexid)
 	if err != nil {
-		return mrc010dex, nil, errors.New("8110,Hyperledger internal error - " + err.Error())
+		return mrc010dex, nil, mcerr.Wrap(mcerr.LedgerReadData, "Hyperledger internal error", err)
 	}
 	if byte_data == nil {
-		return mrc010dex, nil, errors.New("6004,MRC010 [" + dexid + "] not exist")
+		return mrc010dex, nil, mcerr.New(mcerr.NotFound, "MRC010 ["+dexid+"] not exist")
 	}
 	if err = json.Unmarshal(byte_data, &mrc010dex); err != nil {
 		return mrc010dex, nil, err
@@ -527,8 +642,8 @@ func GetDEX010(stub shim.ChaincodeStubInterface, dexid string) (TMRC010DEX, []by
 	return mrc010dex, byte_data, nil
 }
 
-func dex010Status(dex TMRC010DEX) MRC010DexStatus {
-	var now = time.Now().Unix()
+func dex010Status(stub shim.ChaincodeStubInterface, dex TMRC010DEX) MRC010DexStatus {
+	var now = GetTxTime(stub)
 	if dex.CancelDate > 0 {
 		return MRC010DS_CANCLED // Sale or auction canceled
 	}
@@ -564,21 +679,22 @@ func dex010Status(dex TMRC010DEX) MRC010DexStatus {
 // Example :
 //
 //	err := setDEX010(stub, TMRC010DEX, "MRC010Dex ITEM ID", "jobtype", arguments)
-func setDEX010(stub shim.ChaincodeStubInterface, MRC010DexItem TMRC010DEX, jobType string, jobArgs []string) error {
+func setDEX010(stub shim.ChaincodeStubInterface, MRC010DexItem TMRC010DEX, jobType string, jobArgs []string, payment ...mtc.TDexPaymentInfo) error {
 	var err error
 	var byte_data []byte
 
 	if strings.Index(MRC010DexItem.Id, "DEX010_") != 0 || len(MRC010DexItem.Id) != 40 {
-		return errors.New("6102,invalid DEX010 data address")
+		return mcerr.New(mcerr.InvalidDataAddress, "invalid DEX010 data address")
 	}
 
 	MRC010DexItem.JobType = jobType
-	MRC010DexItem.JobDate = time.Now().Unix()
+	MRC010DexItem.JobDate = GetTxTime(stub)
 	if byte_data, err = json.Marshal(jobArgs); err == nil {
 		MRC010DexItem.JobArgs = string(byte_data)
 	}
 
-	if MRC010DexItem.RegDate == 0 {
+	isNew := MRC010DexItem.RegDate == 0
+	if isNew {
 		MRC010DexItem.RegDate = MRC010DexItem.JobDate
 	}
 
@@ -606,12 +722,16 @@ func setDEX010(stub shim.ChaincodeStubInterface, MRC010DexItem TMRC010DEX, jobTy
 	}
 
 	if byte_data, err = json.Marshal(MRC010DexItem); err != nil {
-		return errors.New("3209,Invalid MRC010DexItem data format")
+		return mcerr.New(mcerr.InvalidItemData, "Invalid MRC010DexItem data format")
 	}
 
 	if err := stub.PutState(MRC010DexItem.Id, byte_data); err != nil {
-		return errors.New("8600,dex010set stub.PutState [" + MRC010DexItem.Id + "] Error " + err.Error())
+		return mcerr.New(mcerr.LedgerWrite, "dex010set stub.PutState ["+MRC010DexItem.Id+"] Error "+err.Error())
 	}
+	if err = indexDEX010(stub, MRC010DexItem, isNew); err != nil {
+		return err
+	}
+	AddEvent(stub, TEvent{Type: jobType, Key: MRC010DexItem.Id, Args: jobArgs, Payment: payment})
 	return nil
 }
 
@@ -626,9 +746,9 @@ func Mrc010Sell(stub shim.ChaincodeStubInterface, args []string) error {
 	var argdat []byte
 
 	if len(args) < 12 {
-		return errors.New("1000,mrc010sell operation must include four arguments : " +
-			"seller, amount, mrc010id, sellPrice, selltoken, " +
-			"platformName, platformURL, platformAddress, platformCommission, mintradeunit, " +
+		return mcerr.New(mcerr.InvalidArguments, "mrc010sell operation must include four arguments : "+
+			"seller, amount, mrc010id, sellPrice, selltoken, "+
+			"platformName, platformURL, platformAddress, platformCommission, mintradeunit, "+
 			"signature, nonce")
 	}
 
@@ -639,7 +759,7 @@ func Mrc010Sell(stub shim.ChaincodeStubInterface, args []string) error {
 
 	// 1 amount
 	if sellAmount, err = util.ParsePositive(args[1]); err != nil {
-		return errors.New("1107," + args[1] + " is not positive integer")
+		return mcerr.New(mcerr.InvalidSellAmount, args[1]+" is not positive integer")
 	}
 
 	// 2 mrc010id
@@ -682,32 +802,32 @@ func Mrc010Sell(stub shim.ChaincodeStubInterface, args []string) error {
 		return err
 	}
 	if mrc010.Id == token.Id {
-		return errors.New("3005,The sale token must be different from the payment token")
+		return mcerr.New(mcerr.InvalidData, "The sale token must be different from the payment token")
 	}
 
 	// 5 platform name
 	if err = util.DataAssign(args[5], &dex.PlatformName, "", 1, 256, true); err != nil {
-		return errors.New("3005,Url value error : " + err.Error())
+		return mcerr.New(mcerr.InvalidData, "Url value error : "+err.Error())
 	}
 
 	// 6 Platform URL
 	if err = util.DataAssign(args[6], &dex.PlatformURL, "url", 1, 256, true); err != nil {
-		return errors.New("3005,Url value error : " + err.Error())
+		return mcerr.New(mcerr.InvalidData, "Url value error : "+err.Error())
 	}
 
 	// 7 Platform Address
 	if err = util.DataAssign(args[7], &dex.PlatformAddress, "address", 40, 40, true); err != nil {
-		return errors.New("3005,Data value error : " + err.Error())
+		return mcerr.New(mcerr.InvalidData, "Data value error : "+err.Error())
 	}
 	if util.IsAddress(dex.PlatformAddress) {
 		if _, err = GetAddressInfo(stub, dex.PlatformAddress); err != nil {
-			return errors.New("3005," + "PlatformAddress not found : " + err.Error())
+			return mcerr.New(mcerr.InvalidData, "PlatformAddress not found : "+err.Error())
 		}
 	}
 
 	// 8 PlatformCommission
 	if err = util.NumericDataCheck(args[8], &dex.PlatformCommission, "0.00", "10.00", 2, true); err != nil {
-		return errors.New("3005,PlatformCommission must be 0.00~10.00 : " + err.Error())
+		return mcerr.New(mcerr.InvalidData, "PlatformCommission must be 0.00~10.00 : "+err.Error())
 	}
 
 	// 9 MintradeUnit
@@ -717,17 +837,17 @@ func Mrc010Sell(stub shim.ChaincodeStubInterface, args []string) error {
 	case "1", "10", "100", "1000", "10000", "100000", "1000000", "10000000", "100000000":
 		dex.MinTradeUnit = args[9]
 	default:
-		return errors.New("3005,MinTradeUnit value error : " + args[9])
+		return mcerr.New(mcerr.InvalidData, "MinTradeUnit value error : "+args[9])
 	}
 	tradeUnit, _ := decimal.NewFromString(dex.MinTradeUnit)
 	if sellAmount.Mod(tradeUnit).Cmp(decimal.Zero) != 0 {
-		return errors.New("3005,The amount quantity must be a multiple of " + dex.MinTradeUnit)
+		return mcerr.New(mcerr.InvalidData, "The amount quantity must be a multiple of "+dex.MinTradeUnit)
 	}
 
-	if err = NonceCheck(&sellerWallet, args[11],
-		strings.Join([]string{args[0], args[1], args[2], args[3], args[4],
+	if err = NonceCheck(stub, &sellerWallet, args[11], "mrc010sell",
+		[]string{args[0], args[1], args[2], args[3], args[4],
 			args[5], args[6], args[7], args[8],
-			args[11]}, "|"),
+			args[11]},
 		args[10]); err != nil {
 		return err
 	}
@@ -743,7 +863,7 @@ func Mrc010Sell(stub shim.ChaincodeStubInterface, args []string) error {
 		dex.Id = fmt.Sprintf("%39s%1d", temp, i)
 		argdat, err = stub.GetState(dex.Id)
 		if err != nil {
-			return errors.New("8600,Hyperledger internal error - " + err.Error())
+			return mcerr.Wrap(mcerr.LedgerWrite, "Hyperledger internal error", err)
 		}
 
 		if argdat != nil { // key already exists
@@ -754,7 +874,7 @@ func Mrc010Sell(stub shim.ChaincodeStubInterface, args []string) error {
 		}
 	}
 	if !isSuccess {
-		return errors.New("3005,Data generate error, retry again")
+		return mcerr.New(mcerr.InvalidData, "Data generate error, retry again")
 	}
 
 	params := []string{dex.Id, args[0], args[1], args[2], args[3],
@@ -781,9 +901,9 @@ func Mrc010ReqSell(stub shim.ChaincodeStubInterface, args []string) error {
 	var argdat []byte
 
 	if len(args) < 12 {
-		return errors.New("1000,mrc010requestsell operation must include four arguments : " +
-			"seller, amount, mrc010id, buyPrice, buytoken, " +
-			"platformName, platformURL, platformAddress, platformCommission, mintradeunit," +
+		return mcerr.New(mcerr.InvalidArguments, "mrc010requestsell operation must include four arguments : "+
+			"seller, amount, mrc010id, buyPrice, buytoken, "+
+			"platformName, platformURL, platformAddress, platformCommission, mintradeunit,"+
 			"signature, nonce")
 	}
 
@@ -794,7 +914,7 @@ func Mrc010ReqSell(stub shim.ChaincodeStubInterface, args []string) error {
 
 	// 1 amount
 	if buyAmount, err = util.ParsePositive(args[1]); err != nil {
-		return errors.New("1107," + args[1] + " is not positive integer")
+		return mcerr.New(mcerr.InvalidSellAmount, args[1]+" is not positive integer")
 	}
 
 	// 2 mrc010id
@@ -832,32 +952,32 @@ func Mrc010ReqSell(stub shim.ChaincodeStubInterface, args []string) error {
 		return err
 	}
 	if mrc010.Id == token.Id {
-		return errors.New("3005,The sale token must be different from the payment token")
+		return mcerr.New(mcerr.InvalidData, "The sale token must be different from the payment token")
 	}
 
 	// 5 platformname
 	if err = util.DataAssign(args[5], &dex.PlatformName, "", 1, 256, true); err != nil {
-		return errors.New("3005,Url value error : " + err.Error())
+		return mcerr.New(mcerr.InvalidData, "Url value error : "+err.Error())
 	}
 
 	// 6 PlatformURL
 	if err = util.DataAssign(args[6], &dex.PlatformURL, "url", 1, 256, true); err != nil {
-		return errors.New("3005,Url value error : " + err.Error())
+		return mcerr.New(mcerr.InvalidData, "Url value error : "+err.Error())
 	}
 
 	// 7 PlatformAddress
 	if err = util.DataAssign(args[7], &dex.PlatformAddress, "address", 40, 40, true); err != nil {
-		return errors.New("3005,Data value error : " + err.Error())
+		return mcerr.New(mcerr.InvalidData, "Data value error : "+err.Error())
 	}
 	if util.IsAddress(dex.PlatformAddress) {
 		if _, err = GetAddressInfo(stub, dex.PlatformAddress); err != nil {
-			return errors.New("3005," + "PlatformAddress not found : " + err.Error())
+			return mcerr.New(mcerr.InvalidData, "PlatformAddress not found : "+err.Error())
 		}
 	}
 
 	// 8 PlatformCommission
 	if err = util.NumericDataCheck(args[8], &dex.PlatformCommission, "0.00", "10.00", 2, true); err != nil {
-		return errors.New("3005,Data value error : " + err.Error())
+		return mcerr.New(mcerr.InvalidData, "Data value error : "+err.Error())
 	}
 
 	// 9 MintradeUnit
@@ -867,17 +987,17 @@ func Mrc010ReqSell(stub shim.ChaincodeStubInterface, args []string) error {
 	case "1", "10", "100", "1000", "10000", "100000", "1000000", "10000000", "100000000":
 		dex.MinTradeUnit = args[9]
 	default:
-		return errors.New("3005,MinTradeUnit value error : " + args[9])
+		return mcerr.New(mcerr.InvalidData, "MinTradeUnit value error : "+args[9])
 	}
 	tradeUnit, _ := decimal.NewFromString(dex.MinTradeUnit)
 	if buyAmount.Mod(tradeUnit).Cmp(decimal.Zero) != 0 {
-		return errors.New("3005,The amount quantity must be a multiple of " + dex.MinTradeUnit)
+		return mcerr.New(mcerr.InvalidData, "The amount quantity must be a multiple of "+dex.MinTradeUnit)
 	}
 
-	if err = NonceCheck(&buyerWallet, args[11],
-		strings.Join([]string{args[0], args[1], args[2], args[3], args[4],
+	if err = NonceCheck(stub, &buyerWallet, args[11], "mrc010reqsell",
+		[]string{args[0], args[1], args[2], args[3], args[4],
 			args[5], args[6], args[7], args[8],
-			args[11]}, "|"),
+			args[11]},
 		args[10]); err != nil {
 		return err
 	}
@@ -886,7 +1006,7 @@ func Mrc010ReqSell(stub shim.ChaincodeStubInterface, args []string) error {
 		return err
 	}
 	if err = util.NumericDataCheck(totalPrice.String(), nil, "1", "", 0, false); err != nil {
-		return errors.New("3005,The total purchase amount is too low")
+		return mcerr.New(mcerr.InvalidData, "The total purchase amount is too low")
 	}
 
 	if commission, err = DexFeeCalc(totalPrice, dex.PlatformCommission, dex.BuyToken); err == nil {
@@ -904,7 +1024,7 @@ func Mrc010ReqSell(stub shim.ChaincodeStubInterface, args []string) error {
 		dex.Id = fmt.Sprintf("%39s%1d", temp, i)
 		argdat, err = stub.GetState(dex.Id)
 		if err != nil {
-			return errors.New("8600,Hyperledger internal error - " + err.Error())
+			return mcerr.Wrap(mcerr.LedgerWrite, "Hyperledger internal error", err)
 		}
 
 		if argdat != nil { // key already exists
@@ -915,7 +1035,7 @@ func Mrc010ReqSell(stub shim.ChaincodeStubInterface, args []string) error {
 		}
 	}
 	if !isSuccess {
-		return errors.New("3005,Data generate error, retry again")
+		return mcerr.New(mcerr.InvalidData, "Data generate error, retry again")
 	}
 
 	params := []string{dex.Id, args[0], args[1], args[2], args[3],
@@ -938,7 +1058,7 @@ func Mrc010UnSell(stub shim.ChaincodeStubInterface, args []string) error {
 	var dex TMRC010DEX
 
 	if len(args) < 3 {
-		return errors.New("1000,mrc010unsell operation must include four arguments : " +
+		return mcerr.New(mcerr.InvalidArguments, "mrc010unsell operation must include four arguments : "+
 			"dexid, signature, nonce")
 	}
 
@@ -947,24 +1067,24 @@ func Mrc010UnSell(stub shim.ChaincodeStubInterface, args []string) error {
 		return err
 	}
 	if dex.AuctionStartDate > 0 {
-		return errors.New("3004,DEX Item is not sell item")
+		return mcerr.New(mcerr.DexStatus, "DEX Item is not sell item")
 	}
 	if (dex.JobType != "mrc010_sell") && (dex.JobType != "mrc010_buy") {
-		return errors.New("3014,DEX Item is not sell item, [" + dex.JobType + "]")
+		return mcerr.New(mcerr.DexJobType, "DEX Item is not sell item, ["+dex.JobType+"]")
 	}
-	switch dex010Status(dex) {
+	switch dex010Status(stub, dex) {
 	case MRC010DS_SELL:
 		// OK
 	case MRC010DS_AUCTION_WAIT, MRC010DS_AUCTION, MRC010DS_AUCTION_END, MRC010DS_AUCTION_FINISH:
-		return errors.New("3024,DEX Item is not sell item")
+		return mcerr.New(mcerr.DexAuction, "DEX Item is not sell item")
 	case MRC010DS_CANCLED:
-		return errors.New("3034,DEX Item is already canceled")
+		return mcerr.New(mcerr.DexCanceled, "DEX Item is already canceled")
 	case MRC010DS_SOLDOUT:
-		return errors.New("3044,DEX Item is already traded")
+		return mcerr.New(mcerr.DexSoldOut, "DEX Item is already traded")
 	case MRC010DS_BUY:
-		return errors.New("3054,DEX Item is not sell item")
+		return mcerr.New(mcerr.DexBuy, "DEX Item is not sell item")
 	default:
-		return errors.New("3064,DEX Item status is unknown")
+		return mcerr.New(mcerr.DexUnknown, "DEX Item status is unknown")
 	}
 
 	// 1 seller
@@ -972,8 +1092,8 @@ func Mrc010UnSell(stub shim.ChaincodeStubInterface, args []string) error {
 		return err
 	}
 
-	if err = NonceCheck(&sellerWallet, args[2],
-		strings.Join([]string{args[0], args[2]}, "|"),
+	if err = NonceCheck(stub, &sellerWallet, args[2], "mrc010unsell",
+		[]string{args[0], args[2]},
 		args[1]); err != nil {
 		return err
 	}
@@ -1007,7 +1127,7 @@ func Mrc010UnReqSell(stub shim.ChaincodeStubInterface, args []string) error {
 	var dex TMRC010DEX
 
 	if len(args) < 3 {
-		return errors.New("1000,mrc010unsell operation must include four arguments : " +
+		return mcerr.New(mcerr.InvalidArguments, "mrc010unsell operation must include four arguments : "+
 			"dexid, signature, nonce")
 	}
 
@@ -1016,24 +1136,24 @@ func Mrc010UnReqSell(stub shim.ChaincodeStubInterface, args []string) error {
 		return err
 	}
 	if dex.AuctionStartDate > 0 {
-		return errors.New("3004,DEX Item is not sell item")
+		return mcerr.New(mcerr.DexStatus, "DEX Item is not sell item")
 	}
 	if (dex.JobType != "mrc010_reqsell") && (dex.JobType != "mrc010_acceptreqsell") {
-		return errors.New("3004,DEX Item is not sell item")
+		return mcerr.New(mcerr.DexStatus, "DEX Item is not sell item")
 	}
-	switch dex010Status(dex) {
+	switch dex010Status(stub, dex) {
 	case MRC010DS_BUY:
 		// OK
 	case MRC010DS_AUCTION_WAIT, MRC010DS_AUCTION, MRC010DS_AUCTION_END, MRC010DS_AUCTION_FINISH:
-		return errors.New("3004,DEX Item is not sell item")
+		return mcerr.New(mcerr.DexStatus, "DEX Item is not sell item")
 	case MRC010DS_CANCLED:
-		return errors.New("3004,DEX Item is already canceled")
+		return mcerr.New(mcerr.DexStatus, "DEX Item is already canceled")
 	case MRC010DS_SOLDOUT:
-		return errors.New("3004,DEX Item is already traded")
+		return mcerr.New(mcerr.DexStatus, "DEX Item is already traded")
 	case MRC010DS_SELL:
-		return errors.New("3004,DEX Item is not sellreqeust item")
+		return mcerr.New(mcerr.DexStatus, "DEX Item is not sellreqeust item")
 	default:
-		return errors.New("3004,DEX Item status is unknown")
+		return mcerr.New(mcerr.DexStatus, "DEX Item status is unknown")
 	}
 
 	// 1 seller
@@ -1041,8 +1161,8 @@ func Mrc010UnReqSell(stub shim.ChaincodeStubInterface, args []string) error {
 		return err
 	}
 
-	if err = NonceCheck(&buyerWallet, args[2],
-		strings.Join([]string{args[0], args[2]}, "|"),
+	if err = NonceCheck(stub, &buyerWallet, args[2], "mrc010unreqsell",
+		[]string{args[0], args[2]},
 		args[1]); err != nil {
 		return err
 	}
@@ -1085,7 +1205,7 @@ func Mrc010Buy(stub shim.ChaincodeStubInterface, args []string) error {
 
 	// argument check
 	if len(args) < 5 {
-		return errors.New("1000,mrc010unsell operation must include four arguments : " +
+		return mcerr.New(mcerr.InvalidArguments, "mrc010unsell operation must include four arguments : "+
 			"dexid, buyer, amount, signature, nonce")
 	}
 
@@ -1096,36 +1216,36 @@ func Mrc010Buy(stub shim.ChaincodeStubInterface, args []string) error {
 
 	// is sell item ?
 	if dex.JobType != "mrc010_sell" && dex.JobType != "mrc010_buy" {
-		return errors.New("3004,DEX Item is not request to sell item")
+		return mcerr.New(mcerr.DexStatus, "DEX Item is not request to sell item")
 	}
 
 	// status check
-	switch dex010Status(dex) {
+	switch dex010Status(stub, dex) {
 	case MRC010DS_SELL:
 		// OK
 	case MRC010DS_CANCLED:
-		return errors.New("3004,DEX Item is already canceled")
+		return mcerr.New(mcerr.DexStatus, "DEX Item is already canceled")
 	case MRC010DS_SOLDOUT:
-		return errors.New("3004,DEX Item is already traded")
+		return mcerr.New(mcerr.DexStatus, "DEX Item is already traded")
 	case MRC010DS_AUCTION_WAIT, MRC010DS_AUCTION, MRC010DS_AUCTION_END, MRC010DS_AUCTION_FINISH:
-		return errors.New("3004,DEX Item is not sell item")
+		return mcerr.New(mcerr.DexStatus, "DEX Item is not sell item")
 	case MRC010DS_BUY:
-		return errors.New("3004,DEX Item is sell item")
+		return mcerr.New(mcerr.DexStatus, "DEX Item is sell item")
 	default:
-		return errors.New("3004,DEX Item status is unknown")
+		return mcerr.New(mcerr.DexStatus, "DEX Item status is unknown")
 	}
 
 	// self trade ?
 	if dex.Seller == args[1] {
-		return errors.New("3004,Cross-trading is not allowed")
+		return mcerr.New(mcerr.DexStatus, "Cross-trading is not allowed")
 	}
 
 	// 1 buyer & sign check
 	if buyerWallet, err = GetAddressInfo(stub, args[1]); err != nil {
 		return err
 	}
-	if err = NonceCheck(&buyerWallet, args[4],
-		strings.Join([]string{args[0], args[1], args[2], args[4]}, "|"),
+	if err = NonceCheck(stub, &buyerWallet, args[4], "mrc010buy",
+		[]string{args[0], args[1], args[2], args[4]},
 		args[3]); err != nil {
 		return err
 	}
@@ -1138,7 +1258,7 @@ func Mrc010Buy(stub shim.ChaincodeStubInterface, args []string) error {
 		return err
 	}
 	if remainAmount.Cmp(buyAmount) < 0 {
-		return errors.New("3004,The quantity available for purchase is " + remainAmount.String() + " pieces.")
+		return mcerr.New(mcerr.DexStatus, "The quantity available for purchase is "+remainAmount.String()+" pieces.")
 	}
 	dex.RemainAmount = remainAmount.Sub(buyAmount).String()
 
@@ -1184,7 +1304,7 @@ func Mrc010AcceptReqSell(stub shim.ChaincodeStubInterface, args []string) error
 
 	// argument check
 	if len(args) < 5 {
-		return errors.New("1000,mrc010unsell operation must include four arguments : " +
+		return mcerr.New(mcerr.InvalidArguments, "mrc010unsell operation must include four arguments : "+
 			"dexid, seller, amount, signature, nonce")
 	}
 
@@ -1195,36 +1315,36 @@ func Mrc010AcceptReqSell(stub shim.ChaincodeStubInterface, args []string) error
 
 	// is request sell item ?
 	if dex.JobType != "mrc010_reqsell" && dex.JobType != "mrc010_acceptreqsell" {
-		return errors.New("3004,DEX Item is not request to buy item")
+		return mcerr.New(mcerr.DexStatus, "DEX Item is not request to buy item")
 	}
 
 	// status check
-	switch dex010Status(dex) {
+	switch dex010Status(stub, dex) {
 	case MRC010DS_BUY:
 		// OK
 	case MRC010DS_CANCLED:
-		return errors.New("3004,DEX Item is already canceled")
+		return mcerr.New(mcerr.DexStatus, "DEX Item is already canceled")
 	case MRC010DS_SOLDOUT:
-		return errors.New("3004,DEX Item is already traded")
+		return mcerr.New(mcerr.DexStatus, "DEX Item is already traded")
 	case MRC010DS_AUCTION_WAIT, MRC010DS_AUCTION, MRC010DS_AUCTION_END, MRC010DS_AUCTION_FINISH:
-		return errors.New("3004,DEX Item is not sell item")
+		return mcerr.New(mcerr.DexStatus, "DEX Item is not sell item")
 	case MRC010DS_SELL:
-		return errors.New("3004,DEX Item is not sell reqeust item")
+		return mcerr.New(mcerr.DexStatus, "DEX Item is not sell reqeust item")
 	default:
-		return errors.New("3004,DEX Item status is unknown")
+		return mcerr.New(mcerr.DexStatus, "DEX Item status is unknown")
 	}
 
 	// self trade ?
 	if dex.Buyer == args[1] {
-		return errors.New("3004,Buyer is don't sell")
+		return mcerr.New(mcerr.DexStatus, "Buyer is don't sell")
 	}
 
 	// 1 seller & sign check
 	if sellerWallet, err = GetAddressInfo(stub, args[1]); err != nil {
 		return err
 	}
-	if err = NonceCheck(&sellerWallet, args[4],
-		strings.Join([]string{args[0], args[1], args[2], args[4]}, "|"),
+	if err = NonceCheck(stub, &sellerWallet, args[4], "mrc010acceptreqsell",
+		[]string{args[0], args[1], args[2], args[4]},
 		args[3]); err != nil {
 		return err
 	}
@@ -1237,7 +1357,7 @@ func Mrc010AcceptReqSell(stub shim.ChaincodeStubInterface, args []string) error
 		return err
 	}
 	if remainAmount.Cmp(sellAmount) < 0 {
-		return errors.New("3004,The quantity available for purchase is " + remainAmount.String() + " pieces.")
+		return mcerr.New(mcerr.DexStatus, "The quantity available for purchase is "+remainAmount.String()+" pieces.")
 	}
 	dex.RemainAmount = remainAmount.Sub(sellAmount).String()
 
@@ -1250,7 +1370,7 @@ func Mrc010AcceptReqSell(stub shim.ChaincodeStubInterface, args []string) error
 	}
 
 	if sendPrice.Cmp(decimal.Zero) < 1 {
-		return errors.New("3004,The payment amount is too low to purchase")
+		return mcerr.New(mcerr.DexStatus, "The payment amount is too low to purchase")
 	}
 
 	fmt.Println("sendPrice", sendPrice.String())
@@ -1288,7 +1408,7 @@ func Mrc010DexMatch(stub shim.ChaincodeStubInterface, args []string) error {
 
 		// argument check
 		if len(args) < 5 {
-			return errors.New("1000,mrc010unsell operation must include four arguments : " +
+			return mcerr.New(mcerr.InvalidArguments, "mrc010unsell operation must include four arguments : " +
 				"dexid4Sell, dexid4ReqSell")
 		}
 
@@ -1300,23 +1420,23 @@ func Mrc010DexMatch(stub shim.ChaincodeStubInterface, args []string) error {
 
 		// is sell item ?
 		if dexSell.JobType != "mrc010_sell" && dexSell.JobType != "mrc010_buy" {
-			return errors.New("3004,DEX Item is not request to sell item")
+			return mcerr.New(mcerr.DexStatus, "DEX Item is not request to sell item")
 		}
 
 		// status check
-		switch dex010Status(dexSell) {
+		switch dex010Status(stub, dexSell) {
 		case MRC010DS_SELL:
 			// OK
 		case MRC010DS_CANCLED:
-			return errors.New("3004,DEX Item is already canceled")
+			return mcerr.New(mcerr.DexStatus, "DEX Item is already canceled")
 		case MRC010DS_SOLDOUT:
-			return errors.New("3004,DEX Item is already traded")
+			return mcerr.New(mcerr.DexStatus, "DEX Item is already traded")
 		case MRC010DS_AUCTION_WAIT, MRC010DS_AUCTION, MRC010DS_AUCTION_END, MRC010DS_AUCTION_FINISH:
-			return errors.New("3004,DEX Item is not sell item")
+			return mcerr.New(mcerr.DexStatus, "DEX Item is not sell item")
 		case MRC010DS_BUY:
-			return errors.New("3004,DEX Item is sell item")
+			return mcerr.New(mcerr.DexStatus, "DEX Item is sell item")
 		default:
-			return errors.New("3004,DEX Item status is unknown")
+			return mcerr.New(mcerr.DexStatus, "DEX Item status is unknown")
 		}
 
 		// ===============================
@@ -1327,28 +1447,28 @@ func Mrc010DexMatch(stub shim.ChaincodeStubInterface, args []string) error {
 
 		// is request sell item ?
 		if dexBuy.JobType != "mrc010_reqsell" && dexBuy.JobType != "mrc010_acceptreqsell" {
-			return errors.New("3004,DEX Item is not request to buy item")
+			return mcerr.New(mcerr.DexStatus, "DEX Item is not request to buy item")
 		}
 
 		// status check
-		switch dex010Status(dexBuy) {
+		switch dex010Status(stub, dexBuy) {
 		case MRC010DS_BUY:
 			// OK
 		case MRC010DS_CANCLED:
-			return errors.New("3004,DEX Item is already canceled")
+			return mcerr.New(mcerr.DexStatus, "DEX Item is already canceled")
 		case MRC010DS_SOLDOUT:
-			return errors.New("3004,DEX Item is already traded")
+			return mcerr.New(mcerr.DexStatus, "DEX Item is already traded")
 		case MRC010DS_AUCTION_WAIT, MRC010DS_AUCTION, MRC010DS_AUCTION_END, MRC010DS_AUCTION_FINISH:
-			return errors.New("3004,DEX Item is not sell item")
+			return mcerr.New(mcerr.DexStatus, "DEX Item is not sell item")
 		case MRC010DS_SELL:
-			return errors.New("3004,DEX Item is not sell reqeust item")
+			return mcerr.New(mcerr.DexStatus, "DEX Item is not sell reqeust item")
 		default:
-			return errors.New("3004,DEX Item status is unknown")
+			return mcerr.New(mcerr.DexStatus, "DEX Item status is unknown")
 		}
 
 		// block self trade.
 		if dexSell.Seller == dexBuy.Buyer {
-			return errors.New("3004,Cross-trading is not allowed")
+			return mcerr.New(mcerr.DexStatus, "Cross-trading is not allowed")
 		}
 
 		// get Trade Amount
@@ -1444,12 +1564,12 @@ func Mrc010Auction(stub shim.ChaincodeStubInterface, args []string) error {
 	var now int64
 
 	if len(args) < 15 {
-		return errors.New("1000,mrc010auction operation must include four arguments : " +
-			"address, amount, mrc010id, auction_start_price, selltoken, " +
-			"auction_bidding_unit, auction_buynow_price, auction_start_date, auction_end_date, platformName, " +
+		return mcerr.New(mcerr.InvalidArguments, "mrc010auction operation must include four arguments : "+
+			"address, amount, mrc010id, auction_start_price, selltoken, "+
+			"auction_bidding_unit, auction_buynow_price, auction_start_date, auction_end_date, platformName, "+
 			"platformURL, platformAddress, platformCommission, signature, nonce")
 	}
-	now = time.Now().Unix()
+	now = GetTxTime(stub)
 
 	// 0 seller
 	if sellerWallet, err = GetAddressInfo(stub, args[0]); err != nil {
@@ -1458,7 +1578,7 @@ func Mrc010Auction(stub shim.ChaincodeStubInterface, args []string) error {
 
 	// 1 amount
 	if sellAmount, err = util.ParsePositive(args[1]); err != nil {
-		return errors.New("1108," + args[1] + " is not positive integer")
+		return mcerr.New(mcerr.InvalidAuctionAmount, args[1]+" is not positive integer")
 	}
 
 	// 2 mrc010id
@@ -1513,7 +1633,7 @@ func Mrc010Auction(stub shim.ChaincodeStubInterface, args []string) error {
 
 	if buyNowPrice.Cmp(decimal.Zero) > 0 {
 		if buyNowPrice.Cmp(startPrice) < 1 {
-			return errors.New("3005,Auction buynow is too small")
+			return mcerr.New(mcerr.InvalidData, "Auction buynow is too small")
 		}
 	}
 
@@ -1527,9 +1647,9 @@ func Mrc010Auction(stub shim.ChaincodeStubInterface, args []string) error {
 	if dex.AuctionStartDate <= 0 {
 		dex.AuctionStartDate = now
 	} else if dex.AuctionStartDate < now {
-		return errors.New("3005,The auction start time is in the past")
+		return mcerr.New(mcerr.InvalidData, "The auction start time is in the past")
 	} else if (dex.AuctionStartDate - now) > 1814400 {
-		return errors.New("3005,Auction start time must be within 7 days")
+		return mcerr.New(mcerr.InvalidData, "Auction start time must be within 7 days")
 	}
 
 	if len(args[8]) == 0 {
@@ -1540,40 +1660,40 @@ func Mrc010Auction(stub shim.ChaincodeStubInterface, args []string) error {
 	if dex.AuctionEndDate <= 0 {
 		dex.AuctionEndDate = dex.AuctionStartDate + 86400
 	} else if (dex.AuctionEndDate - now) < 3600 {
-		return errors.New("3005,Auction duration is at least 1 hour")
+		return mcerr.New(mcerr.InvalidData, "Auction duration is at least 1 hour")
 	} else if (dex.AuctionEndDate - now) > 1814400 {
-		return errors.New("3005,The auction period is up to 7 days")
+		return mcerr.New(mcerr.InvalidData, "The auction period is up to 7 days")
 	}
 
 	// 8 platformname
 	if err = util.DataAssign(args[9], &dex.PlatformName, "", 1, 256, true); err != nil {
-		return errors.New("3005,Url value error : " + err.Error())
+		return mcerr.New(mcerr.InvalidData, "Url value error : "+err.Error())
 	}
 
 	// 9 PlatformURL
 	if err = util.DataAssign(args[10], &dex.PlatformURL, "url", 1, 256, true); err != nil {
-		return errors.New("3005,Url value error : " + err.Error())
+		return mcerr.New(mcerr.InvalidData, "Url value error : "+err.Error())
 	}
 
 	// 10 PlatformAddress
 	if err = util.DataAssign(args[11], &dex.PlatformAddress, "address", 40, 40, true); err != nil {
-		return errors.New("3005,Data value error : " + err.Error())
+		return mcerr.New(mcerr.InvalidData, "Data value error : "+err.Error())
 	}
 	if util.IsAddress(dex.PlatformAddress) {
 		if _, err = GetAddressInfo(stub, dex.PlatformAddress); err != nil {
-			return errors.New("3005," + "PlatformAddress not found : " + err.Error())
+			return mcerr.New(mcerr.InvalidData, "PlatformAddress not found : "+err.Error())
 		}
 	}
 
 	// 11 PlatformCommission
 	if err = util.NumericDataCheck(args[12], &dex.PlatformCommission, "0.00", "10.00", 2, true); err != nil {
-		return errors.New("3005,Data value error : " + err.Error())
+		return mcerr.New(mcerr.InvalidData, "Data value error : "+err.Error())
 	}
 
-	if err = NonceCheck(&sellerWallet, args[14],
-		strings.Join([]string{args[0], args[1], args[2], args[3], args[4],
+	if err = NonceCheck(stub, &sellerWallet, args[14], "mrc010auction",
+		[]string{args[0], args[1], args[2], args[3], args[4],
 			args[5], args[6], args[7], args[8], args[9],
-			args[10], args[11], args[12], args[14]}, "|"),
+			args[10], args[11], args[12], args[14]},
 		args[13]); err != nil {
 		return err
 	}
@@ -1589,7 +1709,7 @@ func Mrc010Auction(stub shim.ChaincodeStubInterface, args []string) error {
 		dex.Id = fmt.Sprintf("%39s%1d", temp, i)
 		argdat, err = stub.GetState(dex.Id)
 		if err != nil {
-			return errors.New("8600,Hyperledger internal error - " + err.Error())
+			return mcerr.Wrap(mcerr.LedgerWrite, "Hyperledger internal error", err)
 		}
 
 		if argdat != nil { // key already exists
@@ -1600,7 +1720,7 @@ func Mrc010Auction(stub shim.ChaincodeStubInterface, args []string) error {
 		}
 	}
 	if !isSuccess {
-		return errors.New("3005,Data generate error, retry again")
+		return mcerr.New(mcerr.InvalidData, "Data generate error, retry again")
 	}
 
 	// 	"seller, amount, mrc010id, auction_start_price, selltoken, " +
@@ -1632,7 +1752,7 @@ func Mrc010UnAuction(stub shim.ChaincodeStubInterface, args []string) error {
 	var dex TMRC010DEX
 
 	if len(args) < 3 {
-		return errors.New("1000,mrc010unauction operation must include four arguments : " +
+		return mcerr.New(mcerr.InvalidArguments, "mrc010unauction operation must include four arguments : "+
 			"mrc010dexid, signature, nonce")
 	}
 
@@ -1641,27 +1761,27 @@ func Mrc010UnAuction(stub shim.ChaincodeStubInterface, args []string) error {
 		return err
 	}
 	if dex.AuctionStartDate == 0 {
-		return errors.New("3004,DEX Item is not sell item")
+		return mcerr.New(mcerr.DexStatus, "DEX Item is not sell item")
 	}
 
-	switch dex010Status(dex) {
+	switch dex010Status(stub, dex) {
 	case MRC010DS_AUCTION, MRC010DS_AUCTION_WAIT:
 		// ok
 	case MRC010DS_SELL, MRC010DS_SOLDOUT:
-		return errors.New("3004,DEX Item is not auction item")
+		return mcerr.New(mcerr.DexStatus, "DEX Item is not auction item")
 	case MRC010DS_CANCLED:
-		return errors.New("3004,DEX Item is already canceled")
+		return mcerr.New(mcerr.DexStatus, "DEX Item is already canceled")
 	case MRC010DS_AUCTION_END:
-		return errors.New("3004,DEX Item is already end, use auction finish")
+		return mcerr.New(mcerr.DexStatus, "DEX Item is already end, use auction finish")
 	case MRC010DS_AUCTION_FINISH:
-		return errors.New("3004,DEX Item is already finished")
+		return mcerr.New(mcerr.DexStatus, "DEX Item is already finished")
 	default:
-		return errors.New("3004,DEX Item status is unknown")
+		return mcerr.New(mcerr.DexStatus, "DEX Item status is unknown")
 	}
 
 	// bidder exists ?
 	if dex.AuctionCurrentBidder != "" {
-		return errors.New("3004,DEX Item there is a bidder, so the auction cannot be canceled")
+		return mcerr.New(mcerr.DexStatus, "DEX Item there is a bidder, so the auction cannot be canceled")
 	}
 
 	// sign check.
@@ -1669,8 +1789,8 @@ func Mrc010UnAuction(stub shim.ChaincodeStubInterface, args []string) error {
 		return err
 	}
 
-	if err = NonceCheck(&sellerWallet, args[2],
-		strings.Join([]string{args[0], args[2]}, "|"),
+	if err = NonceCheck(stub, &sellerWallet, args[2], "mrc010unauction",
+		[]string{args[0], args[2]},
 		args[1]); err != nil {
 		return err
 	}
@@ -1715,7 +1835,7 @@ func Mrc010AuctionBid(stub shim.ChaincodeStubInterface, args []string) error {
 	var isBuynow bool
 
 	if len(args) < 5 {
-		return errors.New("1000,mrc010bid operation must include four arguments : " +
+		return mcerr.New(mcerr.InvalidArguments, "mrc010bid operation must include four arguments : "+
 			"mrc010dexid, address, amount, signature, nonce")
 	}
 	buyerAddress = args[1]
@@ -1725,26 +1845,26 @@ func Mrc010AuctionBid(stub shim.ChaincodeStubInterface, args []string) error {
 		return err
 	}
 
-	switch dex010Status(dex) {
+	switch dex010Status(stub, dex) {
 	case MRC010DS_AUCTION:
 		// OK
 	case MRC010DS_SELL, MRC010DS_SOLDOUT:
-		return errors.New("3004,DEX Item is not auction item")
+		return mcerr.New(mcerr.DexStatus, "DEX Item is not auction item")
 	case MRC010DS_AUCTION_WAIT:
-		return errors.New("3004,This is not the auction bidding period")
+		return mcerr.New(mcerr.DexStatus, "This is not the auction bidding period")
 	case MRC010DS_AUCTION_END:
-		return errors.New("3004,DEX Item is already end, use auction finish")
+		return mcerr.New(mcerr.DexStatus, "DEX Item is already end, use auction finish")
 	case MRC010DS_AUCTION_FINISH:
-		return errors.New("3004,DEX Item is already finished")
+		return mcerr.New(mcerr.DexStatus, "DEX Item is already finished")
 	case MRC010DS_CANCLED:
-		return errors.New("3004,DEX Item is already canceled")
+		return mcerr.New(mcerr.DexStatus, "DEX Item is already canceled")
 	default:
-		return errors.New("3004,DEX Item status is unknown")
+		return mcerr.New(mcerr.DexStatus, "DEX Item status is unknown")
 	}
 
 	// buyer is seller ?
 	if dex.Seller == buyerAddress {
-		return errors.New("3004,Seller is don't buy")
+		return mcerr.New(mcerr.DexStatus, "Seller is don't buy")
 	}
 
 	// 1 buyer
@@ -1752,8 +1872,8 @@ func Mrc010AuctionBid(stub shim.ChaincodeStubInterface, args []string) error {
 		return err
 	}
 
-	if err = NonceCheck(&buyerWallet, args[4],
-		strings.Join([]string{args[0], buyerAddress, args[2], args[4]}, "|"),
+	if err = NonceCheck(stub, &buyerWallet, args[4], "mrc010bid",
+		[]string{args[0], buyerAddress, args[2], args[4]},
 		args[3]); err != nil {
 		return err
 	}
@@ -1763,7 +1883,7 @@ func Mrc010AuctionBid(stub shim.ChaincodeStubInterface, args []string) error {
 		return err
 	}
 	if newBidPrice, err = decimal.NewFromString(args[2]); err != nil {
-		return errors.New("3004,The bid amount is incorrect. " + err.Error())
+		return mcerr.New(mcerr.DexStatus, "The bid amount is incorrect. "+err.Error())
 	}
 
 	// get price info
@@ -1774,13 +1894,13 @@ func Mrc010AuctionBid(stub shim.ChaincodeStubInterface, args []string) error {
 		if newBidPrice.Cmp(buyNow) == 0 {
 			isBuynow = true
 		} else if newBidPrice.Cmp(buyNow) > 0 {
-			return errors.New("3004,The bid amount must be less than or equal to the purchase buynow price")
+			return mcerr.New(mcerr.DexStatus, "The bid amount must be less than or equal to the purchase buynow price")
 		}
 	}
 
 	if !isBuynow {
 		if dex.AuctionCurrentBidder == buyerAddress {
-			return errors.New("3004,You are already the highest bidder")
+			return mcerr.New(mcerr.DexStatus, "You are already the highest bidder")
 		}
 	}
 
@@ -1788,7 +1908,7 @@ func Mrc010AuctionBid(stub shim.ChaincodeStubInterface, args []string) error {
 		oldBidPrice, _ = decimal.NewFromString(dex.AuctionCurrentPrice)
 		if !isBuynow {
 			if newBidPrice.Sub(bidUnit).Cmp(oldBidPrice) < 0 {
-				return errors.New("3004,The bid amount must be greater than the current bid amount plus the bid unit")
+				return mcerr.New(mcerr.DexStatus, "The bid amount must be greater than the current bid amount plus the bid unit")
 			}
 		}
 
@@ -1799,7 +1919,7 @@ func Mrc010AuctionBid(stub shim.ChaincodeStubInterface, args []string) error {
 	} else {
 		oldBidPrice, _ = decimal.NewFromString(dex.AuctionStartPrice)
 		if newBidPrice.Cmp(oldBidPrice) < 0 {
-			return errors.New("3004,The bid amount must be equal to or greater than the starting price")
+			return mcerr.New(mcerr.DexStatus, "The bid amount must be equal to or greater than the starting price")
 		}
 		refunderAddress = ""
 	}
@@ -1844,7 +1964,7 @@ func Mrc010AuctionBid(stub shim.ChaincodeStubInterface, args []string) error {
 	}
 
 	// save bid info
-	if err = setDEX010(stub, dex, "mrc010_auctionbid", []string{dex.Id, dex.Seller, buyerAddress, util.JSONEncode(PaymentInfo), args[3], args[4]}); err != nil {
+	if err = setDEX010(stub, dex, "mrc010_auctionbid", []string{dex.Id, dex.Seller, buyerAddress, util.JSONEncode(PaymentInfo), args[3], args[4]}, PaymentInfo...); err != nil {
 		return err
 	}
 	return nil
@@ -1871,7 +1991,7 @@ func Mrc010AuctionFinish(stub shim.ChaincodeStubInterface, args []string) error
 	var PaymentInfo = make([]mtc.TDexPaymentInfo, 0, 12)
 
 	if len(args) < 1 {
-		return errors.New("1000,mrc010auctionfinish operation must include four arguments : " +
+		return mcerr.New(mcerr.InvalidArguments, "mrc010auctionfinish operation must include four arguments : "+
 			"mrc010dexid")
 	}
 	// get item info
@@ -1879,19 +1999,19 @@ func Mrc010AuctionFinish(stub shim.ChaincodeStubInterface, args []string) error
 		return err
 	}
 
-	switch dex010Status(dex) {
+	switch dex010Status(stub, dex) {
 	case MRC010DS_AUCTION_END:
 		// OK
 	case MRC010DS_SELL, MRC010DS_SOLDOUT:
-		return errors.New("3004,DEX Item is not auction item")
+		return mcerr.New(mcerr.DexStatus, "DEX Item is not auction item")
 	case MRC010DS_AUCTION_WAIT, MRC010DS_AUCTION:
-		return errors.New("3004,It cannot be closed while the auction is pending")
+		return mcerr.New(mcerr.DexStatus, "It cannot be closed while the auction is pending")
 	case MRC010DS_AUCTION_FINISH:
-		return errors.New("3004,DEX Item is already finished")
+		return mcerr.New(mcerr.DexStatus, "DEX Item is already finished")
 	case MRC010DS_CANCLED:
-		return errors.New("3004,DEX Item is already canceled")
+		return mcerr.New(mcerr.DexStatus, "DEX Item is already canceled")
 	default:
-		return errors.New("3004,DEX Item status is unknown")
+		return mcerr.New(mcerr.DexStatus, "DEX Item status is unknown")
 	}
 
 	if dex.AuctionCurrentBidder != "" {
@@ -1941,6 +2061,16 @@ func mrc010DexProcess(stub shim.ChaincodeStubInterface, dex TMRC010DEX, buyerWal
 	}
 	var RecvMap map[string]RecvMapType
 
+	// the seller's item and the auction bid are in the DEX, check before they move
+	if err = mrc010MoveCheckByID(stub, dex.MRC010, dex.Seller); err != nil {
+		return err
+	}
+	if tradeType == MRC010MT_Auction {
+		if err = mrc010MoveCheckByID(stub, dex.SellToken, dex.AuctionCurrentBidder); err != nil {
+			return err
+		}
+	}
+
 	if tradeType == MRC010MT_Auction {
 		buyerAddress = dex.AuctionCurrentBidder
 		sellerType = "mrc010_recv_auction"
@@ -2093,7 +2223,7 @@ func mrc010DexProcess(stub shim.ChaincodeStubInterface, dex TMRC010DEX, buyerWal
 	// dex save
 	dex.Buyer = buyerAddress
 	addrParams = []string{dex.Id, dex.Seller, buyerAddress, util.JSONEncode(PaymentInfo), dex.MRC010}
-	if err = setDEX010(stub, dex, dexType, addrParams); err != nil {
+	if err = setDEX010(stub, dex, dexType, addrParams, PaymentInfo...); err != nil {
 		return err
 	}
 
@@ -2130,6 +2260,11 @@ func mrc010DexProcessReqSell(stub shim.ChaincodeStubInterface, dex TMRC010DEX, a
 	}
 	var RecvMap map[string]RecvMapType
 
+	// the buyer's payment is in the DEX, check before the payment moves
+	if err = mrc010MoveCheckByID(stub, dex.BuyToken, dex.Buyer); err != nil {
+		return err
+	}
+
 	actorAddress = dex.Seller
 	sellerType = "mrc010_recv_buy"
 	dexType = "mrc010_acceptreqsell"
@@ -2290,7 +2425,7 @@ func mrc010DexProcessReqSell(stub shim.ChaincodeStubInterface, dex TMRC010DEX, a
 	}
 
 	addrParams = []string{dex.Id, dex.Buyer, actorAddress, util.JSONEncode(PaymentInfo), dex.MRC010}
-	if err = setDEX010(stub, dex, dexType, addrParams); err != nil {
+	if err = setDEX010(stub, dex, dexType, addrParams, PaymentInfo...); err != nil {
 		return err
 	}
 
@@ -0,0 +1,207 @@
+package metacoin
+
+import (
+	"testing"
+)
+
+// dexFixture : alice has token 0 and token 1, bob and carol have token 0
+type dexFixture struct {
+	s                 *testStub
+	alice, bob, carol *testWallet
+	platform          *testWallet
+	coin, item        string
+}
+
+func newDexFixture(t *testing.T) *dexFixture {
+	t.Helper()
+	f := &dexFixture{s: newTestStub()}
+	f.alice = newGenesisWallet(t, f.s, "1000000")
+	f.bob = newTestWallet(t, f.s)
+	f.carol = newTestWallet(t, f.s)
+	f.platform = newTestWallet(t, f.s)
+	f.coin = "0"
+	f.item = registerToken(t, f.s, f.alice, "ITEM", "1000")
+	for _, w := range []*testWallet{f.bob, f.carol} {
+		if err := transfer(t, f.s, f.alice, w, f.coin, "10000"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return f
+}
+
+func TestMrc010SellBuy(t *testing.T) {
+	f := newDexFixture(t)
+	s, alice, bob := f.s, f.alice, f.bob
+
+	// alice sell 100 item, 5 coin per item, platform commission 1%
+	nonce := alice.nonce(t, s)
+	args := []string{alice.Address, "100", f.item, "5", f.coin, "", "", f.platform.Address, "1.00", ""}
+	args = append(args, alice.sign(t, s, "mrc010sell", append(args[:9:9], nonce)...), nonce)
+	s.mustTx(t, func() error { return Mrc010Sell(s, args) })
+	dexid := s.lastEvent(t, "mrc010_sell").Key
+	assertBalance(t, s, alice, f.item, "900")
+
+	// self trade
+	nonce = alice.nonce(t, s)
+	assertError(t, s.tx(func() error {
+		return Mrc010Buy(s, []string{dexid, alice.Address, "10", alice.sign(t, s, "mrc010buy", dexid, alice.Address, "10", nonce), nonce})
+	}), "3004")
+
+	// bob buy 40 item
+	nonce = bob.nonce(t, s)
+	s.mustTx(t, func() error {
+		return Mrc010Buy(s, []string{dexid, bob.Address, "40", bob.sign(t, s, "mrc010buy", dexid, bob.Address, "40", nonce), nonce})
+	})
+	assertBalance(t, s, bob, f.coin, "9800")
+	assertBalance(t, s, bob, f.item, "40")
+	assertBalance(t, s, alice, f.coin, "980198")
+	assertBalance(t, s, f.platform, f.coin, "2")
+
+	dex, _, err := GetDEX010(s, dexid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dex.RemainAmount != "60" {
+		t.Errorf("remain amount : got %s, expect 60", dex.RemainAmount)
+	}
+	if len(s.lastEvent(t, "mrc010_buy").Payment) == 0 {
+		t.Error("mrc010_buy event without payment")
+	}
+
+	// more than remain
+	nonce = bob.nonce(t, s)
+	assertError(t, s.tx(func() error {
+		return Mrc010Buy(s, []string{dexid, bob.Address, "61", bob.sign(t, s, "mrc010buy", dexid, bob.Address, "61", nonce), nonce})
+	}), "3004")
+}
+
+func TestMrc010Auction(t *testing.T) {
+	f := newDexFixture(t)
+	s, alice, bob, carol := f.s, f.alice, f.bob, f.carol
+
+	// alice auction 50 item, start price 100 coin, bidding unit 10, 1 day
+	nonce := alice.nonce(t, s)
+	args := []string{alice.Address, "50", f.item, "100", f.coin, "10", "0", "", "", "", "", "", ""}
+	args = append(args, alice.sign(t, s, "mrc010auction", append(args[:13:13], nonce)...), nonce)
+	s.mustTx(t, func() error { return Mrc010Auction(s, args) })
+	dexid := s.lastEvent(t, "mrc010_auction").Key
+	assertBalance(t, s, alice, f.item, "950")
+
+	bid := func(w *testWallet, amount string) error {
+		nonce := w.nonce(t, s)
+		return s.tx(func() error {
+			return Mrc010AuctionBid(s, []string{dexid, w.Address, amount, w.sign(t, s, "mrc010bid", dexid, w.Address, amount, nonce), nonce})
+		})
+	}
+	if err := bid(bob, "100"); err != nil {
+		t.Fatal(err)
+	}
+	assertBalance(t, s, bob, f.coin, "9900")
+
+	// less than current price + bidding unit
+	assertError(t, bid(carol, "105"), "3004")
+	if err := bid(carol, "110"); err != nil {
+		t.Fatal(err)
+	}
+	assertBalance(t, s, bob, f.coin, "10000")
+	assertBalance(t, s, carol, f.coin, "9890")
+
+	// finish is allowed after the end date only
+	assertError(t, s.tx(func() error { return Mrc010AuctionFinish(s, []string{dexid}) }), "3004")
+	s.advance(86400)
+	s.mustTx(t, func() error { return Mrc010AuctionFinish(s, []string{dexid}) })
+	assertBalance(t, s, carol, f.item, "50")
+	assertBalance(t, s, alice, f.coin, "980110")
+	assertError(t, s.tx(func() error { return Mrc010AuctionFinish(s, []string{dexid}) }), "3004")
+}
+
+func TestTokenPauseFreeze(t *testing.T) {
+	f := newDexFixture(t)
+	s, alice, bob, carol := f.s, f.alice, f.bob, f.carol
+
+	owner := func(function, target string, fn func(sig, nonce string) error) error {
+		nonce := alice.nonce(t, s)
+		sig := alice.sign(t, s, function, f.item, function, target, nonce)
+		return s.tx(func() error { return fn(sig, nonce) })
+	}
+
+	// paused token does not move, the other token does
+	s.mustTx(t, func() error {
+		tk, _, err := GetMRC010(s, f.item)
+		if err == nil {
+			tk.Status = "wait"
+			err = setMRC010(s, tk, "test", nil)
+		}
+		return err
+	})
+	if err := owner("tokenPause", "", func(sig, nonce string) error { return TokenPause(s, f.item, sig, nonce, nil) }); err != nil {
+		t.Fatal(err)
+	}
+	assertError(t, transfer(t, s, alice, bob, f.item, "10"), "4300")
+	if err := transfer(t, s, alice, bob, f.coin, "10"); err != nil {
+		t.Fatal(err)
+	}
+	// signed for the other function
+	assertError(t, owner("tokenPause", "", func(sig, nonce string) error { return TokenUnpause(s, f.item, sig, nonce, nil) }), "2010")
+	if err := owner("tokenUnpause", "", func(sig, nonce string) error { return TokenUnpause(s, f.item, sig, nonce, nil) }); err != nil {
+		t.Fatal(err)
+	}
+	if err := transfer(t, s, alice, bob, f.item, "10"); err != nil {
+		t.Fatal(err)
+	}
+	if tk, _, _ := GetMRC010(s, f.item); tk.Status != "wait" || tk.PrevStatus != "" {
+		t.Errorf("status after the unpause : %q, %q", tk.Status, tk.PrevStatus)
+	}
+
+	// alice sell 20 item, carol is frozen
+	nonce := alice.nonce(t, s)
+	args := []string{alice.Address, "20", f.item, "5", f.coin, "", "", "", "", ""}
+	args = append(args, alice.sign(t, s, "mrc010sell", append(args[:9:9], nonce)...), nonce)
+	s.mustTx(t, func() error { return Mrc010Sell(s, args) })
+	dexid := s.lastEvent(t, "mrc010_sell").Key
+
+	if err := owner("tokenFreezeAddress", carol.Address, func(sig, nonce string) error {
+		return TokenFreezeAddress(s, f.item, carol.Address, sig, nonce, nil)
+	}); err != nil {
+		t.Fatal(err)
+	}
+	buy := func(w *testWallet) error {
+		nonce := w.nonce(t, s)
+		return s.tx(func() error {
+			return Mrc010Buy(s, []string{dexid, w.Address, "10", w.sign(t, s, "mrc010buy", dexid, w.Address, "10", nonce), nonce})
+		})
+	}
+	assertError(t, buy(carol), "4300")
+	assertError(t, transfer(t, s, alice, carol, f.item, "1"), "4300")
+	if err := buy(bob); err != nil {
+		t.Fatal(err)
+	}
+	assertBalance(t, s, bob, f.item, "20")
+
+	// bob sell 5 item and is frozen, the item in the DEX does not move
+	nonce = bob.nonce(t, s)
+	args = []string{bob.Address, "5", f.item, "5", f.coin, "", "", "", "", ""}
+	args = append(args, bob.sign(t, s, "mrc010sell", append(args[:9:9], nonce)...), nonce)
+	s.mustTx(t, func() error { return Mrc010Sell(s, args) })
+	dexid = s.lastEvent(t, "mrc010_sell").Key
+
+	if err := owner("tokenUnfreezeAddress", carol.Address, func(sig, nonce string) error {
+		return TokenUnfreezeAddress(s, f.item, carol.Address, sig, nonce, nil)
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := owner("tokenFreezeAddress", bob.Address, func(sig, nonce string) error {
+		return TokenFreezeAddress(s, f.item, bob.Address, sig, nonce, nil)
+	}); err != nil {
+		t.Fatal(err)
+	}
+	tk, _, _ := GetMRC010(s, f.item)
+	if _, exists := tk.Frozen[carol.Address]; exists || len(tk.Frozen) != 1 {
+		t.Errorf("frozen list : %v", tk.Frozen)
+	}
+	nonce = carol.nonce(t, s)
+	assertError(t, s.tx(func() error {
+		return Mrc010Buy(s, []string{dexid, carol.Address, "5", carol.sign(t, s, "mrc010buy", dexid, carol.Address, "5", nonce), nonce})
+	}), "4300")
+	assertError(t, transfer(t, s, bob, carol, f.item, "1"), "4300")
+}
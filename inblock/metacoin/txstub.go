@@ -0,0 +1,58 @@
+package metacoin
+
+import (
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+)
+
+// Transaction context
+//
+// The events, the fee payer and the delegate signers of the transaction are
+// kept in the context of the invocation, not in the package, so nothing is
+// left when the transaction ends with the error or the panic. Invoke and Init
+// wrap the stub with txStub, the functions find the context through the
+// wrapper stubs. (see batchStub)
+
+// txContext : state of one invocation
+type txContext struct {
+	events    *TEventEnvelope
+	signer    string            // wallet which signed the function first
+	delegates map[string]string // address : delegate key which signed the request
+}
+
+// txContextStub : stub which carries the transaction context
+type txContextStub interface {
+	txContext() *txContext
+}
+
+// txStub : stub of one invocation
+type txStub struct {
+	shim.ChaincodeStubInterface
+	ctx txContext
+}
+
+// newTxStub - stub with the empty transaction context
+func newTxStub(stub shim.ChaincodeStubInterface) *txStub {
+	return &txStub{ChaincodeStubInterface: stub}
+}
+
+func (s *txStub) txContext() *txContext {
+	return &s.ctx
+}
+
+// getTxContext - context of the invocation
+// The stub without the context is the call outside Invoke and Init, it panics.
+func getTxContext(stub shim.ChaincodeStubInterface) *txContext {
+	for {
+		switch s := stub.(type) {
+		case txContextStub:
+			if ctx := s.txContext(); ctx != nil {
+				return ctx
+			}
+			panic("metacoin: the transaction context is missing")
+		case *batchStub:
+			stub = s.ChaincodeStubInterface
+		default:
+			panic("metacoin: the stub has no transaction context")
+		}
+	}
+}
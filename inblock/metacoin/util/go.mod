@@ -2,4 +2,8 @@ module inblock/metacoin/util
 
 go 1.15
 
-require github.com/shopspring/decimal v1.2.0
+require (
+	github.com/decred/dcrd/dcrec/secp256k1/v4 v4.2.0
+	github.com/shopspring/decimal v1.2.0
+	golang.org/x/crypto v0.0.0-20220722155217-630584e8d5aa
+)
@@ -301,6 +301,21 @@ func IsAddress(address string) bool {
 	return address[32:] == calcCRC
 }
 
+// MakeAddress : derive wallet address from the public key and tx id.
+//
+// body = hex(sha256(PKIX DER bytes + "|" + txID + "|" + seq))[:30]
+// address = "MT" + body + crc32(body)
+//
+// The same key and tx id always produce the same address,
+// so the client can compute it before submitting the transaction.
+func MakeAddress(pkix []byte, txID string, seq int) string {
+	h := sha256.New()
+	h.Write(pkix)
+	h.Write([]byte("|" + txID + "|" + strconv.Itoa(seq)))
+	body := fmt.Sprintf("%x", h.Sum(nil))[:30]
+	return fmt.Sprintf("MT%30s%08x", body, crc32.Checksum([]byte(body), crc32.MakeTable(crc32.IEEE)))
+}
+
 // Strtoint to int.
 func Strtoint(data string) (int, error) {
 	var err error
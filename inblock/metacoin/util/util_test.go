@@ -1,6 +1,8 @@
 package util
 
 import (
+	"fmt"
+	"hash/crc32"
 	"strings"
 	"testing"
 )
@@ -205,3 +207,21 @@ func TestNumericDataCheck(t *testing.T) {
 	tNumericDataCheck(t, "105", "dd", "cc200", 1, false, false)
 
 }
+
+func TestMakeAddress(t *testing.T) {
+	a := MakeAddress([]byte("pkix-bytes"), "txid-1", 0)
+	if !IsAddress(a) {
+		t.Fatalf(` MakeAddress() = %q, not a valid address`, a)
+	}
+	if a != MakeAddress([]byte("pkix-bytes"), "txid-1", 0) {
+		t.Fatalf(` MakeAddress() = %q, not deterministic`, a)
+	}
+	if a == MakeAddress([]byte("pkix-bytes"), "txid-2", 0) || a == MakeAddress([]byte("pkix-bytes"), "txid-1", 1) {
+		t.Fatalf(` MakeAddress() = %q, same address for different input`, a)
+	}
+
+	// legacy random address
+	if !IsAddress("MTabcdefghijABCDEFGHIJ0123456789" + fmt.Sprintf("%08x", crc32.ChecksumIEEE([]byte("abcdefghijABCDEFGHIJ0123456789")))) {
+		t.Fatalf(` IsAddress() legacy address rejected`)
+	}
+}
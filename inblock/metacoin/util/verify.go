@@ -0,0 +1,338 @@
+package util
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"hash"
+	"math/big"
+	"strings"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	secp256k1ecdsa "github.com/decred/dcrd/dcrec/secp256k1/v4/ecdsa"
+	"golang.org/x/crypto/sha3"
+)
+
+// Key type of the wallet public key
+const (
+	KeyTypeLegacy    = ""          // wallet before the key type, PKIX ECDSA with the trial hash
+	KeyTypeECDSA     = "ecdsa"     // PKIX ECDSA P-256, P-384, P-521
+	KeyTypeEd25519   = "ed25519"   // PKIX Ed25519
+	KeyTypeSecp256k1 = "secp256k1" // PKIX or hex (33, 65 bytes) secp256k1
+)
+
+// Hash algorithm of the signature
+const (
+	HashLegacy    = ""          // SHA-384, SHA-512, SHA-256 are tried (legacy wallet)
+	HashNone      = "none"      // the data is signed without the hash (Ed25519)
+	HashSHA256    = "sha256"    // SHA-256
+	HashSHA384    = "sha384"    // SHA-384
+	HashSHA512    = "sha512"    // SHA-512
+	HashKeccak256 = "keccak256" // Ethereum Keccak-256
+)
+
+var hashFunctions = map[string]func() hash.Hash{
+	HashSHA256:    sha256.New,
+	HashSHA384:    sha512.New384,
+	HashSHA512:    sha512.New,
+	HashKeccak256: sha3.NewLegacyKeccak256,
+}
+
+// TVerifier : signature verifier of the key type
+//
+// Parse returns nil key for the key of the other type. Hashes is the
+// supported hash list, DefaultHash is used when the wallet does not set the hash.
+type TVerifier struct {
+	KeyType     string
+	Hashes      []string
+	Parse       func(block []byte, raw bool) (crypto.PublicKey, error)
+	DefaultHash func(key crypto.PublicKey) string
+	Verify      func(key crypto.PublicKey, digest, signature []byte) bool
+}
+
+// TPublicKey : parsed public key
+// Bytes is the DER (PEM) or the raw (hex) key, the address is made from it.
+type TPublicKey struct {
+	KeyType string
+	Key     crypto.PublicKey
+	Bytes   []byte
+}
+
+// ID - normalized key, the same key in the other encoding (PEM or hex,
+// compressed or uncompressed point) has the same ID.
+// The compressed point for secp256k1, the PKIX DER for the others.
+func (p TPublicKey) ID() []byte {
+	if key, ok := p.Key.(*secp256k1.PublicKey); ok {
+		return key.SerializeCompressed()
+	}
+	if der, err := x509.MarshalPKIXPublicKey(p.Key); err == nil {
+		return der
+	}
+	return p.Bytes
+}
+
+var verifiers []TVerifier
+
+// RegisterVerifier - add the verifier of the key type
+func RegisterVerifier(v TVerifier) {
+	if v.KeyType == "" || v.Parse == nil || v.Verify == nil || len(v.Hashes) == 0 {
+		panic("util: invalid verifier registration")
+	}
+	if _, exists := findVerifier(v.KeyType); exists {
+		panic("util: verifier " + v.KeyType + " already registered")
+	}
+	verifiers = append(verifiers, v)
+}
+
+// findVerifier - verifier of the key type
+func findVerifier(keyType string) (TVerifier, bool) {
+	for _, v := range verifiers {
+		if v.KeyType == keyType {
+			return v, true
+		}
+	}
+	return TVerifier{}, false
+}
+
+// decodePublicKeyPEM - PEM block of the public key, the PEM without the line break is accepted
+func decodePublicKeyPEM(publicKey string) *pem.Block {
+	block, _ := pem.Decode([]byte(publicKey))
+	if block != nil || strings.Contains(publicKey, "\n") {
+		return block
+	}
+	var dt = len(publicKey) - 24
+	if dt < 26 {
+		return nil
+	}
+	block, _ = pem.Decode([]byte(strings.Join([]string{publicKey[0:26], publicKey[26:dt], publicKey[dt:]}, "\n")))
+	return block
+}
+
+// ParsePublicKey - detect the key type and parse the public key
+//
+// PEM (PKIX) key of the registered key type, or the hex secp256k1 key (0x prefix is optional)
+func ParsePublicKey(publicKey string) (TPublicKey, error) {
+	var data []byte
+	var raw bool
+
+	if h := strings.TrimPrefix(publicKey, "0x"); len(h) >= 66 && isHex(h) {
+		data, _ = hex.DecodeString(h)
+		raw = true
+	} else if block := decodePublicKeyPEM(publicKey); block != nil {
+		data = block.Bytes
+	} else {
+		return TPublicKey{}, errors.New("3103,Public key decode error")
+	}
+
+	for _, v := range verifiers {
+		key, err := v.Parse(data, raw)
+		if err != nil {
+			return TPublicKey{}, err
+		}
+		if key != nil {
+			return TPublicKey{KeyType: v.KeyType, Key: key, Bytes: data}, nil
+		}
+	}
+	return TPublicKey{}, errors.New("3106,Public key type error")
+}
+
+// CheckHash - hash of the key, "" is the default hash of the key type
+func CheckHash(pub TPublicKey, hashName string) (string, error) {
+	v, exists := findVerifier(pub.KeyType)
+	if !exists {
+		return "", errors.New("3106,Public key type error")
+	}
+	if hashName == "" {
+		if v.DefaultHash != nil {
+			return v.DefaultHash(pub.Key), nil
+		}
+		return v.Hashes[0], nil
+	}
+	for _, h := range v.Hashes {
+		if h == hashName {
+			return hashName, nil
+		}
+	}
+	return "", errors.New("3107,Hash " + hashName + " is not supported for " + pub.KeyType + " key")
+}
+
+// SignVerify - verify the signature with the key type and the hash of the wallet
+//
+// The legacy wallet (key type "") and the ECDSA wallet without the hash use
+// EcdsaSignVerify. signature is base64, the hex with 0x prefix is accepted.
+func SignVerify(publicKey, keyType, hashName, data, signature string) error {
+	if keyType == KeyTypeLegacy || (keyType == KeyTypeECDSA && hashName == HashLegacy) {
+		return EcdsaSignVerify(publicKey, data, signature)
+	}
+
+	pub, err := ParsePublicKey(publicKey)
+	if err != nil {
+		return err
+	}
+	if pub.KeyType != keyType {
+		return errors.New("2210,PublicKey type error")
+	}
+	if hashName, err = CheckHash(pub, hashName); err != nil {
+		return err
+	}
+	v, _ := findVerifier(keyType)
+
+	var sig []byte
+	if strings.HasPrefix(signature, "0x") {
+		sig, err = hex.DecodeString(signature[2:])
+	} else {
+		sig, err = base64.StdEncoding.DecodeString(signature)
+	}
+	if err != nil || len(sig) == 0 {
+		return errors.New("2230,Signature format error")
+	}
+
+	var digest = []byte(data)
+	if hashName != HashNone {
+		hasher := hashFunctions[hashName]()
+		hasher.Write(digest)
+		digest = hasher.Sum(nil)
+	}
+	if !v.Verify(pub.Key, digest, sig) {
+		return errors.New("2010,Invalid signature")
+	}
+	return nil
+}
+
+// isHex - hex string
+func isHex(value string) bool {
+	for _, c := range value {
+		if !strings.ContainsRune("0123456789abcdefABCDEF", c) {
+			return false
+		}
+	}
+	return true
+}
+
+// signatureRS - ASN.1 or r|s signature
+func signatureRS(signature []byte, size int) (*big.Int, *big.Int, bool) {
+	var esig ecdsaSignature
+	if rest, err := asn1.Unmarshal(signature, &esig); err == nil && len(rest) == 0 && esig.R != nil && esig.S != nil {
+		return esig.R, esig.S, true
+	}
+	// r|s or r|s|v (Ethereum)
+	if len(signature) == size*2 || len(signature) == size*2+1 {
+		return new(big.Int).SetBytes(signature[:size]), new(big.Int).SetBytes(signature[size : size*2]), true
+	}
+	return nil, nil, false
+}
+
+// secp256k1 curve OID (SEC 2)
+var oidSecp256k1 = asn1.ObjectIdentifier{1, 3, 132, 0, 10}
+
+// subjectPublicKeyInfo : PKIX public key
+type subjectPublicKeyInfo struct {
+	Algorithm struct {
+		Algorithm  asn1.ObjectIdentifier
+		Parameters asn1.RawValue `asn1:"optional"`
+	}
+	PublicKey asn1.BitString
+}
+
+func init() {
+	RegisterVerifier(TVerifier{
+		KeyType: KeyTypeECDSA,
+		Hashes:  []string{HashSHA256, HashSHA384, HashSHA512},
+		Parse: func(block []byte, raw bool) (crypto.PublicKey, error) {
+			if raw {
+				return nil, nil
+			}
+			pub, err := x509.ParsePKIXPublicKey(block)
+			if err != nil {
+				return nil, nil
+			}
+			key, ok := pub.(*ecdsa.PublicKey)
+			if !ok {
+				return nil, nil
+			}
+			switch key.Curve.Params().BitSize {
+			case 256, 384, 521:
+				return key, nil
+			}
+			return nil, errors.New("3102,Public key curve size must be 256, 384 or 521")
+		},
+		DefaultHash: func(key crypto.PublicKey) string {
+			switch key.(*ecdsa.PublicKey).Curve {
+			case elliptic.P384():
+				return HashSHA384
+			case elliptic.P521():
+				return HashSHA512
+			}
+			return HashSHA256
+		},
+		Verify: func(key crypto.PublicKey, digest, signature []byte) bool {
+			pub := key.(*ecdsa.PublicKey)
+			r, s, ok := signatureRS(signature, (pub.Curve.Params().BitSize+7)/8)
+			return ok && ecdsa.Verify(pub, digest, r, s)
+		},
+	})
+
+	RegisterVerifier(TVerifier{
+		KeyType: KeyTypeEd25519,
+		Hashes:  []string{HashNone},
+		Parse: func(block []byte, raw bool) (crypto.PublicKey, error) {
+			if raw {
+				return nil, nil
+			}
+			pub, err := x509.ParsePKIXPublicKey(block)
+			if err != nil {
+				return nil, nil
+			}
+			if key, ok := pub.(ed25519.PublicKey); ok {
+				return key, nil
+			}
+			return nil, nil
+		},
+		Verify: func(key crypto.PublicKey, digest, signature []byte) bool {
+			return len(signature) == ed25519.SignatureSize && ed25519.Verify(key.(ed25519.PublicKey), digest, signature)
+		},
+	})
+
+	RegisterVerifier(TVerifier{
+		KeyType: KeyTypeSecp256k1,
+		Hashes:  []string{HashSHA256, HashKeccak256},
+		Parse: func(block []byte, raw bool) (crypto.PublicKey, error) {
+			if !raw {
+				var spki subjectPublicKeyInfo
+				var curve asn1.ObjectIdentifier
+				if _, err := asn1.Unmarshal(block, &spki); err != nil {
+					return nil, nil
+				}
+				if _, err := asn1.Unmarshal(spki.Algorithm.Parameters.FullBytes, &curve); err != nil || !curve.Equal(oidSecp256k1) {
+					return nil, nil
+				}
+				block = spki.PublicKey.RightAlign()
+			}
+			key, err := secp256k1.ParsePubKey(block)
+			if err != nil {
+				return nil, errors.New("3105,Public key parsing error")
+			}
+			return key, nil
+		},
+		Verify: func(key crypto.PublicKey, digest, signature []byte) bool {
+			var r, s secp256k1.ModNScalar
+			br, bs, ok := signatureRS(signature, 32)
+			if !ok || br.BitLen() > 256 || bs.BitLen() > 256 {
+				return false
+			}
+			if r.SetByteSlice(br.Bytes()) || s.SetByteSlice(bs.Bytes()) {
+				return false
+			}
+			return secp256k1ecdsa.NewSignature(&r, &s).Verify(digest, key.(*secp256k1.PublicKey))
+		},
+	})
+}
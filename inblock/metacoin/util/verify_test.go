@@ -0,0 +1,150 @@
+package util
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"strings"
+	"testing"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	secp256k1ecdsa "github.com/decred/dcrd/dcrec/secp256k1/v4/ecdsa"
+	"golang.org/x/crypto/sha3"
+)
+
+// tPEM - PEM of the PKIX public key
+func tPEM(t *testing.T, der []byte) string {
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+}
+
+// tSecp256k1PEM - PKIX PEM of the secp256k1 key
+func tSecp256k1PEM(t *testing.T, key *secp256k1.PrivateKey) string {
+	var spki subjectPublicKeyInfo
+	spki.Algorithm.Algorithm = asn1.ObjectIdentifier{1, 2, 840, 10045, 2, 1}
+	spki.Algorithm.Parameters.FullBytes, _ = asn1.Marshal(oidSecp256k1)
+	point := key.PubKey().SerializeUncompressed()
+	spki.PublicKey = asn1.BitString{Bytes: point, BitLength: len(point) * 8}
+	der, err := asn1.Marshal(spki)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tPEM(t, der)
+}
+
+func tAssertVerify(t *testing.T, name string, err error, code string) {
+	t.Helper()
+	if code == "" && err != nil {
+		t.Errorf("%s : %v", name, err)
+	} else if code != "" && (err == nil || !strings.HasPrefix(err.Error(), code+",")) {
+		t.Errorf("%s : expect %s, got %v", name, code, err)
+	}
+}
+
+func TestSignVerifyECDSA(t *testing.T) {
+	const data = "MTaddress|MTto|0|10|nonce"
+	key, _ := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	der, _ := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	publicKey := tPEM(t, der)
+
+	pub, err := ParsePublicKey(publicKey)
+	if err != nil || pub.KeyType != KeyTypeECDSA {
+		t.Fatalf("parse : %+v, %v", pub, err)
+	}
+	if hash, _ := CheckHash(pub, ""); hash != HashSHA384 {
+		t.Errorf("default hash of P-384 : %s", hash)
+	}
+	if _, err = CheckHash(pub, HashKeccak256); err == nil {
+		t.Error("keccak256 for ECDSA")
+	}
+
+	h := sha256.Sum256([]byte(data))
+	sig, _ := ecdsa.SignASN1(rand.Reader, key, h[:])
+	sign := base64.StdEncoding.EncodeToString(sig)
+
+	// legacy wallet tries the hash, the new wallet uses the hash only
+	tAssertVerify(t, "legacy", SignVerify(publicKey, KeyTypeLegacy, HashLegacy, data, sign), "")
+	tAssertVerify(t, "sha256", SignVerify(publicKey, KeyTypeECDSA, HashSHA256, data, sign), "")
+	tAssertVerify(t, "sha384", SignVerify(publicKey, KeyTypeECDSA, HashSHA384, data, sign), "2010")
+	tAssertVerify(t, "key type", SignVerify(publicKey, KeyTypeEd25519, HashNone, data, sign), "2210")
+}
+
+func TestSignVerifyEd25519(t *testing.T) {
+	const data = "MTaddress|MTto|0|10|nonce"
+	pubKey, key, _ := ed25519.GenerateKey(rand.Reader)
+	der, _ := x509.MarshalPKIXPublicKey(pubKey)
+	publicKey := tPEM(t, der)
+
+	pub, err := ParsePublicKey(publicKey)
+	if err != nil || pub.KeyType != KeyTypeEd25519 {
+		t.Fatalf("parse : %+v, %v", pub, err)
+	}
+	if hash, _ := CheckHash(pub, ""); hash != HashNone {
+		t.Errorf("default hash of Ed25519 : %s", hash)
+	}
+
+	sign := base64.StdEncoding.EncodeToString(ed25519.Sign(key, []byte(data)))
+	tAssertVerify(t, "ed25519", SignVerify(publicKey, KeyTypeEd25519, HashNone, data, sign), "")
+	tAssertVerify(t, "other data", SignVerify(publicKey, KeyTypeEd25519, HashNone, data+"1", sign), "2010")
+	tAssertVerify(t, "sha512", SignVerify(publicKey, KeyTypeEd25519, HashSHA512, data, sign), "3107")
+	tAssertVerify(t, "legacy", SignVerify(publicKey, KeyTypeLegacy, HashLegacy, data, sign), "2210")
+}
+
+func TestSignVerifySecp256k1(t *testing.T) {
+	const data = "MTaddress|MTto|0|10|nonce"
+	key, err := secp256k1.GeneratePrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// PKIX PEM, DER signature of SHA-256
+	publicKey := tSecp256k1PEM(t, key)
+	pub, err := ParsePublicKey(publicKey)
+	if err != nil || pub.KeyType != KeyTypeSecp256k1 {
+		t.Fatalf("parse : %+v, %v", pub, err)
+	}
+	h := sha256.Sum256([]byte(data))
+	sign := base64.StdEncoding.EncodeToString(secp256k1ecdsa.Sign(key, h[:]).Serialize())
+	tAssertVerify(t, "sha256", SignVerify(publicKey, KeyTypeSecp256k1, HashSHA256, data, sign), "")
+	tAssertVerify(t, "keccak256", SignVerify(publicKey, KeyTypeSecp256k1, HashKeccak256, data, sign), "2010")
+
+	// hex key, Ethereum r|s|v signature of Keccak-256
+	publicKey = "0x" + hex.EncodeToString(key.PubKey().SerializeCompressed())
+	if pub, err = ParsePublicKey(publicKey); err != nil || pub.KeyType != KeyTypeSecp256k1 {
+		t.Fatalf("parse hex : %+v, %v", pub, err)
+	}
+	// the same key in the other encoding has the same ID
+	pem, _ := ParsePublicKey(tSecp256k1PEM(t, key))
+	uncompressed, _ := ParsePublicKey(hex.EncodeToString(key.PubKey().SerializeUncompressed()))
+	if !bytes.Equal(pub.ID(), pem.ID()) || !bytes.Equal(pub.ID(), uncompressed.ID()) || bytes.Equal(pub.Bytes, uncompressed.Bytes) {
+		t.Errorf("key id : %x, %x, %x", pub.ID(), pem.ID(), uncompressed.ID())
+	}
+
+	hasher := sha3.NewLegacyKeccak256()
+	hasher.Write([]byte(data))
+	compact := secp256k1ecdsa.SignCompact(key, hasher.Sum(nil), false) // v|r|s
+	sig := append(compact[1:], compact[0]-27)
+	tAssertVerify(t, "keccak256", SignVerify(publicKey, KeyTypeSecp256k1, HashKeccak256, data, "0x"+hex.EncodeToString(sig)), "")
+	tAssertVerify(t, "sha256", SignVerify(publicKey, KeyTypeSecp256k1, HashSHA256, data, "0x"+hex.EncodeToString(sig)), "2010")
+}
+
+func TestParsePublicKeyError(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P224(), rand.Reader)
+	der, _ := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if _, err := ParsePublicKey(tPEM(t, der)); err == nil || !strings.HasPrefix(err.Error(), "3102,") {
+		t.Errorf("P-224 : %v", err)
+	}
+	if _, err := ParsePublicKey("0x" + strings.Repeat("00", 33)); err == nil {
+		t.Error("invalid hex key")
+	}
+	if _, err := ParsePublicKey("-----BEGIN PUBLIC KEY-----"); err == nil {
+		t.Error("invalid PEM")
+	}
+}
@@ -0,0 +1,182 @@
+package metacoin
+
+import (
+	"bytes"
+	"strconv"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+
+	"inblock/metacoin/mcerr"
+	"inblock/metacoin/mtc"
+	"inblock/metacoin/util"
+)
+
+// Wallet key rotation
+//
+// rotateKey is signed by the current key and replaces the public key of the
+// wallet, the address and the assets are not changed. With the window, the
+// new key is kept as the pending key and confirmKey signed by the new key
+// must be sent before the window ends, so a wrong key can not lock the wallet.
+//
+// The replaced keys are kept in TWallet.Keys with the time range they signed for.
+// rotateKey to the current key cancels the pending key, and confirmKey of the
+// expired pending key removes it without changing the key.
+// The delegates of the wallet are removed when the key is replaced.
+const KeyRotateWindowMax = 7 * 86400
+
+// keyFrom - start time of the current key
+func keyFrom(wallet mtc.TWallet) int64 {
+	if wallet.KeyDate != 0 {
+		return wallet.KeyDate
+	}
+	return wallet.Regdate
+}
+
+// pushKey - move the current key to the history
+// The delegates were registered by the replaced key and are removed.
+func pushKey(stub shim.ChaincodeStubInterface, wallet *mtc.TWallet) {
+	now := GetTxTime(stub)
+	wallet.Keys = append(wallet.Keys, mtc.TWalletKey{
+		PublicKey: wallet.Password,
+		KeyType:   wallet.KeyType,
+		Hash:      wallet.Hash,
+		Signers:   wallet.Signers,
+		Threshold: wallet.Threshold,
+		From:      keyFrom(*wallet),
+		To:        now,
+		TxID:      stub.GetTxID(),
+	})
+	wallet.KeyDate = now
+	wallet.PendingKey = nil
+	wallet.Delegates = nil
+}
+
+// sameKey - the key of the wallet is the key in any encoding with the same hash
+func sameKey(wallet mtc.TWallet, pub util.TPublicKey, hash string) bool {
+	if wallet.KeyType == KeyTypeMultisig || wallet.Hash != hash {
+		return false
+	}
+	current, err := util.ParsePublicKey(wallet.Password)
+	return err == nil && bytes.Equal(current.ID(), pub.ID())
+}
+
+// replaceKey - move the current key to the history and set the new key
+// The multisig wallet becomes the wallet of the one key.
+func replaceKey(stub shim.ChaincodeStubInterface, wallet *mtc.TWallet, key mtc.TWalletKey) {
+	pushKey(stub, wallet)
+	wallet.Password = key.PublicKey
+	wallet.KeyType = key.KeyType
+	wallet.Hash = key.Hash
+	wallet.Signers = nil
+	wallet.Threshold = 0
+}
+
+// RotateKey - replace the public key of the wallet
+//
+// hash "" is the default hash of the key type (see parseWalletKey). window is the countersign time
+// in seconds, "" or "0" replaces the key now. The current key cancels the pending key.
+// The current key signs "address|publicKey|hash|window|nonce".
+func RotateKey(stub shim.ChaincodeStubInterface, address, publicKey, hash, window, signature, tkey string, args []string) error {
+	var seconds int
+	var err error
+
+	if window != "" {
+		if seconds, err = util.Strtoint(window); err != nil || seconds < 0 || seconds > KeyRotateWindowMax {
+			return mcerr.New(mcerr.InvalidValue, "Window must be 0 ~ "+strconv.Itoa(KeyRotateWindowMax)).WithField("window")
+		}
+	}
+	pub, keyHash, err := parseWalletKey(publicKey, hash)
+	if err != nil {
+		return err
+	}
+	wallet, err := GetAddressInfo(stub, address)
+	if err != nil {
+		return err
+	}
+	same := sameKey(wallet, pub, keyHash)
+	if same && wallet.PendingKey == nil {
+		return mcerr.New(mcerr.NoDataChange, "Public key is not changed").WithField("publicKey")
+	}
+	if err = NonceCheck(stub, &wallet, tkey, "rotateKey",
+		[]string{address, publicKey, hash, window, tkey},
+		signature); err != nil {
+		return err
+	}
+
+	now := GetTxTime(stub)
+	key := mtc.TWalletKey{PublicKey: publicKey, KeyType: pub.KeyType, Hash: keyHash, From: now, TxID: stub.GetTxID()}
+	switch {
+	case same:
+		wallet.PendingKey = nil
+	case seconds == 0:
+		replaceKey(stub, &wallet, key)
+	default:
+		key.To = now + int64(seconds)
+		wallet.PendingKey = &key
+	}
+	if err = SetAddressInfo(stub, wallet, "rotateKey", args); err != nil {
+		return err
+	}
+
+	if same {
+		AddEvent(stub, TEvent{Type: "rotate_key_cancel", Key: address, Args: []string{address}})
+	} else if wallet.PendingKey != nil {
+		AddEvent(stub, TEvent{Type: "rotate_key_pending", Key: address, Args: []string{address, publicKey, strconv.FormatInt(key.To, 10)}})
+	} else {
+		AddEvent(stub, TEvent{Type: "rotate_key", Key: address, Args: []string{address, publicKey}})
+	}
+	return nil
+}
+
+// ConfirmKey - countersign of the pending key, the pending key becomes the wallet key
+// The pending key signs "address|publicKey|nonce". The expired pending key is
+// removed and the key is not changed, the rotate_key_expired event tells it.
+func ConfirmKey(stub shim.ChaincodeStubInterface, address, signature, tkey string, args []string) error {
+	wallet, err := GetAddressInfo(stub, address)
+	if err != nil {
+		return err
+	}
+	if wallet.PendingKey == nil {
+		return mcerr.New(mcerr.NotFound, "No pending key of the address ["+address+"]")
+	}
+	key := *wallet.PendingKey
+
+	signer := wallet
+	signer.Password, signer.KeyType, signer.Hash = key.PublicKey, key.KeyType, key.Hash
+	if err = NonceCheck(stub, &signer, tkey, "confirmKey",
+		[]string{address, key.PublicKey, tkey},
+		signature); err != nil {
+		return err
+	}
+	wallet.Nonce = signer.Nonce
+	if GetTxTime(stub) > key.To {
+		wallet.PendingKey = nil
+		if err = SetAddressInfo(stub, wallet, "confirmKeyExpired", args); err != nil {
+			return err
+		}
+		AddEvent(stub, TEvent{Type: "rotate_key_expired", Key: address, Args: []string{address, key.PublicKey, strconv.FormatInt(key.To, 10)}})
+		return nil
+	}
+	replaceKey(stub, &wallet, key)
+	if err = SetAddressInfo(stub, wallet, "confirmKey", args); err != nil {
+		return err
+	}
+	AddEvent(stub, TEvent{Type: "rotate_key", Key: address, Args: []string{address, key.PublicKey}})
+	return nil
+}
+
+// KeyHistory - replaced keys and the current key (To is 0) of the wallet, the oldest first
+func KeyHistory(stub shim.ChaincodeStubInterface, address string) ([]mtc.TWalletKey, error) {
+	wallet, err := GetAddressInfo(stub, address)
+	if err != nil {
+		return nil, err
+	}
+	return append(wallet.Keys, mtc.TWalletKey{
+		PublicKey: wallet.Password,
+		KeyType:   wallet.KeyType,
+		Hash:      wallet.Hash,
+		Signers:   wallet.Signers,
+		Threshold: wallet.Threshold,
+		From:      keyFrom(wallet),
+	}), nil
+}
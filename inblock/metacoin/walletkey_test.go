@@ -0,0 +1,104 @@
+package metacoin
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRotateKey(t *testing.T) {
+	s := newTestStub()
+	alice := newGenesisWallet(t, s, "1000")
+	bob := newTestWallet(t, s)
+	regdate := bob.wallet(t, s).Regdate
+
+	rotate := func(signer *testWallet, publicKey, window string) error {
+		nonce := bob.nonce(t, s)
+		sig := signer.sign(t, s, "rotateKey", bob.Address, publicKey, "", window, nonce)
+		return s.tx(func() error { return RotateKey(s, bob.Address, publicKey, "", window, sig, nonce, nil) })
+	}
+	confirm := func(signer *testWallet, publicKey string) error {
+		nonce := bob.nonce(t, s)
+		sig := signer.sign(t, s, "confirmKey", bob.Address, publicKey, nonce)
+		return s.tx(func() error { return ConfirmKey(s, bob.Address, sig, nonce, nil) })
+	}
+	if err := transfer(t, s, alice, bob, "0", "100"); err != nil {
+		t.Fatal(err)
+	}
+
+	// signed by the current key, the address and the balance are kept
+	key2 := newTestKey(t)
+	assertError(t, rotate(bob, bob.PublicKey, ""), "4900")
+	assertError(t, rotate(key2, key2.PublicKey, ""), "2010")
+	assertError(t, rotate(bob, key2.PublicKey, "604801"), "1003")
+	s.advance(10)
+	if err := rotate(bob, key2.PublicKey, ""); err != nil {
+		t.Fatal(err)
+	}
+	key2.Address = bob.Address
+	assertError(t, transfer(t, s, bob, alice, "0", "10"), "2010")
+	if err := transfer(t, s, key2, alice, "0", "10"); err != nil {
+		t.Fatal(err)
+	}
+	assertBalance(t, s, key2, "0", "90")
+
+	// countersign by the pending key in the window
+	key3 := newTestKey(t)
+	bob = key2
+	assertError(t, confirm(key3, key3.PublicKey), "6004")
+	if err := rotate(bob, key3.PublicKey, "3600"); err != nil {
+		t.Fatal(err)
+	}
+	if pending := bob.wallet(t, s).PendingKey; pending == nil || pending.PublicKey != key3.PublicKey {
+		t.Fatalf("pending key : %+v", pending)
+	}
+	assertError(t, confirm(bob, key3.PublicKey), "2010")
+
+	// the current key in the other encoding is not changed, it cancels the pending key
+	oneLine := strings.ReplaceAll(bob.PublicKey, "\n", "")
+	if err := rotate(bob, oneLine, ""); err != nil {
+		t.Fatal(err)
+	}
+	if w := bob.wallet(t, s); w.PendingKey != nil || len(w.Keys) != 1 || w.Password != bob.PublicKey {
+		t.Fatalf("cancel of the pending key : %+v", w)
+	}
+	s.lastEvent(t, "rotate_key_cancel")
+	assertError(t, rotate(bob, oneLine, ""), "4900")
+
+	// the expired pending key is removed by confirmKey, the key is not changed
+	if err := rotate(bob, key3.PublicKey, "3600"); err != nil {
+		t.Fatal(err)
+	}
+	s.advance(3601)
+	if err := confirm(key3, key3.PublicKey); err != nil {
+		t.Fatal(err)
+	}
+	if w := bob.wallet(t, s); w.PendingKey != nil || w.Password != bob.PublicKey {
+		t.Fatalf("expired pending key : %+v", w)
+	}
+	s.lastEvent(t, "rotate_key_expired")
+	assertError(t, confirm(key3, key3.PublicKey), "6004")
+
+	if err := rotate(bob, key3.PublicKey, "3600"); err != nil {
+		t.Fatal(err)
+	}
+	if err := confirm(key3, key3.PublicKey); err != nil {
+		t.Fatal(err)
+	}
+	key3.Address = bob.Address
+	if err := transfer(t, s, key3, alice, "0", "10"); err != nil {
+		t.Fatal(err)
+	}
+
+	// history of the keys
+	history, err := KeyHistory(s, bob.Address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 3 || history[0].From != regdate || history[1].PublicKey != key2.PublicKey ||
+		history[1].To != history[2].From || history[2].PublicKey != key3.PublicKey || history[2].To != 0 {
+		t.Errorf("key history : %+v", history)
+	}
+	if bob.wallet(t, s).PendingKey != nil {
+		t.Error("pending key is not removed")
+	}
+}
@@ -0,0 +1,410 @@
+package metacoin
+
+import (
+	"encoding/json"
+	"hash/crc32"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/shopspring/decimal"
+
+	"inblock/metacoin/mcerr"
+	"inblock/metacoin/mtc"
+	"inblock/metacoin/util"
+)
+
+// Wallet state keys
+//
+// The wallet document (address key) has no balance. Each balance is saved to
+// the <address>_<asset> key, so the transactions of the different token do not
+// conflict on the wallet. GetAddressInfo reads the wallet document only, the
+// operation loads the balance key of the asset it touches to the MRC010, MRC402,
+// MRC800 map (loadAsset) and SetAddressInfo writes the changed key only.
+// The wallet document is written when the other data (nonce, pending, asset
+// list ...) is changed.
+//
+// The receive-only hot address can have the MRC010 shards (AddressShards).
+// The received amount is added to the <address>_MRC010_<token>_<n> key chosen
+// by the tx id instead of the balance key. BalanceOf sums the shards, and the
+// shards are moved to the balance when the address spends the token.
+const (
+	AssetMRC010 = "MRC010_"
+	AssetMRC402 = "MRC402_"
+	AssetMRC800 = "MRC800_"
+	ShardMax    = 64
+)
+
+// TBalanceState : value of the balance key and the shard key
+// MRC402, MRC800 use balance, saleamount, auctionamount only.
+type TBalanceState struct {
+	mtc.TMRC010BalanceV2
+	JobType string `json:"job_type"`
+	JobArgs string `json:"job_args"`
+	JobDate int64  `json:"jobdate"`
+}
+
+// balanceKey - state key of the address balance
+func balanceKey(address, asset string) string {
+	return address + "_" + asset
+}
+
+// shardKey - state key of the MRC010 receive shard
+func shardKey(address string, token, shard int) string {
+	return balanceKey(address, AssetMRC010+strconv.Itoa(token)) + "_" + strconv.Itoa(shard)
+}
+
+// isBalanceKey - <address>_<asset> key
+func isBalanceKey(key string) bool {
+	if len(key) <= 41 || key[40] != '_' || !util.IsAddress(key[:40]) {
+		return false
+	}
+	asset := key[41:]
+	return strings.HasPrefix(asset, AssetMRC010) || strings.HasPrefix(asset, AssetMRC402) ||
+		strings.HasPrefix(asset, AssetMRC800)
+}
+
+// walletState - state of the wallet, made on the first use
+func walletState(wallet *mtc.TWallet) *mtc.TWalletState {
+	if wallet.State == nil {
+		wallet.State = &mtc.TWalletState{
+			Loaded: make(map[string]string),
+			Read:   make(map[string]bool),
+			Shard:  make(map[string]string),
+			Credit: make(map[int]mtc.TMRC010BalanceV2),
+		}
+	}
+	return wallet.State
+}
+
+// balanceValue - saved form of the balance, "" is the zero balance
+func balanceValue(b mtc.TMRC010BalanceV2) string {
+	if decimalOf(b.Balance).IsZero() && decimalOf(b.SaleAmount).IsZero() &&
+		decimalOf(b.AuctionAmount).IsZero() && len(b.LockedList) == 0 {
+		return ""
+	}
+	value, _ := json.Marshal(b)
+	return string(value)
+}
+
+// docValue - wallet document without the balance and the job
+func docValue(wallet mtc.TWallet) string {
+	wallet.JobType, wallet.JobArgs, wallet.JobDate = "", "", 0
+	wallet.MRC010, wallet.MRC402, wallet.MRC800, wallet.Balance = nil, nil, nil, nil
+	value, _ := json.Marshal(wallet)
+	return string(value)
+}
+
+// addBalance - add the amount, locked until unlockDate if it is after now
+func addBalance(b mtc.TMRC010BalanceV2, amount decimal.Decimal, unlockDate, now int64) mtc.TMRC010BalanceV2 {
+	if unlockDate > now {
+		if b.LockedList == nil {
+			b.LockedList = make(map[int]string)
+		}
+		b.LockedList[int(unlockDate)] = decimalOf(b.LockedList[int(unlockDate)]).Add(amount).String()
+	} else {
+		b.Balance = decimalOf(b.Balance).Add(amount).String()
+	}
+	return b
+}
+
+// mergeBalance - add the balance and the locked amount of src to dst
+func mergeBalance(dst, src mtc.TMRC010BalanceV2) mtc.TMRC010BalanceV2 {
+	dst.Balance = decimalOf(dst.Balance).Add(decimalOf(src.Balance)).String()
+	for unlockDate, amount := range src.LockedList {
+		if dst.LockedList == nil {
+			dst.LockedList = make(map[int]string)
+		}
+		dst.LockedList[unlockDate] = decimalOf(dst.LockedList[unlockDate]).Add(decimalOf(amount)).String()
+	}
+	return dst
+}
+
+// loadBalance - balance of the wallet document, the balance keys are not read
+//
+// The balance saved in the document by the old chaincode is kept in the map
+// and moved to the balance key on the next SetAddressInfo.
+func loadBalance(wallet *mtc.TWallet) {
+	state := walletState(wallet)
+	state.Doc = docValue(*wallet)
+	if wallet.MRC010 == nil {
+		wallet.MRC010 = make(map[int]mtc.TMRC010BalanceV2)
+	}
+}
+
+// hasAsset - the asset is in the asset list of the wallet document
+func hasAsset(wallet mtc.TWallet, asset string) bool {
+	for _, a := range wallet.Assets {
+		if a == asset {
+			return true
+		}
+	}
+	return false
+}
+
+// loadAsset - read the balance key of the asset to the wallet map, once per wallet
+// The asset not in the asset list has no balance key.
+func loadAsset(stub shim.ChaincodeStubInterface, wallet *mtc.TWallet, asset string) error {
+	state := walletState(wallet)
+	key := balanceKey(wallet.Id, asset)
+	if state.Read[key] {
+		return nil
+	}
+	state.Read[key] = true
+	if !hasAsset(*wallet, asset) {
+		return nil
+	}
+
+	value, err := stub.GetState(key)
+	if err != nil {
+		return mcerr.Wrap(mcerr.LedgerRead, "Hyperledger internal error", err)
+	}
+	if value == nil {
+		return nil
+	}
+	var b TBalanceState
+	if err = json.Unmarshal(value, &b); err != nil {
+		return mcerr.Wrap(mcerr.WalletData, "Balance ["+key+"] is in the wrong data", err)
+	}
+
+	switch {
+	case strings.HasPrefix(asset, AssetMRC010):
+		token, err := strconv.Atoi(asset[len(AssetMRC010):])
+		if err != nil {
+			return mcerr.Wrap(mcerr.WalletData, "Balance ["+key+"] is in the wrong key", err)
+		}
+		wallet.MRC010[token] = b.TMRC010BalanceV2
+	case strings.HasPrefix(asset, AssetMRC402):
+		if wallet.MRC402 == nil {
+			wallet.MRC402 = make(map[string]mtc.NFTBalance)
+		}
+		wallet.MRC402[asset[len(AssetMRC402):]] = mtc.NFTBalance{Balance: b.Balance,
+			SaleAmount: b.SaleAmount, AuctionAmount: b.AuctionAmount}
+	case strings.HasPrefix(asset, AssetMRC800):
+		if wallet.MRC800 == nil {
+			wallet.MRC800 = make(map[string]string)
+		}
+		wallet.MRC800[asset[len(AssetMRC800):]] = b.Balance
+	default:
+		return nil
+	}
+	state.Loaded[key] = balanceValue(b.TMRC010BalanceV2)
+	return nil
+}
+
+// loadMRC010 - loadAsset of the MRC010 token
+func loadMRC010(stub shim.ChaincodeStubInterface, wallet *mtc.TWallet, token int) error {
+	return loadAsset(stub, wallet, AssetMRC010+strconv.Itoa(token))
+}
+
+// loadAssets - read all balance keys of the wallet, token 0 is always in the map
+func loadAssets(stub shim.ChaincodeStubInterface, wallet *mtc.TWallet) error {
+	for _, asset := range wallet.Assets {
+		if err := loadAsset(stub, wallet, asset); err != nil {
+			return err
+		}
+	}
+	if _, exists := wallet.MRC010[0]; !exists {
+		wallet.MRC010[0] = newBalanceV2()
+	}
+	releaseLocked(wallet, GetTxTime(stub))
+	return nil
+}
+
+// walletBalances - balance key : balance of the wallet maps
+func walletBalances(wallet mtc.TWallet) map[string]mtc.TMRC010BalanceV2 {
+	var list = make(map[string]mtc.TMRC010BalanceV2)
+	for token, b := range wallet.MRC010 {
+		list[AssetMRC010+strconv.Itoa(token)] = b
+	}
+	for id, b := range wallet.MRC402 {
+		list[AssetMRC402+id] = mtc.TMRC010BalanceV2{Balance: b.Balance, SaleAmount: b.SaleAmount, AuctionAmount: b.AuctionAmount}
+	}
+	for id, b := range wallet.MRC800 {
+		list[AssetMRC800+id] = mtc.TMRC010BalanceV2{Balance: b}
+	}
+	return list
+}
+
+// saveBalance - write the changed balance key and the received shard amount,
+// delete the removed balance key. returns the asset list of the document.
+func saveBalance(stub shim.ChaincodeStubInterface, wallet mtc.TWallet) ([]string, error) {
+	var assets = make([]string, 0)
+	var err error
+	state := walletState(&wallet)
+	job := TBalanceState{JobType: wallet.JobType, JobArgs: wallet.JobArgs, JobDate: wallet.JobDate}
+
+	put := func(key string, b mtc.TMRC010BalanceV2) (string, error) {
+		job.TMRC010BalanceV2 = b
+		dat, err := json.Marshal(job)
+		if err != nil {
+			return "", mcerr.Wrap(mcerr.InvalidData, "Invalid Data format", err)
+		}
+		if err = stub.PutState(key, dat); err != nil {
+			return "", mcerr.Wrap(mcerr.LedgerWrite, "Hyperledger internal error", err)
+		}
+		return balanceValue(b), nil
+	}
+
+	balances := walletBalances(wallet)
+	for asset, b := range balances {
+		key := balanceKey(wallet.Id, asset)
+		loaded, exists := state.Loaded[key]
+		if !state.Read[key] && hasAsset(wallet, asset) {
+			return nil, mcerr.New(mcerr.WalletData, "Balance ["+key+"] is changed before loadAsset")
+		}
+		assets = append(assets, asset)
+		if exists && loaded == balanceValue(b) {
+			continue
+		}
+		if state.Loaded[key], err = put(key, b); err != nil {
+			return nil, err
+		}
+		// new balance key of the wallet with the endorsement policy
+		if !exists && len(wallet.Policy) > 0 {
+			if err = setKeyPolicies(stub, wallet.Policy, key); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for key := range state.Loaded {
+		if _, exists := balances[key[len(wallet.Id)+1:]]; exists {
+			continue
+		}
+		if err = stub.DelState(key); err != nil {
+			return nil, mcerr.Wrap(mcerr.LedgerWrite, "Hyperledger internal error", err)
+		}
+		delete(state.Loaded, key)
+	}
+	// the balance key not loaded by this transaction stays
+	for _, asset := range wallet.Assets {
+		if _, exists := balances[asset]; !exists && !state.Read[balanceKey(wallet.Id, asset)] {
+			assets = append(assets, asset)
+		}
+	}
+
+	// sharded wallet received amount
+	for token, credit := range state.Credit {
+		key := shardKey(wallet.Id, token, shardNo(stub, wallet.Shards))
+		b, err := shardBalance(stub, state, key)
+		if err != nil {
+			return nil, err
+		}
+		if state.Shard[key], err = put(key, mergeBalance(b, credit)); err != nil {
+			return nil, err
+		}
+		// new shard key of the wallet with the endorsement policy
+		if balanceValue(b) == "" && len(wallet.Policy) > 0 {
+			if err = setKeyPolicies(stub, wallet.Policy, key); err != nil {
+				return nil, err
+			}
+		}
+		delete(state.Credit, token)
+	}
+
+	sort.Strings(assets)
+	return assets, nil
+}
+
+// shardNo - shard of the transaction
+func shardNo(stub shim.ChaincodeStubInterface, shards int) int {
+	return int(crc32.ChecksumIEEE([]byte(stub.GetTxID())) % uint32(shards))
+}
+
+// shardBalance - balance of the shard key, the value written by this transaction first
+func shardBalance(stub shim.ChaincodeStubInterface, state *mtc.TWalletState, key string) (mtc.TMRC010BalanceV2, error) {
+	var b TBalanceState
+
+	value, exists := state.Shard[key]
+	if !exists {
+		dat, err := stub.GetState(key)
+		if err != nil {
+			return newBalanceV2(), mcerr.Wrap(mcerr.LedgerRead, "Hyperledger internal error", err)
+		}
+		value = string(dat)
+	}
+	if value == "" {
+		return newBalanceV2(), nil
+	}
+	if err := json.Unmarshal([]byte(value), &b); err != nil {
+		return newBalanceV2(), mcerr.Wrap(mcerr.WalletData, "Balance ["+key+"] is in the wrong data", err)
+	}
+	return b.TMRC010BalanceV2, nil
+}
+
+// mrc010ShardSum - add the shards of the token to the balance
+// sweep deletes the shard keys and the amount received in this transaction is
+// added too, the balance is saved by SetAddressInfo.
+func mrc010ShardSum(stub shim.ChaincodeStubInterface, wallet *mtc.TWallet, token int, sweep bool) error {
+	if wallet.Shards == 0 {
+		return nil
+	}
+	state := walletState(wallet)
+	b, exists := wallet.MRC010[token]
+	if !exists {
+		b = newBalanceV2()
+	}
+
+	for n := 0; n < wallet.Shards; n++ {
+		key := shardKey(wallet.Id, token, n)
+		shard, err := shardBalance(stub, state, key)
+		if err != nil {
+			return err
+		}
+		if balanceValue(shard) == "" {
+			continue
+		}
+		b = mergeBalance(b, shard)
+		if sweep {
+			if err = stub.DelState(key); err != nil {
+				return mcerr.Wrap(mcerr.LedgerWrite, "Hyperledger internal error", err)
+			}
+			state.Shard[key] = ""
+		}
+	}
+	if credit, exists := state.Credit[token]; exists && sweep {
+		b = mergeBalance(b, credit)
+		delete(state.Credit, token)
+	}
+	wallet.MRC010[token] = b
+	releaseLocked(wallet, GetTxTime(stub))
+	return nil
+}
+
+// AddressShards - set the MRC010 receive shard count of the address (admin)
+//
+// The shards of the old count are moved to the balance first. 0 is no shard.
+func AddressShards(stub shim.ChaincodeStubInterface, address, shards string, args []string) error {
+	var wallet mtc.TWallet
+	var count int
+	var err error
+
+	if _, err = CheckAdmin(stub); err != nil {
+		return err
+	}
+	if count, err = util.Strtoint(shards); err != nil || count < 0 || count > ShardMax {
+		return mcerr.New(mcerr.InvalidValue, "Shards must be 0 to "+strconv.Itoa(ShardMax)).WithField("shards")
+	}
+	if wallet, err = GetAddressInfo(stub, address); err != nil {
+		return err
+	}
+	if wallet.Shards == count {
+		return mcerr.New(mcerr.NoDataChange, "Shards is not changed").WithField("shards")
+	}
+
+	if err = loadAssets(stub, &wallet); err != nil {
+		return err
+	}
+	for token := range wallet.MRC010 {
+		if err = mrc010ShardSum(stub, &wallet, token, true); err != nil {
+			return err
+		}
+	}
+	wallet.Shards = count
+	if err = SetAddressInfo(stub, wallet, "addressShards", args); err != nil {
+		return err
+	}
+	AddEvent(stub, TEvent{Type: "address_shards", Key: address, Args: []string{address, shards}})
+	return nil
+}
@@ -0,0 +1,170 @@
+package metacoin
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"inblock/metacoin/mtc"
+)
+
+// walletDoc - saved wallet document
+func walletDoc(t *testing.T, s *testStub, address string) map[string]interface{} {
+	t.Helper()
+	var doc map[string]interface{}
+	if err := json.Unmarshal(s.state[address], &doc); err != nil {
+		t.Fatal(err)
+	}
+	return doc
+}
+
+// written - key is written by the last transaction
+func written(s *testStub, key string) bool {
+	for _, k := range s.keys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+func TestWalletStateKeys(t *testing.T) {
+	s := newTestStub()
+	alice := newGenesisWallet(t, s, "1000")
+	bob := newTestWallet(t, s)
+	item := registerToken(t, s, alice, "ITEM", "100")
+
+	doc := walletDoc(t, s, bob.Address)
+	if _, exists := doc["mrc010"]; exists {
+		t.Errorf("balance in the wallet document : %+v", doc)
+	}
+	if _, exists := s.state[balanceKey(bob.Address, AssetMRC010+"0")]; !exists {
+		t.Fatal("token 0 balance key is not saved")
+	}
+
+	// the receiver document is not written
+	if err := transfer(t, s, alice, bob, "0", "10"); err != nil {
+		t.Fatal(err)
+	}
+	if written(s, bob.Address) || !written(s, balanceKey(bob.Address, AssetMRC010+"0")) {
+		t.Errorf("keys of the transfer : %v", s.keys)
+	}
+	assertBalance(t, s, bob, "0", "10")
+
+	// new token adds the balance key to the asset list
+	if err := transfer(t, s, alice, bob, item, "5"); err != nil {
+		t.Fatal(err)
+	}
+	if !written(s, bob.Address) {
+		t.Errorf("wallet document is not written for the new token : %v", s.keys)
+	}
+	if assets := bob.wallet(t, s).Assets; len(assets) != 2 || assets[1] != AssetMRC010+item {
+		t.Errorf("asset list : %v", assets)
+	}
+
+	// the transfer reads the balance key of the token only
+	if err := transfer(t, s, alice, bob, "0", "1"); err != nil {
+		t.Fatal(err)
+	}
+	for _, w := range []*testWallet{alice, bob} {
+		if !s.reads[balanceKey(w.Address, AssetMRC010+"0")] || s.reads[balanceKey(w.Address, AssetMRC010+item)] {
+			t.Errorf("keys read by the transfer : %v", s.reads)
+		}
+	}
+	if assets := bob.wallet(t, s).Assets; len(assets) != 2 {
+		t.Errorf("asset list after the other token transfer : %v", assets)
+	}
+	assertBalance(t, s, bob, item, "5")
+
+	// sending all deletes the balance key
+	if err := transfer(t, s, bob, alice, item, "5"); err != nil {
+		t.Fatal(err)
+	}
+	if _, exists := s.state[balanceKey(bob.Address, AssetMRC010+item)]; exists {
+		t.Error("empty balance key is not deleted")
+	}
+
+	// document saved with the balance by the old chaincode
+	wallet := bob.wallet(t, s)
+	wallet.Assets = nil
+	wallet.MRC010 = map[int]mtc.TMRC010BalanceV2{0: {Balance: "50", SaleAmount: "0", AuctionAmount: "0"}}
+	s.state[bob.Address], _ = json.Marshal(wallet)
+	delete(s.state, balanceKey(bob.Address, AssetMRC010+"0"))
+	assertBalance(t, s, bob, "0", "50")
+
+	if err := transfer(t, s, alice, bob, "0", "10"); err != nil {
+		t.Fatal(err)
+	}
+	if _, exists := walletDoc(t, s, bob.Address)["mrc010"]; exists {
+		t.Error("balance is not moved out of the wallet document")
+	}
+	assertBalance(t, s, bob, "0", "60")
+}
+
+func TestAddressShards(t *testing.T) {
+	s := newTestStub()
+	alice := newGenesisWallet(t, s, "1000")
+	hot := newTestWallet(t, s)
+	admin := s.Creator
+
+	s.Creator = testCreator("Org2MSP", "user", nil)
+	assertError(t, s.tx(func() error { return AddressShards(s, hot.Address, "4", nil) }), "6030")
+	s.Creator = admin
+	assertError(t, s.tx(func() error { return AddressShards(s, hot.Address, "65", nil) }), "1003")
+	s.mustTx(t, func() error { return AddressShards(s, hot.Address, "4", nil) })
+	assertError(t, s.tx(func() error { return AddressShards(s, hot.Address, "4", nil) }), "4900")
+
+	// the receive reads and writes one shard only
+	for _, amount := range []string{"10", "20", "30"} {
+		if err := transfer(t, s, alice, hot, "0", amount); err != nil {
+			t.Fatal(err)
+		}
+		if written(s, hot.Address) || written(s, balanceKey(hot.Address, AssetMRC010+"0")) {
+			t.Errorf("keys of the sharded receive : %v", s.keys)
+		}
+		prefix := balanceKey(hot.Address, AssetMRC010+"0") + "_"
+		for key := range s.reads {
+			if strings.HasPrefix(key, prefix) && !written(s, key) {
+				t.Errorf("receive reads the other shard %s", key)
+			}
+		}
+	}
+
+	// shards are summed by BalanceOf
+	assertBalance(t, s, hot, "0", "0")
+	value, err := BalanceOf(s, hot.Address, BalanceFormatV2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var v2 map[int]mtc.TMRC010BalanceV2
+	if err = json.Unmarshal([]byte(value), &v2); err != nil || v2[0].Balance != "60" {
+		t.Errorf("balance of the sharded address : %s", value)
+	}
+
+	// address history sums the shards
+	page, err := History(s, hot.Address, "1", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b, ok := page.Records[0].Balance.(*THistoryWallet); !ok || b.MRC010[0].Balance != "60" {
+		t.Errorf("history of the sharded address : %+v", page.Records[0].Balance)
+	}
+
+	// spend moves the shards to the balance
+	if err = transfer(t, s, hot, alice, "0", "15"); err != nil {
+		t.Fatal(err)
+	}
+	assertBalance(t, s, hot, "0", "45")
+	for n := 0; n < 4; n++ {
+		if _, exists := s.state[shardKey(hot.Address, 0, n)]; exists {
+			t.Errorf("shard %d is not deleted after the spend", n)
+		}
+	}
+
+	// shards off
+	if err = transfer(t, s, alice, hot, "0", "5"); err != nil {
+		t.Fatal(err)
+	}
+	s.mustTx(t, func() error { return AddressShards(s, hot.Address, "0", nil) })
+	assertBalance(t, s, hot, "0", "50")
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+)
+
+// TLS environment variables
+// value is the file path or the PEM contents.
+//
+//	CHAINCODE_TLS_KEY        server private key
+//	CHAINCODE_TLS_CERT       server certificate
+//	CHAINCODE_CLIENT_CA_CERT CA certificate of the peer (client), for mutual TLS
+//
+// TLS is disabled when none of them is set.
+const (
+	envTLSKey       = "CHAINCODE_TLS_KEY"
+	envTLSCert      = "CHAINCODE_TLS_CERT"
+	envClientCACert = "CHAINCODE_CLIENT_CA_CERT"
+)
+
+// tlsConfig : TLS setting from the environment variables
+type tlsConfig struct {
+	Key          string
+	Cert         string
+	ClientCACert string
+}
+
+func tlsConfigFromEnv() tlsConfig {
+	return tlsConfig{
+		Key:          os.Getenv(envTLSKey),
+		Cert:         os.Getenv(envTLSCert),
+		ClientCACert: os.Getenv(envClientCACert),
+	}
+}
+
+// Properties - make shim.TLSProperties
+// partial setting (ex: key without cert) is error.
+func (c tlsConfig) Properties() (shim.TLSProperties, error) {
+	var props shim.TLSProperties
+	var err error
+
+	if c.Key == "" && c.Cert == "" && c.ClientCACert == "" {
+		return shim.TLSProperties{Disabled: true}, nil
+	}
+
+	var missing []string
+	if c.Key == "" {
+		missing = append(missing, envTLSKey)
+	}
+	if c.Cert == "" {
+		missing = append(missing, envTLSCert)
+	}
+	if c.ClientCACert == "" {
+		missing = append(missing, envClientCACert)
+	}
+	if len(missing) > 0 {
+		return props, errors.New("incomplete TLS configuration, " + strings.Join(missing, ", ") + " not set")
+	}
+
+	if props.Key, err = readPEM(envTLSKey, c.Key); err != nil {
+		return props, err
+	}
+	if props.Cert, err = readPEM(envTLSCert, c.Cert); err != nil {
+		return props, err
+	}
+	if props.ClientCACerts, err = readPEM(envClientCACert, c.ClientCACert); err != nil {
+		return props, err
+	}
+	return props, nil
+}
+
+// readPEM - PEM contents or the file path
+func readPEM(name, value string) ([]byte, error) {
+	var data []byte
+	var err error
+
+	if strings.HasPrefix(strings.TrimSpace(value), "-----BEGIN") {
+		data = []byte(value)
+	} else if data, err = ioutil.ReadFile(value); err != nil {
+		return nil, errors.New(name + " read error - " + err.Error())
+	}
+
+	if !strings.Contains(string(data), "-----BEGIN") {
+		return nil, errors.New(name + " is not PEM format")
+	}
+	return data, nil
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testPEM = "-----BEGIN CERTIFICATE-----\nMIIB\n-----END CERTIFICATE-----\n"
+
+func TestTLSProperties(t *testing.T) {
+	// none of them is TLS disabled
+	props, err := tlsConfig{}.Properties()
+	if err != nil || !props.Disabled {
+		t.Fatalf("empty config : %+v, %v", props, err)
+	}
+
+	// all three or none
+	partial := []tlsConfig{
+		{Key: testPEM},
+		{Key: testPEM, Cert: testPEM},
+		{Cert: testPEM, ClientCACert: testPEM},
+		{ClientCACert: testPEM},
+	}
+	for _, c := range partial {
+		if _, err = c.Properties(); err == nil || !strings.Contains(err.Error(), "incomplete TLS configuration") {
+			t.Errorf("partial config %+v : %v", c, err)
+		}
+	}
+	if _, err = (tlsConfig{Key: testPEM}).Properties(); !strings.Contains(err.Error(), envTLSCert+", "+envClientCACert) {
+		t.Errorf("missing variables : %v", err)
+	}
+
+	// the PEM contents and the file path
+	dir := t.TempDir()
+	path := filepath.Join(dir, "cert.pem")
+	if err = ioutil.WriteFile(path, []byte(testPEM), 0600); err != nil {
+		t.Fatal(err)
+	}
+	props, err = tlsConfig{Key: "  " + testPEM, Cert: path, ClientCACert: path}.Properties()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if props.Disabled || string(props.Key) != "  "+testPEM || string(props.Cert) != testPEM || string(props.ClientCACerts) != testPEM {
+		t.Errorf("properties : %+v", props)
+	}
+
+	// the missing file and the file which is not PEM
+	if _, err = (tlsConfig{Key: testPEM, Cert: filepath.Join(dir, "none.pem"), ClientCACert: path}).Properties(); err == nil || !strings.Contains(err.Error(), envTLSCert+" read error") {
+		t.Errorf("missing file : %v", err)
+	}
+	text := filepath.Join(dir, "ca.txt")
+	if err = ioutil.WriteFile(text, []byte("not a certificate"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = (tlsConfig{Key: testPEM, Cert: path, ClientCACert: text}).Properties(); err == nil || !strings.Contains(err.Error(), envClientCACert+" is not PEM format") {
+		t.Errorf("not PEM : %v", err)
+	}
+}